@@ -0,0 +1,192 @@
+package gobvh
+
+import (
+	"math"
+)
+
+// ==============================================
+
+//
+// WorkloadSpec describes how a BVH will be used, see Advise().
+//
+// QueryFraction is the fraction of operations which are queries, the
+// remainder being insertions and erasures (0.0 to 1.0).
+//
+type WorkloadSpec struct {
+	QueryFraction float64
+}
+
+// ..............................................
+
+//
+// SampleProfile summarizes the shape of a set of elements, see ProfileSample().
+//
+// ExtentSkew is the ratio of the longest to the shortest side of the bound
+// of all elements (1.0 for a cube-like extent).
+//
+// Clustering is 0.0 for elements spread like uniform random points,
+// approaching 1.0 as elements gather into a few tight clusters.
+//
+// MeanSize is the mean extent of an element, as a fraction of the extent of
+// all elements (averaged over dimensions); 0.0 for point data.
+//
+// SizeSpread is the coefficient of variation of the element extents.
+//
+type SampleProfile struct {
+	Count      int
+	Dimensions uint
+	ExtentSkew float64
+	Clustering float64
+	MeanSize   float64
+	SizeSpread float64
+}
+
+// ==============================================
+
+//
+// ProfileSample(traits, sample) measures a representative sample of elements.
+//
+func ProfileSample[BoundType any](boundtraits BoundTraits[BoundType], sample []Boundable[BoundType]) SampleProfile {
+	profile := SampleProfile{Count: len(sample), ExtentSkew: 1.0}
+	if len(sample) == 0 {
+		return profile
+	}
+
+	total := sample[0].GetBound()
+	for _, element := range sample[1:] {
+		total = boundtraits.Union(total, element.GetBound())
+	}
+	dims := boundtraits.Dimensions(total)
+	profile.Dimensions = dims
+
+	// extents of the whole sample:
+	extent := make([]float64, dims)
+	longest, shortest := 0.0, math.Inf(1)
+	var i uint
+	for i = 0; i < dims; i++ {
+		lo, hi := boundtraits.IntervalRange(total, i)
+		extent[i] = hi - lo
+		longest = math.Max(longest, extent[i])
+		shortest = math.Min(shortest, extent[i])
+	}
+	if shortest > 0.0 {
+		profile.ExtentSkew = longest / shortest
+	} else if longest > 0.0 {
+		profile.ExtentSkew = math.Inf(1)
+	}
+
+	// element sizes relative to the sample extent:
+	var sum, sumsq float64
+	for _, element := range sample {
+		bound := element.GetBound()
+		var size float64
+		for i = 0; i < dims; i++ {
+			if extent[i] > 0.0 {
+				lo, hi := boundtraits.IntervalRange(bound, i)
+				size += (hi - lo) / extent[i]
+			}
+		}
+		size /= float64(dims)
+		sum += size
+		sumsq += float64(size * size)
+	}
+	n := float64(len(sample))
+	profile.MeanSize = sum / n
+	if profile.MeanSize > 0.0 {
+		variance := math.Max(0.0, sumsq/n-float64(profile.MeanSize*profile.MeanSize))
+		profile.SizeSpread = math.Sqrt(variance) / profile.MeanSize
+	}
+
+	profile.Clustering = sampleClustering(boundtraits, sample, total, extent)
+	return profile
+}
+
+// ..............................................
+
+//
+// Advise(traits, sample, workload) recommends Options for a BVH holding
+// elements like those in sample, used as described by workload.
+//
+// The recommendation is a heuristic based on ProfileSample(): query-heavy
+// workloads, large overlapping elements and clustered data favor a smaller
+// fan-out (tighter bounds), while update-heavy workloads favor a larger one
+// (fewer splits).  The result can be passed straight to NewWithOptions().
+//
+func Advise[BoundType any](boundtraits BoundTraits[BoundType], sample []Boundable[BoundType], workload WorkloadSpec) Options {
+	profile := ProfileSample(boundtraits, sample)
+	options := DefaultOptions()
+
+	switch {
+	case workload.QueryFraction >= 0.9:
+		options.MaxChildren = 8
+	case workload.QueryFraction <= 0.5:
+		options.MaxChildren = 32
+	}
+
+	if profile.MeanSize > 0.05 || profile.Clustering > 0.75 {
+		options.MaxChildren = maxInt(8, options.MaxChildren/2)
+	}
+
+	return options
+}
+
+// ==============================================
+
+// compares the occupancy of a grid of element centroids against the occupancy
+// expected of uniform random points.
+func sampleClustering[BoundType any](boundtraits BoundTraits[BoundType], sample []Boundable[BoundType], total BoundType, extent []float64) float64 {
+	// use (up to) the three widest dimensions:
+	gridims := make([]uint, 0, 3)
+	for len(gridims) < 3 && len(gridims) < len(extent) {
+		var widest uint
+		found := false
+		for i := range extent {
+			used := false
+			for _, dim := range gridims {
+				used = used || dim == uint(i)
+			}
+			if !used && extent[i] > 0.0 && (!found || extent[i] > extent[widest]) {
+				widest = uint(i)
+				found = true
+			}
+		}
+		if !found {
+			break
+		}
+		gridims = append(gridims, widest)
+	}
+	if len(gridims) == 0 || len(sample) < 2 {
+		return 0.0
+	}
+
+	// about one cell per element:
+	res := int(math.Ceil(math.Pow(float64(len(sample)), 1.0/float64(len(gridims)))))
+	cells := math.Pow(float64(res), float64(len(gridims)))
+	occupied := make(map[int]bool)
+	for _, element := range sample {
+		centroid := boundCentroid(boundtraits, element.GetBound())
+		cell := 0
+		for _, dim := range gridims {
+			lo, _ := boundtraits.IntervalRange(total, dim)
+			c := int((centroid[dim] - lo) / extent[dim] * float64(res))
+			if c >= res {
+				c = res - 1
+			}
+			cell = cell*res + c
+		}
+		occupied[cell] = true
+	}
+
+	expected := cells * (1.0 - math.Exp(-float64(len(sample))/cells))
+	clustering := 1.0 - float64(len(occupied))/expected
+	return math.Min(1.0, math.Max(0.0, clustering))
+}
+
+// ..............................................
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
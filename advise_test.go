@@ -0,0 +1,65 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestAdvise(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+
+	uniform := make([]Boundable[AABB2D], 2000)
+	clustered := make([]Boundable[AABB2D], 2000)
+	for i := range uniform {
+		uniform[i] = Point2D{rng.Float64() * 100.0, rng.Float64() * 100.0}
+		cx, cy := float64(i%3)*40.0, float64(i%3)*30.0
+		clustered[i] = Point2D{cx + rng.NormFloat64()*0.1, cy + rng.NormFloat64()*0.1}
+	}
+
+	profile := ProfileSample[AABB2D](Traits2D{}, uniform)
+	if profile.Count != 2000 || profile.Dimensions != 2 || profile.MeanSize != 0.0 {
+		t.Errorf("Unexpected profile of uniform points: %+v", profile)
+	}
+	if profile.Clustering > 0.2 {
+		t.Errorf("Uniform points measured as clustered: %f", profile.Clustering)
+	}
+	if profile.ExtentSkew < 1.0 || profile.ExtentSkew > 1.1 {
+		t.Errorf("Unexpected extent skew for a square: %f", profile.ExtentSkew)
+	}
+	if c := ProfileSample[AABB2D](Traits2D{}, clustered).Clustering; c < 0.75 {
+		t.Errorf("Clustered points measured as spread out: %f", c)
+	}
+
+	queryheavy := Advise[AABB2D](Traits2D{}, uniform, WorkloadSpec{QueryFraction: 0.99})
+	updateheavy := Advise[AABB2D](Traits2D{}, uniform, WorkloadSpec{QueryFraction: 0.1})
+	if queryheavy.MaxChildren >= updateheavy.MaxChildren {
+		t.Errorf("Expected a smaller fan-out for queries (%d) than updates (%d)", queryheavy.MaxChildren, updateheavy.MaxChildren)
+	}
+	if c := Advise[AABB2D](Traits2D{}, clustered, WorkloadSpec{QueryFraction: 0.1}); c.MaxChildren >= updateheavy.MaxChildren {
+		t.Errorf("Expected a smaller fan-out for clustered data, found %d", c.MaxChildren)
+	}
+
+	// the advice is ready to use:
+	bvh := NewWithOptions[AABB2D](Traits2D{}, queryheavy)
+	for _, element := range uniform {
+		bvh.Insert(element)
+	}
+	var cb CheckBound
+	cb.T = t
+	bvh.ForEach(&cb)
+	var check func(node *bvhNode[AABB2D])
+	check = func(node *bvhNode[AABB2D]) {
+		if len(node.children) >= 2*queryheavy.MaxChildren {
+			t.Errorf("Node with %d children exceeds the fan-out %d", len(node.children), queryheavy.MaxChildren)
+		}
+		for _, child := range node.children {
+			if childnode, ok := child.(*bvhNode[AABB2D]); ok {
+				check(childnode)
+			}
+		}
+	}
+	check(&bvh.root)
+	simpleNNSearch(t, bvh, uniform[17].(Point2D), uniform[17].(Point2D), true)
+}
@@ -0,0 +1,104 @@
+package gobvh
+
+import (
+	"math"
+)
+
+// ==============================================
+
+//
+// BVH.AllKNearest(k) returns the k nearest neighbors of every stored
+// element (nearest first, not including the element itself), e.g. to
+// build a kNN graph.  See AllKNearestFunc().
+//
+func (bvh *BVH[BoundType]) AllKNearest(k int) map[Boundable[BoundType]][]Boundable[BoundType] {
+	graph := make(map[Boundable[BoundType]][]Boundable[BoundType], bvh.count)
+	bvh.AllKNearestFunc(k, func(element Boundable[BoundType], neighbors []Boundable[BoundType], distances []float64) error {
+		graph[element] = neighbors
+		return nil
+	})
+	return graph
+}
+
+// ..............................................
+
+//
+// BVH.AllKNearestFunc(k, fn) calls fn(element, neighbors, distances) for
+// every stored element, with its k nearest neighbors (nearest first, not
+// including the element itself) and their distances.
+//
+// Rather than one search per element, the elements of each leaf are
+// searched for together: a single best-first traversal, ordered by the
+// distance from the leaf's bound, serves them all, and is pruned once no
+// subtree can improve on the k-th neighbor of any of them.  Distances are
+// between element bounds, and come from the traits' DistanceTraits if
+// available, see DistanceTraits.  An error returned by fn stops the
+// search and is returned.
+//
+func (bvh *BVH[BoundType]) AllKNearestFunc(k int, fn func(element Boundable[BoundType], neighbors []Boundable[BoundType], distances []float64) error) error {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryAllKNearest, K: k})
+	}
+	if k < 1 {
+		return nil
+	}
+	return bvh.allKNearestNode(&bvh.root, k, bvh.minDistance(), fn)
+}
+
+// ==============================================
+
+// searches for the neighbors of the elements directly in node, then recurses
+func (bvh *BVH[BoundType]) allKNearestNode(node *bvhNode[BoundType], k int, distance DistanceFunc[BoundType], fn func(Boundable[BoundType], []Boundable[BoundType], []float64) error) error {
+	elements := make([]Boundable[BoundType], 0, len(node.children))
+	queries := make([]*KNearest[BoundType], 0, len(node.children))
+	var groupbound BoundType
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			if err := bvh.allKNearestNode(value, k, distance, fn); err != nil {
+				return err
+			}
+		} else if child != nil {
+			if len(queries) == 0 {
+				groupbound = child.GetBound()
+			} else {
+				groupbound = bvh.boundtraits.Union(groupbound, child.GetBound())
+			}
+			elements = append(elements, child)
+			queries = append(queries, &KNearest[BoundType]{K: k, Target: child.GetBound(), Distance: distance, found: make(knnHeap[BoundType], 0, k)})
+		}
+	}
+	if len(queries) == 0 {
+		return nil
+	}
+
+	// the distance beyond which no query can gain a neighbor:
+	cutoff := math.Inf(1)
+	key := func(bound BoundType) (float64, bool) {
+		return distance(groupbound, bound), true
+	}
+	err := orderedDescent(&bvh.root, key, &cutoff, nil, func(candidate Boundable[BoundType], d float64) error {
+		worst := 0.0
+		for index, query := range queries {
+			if elements[index] != candidate {
+				query.Evaluate(candidate)
+			}
+			if len(query.found) < k {
+				worst = math.Inf(1)
+			} else {
+				worst = math.Max(worst, query.found[0].distance)
+			}
+		}
+		cutoff = worst
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for index, query := range queries {
+		if err := fn(elements[index], query.Results(), query.Distances()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
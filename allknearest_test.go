@@ -0,0 +1,84 @@
+package gobvh
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHAllKNearest(t *testing.T) {
+	rng := rand.New(rand.NewSource(780))
+	bvh := New[AABB2D](Traits2D{})
+	points := make([]Point2D, 1500)
+	for i := range points {
+		points[i] = Point2D{rng.Float64() * 100.0, rng.Float64() * 100.0}
+		bvh.Insert(points[i])
+	}
+
+	const k = 5
+	graph := bvh.AllKNearest(k)
+	if len(graph) != len(points) {
+		t.Fatalf("Expected neighbors for %d elements, found %d", len(points), len(graph))
+	}
+	for _, p := range points[:200] {
+		distances := make([]float64, 0, len(points))
+		for _, q := range points {
+			if q != p {
+				distances = append(distances, distance2D(p, q))
+			}
+		}
+		sort.Float64s(distances)
+		neighbors := graph[p]
+		if len(neighbors) != k {
+			t.Fatalf("Expected %d neighbors of %v, found %d", k, p, len(neighbors))
+		}
+		for i, neighbor := range neighbors {
+			if neighbor == p {
+				t.Errorf("Element %v is its own neighbor", p)
+			}
+			if d := distance2D(p, neighbor.(Point2D)); d != distances[i] {
+				t.Errorf("Expected neighbor %d of %v at %g, found %g", i, p, distances[i], d)
+			}
+		}
+	}
+
+	// more neighbors than elements:
+	small := New[AABB2D](Traits2D{})
+	small.Insert(Point2D{0.0, 0.0})
+	small.Insert(Point2D{1.0, 0.0})
+	for element, neighbors := range small.AllKNearest(4) {
+		if len(neighbors) != 1 || neighbors[0] == element {
+			t.Errorf("Expected the other element as the only neighbor of %v, found %v", element, neighbors)
+		}
+	}
+
+	// errors stop the search:
+	stop := errors.New("stop")
+	calls := 0
+	err := bvh.AllKNearestFunc(k, func(Boundable[AABB2D], []Boundable[AABB2D], []float64) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("Expected the search to stop at the first error, found %v after %d calls", err, calls)
+	}
+}
+
+// ........................................................
+
+func BenchmarkAllKNearest(b *testing.B) {
+	rng := rand.New(rand.NewSource(780))
+	bvh := New[AABB2D](Traits2D{})
+	for i := 0; i < 20000; i++ {
+		bvh.Insert(Point2D{rng.Float64() * 100.0, rng.Float64() * 100.0})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bvh.AllKNearestFunc(8, func(Boundable[AABB2D], []Boundable[AABB2D], []float64) error {
+			return nil
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package gobvh
+
+// ==============================================
+
+//
+// BVH.ResetArena() empties the bvh, recycling all of its nodes at once.
+//
+// Nodes are allocated from an arena owned by the bvh, and erasing elements
+// does not return nodes to it; ResetArena() makes the whole arena (and the
+// children slices of its nodes) available again in O(1), so a tree rebuilt
+// from scratch every frame stops allocating once it has warmed up:
+//
+//	for {
+//		bvh.ResetArena()
+//		for _, element := range elements {
+//			bvh.Insert(element)
+//		}
+//		...
+//	}
+//
+// Tags and other per-element annotations (and any eviction order, see
+// SetCapacity()) are forgotten, and the bound of
+// the previous contents is reported by DirtyBounds().  Recycled storage may
+// hold references to the previous elements until it is reused.
+//
+func (bvh *BVH[BoundType]) ResetArena() {
+	if len(bvh.root.children) > 0 {
+		if bvh.journaling {
+			for _, element := range collectElements(&bvh.root, nil) {
+				bvh.logChange(ChangeErased, element, element.GetBound())
+			}
+		}
+		oldbound := bvh.root.bound
+		for node := range bvh.dirtyindex {
+			delete(bvh.dirtyindex, node) // recycled nodes must not match stale entries
+		}
+		bvh.markDirty(&bvh.root, oldbound)
+	}
+	bvh.own(&bvh.root) // (its children slice may be a snapshot's)
+	bvh.root = bvhNode[BoundType]{children: bvh.root.children[:0], epoch: bvh.arena.epoch}
+	bvh.info = nil
+	bvh.count = 0
+	bvh.contenthash = 0
+	bvh.version++
+	if bvh.evictor != nil {
+		bvh.evictor.Reset()
+	}
+	bvh.arena.reset()
+}
+
+// ==============================================
+
+// number of nodes allocated at a time by a nodeArena
+const arenaChunkSize = 64
+
+// ..............................................
+
+// nodeArena allocates nodes in chunks, which are kept (with the children
+// slices of their nodes) for reuse after a reset, unless a snapshot may
+// still hold them.
+type nodeArena[BoundType any] struct {
+	chunks [][]bvhNode[BoundType]
+	used   int
+	epoch  uint64 // stamped on each node allocated, advanced by Snapshot()
+	shared bool   // nodes allocated are held by a snapshot, see Snapshot()
+}
+
+// ..............................................
+
+func (arena *nodeArena[BoundType]) alloc() *bvhNode[BoundType] {
+	chunk, offset := arena.used/arenaChunkSize, arena.used%arenaChunkSize
+	if chunk == len(arena.chunks) {
+		arena.chunks = append(arena.chunks, make([]bvhNode[BoundType], arenaChunkSize))
+	}
+	arena.used++
+	node := &arena.chunks[chunk][offset]
+	*node = bvhNode[BoundType]{children: node.children[:0], epoch: arena.epoch}
+	return node
+}
+
+// ..............................................
+
+// returns node to the arena if it was the most recent allocation
+func (arena *nodeArena[BoundType]) release(node *bvhNode[BoundType]) {
+	if arena.used > 0 {
+		last := arena.used - 1
+		if node == &arena.chunks[last/arenaChunkSize][last%arenaChunkSize] {
+			arena.used = last
+		}
+	}
+}
+
+// ..............................................
+
+func (arena *nodeArena[BoundType]) reset() {
+	if arena.shared {
+		*arena = nodeArena[BoundType]{epoch: arena.epoch} // (the snapshot keeps the chunks)
+		return
+	}
+	arena.used = 0
+}
+
+// ..............................................
+
+// capacity of the arena, in nodes
+func (arena *nodeArena[BoundType]) capacity() int {
+	return len(arena.chunks) * arenaChunkSize
+}
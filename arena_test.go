@@ -0,0 +1,62 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHResetArena(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	elements := make([]Boundable[AABB2D], 2000)
+	for i := range elements {
+		elements[i] = Point2D{rng.Float64() * 100.0, rng.Float64() * 100.0}
+	}
+
+	bvh := New[AABB2D](Traits2D{})
+	bvh.InsertTagged(elements[0], Tag(1))
+	frame := func() {
+		bvh.ResetArena()
+		bvh.ClearDirty()
+		for _, element := range elements {
+			bvh.Insert(element)
+		}
+	}
+	frame()
+
+	if bvh.HasTag(elements[0], Tag(1)) {
+		t.Errorf("Expected tags to be forgotten by ResetArena()")
+	}
+	collected := collectElements(&bvh.root, nil)
+	if len(collected) != len(elements) {
+		t.Errorf("Expected %d elements after reset, found %d", len(elements), len(collected))
+	}
+	checkParents(t, &bvh.root)
+
+	// warmed up: rebuilding from scratch should not allocate
+	frame()
+	allocs := testing.AllocsPerRun(5, frame)
+	if allocs > 0 {
+		t.Errorf("Expected no allocations per rebuild after warm-up, found %v", allocs)
+	}
+
+	bvh.ClearDirty()
+	bvh.ResetArena()
+	if len(bvh.root.children) != 0 || len(bvh.DirtyBounds()) != 1 {
+		t.Errorf("Expected an empty tree with its old bound marked dirty")
+	}
+}
+
+// ........................................................
+
+func checkParents(t *testing.T, node *bvhNode[AABB2D]) {
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[AABB2D]); ok {
+			if value.parent != node {
+				t.Errorf("Broken parent pointer")
+			}
+			checkParents(t, value)
+		}
+	}
+}
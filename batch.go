@@ -0,0 +1,239 @@
+package gobvh
+
+// ==============================================
+
+//
+// QueryBatch gathers region queries to be answered together, by a single
+// descent of the hierarchy, see BVH.RunBatch() and BVH.StartBatch().
+//
+// Many small searches made one at a time each pay for the descent from the
+// root; in a batch, a node is visited once for all the queries which
+// overlap it, and only those queries are tested against its children.
+//
+type QueryBatch[BoundType any] struct {
+	regions []BoundType
+}
+
+// ..............................................
+
+//
+// QueryResult is the answer to one query of a batch: ID identifies the
+// query (the index returned by QueryBatch.Add(), or the ID of a
+// QueryRequest), and Elements are those whose bound intersects its region.
+//
+type QueryResult[BoundType any] struct {
+	ID       int
+	Elements []Boundable[BoundType]
+}
+
+// ..............................................
+
+//
+// QueryRequest is a region query submitted to BVH.Serve(); its ID is
+// returned with the result.
+//
+type QueryRequest[BoundType any] struct {
+	ID     int
+	Region BoundType
+}
+
+// ==============================================
+
+//
+// NewQueryBatch() returns an empty batch.
+//
+func NewQueryBatch[BoundType any]() *QueryBatch[BoundType] {
+	return &QueryBatch[BoundType]{regions: make([]BoundType, 0, 16)}
+}
+
+// ..............................................
+
+//
+// QueryBatch.Add(region) adds a search for the elements whose bound intersects
+// region (as FindAllIntersecting()), and returns the index identifying it.
+//
+func (batch *QueryBatch[BoundType]) Add(region BoundType) int {
+	batch.regions = append(batch.regions, region)
+	return len(batch.regions) - 1
+}
+
+// ..............................................
+
+//
+// QueryBatch.Len() returns the number of queries in the batch.
+//
+func (batch *QueryBatch[BoundType]) Len() int {
+	return len(batch.regions)
+}
+
+// ..............................................
+
+//
+// QueryBatch.Reset() removes all the queries, keeping the storage for reuse.
+//
+func (batch *QueryBatch[BoundType]) Reset() {
+	batch.regions = batch.regions[:0]
+}
+
+// ==============================================
+
+//
+// BVH.RunBatch(batch, fn) answers every query of batch in a single descent
+// of the hierarchy, calling fn(query, element) for each element whose bound
+// intersects the region of the query with that index.
+//
+// Results of different queries are interleaved, in no particular order.
+// An error returned by fn stops the search and is returned.
+//
+func (bvh *BVH[BoundType]) RunBatch(batch *QueryBatch[BoundType], fn func(query int, element Boundable[BoundType]) error) error {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryRunBatch, Regions: batch.regions})
+	}
+	if len(batch.regions) == 0 || len(bvh.root.children) == 0 {
+		return nil
+	}
+	bw := batchWalker[BoundType]{boundtraits: bvh.boundtraits, regions: batch.regions, fn: fn, touch: bvh.hit}
+	active := make([]int, len(batch.regions), 4*len(batch.regions))
+	for i := range active {
+		active[i] = i
+	}
+	return bw.walk(&bvh.root, active)
+}
+
+// ..............................................
+
+//
+// BVH.StartBatch(batch) runs batch, see RunBatch(), on another goroutine and
+// returns the channel on which the results are delivered, one per query in
+// the order they were added; the channel is closed after the last.
+//
+// The hierarchy must not be modified, nor batch reused, until the channel
+// is closed.
+//
+func (bvh *BVH[BoundType]) StartBatch(batch *QueryBatch[BoundType]) <-chan QueryResult[BoundType] {
+	results := make(chan QueryResult[BoundType], batch.Len())
+	go func() {
+		defer close(results)
+		for _, result := range bvh.collectBatch(batch, nil) {
+			results <- result
+		}
+	}()
+	return results
+}
+
+// ..............................................
+
+//
+// BVH.Serve(requests, maxbatch) answers the queries submitted on requests,
+// on another goroutine, until requests is closed; results are delivered on
+// the returned channel, which is closed after the last.
+//
+// Requests waiting when the server is ready are gathered, up to maxbatch of
+// them (maxbatch < 1 is treated as 1), and answered together as a
+// QueryBatch, so a burst of small queries shares one descent of the
+// hierarchy.  Results are sent in the order their requests were received.
+//
+// The hierarchy must not be modified while the server runs.
+//
+func (bvh *BVH[BoundType]) Serve(requests <-chan QueryRequest[BoundType], maxbatch int) <-chan QueryResult[BoundType] {
+	if maxbatch < 1 {
+		maxbatch = 1
+	}
+	results := make(chan QueryResult[BoundType], maxbatch)
+	go func() {
+		defer close(results)
+		batch := NewQueryBatch[BoundType]()
+		ids := make([]int, 0, maxbatch)
+		for request := range requests {
+			batch.Reset()
+			ids = append(ids[:0], request.ID)
+			batch.Add(request.Region)
+
+			// gather the requests already waiting:
+		gather:
+			for batch.Len() < maxbatch {
+				select {
+				case request, ok := <-requests:
+					if !ok {
+						break gather
+					}
+					ids = append(ids, request.ID)
+					batch.Add(request.Region)
+				default:
+					break gather
+				}
+			}
+
+			for _, result := range bvh.collectBatch(batch, ids) {
+				results <- result
+			}
+		}
+	}()
+	return results
+}
+
+// ==============================================
+
+// runs batch, collecting the elements found by each query, identified by
+// ids (or by their index, if ids is nil)
+func (bvh *BVH[BoundType]) collectBatch(batch *QueryBatch[BoundType], ids []int) []QueryResult[BoundType] {
+	results := make([]QueryResult[BoundType], batch.Len())
+	for index := range results {
+		results[index].ID = index
+		if ids != nil {
+			results[index].ID = ids[index]
+		}
+	}
+	bvh.RunBatch(batch, func(query int, element Boundable[BoundType]) error {
+		results[query].Elements = append(results[query].Elements, element)
+		return nil
+	})
+	return results
+}
+
+// ..............................................
+
+// the state of RunBatch()
+type batchWalker[BoundType any] struct {
+	boundtraits BoundTraits[BoundType]
+	regions     []BoundType
+	fn          func(int, Boundable[BoundType]) error
+	touch       func(Boundable[BoundType]) // reports elements found to the evictor
+}
+
+// ..............................................
+
+// visits node with the (ascending) indices of the queries which may reach
+// it; those overlapping node are appended after them, in the same slice,
+// for its children
+func (bw *batchWalker[BoundType]) walk(node *bvhNode[BoundType], active []int) error {
+	start := len(active)
+	for _, query := range active {
+		if boundsOverlap(bw.boundtraits, bw.regions[query], node.bound) {
+			active = append(active, query)
+		}
+	}
+	overlapping := active[start:]
+	if len(overlapping) == 0 {
+		return nil
+	}
+
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			if err := bw.walk(value, overlapping); err != nil {
+				return err
+			}
+		} else if child != nil {
+			bound := child.GetBound()
+			for _, query := range overlapping {
+				if boundsOverlap(bw.boundtraits, bw.regions[query], bound) {
+					bw.touch(child)
+					if err := bw.fn(query, child); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
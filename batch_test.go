@@ -0,0 +1,171 @@
+package gobvh
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func randomRegion(rng *rand.Rand, size float64) AABB2D {
+	x, y := rng.Float64()*100.0, rng.Float64()*100.0
+	return AABB2D{L: Point2D{x, y}, H: Point2D{x + rng.Float64()*size, y + rng.Float64()*size}}
+}
+
+// ........................................................
+
+func sameElements(found []Boundable[AABB2D], expected map[Boundable[AABB2D]]bool) bool {
+	if len(found) != len(expected) {
+		return false
+	}
+	for _, element := range found {
+		if !expected[element] {
+			return false
+		}
+	}
+	return true
+}
+
+// ........................................................
+
+func intersecting(bvh *BVH[AABB2D], region AABB2D) map[Boundable[AABB2D]]bool {
+	expected := make(map[Boundable[AABB2D]]bool)
+	bvh.FindAllIntersecting(region, func(element Boundable[AABB2D]) error {
+		expected[element] = true
+		return nil
+	})
+	return expected
+}
+
+// ========================================================
+
+func TestBVHQueryBatch(t *testing.T) {
+	rng := rand.New(rand.NewSource(785))
+	bvh := New[AABB2D](Traits2D{})
+	for _, element := range randomBoxes(rng, 2000, 2.0) {
+		bvh.Insert(element)
+	}
+
+	batch := NewQueryBatch[AABB2D]()
+	for i := 0; i < 50; i++ {
+		if index := batch.Add(randomRegion(rng, 10.0)); index != i {
+			t.Errorf("Expected query index %d, found %d", i, index)
+		}
+	}
+
+	found := make([][]Boundable[AABB2D], batch.Len())
+	err := bvh.RunBatch(batch, func(query int, element Boundable[AABB2D]) error {
+		found[query] = append(found[query], element)
+		return nil
+	})
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	for query, region := range batch.regions {
+		if !sameElements(found[query], intersecting(bvh, region)) {
+			t.Errorf("Query %d of the batch disagrees with FindAllIntersecting()", query)
+		}
+	}
+
+	count := 0
+	for result := range bvh.StartBatch(batch) {
+		if result.ID != count {
+			t.Errorf("Expected result %d, found %d", count, result.ID)
+		}
+		if !sameElements(result.Elements, intersecting(bvh, batch.regions[result.ID])) {
+			t.Errorf("Result %d of StartBatch() disagrees with FindAllIntersecting()", result.ID)
+		}
+		count++
+	}
+	if count != batch.Len() {
+		t.Errorf("Expected %d results, found %d", batch.Len(), count)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = bvh.RunBatch(batch, func(int, Boundable[AABB2D]) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("Expected the batch to stop at the first error, found %v after %d calls", err, calls)
+	}
+
+	batch.Reset()
+	if batch.Len() != 0 {
+		t.Errorf("Expected an empty batch after Reset()")
+	}
+	bvh.RunBatch(batch, func(int, Boundable[AABB2D]) error {
+		t.Errorf("Expected no results from an empty batch")
+		return nil
+	})
+}
+
+// ........................................................
+
+func TestBVHServe(t *testing.T) {
+	rng := rand.New(rand.NewSource(7850))
+	bvh := New[AABB2D](Traits2D{})
+	for _, element := range randomBoxes(rng, 1000, 2.0) {
+		bvh.Insert(element)
+	}
+
+	regions := make([]AABB2D, 200)
+	for i := range regions {
+		regions[i] = randomRegion(rng, 5.0)
+	}
+	requests := make(chan QueryRequest[AABB2D], 32)
+	results := bvh.Serve(requests, 8)
+	go func() {
+		for i, region := range regions {
+			requests <- QueryRequest[AABB2D]{ID: 1000 + i, Region: region}
+		}
+		close(requests)
+	}()
+
+	next := 1000
+	for result := range results {
+		if result.ID != next {
+			t.Errorf("Expected result %d, found %d", next, result.ID)
+		} else if !sameElements(result.Elements, intersecting(bvh, regions[result.ID-1000])) {
+			t.Errorf("Result %d of Serve() disagrees with FindAllIntersecting()", result.ID)
+		}
+		next++
+	}
+	if next != 1200 {
+		t.Errorf("Expected 200 results, found %d", next-1000)
+	}
+}
+
+// ........................................................
+
+func BenchmarkQueryBatch(b *testing.B) {
+	rng := rand.New(rand.NewSource(785))
+	bvh := New[AABB2D](Traits2D{})
+	for _, element := range randomBoxes(rng, 20000, 1.0) {
+		bvh.Insert(element)
+	}
+	batch := NewQueryBatch[AABB2D]()
+	for i := 0; i < 1000; i++ {
+		batch.Add(randomRegion(rng, 1.0))
+	}
+	nothing := func(int, Boundable[AABB2D]) error {
+		return nil
+	}
+
+	b.Run("Batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			bvh.RunBatch(batch, nothing)
+		}
+	})
+	b.Run("Single", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for query, region := range batch.regions {
+				bvh.FindAllIntersecting(region, func(element Boundable[AABB2D]) error {
+					return nothing(query, element)
+				})
+			}
+		}
+	})
+}
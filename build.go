@@ -0,0 +1,323 @@
+package gobvh
+
+import (
+	"math"
+	"sort"
+)
+
+// ==============================================
+
+//
+// Packing chooses how Build() packs elements into nodes, see Options.
+//
+// PackSTR (the default) is Sort-Tile-Recursive packing: the elements are
+// sorted by centroid along the first axis and cut into slabs, each slab is
+// sorted along the next axis and cut again, and so on; the runs left after
+// the last axis become full leaves, and the leaves are packed into their
+// parents the same way, up to the root.
+//
+// PackHilbert sorts the elements by the position of their centroids (see
+// CentroidTraits) along a Hilbert curve, and packs consecutive runs into
+// leaves, and consecutive leaves into their parents.  The curve keeps each
+// run compact however the data is distributed, which suits skewed data
+// (e.g. geographic data clustered in cities) better than STR's slabs.
+//
+type Packing int
+
+const (
+	PackSTR Packing = iota
+	PackHilbert
+)
+
+// ..............................................
+
+//
+// BVH.Build(elements) replaces the contents of the bvh with elements,
+// bulk-loaded by packing them into full nodes, see Packing.
+//
+// This takes O(n log n), much less than inserting the elements one at a
+// time, and gives a balanced tree of tightly packed, little overlapping
+// nodes; it suits static data, which is then best left unmodified.
+//
+// The sorting and packing are shared among Options.BuildWorkers
+// goroutines, so GetBound() and the traits must be safe to call
+// concurrently (as they are when they only read the bounds).
+//
+// As ResetArena(), the nodes of the previous contents are recycled, and
+// tags and other per-element annotations are forgotten.  The order of
+// elements is not changed.
+//
+func (bvh *BVH[BoundType]) Build(elements []Boundable[BoundType]) {
+	bvh.ResetArena()
+	if len(elements) == 0 {
+		return
+	}
+
+	items := append(make([]Boundable[BoundType], 0, len(elements)), elements...)
+	dims := bvh.boundtraits.Dimensions(items[0].GetBound())
+	workers := bvh.buildWorkers()
+	if bvh.options.Packing == PackHilbert {
+		items = bvh.hilbertOrder(items, workers)
+	}
+	bvh.packRoot(items, dims, workers)
+	bvh.built(elements)
+}
+
+// ..............................................
+
+//
+// BVH.BuildMedian(elements) replaces the contents of the bvh with elements,
+// built top-down by median splits: each node's elements are sorted by
+// centroid (see CentroidTraits) along the axis where the centroids are most
+// spread out and halved, and the largest half halved again, until there are
+// enough groups for the node's children; each group then becomes a child.
+//
+// It is simple and predictable: the tree is balanced whatever the data, and
+// its quality sits between Build() and BuildSAH(), far above inserting the
+// elements one at a time.  It is the builder Optimize() would use without
+// the surface area heuristic, and a good choice when no other stands out.
+//
+// As ResetArena(), the nodes of the previous contents are recycled, and
+// tags and other per-element annotations are forgotten.  The order of
+// elements is not changed.
+//
+func (bvh *BVH[BoundType]) BuildMedian(elements []Boundable[BoundType]) {
+	bvh.ResetArena()
+	if len(elements) == 0 {
+		return
+	}
+	items := append(make([]Boundable[BoundType], 0, len(elements)), elements...)
+	bvh.buildNode(&bvh.root, items)
+	bvh.built(elements)
+}
+
+// ==============================================
+
+// the number of goroutines a build uses, see Options
+func (bvh *BVH[BoundType]) buildWorkers() int {
+	if bvh.options.BuildWorkers <= 0 {
+		return defaultBuildWorkers()
+	}
+	return bvh.options.BuildWorkers
+}
+
+// ..............................................
+
+// packs items (in curve order for PackHilbert) into nodes, level by level,
+// until they fit in the root, then fills the root with them
+func (bvh *BVH[BoundType]) packRoot(items []Boundable[BoundType], dims uint, workers int) {
+	for len(items) > bvh.options.MaxChildren-1 {
+		items = bvh.packLevel(items, dims, workers)
+	}
+	for _, child := range items {
+		bvh.root.children = bvh.appendChild(bvh.root.children, child)
+	}
+	fixParentPointers(&bvh.root)
+	bvh.recalculateBounds(&bvh.root)
+	assignLevels(&bvh.root)
+}
+
+// ..............................................
+
+// packs items (which are reordered by STR) into a level of full nodes
+func (bvh *BVH[BoundType]) packLevel(items []Boundable[BoundType], dims uint, workers int) []Boundable[BoundType] {
+	capacity := bvh.options.MaxChildren - 1
+	groups := make([][]Boundable[BoundType], 0, len(items)/capacity+1)
+	if bvh.options.Packing == PackHilbert {
+		groups = packRuns(items, capacity, groups) // parents stay in curve order
+	} else {
+		groups = bvh.strTiles(items, 0, dims, capacity, workers, groups)
+	}
+	return bvh.packNodes(groups, workers)
+}
+
+// ..............................................
+
+// ==============================================
+
+// appends to groups the runs of at most capacity items, packed by STR from
+// axis onwards (items are reordered); the slabs are tiled by up to workers
+// goroutines
+func (bvh *BVH[BoundType]) strTiles(items []Boundable[BoundType], axis uint, dims uint, capacity int, workers int, groups [][]Boundable[BoundType]) [][]Boundable[BoundType] {
+	sortCentroidsStable(bvh.boundtraits, items, axis, dims, workers)
+
+	leaves := (len(items) + capacity - 1) / capacity
+	if axis+1 >= dims || leaves <= 1 {
+		return packRuns(items, capacity, groups)
+	}
+
+	// as many slabs along this axis as leaves along each remaining axis:
+	slabs := int(math.Ceil(math.Pow(float64(leaves), 1.0/float64(dims-axis))))
+	slabsize := capacity * ((leaves + slabs - 1) / slabs)
+	var slablist [][]Boundable[BoundType]
+	for len(items) > 0 {
+		size := slabsize
+		if size > len(items) {
+			size = len(items)
+		}
+		slablist = append(slablist, items[:size])
+		items = items[size:]
+	}
+	tiles := make([][][]Boundable[BoundType], len(slablist))
+	inner := maxInt(1, workers/len(slablist))
+	parallelFor(len(slablist), workers, func(index int) {
+		tiles[index] = bvh.strTiles(slablist[index], axis+1, dims, capacity, inner, nil)
+	})
+	for _, tile := range tiles {
+		groups = append(groups, tile...)
+	}
+	return groups
+}
+
+// ..............................................
+
+// a node for each group, filled by up to workers goroutines; the nodes are
+// allocated first, since the arena is not safe for concurrent use
+func (bvh *BVH[BoundType]) packNodes(groups [][]Boundable[BoundType], workers int) []Boundable[BoundType] {
+	nodes := make([]*bvhNode[BoundType], len(groups))
+	for index := range groups {
+		nodes[index] = bvh.arena.alloc()
+	}
+	parallelFor(len(groups), workers, func(index int) {
+		node := nodes[index]
+		for _, child := range groups[index] {
+			node.children = bvh.appendChild(node.children, child)
+		}
+		fixParentPointers(node)
+		bvh.recalculateBounds(node)
+	})
+	parents := make([]Boundable[BoundType], len(nodes))
+	for index, node := range nodes {
+		parents[index] = node
+	}
+	return parents
+}
+
+// ..............................................
+
+// appends to groups the consecutive runs of at most capacity items
+func packRuns[BoundType any](items []Boundable[BoundType], capacity int, groups [][]Boundable[BoundType]) [][]Boundable[BoundType] {
+	for len(items) > capacity {
+		groups = append(groups, items[:capacity])
+		items = items[capacity:]
+	}
+	return append(groups, items)
+}
+
+// ..............................................
+
+// sorts items by the Hilbert codes of their centroids, relative to the
+// bound of all of them, returning a new slice; the codes are computed by up
+// to workers goroutines
+func (bvh *BVH[BoundType]) hilbertOrder(items []Boundable[BoundType], workers int) []Boundable[BoundType] {
+	frame := unionOf(bvh.boundtraits, items, -1)
+	codes := make([]uint64, len(items))
+	parallelFor(len(items), workers, func(index int) {
+		codes[index] = hilbertCode(bvh.boundtraits, items[index].GetBound(), frame)
+	})
+	sorted, _ := radixSortCodes(items, codes)
+	return sorted
+}
+
+// ..............................................
+
+// accounts for the elements of a build, as inserted() does for one element
+func (bvh *BVH[BoundType]) built(elements []Boundable[BoundType]) {
+	bvh.countBuilt(elements)
+	bvh.finishBuild()
+}
+
+// counts the elements of a build, which may arrive in several parts
+func (bvh *BVH[BoundType]) countBuilt(elements []Boundable[BoundType]) {
+	for _, element := range elements {
+		bvh.count++
+		bvh.contenthash += bvh.elementHash(element, element.GetBound())
+		if bvh.evictor != nil {
+			bvh.evictor.Inserted(element)
+		}
+		bvh.logChange(ChangeInserted, element, element.GetBound())
+	}
+}
+
+// marks the built tree dirty, and evicts any elements over capacity
+func (bvh *BVH[BoundType]) finishBuild() {
+	bvh.markDirty(&bvh.root, bvh.root.bound)
+	bvh.evictOverCapacity()
+}
+
+// ..............................................
+
+// sets the level of every node below node, from its own
+func assignLevels[BoundType any](node *bvhNode[BoundType]) {
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			value.level = node.level + 1
+			assignLevels(value)
+		}
+	}
+}
+
+// ==============================================
+
+// the fewest items per block worth sorting on a goroutine of its own
+const parallelSortBlock = 4096
+
+// ..............................................
+
+// sorts items stably by centroid, as medianSorter orders them; large slices
+// are divided into blocks sorted by up to workers goroutines, and the blocks
+// merged pairwise, which gives the same order as sorting them together
+func sortCentroidsStable[BoundType any](bounder BoundTraits[BoundType], items []Boundable[BoundType], axis uint, dims uint, workers int) {
+	if workers > len(items)/parallelSortBlock {
+		workers = len(items) / parallelSortBlock
+	}
+	if workers <= 1 {
+		sort.Stable(&medianSorter[BoundType]{bounder: bounder, items: items, axis: axis, dims: dims})
+		return
+	}
+
+	starts := make([]int, workers+1)
+	for block := range starts {
+		starts[block] = len(items) * block / workers
+	}
+	parallelFor(workers, workers, func(block int) {
+		sort.Stable(&medianSorter[BoundType]{bounder: bounder, items: items[starts[block]:starts[block+1]], axis: axis, dims: dims})
+	})
+
+	src, dst := items, make([]Boundable[BoundType], len(items))
+	for len(starts) > 2 {
+		pairs := len(starts) / 2 // the number of runs, rounded up, halved
+		parallelFor(pairs, workers, func(pair int) {
+			lo, mid, hi := starts[2*pair], starts[2*pair+1], starts[2*pair+1]
+			if 2*pair+2 < len(starts) {
+				hi = starts[2*pair+2]
+			}
+			mergeCentroids(bounder, src[lo:mid], src[mid:hi], dst[lo:hi], axis, dims)
+		})
+		merged := make([]int, 0, pairs+1)
+		for pair := 0; pair < pairs; pair++ {
+			merged = append(merged, starts[2*pair])
+		}
+		starts = append(merged, len(items))
+		src, dst = dst, src
+	}
+	if &src[0] != &items[0] {
+		copy(items, src)
+	}
+}
+
+// ..............................................
+
+// merges the sorted runs a and b into dst, taking from a first on ties
+func mergeCentroids[BoundType any](bounder BoundTraits[BoundType], a []Boundable[BoundType], b []Boundable[BoundType], dst []Boundable[BoundType], axis uint, dims uint) {
+	i, j := 0, 0
+	for index := range dst {
+		if j == len(b) || (i < len(a) && !centroidLess(bounder, b[j].GetBound(), a[i].GetBound(), axis, dims)) {
+			dst[index] = a[i]
+			i++
+		} else {
+			dst[index] = b[j]
+			j++
+		}
+	}
+}
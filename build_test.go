@@ -0,0 +1,249 @@
+package gobvh
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHBuild(t *testing.T) {
+	rng := rand.New(rand.NewSource(786))
+	boxes := randomBoxes(rng, 5000, 2.0)
+	elements := make([]Boundable[AABB2D], len(boxes))
+	inserted := New[AABB2D](Traits2D{})
+	for i, element := range boxes {
+		elements[i] = element
+		inserted.Insert(element)
+	}
+
+	bvh := New[AABB2D](Traits2D{})
+	bvh.Insert(Point2D{500.0, 500.0}) // replaced by Build()
+	bvh.Build(elements)
+	if err := bvh.Validate(); err != nil {
+		t.Errorf(err.Error())
+	}
+	if bvh.Len() != len(elements) || bvh.ContentHash() != inserted.ContentHash() {
+		t.Errorf("Expected %d elements after Build(), found %d", len(elements), bvh.Len())
+	}
+	for i, element := range elements {
+		if element != boxes[i] {
+			t.Errorf("Expected Build() to leave the order of elements unchanged")
+			break
+		}
+	}
+
+	for i := 0; i < 50; i++ {
+		region := randomRegion(rng, 10.0)
+		found := make([]Boundable[AABB2D], 0, 16)
+		bvh.FindAllIntersecting(region, func(element Boundable[AABB2D]) error {
+			found = append(found, element)
+			return nil
+		})
+		if !sameElements(found, intersecting(inserted, region)) {
+			t.Errorf("Search of the built tree disagrees with the inserted tree in %v", region)
+		}
+	}
+
+	stats := bvh.Stats()
+	if stats.MeanLeafSize < 0.9*float64(DefaultOptions().MaxChildren-1) {
+		t.Errorf("Expected packed leaves, found a mean leaf size of %g", stats.MeanLeafSize)
+	}
+	if bvh.SAHCost() >= inserted.SAHCost() {
+		t.Errorf("Expected a packed tree to cost less than an inserted one, found %g and %g", bvh.SAHCost(), inserted.SAHCost())
+	}
+
+	// the built tree remains modifiable:
+	for _, element := range boxes[:500] {
+		if !bvh.Erase(element) {
+			t.Errorf("Failed to erase %v from the built tree", element.GetBound())
+		}
+	}
+	bvh.Insert(Point2D{50.0, 50.0})
+	if err := bvh.Validate(); err != nil {
+		t.Errorf(err.Error())
+	}
+
+	bvh.Build(nil)
+	if bvh.Len() != 0 || len(bvh.root.children) != 0 {
+		t.Errorf("Expected Build(nil) to empty the tree")
+	}
+	bvh.Build(elements[:3])
+	if err := bvh.Validate(); err != nil || bvh.Len() != 3 {
+		t.Errorf("Expected a small build to fit in the root, found %d elements (%v)", bvh.Len(), err)
+	}
+}
+
+// ........................................................
+
+func TestBVHBuildHilbert(t *testing.T) {
+	rng := rand.New(rand.NewSource(795))
+	elements := make([]Boundable[AABB2D], 0, 5000)
+	for _, element := range randomBoxes(rng, 1000, 2.0) {
+		elements = append(elements, element)
+	}
+	for i := 0; i < 4000; i++ { // skewed: most of the data in a few clusters
+		cx, cy := float64(10+30*(i%3)), float64(20+25*(i%2))
+		elements = append(elements, Point2D{cx + rng.NormFloat64(), cy + rng.NormFloat64()})
+	}
+	inserted := New[AABB2D](Traits2D{})
+	for _, element := range elements {
+		inserted.Insert(element)
+	}
+
+	bvh := New[AABB2D](Traits2D{}, WithPacking(PackHilbert))
+	bvh.Build(elements)
+	if err := bvh.Validate(); err != nil {
+		t.Errorf(err.Error())
+	}
+	if bvh.Len() != len(elements) || bvh.ContentHash() != inserted.ContentHash() {
+		t.Errorf("Expected %d elements after Build(), found %d", len(elements), bvh.Len())
+	}
+	for i := 0; i < 50; i++ {
+		region := randomRegion(rng, 10.0)
+		if bvh.Count(region) != inserted.Count(region) {
+			t.Errorf("Search of the Hilbert packed tree disagrees with the inserted tree in %v", region)
+		}
+	}
+	if stats := bvh.Stats(); stats.MeanLeafSize < 0.9*float64(DefaultOptions().MaxChildren-1) {
+		t.Errorf("Expected packed leaves, found a mean leaf size of %g", stats.MeanLeafSize)
+	}
+	if bvh.SAHCost() >= inserted.SAHCost() {
+		t.Errorf("Expected a packed tree to cost less than an inserted one, found %g and %g", bvh.SAHCost(), inserted.SAHCost())
+	}
+}
+
+// ........................................................
+
+func TestBVHBuildWorkers(t *testing.T) {
+	rng := rand.New(rand.NewSource(796))
+	elements := randomBoxes(rng, 30000, 1.0)
+	for i := 0; i < 5000; i++ { // ties, which must not be ordered by chance
+		box := *elements[i].(*Box2D)
+		elements = append(elements, &box)
+	}
+
+	for _, packing := range []Packing{PackSTR, PackHilbert} {
+		serial := New[AABB2D](Traits2D{}, WithPacking(packing), WithBuildWorkers(1))
+		serial.Build(elements)
+		expected := collectElements(&serial.root, nil)
+		for _, workers := range []int{0, 2, 3, 8} {
+			bvh := New[AABB2D](Traits2D{}, WithPacking(packing), WithBuildWorkers(workers))
+			bvh.Build(elements)
+			if err := bvh.Validate(); err != nil {
+				t.Errorf(err.Error())
+			}
+			found := collectElements(&bvh.root, nil)
+			same := len(found) == len(expected) && bvh.root.descendants == serial.root.descendants
+			for i := 0; same && i < len(found); i++ {
+				same = found[i] == expected[i]
+			}
+			if !same {
+				t.Errorf("Expected the same tree from %d workers as from one (packing %d)", workers, packing)
+			}
+		}
+	}
+}
+
+// ........................................................
+
+func TestSortCentroidsStable(t *testing.T) {
+	rng := rand.New(rand.NewSource(796))
+	items := randomBoxes(rng, 3*parallelSortBlock+17, 1.0)
+	for i := 0; i < len(items); i += 3 {
+		box := *items[i].(*Box2D)
+		box.B.H = box.B.L // a different bound with the same first coordinate
+		items = append(items, &box)
+	}
+	expected := append([]Boundable[AABB2D](nil), items...)
+	sort.Stable(&medianSorter[AABB2D]{bounder: Traits2D{}, items: expected, axis: 1, dims: 2})
+
+	for _, workers := range []int{1, 2, 3, 4} {
+		sorted := append([]Boundable[AABB2D](nil), items...)
+		sortCentroidsStable[AABB2D](Traits2D{}, sorted, 1, 2, workers)
+		for i := range sorted {
+			if sorted[i] != expected[i] {
+				t.Errorf("Expected %d workers to sort as sort.Stable, differing at %d", workers, i)
+				break
+			}
+		}
+	}
+}
+
+// ........................................................
+
+func TestBVHBuildMedian(t *testing.T) {
+	rng := rand.New(rand.NewSource(801))
+	elements := randomBoxes(rng, 5000, 2.0)
+	inserted := New[AABB2D](Traits2D{})
+	for _, element := range elements {
+		inserted.Insert(element)
+	}
+
+	bvh := New[AABB2D](Traits2D{})
+	bvh.Insert(Point2D{500.0, 500.0}) // replaced by BuildMedian()
+	bvh.BuildMedian(elements)
+	if err := bvh.Validate(); err != nil {
+		t.Errorf(err.Error())
+	}
+	if bvh.Len() != len(elements) || bvh.ContentHash() != inserted.ContentHash() {
+		t.Errorf("Expected %d elements after BuildMedian(), found %d", len(elements), bvh.Len())
+	}
+	for i := 0; i < 50; i++ {
+		region := randomRegion(rng, 10.0)
+		if bvh.Count(region) != inserted.Count(region) {
+			t.Errorf("Search of the median built tree disagrees with the inserted tree in %v", region)
+		}
+	}
+	if bvh.SAHCost() >= inserted.SAHCost() {
+		t.Errorf("Expected a median built tree to cost less than an inserted one, found %g and %g", bvh.SAHCost(), inserted.SAHCost())
+	}
+
+	bvh.BuildMedian(nil)
+	if bvh.Len() != 0 || len(bvh.root.children) != 0 {
+		t.Errorf("Expected BuildMedian(nil) to empty the tree")
+	}
+}
+
+// ........................................................
+
+func BenchmarkBuild(b *testing.B) {
+	rng := rand.New(rand.NewSource(786))
+	elements := make([]Boundable[AABB2D], 100000)
+	for i, element := range randomBoxes(rng, len(elements), 1.0) {
+		elements[i] = element
+	}
+	bvh := New[AABB2D](Traits2D{})
+
+	b.Run("STR", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			bvh.Build(elements)
+		}
+	})
+	b.Run("STRSerial", func(b *testing.B) {
+		serial := New[AABB2D](Traits2D{}, WithBuildWorkers(1))
+		for i := 0; i < b.N; i++ {
+			serial.Build(elements)
+		}
+	})
+	b.Run("Hilbert", func(b *testing.B) {
+		hilbert := New[AABB2D](Traits2D{}, WithPacking(PackHilbert))
+		for i := 0; i < b.N; i++ {
+			hilbert.Build(elements)
+		}
+	})
+	b.Run("Median", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			bvh.BuildMedian(elements)
+		}
+	})
+	b.Run("Insert", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			bvh.ResetArena()
+			for _, element := range elements {
+				bvh.Insert(element)
+			}
+		}
+	})
+}
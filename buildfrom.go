@@ -0,0 +1,85 @@
+//go:build go1.23
+
+package gobvh
+
+import (
+	"iter"
+)
+
+// ==============================================
+
+//
+// BVH.BuildFrom(seq) replaces the contents of the bvh with the elements of
+// seq, bulk-loaded as Build() does, but consuming them lazily: elements are
+// buffered in chunks, and each chunk is packed into leaves before the next
+// is read, so only the leaves (not a slice of every element) are held while
+// loading.  This suits datasets read from disk or the network, e.g.
+//
+//	bvh.BuildFrom(func(yield func(gobvh.Boundable[Box]) bool) {
+//		for scanner.Scan() {
+//			if !yield(parseBox(scanner.Text())) {
+//				return
+//			}
+//		}
+//	})
+//
+// The leaves of each chunk are packed separately; the levels above them are
+// packed as Build() packs them.  Unless the sequence is spatially coherent
+// (e.g. sorted, or read tile by tile) the leaves of different chunks
+// overlap, and the tree is looser than one from Build() over the same
+// elements (by a quarter or so of SAHCost() for elements in random order).
+//
+// As ResetArena(), the nodes of the previous contents are recycled, and tags
+// and other per-element annotations are forgotten.
+//
+func (bvh *BVH[BoundType]) BuildFrom(seq iter.Seq[Boundable[BoundType]]) {
+	bvh.ResetArena()
+	workers := bvh.buildWorkers()
+	var dims uint
+	var leaves []Boundable[BoundType]
+	buffer := make([]Boundable[BoundType], 0, buildChunk)
+
+	// packs the buffer into leaves, which no longer refer to it:
+	flush := func() {
+		bvh.countBuilt(buffer)
+		items := buffer
+		if bvh.options.Packing == PackHilbert {
+			items = bvh.hilbertOrder(items, workers)
+		}
+		leaves = append(leaves, bvh.packLevel(items, dims, workers)...)
+		for index := range buffer {
+			buffer[index] = nil
+		}
+		buffer = buffer[:0]
+	}
+	for element := range seq {
+		if len(buffer) == 0 && len(leaves) == 0 {
+			dims = bvh.boundtraits.Dimensions(element.GetBound())
+		}
+		buffer = append(buffer, element)
+		if len(buffer) == buildChunk {
+			flush()
+		}
+	}
+
+	if len(leaves) == 0 {
+		// everything fit in one chunk:
+		if len(buffer) > 0 {
+			bvh.Build(buffer)
+		}
+		return
+	}
+	if len(buffer) > 0 {
+		flush()
+	}
+	if bvh.options.Packing == PackHilbert {
+		leaves = bvh.hilbertOrder(leaves, workers)
+	}
+	bvh.packRoot(leaves, dims, workers)
+	bvh.finishBuild()
+}
+
+// ==============================================
+
+// the number of elements BuildFrom() buffers before packing them
+const buildChunk = 1 << 16
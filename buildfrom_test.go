@@ -0,0 +1,62 @@
+//go:build go1.23
+
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHBuildFrom(t *testing.T) {
+	rng := rand.New(rand.NewSource(798))
+	elements := randomBoxes(rng, 2*buildChunk+1000, 1.0)
+	seq := func(yield func(Boundable[AABB2D]) bool) {
+		for _, element := range elements {
+			if !yield(element) {
+				return
+			}
+		}
+	}
+
+	for _, packing := range []Packing{PackSTR, PackHilbert} {
+		built := New[AABB2D](Traits2D{}, WithPacking(packing))
+		built.Build(elements)
+		bvh := New[AABB2D](Traits2D{}, WithPacking(packing))
+		bvh.Insert(Point2D{500.0, 500.0}) // replaced by BuildFrom()
+		bvh.BuildFrom(seq)
+		if err := bvh.Validate(); err != nil {
+			t.Errorf(err.Error())
+		}
+		if bvh.Len() != len(elements) || bvh.ContentHash() != built.ContentHash() {
+			t.Errorf("Expected %d elements after BuildFrom(), found %d", len(elements), bvh.Len())
+		}
+		for i := 0; i < 50; i++ {
+			region := randomRegion(rng, 10.0)
+			if bvh.Count(region) != built.Count(region) {
+				t.Errorf("Search of the streamed tree disagrees with the built tree in %v", region)
+			}
+		}
+		if bvh.SAHCost() > 1.5*built.SAHCost() {
+			t.Errorf("Expected a streamed tree within half again the cost of a built one, found %g and %g", bvh.SAHCost(), built.SAHCost())
+		}
+	}
+
+	// small and empty sequences:
+	bvh := New[AABB2D](Traits2D{})
+	bvh.BuildFrom(func(yield func(Boundable[AABB2D]) bool) {
+		for _, element := range elements[:100] {
+			if !yield(element) {
+				return
+			}
+		}
+	})
+	if err := bvh.Validate(); err != nil || bvh.Len() != 100 {
+		t.Errorf("Expected a small sequence to build, found %d elements (%v)", bvh.Len(), err)
+	}
+	bvh.BuildFrom(func(yield func(Boundable[AABB2D]) bool) {})
+	if bvh.Len() != 0 || len(bvh.root.children) != 0 {
+		t.Errorf("Expected an empty sequence to empty the tree")
+	}
+}
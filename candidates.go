@@ -0,0 +1,82 @@
+package gobvh
+
+// ==============================================
+
+//
+// BVH.CollectCandidates(pred) is the broad phase of a two-phase query.
+//
+// The predicate is given bounds, both of nodes in the hierarchy and of
+// elements, and should report whether the bound may contain something of
+// interest.  Subtrees whose bound fails the predicate are pruned.
+// Every element whose own bound passes the predicate is returned.
+//
+// The result is a conservative candidate set: it only reflects bounds,
+// not the exact geometry of the elements.  Run your exact (narrow phase)
+// test over the candidates, for example with VerifyCandidates().
+//
+func (bvh *BVH[BoundType]) CollectCandidates(pred func(BoundType) bool) []Boundable[BoundType] {
+	candidates := make([]Boundable[BoundType], 0, 8)
+	collector := predicateSearcher[BoundType]{
+		pred: pred,
+		fn: func(element Boundable[BoundType]) error {
+			candidates = append(candidates, element)
+			return nil
+		},
+	}
+	bvh.FindAll(&collector)
+	return candidates
+}
+
+// ..............................................
+
+//
+// VerifyCandidates(candidates, parallelism, verify) is the narrow phase of a two-phase query.
+//
+// It calls verify(element) for each of the candidates, running at most
+// parallelism calls concurrently (parallelism < 1 is treated as 1), and
+// returns the candidates for which verify reported true, in their original order.
+//
+// If any call to verify returns an error, no further candidates are
+// dispatched and the first error encountered is returned.
+//
+// Built for tinygo (or with the gobvh_embedded tag) the candidates are
+// always verified sequentially, without starting goroutines.
+//
+func VerifyCandidates[BoundType any](candidates []Boundable[BoundType], parallelism int, verify func(Boundable[BoundType]) (bool, error)) ([]Boundable[BoundType], error) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	accepted, err := verifyAll(candidates, parallelism, verify)
+	if err != nil {
+		return nil, err
+	}
+
+	verified := make([]Boundable[BoundType], 0, len(candidates))
+	for index, element := range candidates {
+		if accepted[index] {
+			verified = append(verified, element)
+		}
+	}
+	return verified, nil
+}
+
+// ==============================================
+
+// predicateSearcher adapts a bound predicate to the Searcher interface,
+// handing every element whose bound passes the predicate to fn.
+type predicateSearcher[BoundType any] struct {
+	pred func(BoundType) bool
+	fn   func(Boundable[BoundType]) error
+}
+
+func (ps *predicateSearcher[BoundType]) DoesIntersect(bound BoundType) bool {
+	return ps.pred(bound)
+}
+
+func (ps *predicateSearcher[BoundType]) Evaluate(element Boundable[BoundType]) error {
+	if ps.pred(element.GetBound()) {
+		return ps.fn(element)
+	}
+	return nil
+}
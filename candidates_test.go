@@ -0,0 +1,55 @@
+package gobvh
+
+import (
+	"fmt"
+	"testing"
+)
+
+// ========================================================
+
+func boxPredicate(region AABB2D) func(AABB2D) bool {
+	return func(b AABB2D) bool {
+		return b.L[0] <= region.H[0] && region.L[0] <= b.H[0] && b.L[1] <= region.H[1] && region.L[1] <= b.H[1]
+	}
+}
+
+func TestBVHCandidates(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	var x, y float64
+	for x = 0.0; x < 32.0; x += 1.0 {
+		for y = 0.0; y < 32.0; y += 1.0 {
+			bvh.Insert(Point2D{x, y})
+		}
+	}
+
+	// broad phase: everything in the box (2 2)-(5 5)
+	candidates := bvh.CollectCandidates(boxPredicate(AABB2D{Point2D{2.0, 2.0}, Point2D{5.0, 5.0}}))
+	if len(candidates) != 16 {
+		t.Errorf("Expected 16 candidates, found %d", len(candidates))
+	}
+
+	// narrow phase: only points on the diagonal
+	verified, err := VerifyCandidates(candidates, 4, func(element Boundable[AABB2D]) (bool, error) {
+		p := element.(Point2D)
+		return p[0] == p[1], nil
+	})
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if len(verified) != 4 {
+		t.Errorf("Expected 4 verified elements, found %d", len(verified))
+	}
+	for index := 1; index < len(verified); index++ {
+		if verified[index-1].(Point2D)[0] == verified[index].(Point2D)[0] {
+			t.Errorf("Duplicate verified element %v", verified[index])
+		}
+	}
+
+	// errors stop the verification:
+	_, err = VerifyCandidates(candidates, 2, func(element Boundable[AABB2D]) (bool, error) {
+		return false, fmt.Errorf("narrow phase failure")
+	})
+	if err == nil {
+		t.Errorf("Expected an error from the narrow phase")
+	}
+}
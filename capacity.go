@@ -0,0 +1,174 @@
+package gobvh
+
+import (
+	"container/list"
+	"sync"
+)
+
+// ==============================================
+
+//
+// Evictor chooses which element to evict from a capacity-bounded BVH,
+// see BVH.SetCapacity().
+//
+// The BVH reports every element inserted and erased, and every element a
+// query finds (a "hit"); Victim() is asked for the element to evict, nil
+// to evict nothing.  Reset() forgets everything.
+//
+// A query finds the elements it hands to its callback or returns: those
+// a Searcher intersects, those Count() counts, the closest element hit by
+// Raycast(), and so on.  Elements rejected by a query option, or evaluated
+// by a Searcher only to fail its intersection test, are not hits; nor are
+// the elements visited by ForEach() and All(), or paired by SelfCollide(),
+// NeighborEdges() and AllKNearest(), which walk the whole hierarchy.
+//
+// Searches do not otherwise modify the bvh, and may run concurrently, so
+// Hit() must be safe to call from several goroutines at once (and with the
+// other methods); the evictors provided are.
+//
+// NewFIFOEvictor() and NewLRUEvictor() provide the usual policies;
+// implement Evictor for your own.
+//
+type Evictor[BoundType any] interface {
+	Inserted(element Boundable[BoundType])
+	Erased(element Boundable[BoundType])
+	Hit(element Boundable[BoundType])
+	Victim() Boundable[BoundType]
+	Reset()
+}
+
+// ..............................................
+
+//
+// BVH.SetCapacity(capacity, evictor, onevict) bounds the number of elements
+// stored; capacity <= 0 (or a nil evictor) removes the bound.
+//
+// Whenever an insertion takes the bvh over capacity, the element chosen by
+// evictor.Victim() is erased, and then onevict(element) is called if onevict
+// is not nil, e.g. to release a streamed chunk.  The elements already
+// stored are reported to the evictor, in no particular order, and the bvh
+// is immediately brought within capacity.
+//
+func (bvh *BVH[BoundType]) SetCapacity(capacity int, evictor Evictor[BoundType], onevict func(Boundable[BoundType])) {
+	if capacity <= 0 || evictor == nil {
+		bvh.capacity, bvh.evictor, bvh.onevict = 0, nil, nil
+		return
+	}
+	bvh.capacity, bvh.evictor, bvh.onevict = capacity, evictor, onevict
+	evictor.Reset()
+	for _, element := range collectElements(&bvh.root, nil) {
+		evictor.Inserted(element)
+	}
+	bvh.evictOverCapacity()
+}
+
+// ..............................................
+
+//
+// BVH.Capacity() returns the bound on the number of elements, 0 if there is none.
+//
+func (bvh *BVH[BoundType]) Capacity() int {
+	return bvh.capacity
+}
+
+// ==============================================
+
+// erases the evictor's victims until the bvh is within capacity
+func (bvh *BVH[BoundType]) evictOverCapacity() {
+	for bvh.evictor != nil && bvh.count > bvh.capacity {
+		victim := bvh.evictor.Victim()
+		if victim == nil || !bvh.Erase(victim) {
+			return
+		}
+		if bvh.onevict != nil {
+			bvh.onevict(victim)
+		}
+	}
+}
+
+// ..............................................
+
+// reports element, found by a search, to the evictor
+func (bvh *BVH[BoundType]) hit(element Boundable[BoundType]) {
+	if bvh.evictor != nil {
+		bvh.evictor.Hit(element)
+	}
+}
+
+// ==============================================
+
+//
+// NewFIFOEvictor() returns an Evictor which evicts the element inserted first.
+//
+func NewFIFOEvictor[BoundType any]() Evictor[BoundType] {
+	return &listEvictor[BoundType]{}
+}
+
+// ..............................................
+
+//
+// NewLRUEvictor() returns an Evictor which evicts the element least recently
+// hit by a search (or inserted, if it has not been).
+//
+func NewLRUEvictor[BoundType any]() Evictor[BoundType] {
+	return &listEvictor[BoundType]{recency: true}
+}
+
+// ..............................................
+
+// listEvictor keeps elements in a list, oldest first; with recency,
+// hits move elements to the back.
+type listEvictor[BoundType any] struct {
+	recency bool
+	lock    sync.Mutex // hits come from concurrent searches
+	order   list.List
+	entries map[Boundable[BoundType]]*list.Element
+}
+
+func (le *listEvictor[BoundType]) Inserted(element Boundable[BoundType]) {
+	le.lock.Lock()
+	defer le.lock.Unlock()
+	if le.entries == nil {
+		le.entries = make(map[Boundable[BoundType]]*list.Element)
+	}
+	if entry, ok := le.entries[element]; ok {
+		le.order.MoveToBack(entry)
+		return
+	}
+	le.entries[element] = le.order.PushBack(element)
+}
+
+func (le *listEvictor[BoundType]) Erased(element Boundable[BoundType]) {
+	le.lock.Lock()
+	defer le.lock.Unlock()
+	if entry, ok := le.entries[element]; ok {
+		le.order.Remove(entry)
+		delete(le.entries, element)
+	}
+}
+
+func (le *listEvictor[BoundType]) Hit(element Boundable[BoundType]) {
+	if le.recency {
+		le.lock.Lock()
+		defer le.lock.Unlock()
+		if entry, ok := le.entries[element]; ok {
+			le.order.MoveToBack(entry)
+		}
+	}
+}
+
+func (le *listEvictor[BoundType]) Victim() Boundable[BoundType] {
+	le.lock.Lock()
+	defer le.lock.Unlock()
+	if front := le.order.Front(); front != nil {
+		return front.Value.(Boundable[BoundType])
+	}
+	return nil
+}
+
+func (le *listEvictor[BoundType]) Reset() {
+	le.lock.Lock()
+	defer le.lock.Unlock()
+	le.order.Init()
+	le.entries = nil
+}
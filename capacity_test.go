@@ -0,0 +1,179 @@
+package gobvh
+
+import (
+	"sync"
+	"testing"
+)
+
+// ========================================================
+
+// an Evictor which evicts the element furthest along x
+type FurthestEvictor struct {
+	elements map[Boundable[AABB2D]]bool
+}
+
+func (fe *FurthestEvictor) Inserted(element Boundable[AABB2D]) {
+	fe.elements[element] = true
+}
+
+func (fe *FurthestEvictor) Erased(element Boundable[AABB2D]) {
+	delete(fe.elements, element)
+}
+
+func (fe *FurthestEvictor) Hit(element Boundable[AABB2D]) {}
+
+func (fe *FurthestEvictor) Victim() Boundable[AABB2D] {
+	var victim Boundable[AABB2D]
+	for element := range fe.elements {
+		if victim == nil || element.GetBound().L[0] > victim.GetBound().L[0] {
+			victim = element
+		}
+	}
+	return victim
+}
+
+func (fe *FurthestEvictor) Reset() {
+	fe.elements = make(map[Boundable[AABB2D]]bool)
+}
+
+// ........................................................
+
+func TestBVHCapacity(t *testing.T) {
+	// FIFO:
+	bvh := New[AABB2D](Traits2D{})
+	evicted := []Boundable[AABB2D]{}
+	bvh.SetCapacity(50, NewFIFOEvictor[AABB2D](), func(element Boundable[AABB2D]) {
+		evicted = append(evicted, element)
+	})
+	for i := 0; i < 80; i++ {
+		bvh.Insert(Point2D{float64(i), 0.0})
+	}
+	if bvh.Len() != 50 || len(evicted) != 30 || len(collectElements(&bvh.root, nil)) != 50 {
+		t.Errorf("Expected 50 elements and 30 evictions, found %d and %d", bvh.Len(), len(evicted))
+	}
+	for i, element := range evicted {
+		if element != (Point2D{float64(i), 0.0}) {
+			t.Errorf("Expected FIFO evictions in insertion order, found %v at %d", element, i)
+		}
+	}
+
+	// LRU, with searches keeping the first ten elements alive:
+	bvh = New[AABB2D](Traits2D{})
+	bvh.SetCapacity(20, NewLRUEvictor[AABB2D](), nil)
+	recent := AABB2D{L: Point2D{-0.5, -1}, H: Point2D{9.5, 1}}
+	for i := 0; i < 100; i++ {
+		bvh.Insert(Point2D{float64(i), 0.0})
+		bvh.FindAll(&BoxCollector{Box: recent})
+	}
+	if bvh.Len() != 20 {
+		t.Errorf("Expected 20 elements, found %d", bvh.Len())
+	}
+	for i := 0; i < 10; i++ {
+		if !bvh.Contains(Point2D{float64(i), 0.0}) {
+			t.Errorf("Expected recently hit element %d to survive", i)
+		}
+	}
+	if !bvh.Contains(Point2D{99.0, 0.0}) || bvh.Contains(Point2D{50.0, 0.0}) {
+		t.Errorf("Expected the least recently used elements to be evicted")
+	}
+
+	// a custom policy, applied to elements already stored:
+	bvh = New[AABB2D](Traits2D{})
+	for i := 0; i < 30; i++ {
+		bvh.Insert(Point2D{float64(i), 0.0})
+	}
+	bvh.SetCapacity(10, &FurthestEvictor{}, nil)
+	if bvh.Len() != 10 || bvh.Contains(Point2D{10.0, 0.0}) || !bvh.Contains(Point2D{9.0, 0.0}) {
+		t.Errorf("Expected only the 10 nearest elements to remain, found %d", bvh.Len())
+	}
+
+	bvh.SetCapacity(0, nil, nil)
+	for i := 30; i < 60; i++ {
+		bvh.Insert(Point2D{float64(i), 0.0})
+	}
+	if bvh.Len() != 40 || bvh.Capacity() != 0 {
+		t.Errorf("Expected no bound after removing the capacity, found %d elements", bvh.Len())
+	}
+}
+
+// ........................................................
+
+func TestBVHCapacityQueryKinds(t *testing.T) {
+	hot := Point2D{0.0, 0.0}
+	box := AABB2D{L: Point2D{-0.5, -0.5}, H: Point2D{0.5, 0.5}}
+	batch := NewQueryBatch[AABB2D]()
+	batch.Add(box)
+	ray := NewSegment([]float64{0.0, -1.0}, []float64{0.0, 1.0})
+	entered := func(Boundable[AABB2D], Ray) (float64, bool, error) { return 0.5, true, nil }
+	queries := map[string]func(bvh *BVH[AABB2D]){
+		"FindAll": func(bvh *BVH[AABB2D]) {
+			bvh.FindAll(&BoxCollector{Box: box})
+		},
+		"FindNearest": func(bvh *BVH[AABB2D]) {
+			bvh.FindNearest(&BoxCollector{Box: box}, hot.GetBound())
+		},
+		"FindAllIntersecting": func(bvh *BVH[AABB2D]) {
+			bvh.FindAllIntersecting(box, func(Boundable[AABB2D]) error { return nil })
+		},
+		"Count": func(bvh *BVH[AABB2D]) {
+			bvh.Count(box)
+		},
+		"Stab": func(bvh *BVH[AABB2D]) {
+			bvh.Stab([]float64{0.0, 0.0}, func(Boundable[AABB2D]) error { return nil })
+		},
+		"Raycast": func(bvh *BVH[AABB2D]) {
+			bvh.Raycast(ray, entered)
+		},
+		"RaycastAny": func(bvh *BVH[AABB2D]) {
+			bvh.RaycastAny(ray, entered)
+		},
+		"RunBatch": func(bvh *BVH[AABB2D]) {
+			bvh.RunBatch(batch, func(int, Boundable[AABB2D]) error { return nil })
+		},
+	}
+
+	// one element queried after every insertion survives, however many
+	// neighbors its queries evaluate and reject:
+	for name, query := range queries {
+		bvh := New[AABB2D](Traits2D{})
+		bvh.SetCapacity(10, NewLRUEvictor[AABB2D](), nil)
+		bvh.Insert(hot)
+		for i := 1; i < 100; i++ {
+			bvh.Insert(Point2D{float64(i), 0.0})
+			query(bvh)
+		}
+		if !bvh.Contains(hot) {
+			t.Errorf("Expected the element found by %s to survive", name)
+		}
+		if bvh.Contains(Point2D{1.0, 0.0}) {
+			t.Errorf("Expected the neighbors rejected by %s to be evicted", name)
+		}
+	}
+}
+
+// ........................................................
+
+func TestBVHCapacityConcurrentSearches(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	bvh.SetCapacity(100, NewLRUEvictor[AABB2D](), nil)
+	for i := 0; i < 100; i++ {
+		bvh.Insert(Point2D{float64(i), 0.0})
+	}
+
+	// searches only record hits, so may run concurrently:
+	var searches sync.WaitGroup
+	for worker := 0; worker < 4; worker++ {
+		searches.Add(1)
+		go func(worker int) {
+			defer searches.Done()
+			for i := 0; i < 50; i++ {
+				x := float64((worker*50 + i) % 100)
+				bvh.FindAll(&BoxCollector{Box: AABB2D{L: Point2D{x - 0.5, -1}, H: Point2D{x + 0.5, 1}}})
+			}
+		}(worker)
+	}
+	searches.Wait()
+	if err := bvh.Validate(); err != nil || bvh.Len() != 100 {
+		t.Errorf("Expected concurrent searches to leave 100 elements, found %d (%v)", bvh.Len(), err)
+	}
+}
@@ -0,0 +1,87 @@
+package gobvh
+
+// ==============================================
+
+//
+// Swept is a moving element stored for continuous collision detection:
+// its bound is the union of the element's bounds at the start and end of
+// a timestep, so a fast-moving element cannot tunnel through another
+// between two discrete positions.  See NewSwept() and SweptCollide().
+//
+// Element is the moving element, Start and End its bounds at either end
+// of the timestep.
+//
+type Swept[BoundType any] struct {
+	Element Boundable[BoundType]
+	Start   BoundType
+	End     BoundType
+	bound   BoundType
+}
+
+// ..............................................
+
+//
+// NewSwept(traits, element, end) returns element swept from its current
+// bound to end, ready to be inserted.
+//
+func NewSwept[BoundType any](boundtraits BoundTraits[BoundType], element Boundable[BoundType], end BoundType) *Swept[BoundType] {
+	swept := &Swept[BoundType]{Element: element}
+	swept.Move(boundtraits, element.GetBound(), end)
+	return swept
+}
+
+// ..............................................
+
+//
+// Swept.Move(traits, start, end) sets the motion of the next timestep.
+// If the Swept is stored, pass its old bound to BVH.Update() afterwards.
+//
+func (swept *Swept[BoundType]) Move(boundtraits BoundTraits[BoundType], start BoundType, end BoundType) {
+	swept.Start, swept.End = start, end
+	swept.bound = boundtraits.Union(start, end)
+}
+
+// ..............................................
+
+// the swept bound:
+func (swept *Swept[BoundType]) GetBound() BoundType {
+	return swept.bound
+}
+
+// ==============================================
+
+//
+// SweptHit is a candidate of SweptCollide(): the element, and the conservative
+// time of impact 0 <= TOI <= 1, as a fraction of the displacement.
+//
+type SweptHit[BoundType any] struct {
+	Element Boundable[BoundType]
+	TOI     float64
+}
+
+// ..............................................
+
+//
+// BVH.SweptCollide(element, displacement) sweeps the bound of element along
+// displacement (one entry per dimension) and returns the stored elements
+// it may collide with, sorted by increasing time of impact.
+//
+// The time of impact is conservative: bounds are the boxes given by
+// IntervalRange(), and a stored Swept covers its whole motion, so the real
+// contact (if any) is never earlier.  The element itself, or a Swept of it,
+// is not reported.  Use Shapecast() to stop at the first real contact.
+//
+func (bvh *BVH[BoundType]) SweptCollide(element Boundable[BoundType], displacement []float64) []SweptHit[BoundType] {
+	hits := make([]SweptHit[BoundType], 0, 8)
+	bvh.Shapecast(element.GetBound(), displacement, func(other Boundable[BoundType], toi float64) error {
+		if other == element {
+			return nil
+		}
+		if swept, ok := other.(*Swept[BoundType]); ok && swept.Element == element {
+			return nil
+		}
+		hits = append(hits, SweptHit[BoundType]{Element: other, TOI: toi})
+		return nil
+	})
+	return hits
+}
@@ -0,0 +1,87 @@
+package gobvh
+
+import (
+	"math"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHSweptCollide(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	walls := []*Box2D{
+		{AABB2D{L: Point2D{30.0, -10.0}, H: Point2D{30.5, 10.0}}},
+		{AABB2D{L: Point2D{70.0, -10.0}, H: Point2D{70.5, 10.0}}},
+		{AABB2D{L: Point2D{50.0, -10.0}, H: Point2D{50.5, 10.0}}},
+		{AABB2D{L: Point2D{50.0, 20.0}, H: Point2D{50.5, 30.0}}}, // out of the way
+	}
+	for _, wall := range walls {
+		bvh.Insert(wall)
+	}
+	bullet := &Box2D{AABB2D{L: Point2D{0.0, 0.0}, H: Point2D{1.0, 1.0}}}
+	bvh.Insert(bullet)
+
+	// the bullet passes through three walls in one step:
+	hits := bvh.SweptCollide(bullet, []float64{100.0, 0.0})
+	if len(hits) != 3 {
+		t.Fatalf("Expected 3 walls hit, found %d", len(hits))
+	}
+	for i, wall := range []*Box2D{walls[0], walls[2], walls[1]} {
+		expected := (wall.B.L[0] - 1.0) / 100.0
+		if hits[i].Element != wall || math.Abs(hits[i].TOI-expected) > 1e-12 {
+			t.Errorf("Expected hit %d on %v at %g, found %v at %g", i, wall, expected, hits[i].Element, hits[i].TOI)
+		}
+	}
+
+	// a slower bullet stops short of the second wall:
+	if hits := bvh.SweptCollide(bullet, []float64{40.0, 0.0}); len(hits) != 1 || hits[0].Element != walls[0] {
+		t.Errorf("Expected only the first wall hit, found %v", hits)
+	}
+}
+
+// ........................................................
+
+func TestBVHSwept(t *testing.T) {
+	traits := Traits2D{}
+	bvh := New[AABB2D](traits)
+	wall := &Box2D{AABB2D{L: Point2D{50.0, -10.0}, H: Point2D{50.5, 10.0}}}
+	bvh.Insert(wall)
+
+	// discretely, the bullet is never inside the wall; its swept bound is:
+	bullet := &Box2D{AABB2D{L: Point2D{0.0, 0.0}, H: Point2D{1.0, 1.0}}}
+	end := AABB2D{L: Point2D{100.0, 0.0}, H: Point2D{101.0, 1.0}}
+	swept := NewSwept[AABB2D](traits, bullet, end)
+	bvh.Insert(swept)
+	if bound := swept.GetBound(); bound.L != bullet.B.L || bound.H != end.H {
+		t.Errorf("Expected the swept bound to span the motion, found %v", bound)
+	}
+	pairs := 0
+	bvh.SelfCollide(func(x Boundable[AABB2D], y Boundable[AABB2D]) error {
+		pairs++
+		return nil
+	})
+	if pairs != 1 {
+		t.Errorf("Expected the swept bullet to collide with the wall, found %d pairs", pairs)
+	}
+
+	// the swept element is not its own candidate:
+	if hits := bvh.SweptCollide(bullet, []float64{100.0, 0.0}); len(hits) != 1 || hits[0].Element != wall {
+		t.Errorf("Expected only the wall hit, found %v", hits)
+	}
+
+	// next timestep, moving away from the wall:
+	oldbound := swept.GetBound()
+	bullet.B = end
+	swept.Move(traits, end, AABB2D{L: Point2D{200.0, 0.0}, H: Point2D{201.0, 1.0}})
+	if !bvh.Update(swept, oldbound) {
+		t.Fatalf("Failed to update the swept element")
+	}
+	pairs = 0
+	bvh.SelfCollide(func(x Boundable[AABB2D], y Boundable[AABB2D]) error {
+		pairs++
+		return nil
+	})
+	if pairs != 0 {
+		t.Errorf("Expected no collision after moving away, found %d pairs", pairs)
+	}
+}
@@ -0,0 +1,37 @@
+package gobvh
+
+// ==============================================
+
+//
+// BVH.Clone() returns an independent copy of the bvh: the same hierarchy,
+// node for node, over the same elements (which are shared, not copied).
+//
+// Either tree may then be modified without affecting the other, e.g. to
+// fork the state of a simulation for speculative evaluation, and a clone
+// searches, and changes under the same modifications, exactly as the
+// original does.  Options, tags and other annotations, Version(),
+// ContentHash(), the SplitStrategy and the InsertHeuristic are copied.  The capacity bound (see
+// SetCapacity()), whose evictor holds state of its own, the recorder (see
+// SetRecorder()), the dirty regions, any deferred mutations (see Defer())
+// and the journal (see SetJournal()) are not: the clone starts unbounded,
+// unrecorded, clean, flushed and unjournaled.
+//
+func (bvh *BVH[BoundType]) Clone() *BVH[BoundType] {
+	clone := &BVH[BoundType]{
+		boundtraits: bvh.boundtraits,
+		snaptraits:  bvh.snaptraits,
+		fattraits:   bvh.fattraits,
+		options:     bvh.options,
+		count:       bvh.count,
+		contenthash: bvh.contenthash,
+		version:     bvh.version,
+		splitter:    bvh.splitter,
+		inserter:    bvh.inserter,
+		tunestate:   bvh.tunestate,
+	}
+	for element, info := range bvh.info {
+		clone.setInfo(element, *info)
+	}
+	clone.cloneNode(&clone.root, &bvh.root, nil)
+	return clone
+}
@@ -0,0 +1,116 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHClone(t *testing.T) {
+	rng := rand.New(rand.NewSource(800))
+	bvh := New[AABB2D](Traits2D{}, WithMaxChildren(8))
+	randomPoints := func(n int) []Boundable[AABB2D] { // the digest requires points
+		points := make([]Boundable[AABB2D], n)
+		for i := range points {
+			points[i] = Point2D{rng.Float64() * 100.0, rng.Float64() * 100.0}
+		}
+		return points
+	}
+	elements := randomPoints(3000)
+	for i, element := range elements {
+		if i%10 == 0 {
+			bvh.InsertTagged(element, 3)
+		} else {
+			bvh.Insert(element)
+		}
+	}
+
+	clone := bvh.Clone()
+	if err := clone.Validate(); err != nil {
+		t.Errorf(err.Error())
+	}
+	var original, copied digest
+	original.addNode(&bvh.root)
+	copied.addNode(&clone.root)
+	if original.hash != copied.hash || clone.Len() != bvh.Len() || clone.ContentHash() != bvh.ContentHash() {
+		t.Errorf("Expected the clone to have the same hierarchy and contents")
+	}
+	if clone.Options() != bvh.Options() || clone.Version() != bvh.Version() {
+		t.Errorf("Expected the clone to keep the options and version")
+	}
+	everything := func(AABB2D) bool { return true }
+	if len(clone.FindAllWithTag(3, everything)) != 300 {
+		t.Errorf("Expected the clone to keep tags")
+	}
+
+	// the same modifications give the same trees, and the trees are independent:
+	more := randomPoints(500)
+	for _, element := range more {
+		bvh.Insert(element)
+		clone.Insert(element)
+	}
+	for _, element := range elements[:300] {
+		bvh.Erase(element)
+		clone.Erase(element)
+	}
+	original, copied = digest{}, digest{}
+	original.addNode(&bvh.root)
+	copied.addNode(&clone.root)
+	if original.hash != copied.hash {
+		t.Errorf("Expected the clone to change as the original under the same modifications")
+	}
+	for _, element := range elements[300:1300] {
+		clone.Erase(element)
+	}
+	clone.InsertTagged(Point2D{50.0, 50.0}, 3)
+	if err := bvh.Validate(); err != nil || bvh.Len() != 3200 {
+		t.Errorf("Expected modifying the clone to leave the original alone, found %d elements (%v)", bvh.Len(), err)
+	}
+	if err := clone.Validate(); err != nil || clone.Len() != 2201 {
+		t.Errorf("Expected 2201 elements in the modified clone, found %d (%v)", clone.Len(), err)
+	}
+	if len(bvh.FindAllWithTag(3, everything)) != 270 {
+		t.Errorf("Expected the original's tags to be unaffected by the clone")
+	}
+
+	empty := New[AABB2D](Traits2D{}).Clone()
+	if err := empty.Validate(); err != nil || empty.Len() != 0 {
+		t.Errorf("Expected the clone of an empty tree to be empty (%v)", err)
+	}
+	empty.Insert(Point2D{1.0, 1.0})
+	if empty.Len() != 1 {
+		t.Errorf("Expected the clone of an empty tree to accept insertions")
+	}
+}
+
+// ........................................................
+
+func TestBVHCloneInsertHeuristic(t *testing.T) {
+	rng := rand.New(rand.NewSource(802))
+	points := make([]Boundable[AABB2D], 1500) // the digest requires points
+	for i := range points {
+		points[i] = Point2D{rng.Float64() * 100.0, rng.Float64() * 100.0}
+	}
+	bvh := New[AABB2D](Traits2D{}, WithMaxChildren(6))
+	bvh.SetInsertHeuristic(EnlargementInsert[AABB2D]{})
+	for _, element := range points[:500] {
+		bvh.Insert(element)
+	}
+
+	// later insertions are placed by the same heuristic in both trees:
+	clone := bvh.Clone()
+	for _, element := range points[500:] {
+		bvh.Insert(element)
+		clone.Insert(element)
+	}
+	if err := clone.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	var original, copied digest
+	original.addNode(&bvh.root)
+	copied.addNode(&clone.root)
+	if original.hash != copied.hash || clone.SAHCost() != bvh.SAHCost() {
+		t.Errorf("Expected the clone to place insertions as the original does")
+	}
+}
@@ -0,0 +1,305 @@
+// # gobvh-bench: compare GoBVH against brute force and a uniform grid.
+//
+// For each dataset size and query selectivity, gobvh-bench builds a BVH, a
+// flat slice (linear scan) and a uniform grid over the same random boxes,
+// runs the same window queries against all three, checks that they agree,
+// and writes one CSV row per method:
+//
+//	size,selectivity,distribution,method,build_ns,query_ns,avg_results
+//
+// Selectivity is the fraction of the unit square covered by each query window.
+// Use -distribution clustered to see how skewed data affects the grid.
+//
+// Example:
+//
+//	go run ./cmd/gobvh-bench -sizes 1000,100000 -selectivity 0.0001,0.01 -out report.csv
+//
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/drone115b/gobvh"
+)
+
+// ==============================================
+
+type box struct {
+	lo [2]float64
+	hi [2]float64
+}
+
+func (b *box) GetBound() box {
+	return *b
+}
+
+func (b box) intersects(other box) bool {
+	return b.lo[0] <= other.hi[0] && other.lo[0] <= b.hi[0] && b.lo[1] <= other.hi[1] && other.lo[1] <= b.hi[1]
+}
+
+// ..............................................
+
+type boxTraits struct{}
+
+func (traits boxTraits) IntervalRange(bound box, dim uint) (float64, float64) {
+	return bound.lo[dim], bound.hi[dim]
+}
+
+func (traits boxTraits) Union(a box, b box) box {
+	return box{
+		lo: [2]float64{math.Min(a.lo[0], b.lo[0]), math.Min(a.lo[1], b.lo[1])},
+		hi: [2]float64{math.Max(a.hi[0], b.hi[0]), math.Max(a.hi[1], b.hi[1])},
+	}
+}
+
+func (traits boxTraits) Dimensions(bound box) uint {
+	return 2
+}
+
+// ..............................................
+
+// counts the elements intersecting a window
+type windowCounter struct {
+	window box
+	count  int
+}
+
+func (wc *windowCounter) DoesIntersect(bound box) bool {
+	return wc.window.intersects(bound)
+}
+
+func (wc *windowCounter) Evaluate(element gobvh.Boundable[box]) error {
+	if wc.window.intersects(element.GetBound()) {
+		wc.count++
+	}
+	return nil
+}
+
+// ==============================================
+
+// uniform grid over the unit square, each cell lists the boxes overlapping it
+type grid struct {
+	res   int
+	cells [][]*box
+}
+
+func newGrid(boxes []*box) *grid {
+	res := int(math.Max(1.0, math.Sqrt(float64(len(boxes))/4.0)))
+	g := &grid{res: res, cells: make([][]*box, res*res)}
+	for _, b := range boxes {
+		x0, y0, x1, y1 := g.cellRange(*b)
+		for y := y0; y <= y1; y++ {
+			for x := x0; x <= x1; x++ {
+				g.cells[y*res+x] = append(g.cells[y*res+x], b)
+			}
+		}
+	}
+	return g
+}
+
+func (g *grid) cellRange(b box) (int, int, int, int) {
+	clamp := func(v float64) int {
+		c := int(v * float64(g.res))
+		if c < 0 {
+			return 0
+		}
+		if c >= g.res {
+			return g.res - 1
+		}
+		return c
+	}
+	return clamp(b.lo[0]), clamp(b.lo[1]), clamp(b.hi[0]), clamp(b.hi[1])
+}
+
+func (g *grid) count(window box) int {
+	// a box spanning several cells is reported once, from the first cell
+	// of the window that it overlaps:
+	x0, y0, x1, y1 := g.cellRange(window)
+	n := 0
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			for _, b := range g.cells[y*g.res+x] {
+				if window.intersects(*b) {
+					bx, by, _, _ := g.cellRange(*b)
+					if (bx == x || (x == x0 && bx < x0)) && (by == y || (y == y0 && by < y0)) {
+						n++
+					}
+				}
+			}
+		}
+	}
+	return n
+}
+
+// ==============================================
+
+func makeBoxes(rng *rand.Rand, n int, distribution string) []*box {
+	boxes := make([]*box, n)
+	for i := range boxes {
+		var x, y float64
+		if distribution == "clustered" {
+			// a few tight gaussian clusters:
+			cx, cy := float64(i%8)/8.0+0.0625, float64((i/8)%8)/8.0+0.0625
+			x = math.Min(math.Max(cx+rng.NormFloat64()*0.01, 0.0), 1.0)
+			y = math.Min(math.Max(cy+rng.NormFloat64()*0.01, 0.0), 1.0)
+		} else {
+			x, y = rng.Float64(), rng.Float64()
+		}
+		size := rng.Float64() * 0.001
+		boxes[i] = &box{lo: [2]float64{x, y}, hi: [2]float64{math.Min(x+size, 1.0), math.Min(y+size, 1.0)}}
+	}
+	return boxes
+}
+
+func makeWindows(rng *rand.Rand, n int, selectivity float64) []box {
+	side := math.Sqrt(selectivity)
+	windows := make([]box, n)
+	for i := range windows {
+		x, y := rng.Float64()*(1.0-side), rng.Float64()*(1.0-side)
+		windows[i] = box{lo: [2]float64{x, y}, hi: [2]float64{x + side, y + side}}
+	}
+	return windows
+}
+
+// ..............................................
+
+func parseList(text string, parse func(string) (float64, error)) ([]float64, error) {
+	values := make([]float64, 0, 4)
+	for _, field := range strings.Split(text, ",") {
+		value, err := parse(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// ..............................................
+
+func run(out io.Writer, sizes []float64, selectivities []float64, queries int, distribution string, seed int64) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"size", "selectivity", "distribution", "method", "build_ns", "query_ns", "avg_results"})
+
+	for _, size := range sizes {
+		rng := rand.New(rand.NewSource(seed))
+		boxes := makeBoxes(rng, int(size), distribution)
+
+		start := time.Now()
+		bvh := gobvh.New[box](boxTraits{})
+		for _, b := range boxes {
+			bvh.Insert(b)
+		}
+		bvhbuild := time.Since(start)
+
+		start = time.Now()
+		g := newGrid(boxes)
+		gridbuild := time.Since(start)
+
+		for _, selectivity := range selectivities {
+			windows := makeWindows(rng, queries, selectivity)
+			results := make([][3]int, len(windows))
+
+			start = time.Now()
+			for i, window := range windows {
+				wc := windowCounter{window: window}
+				bvh.FindAll(&wc)
+				results[i][0] = wc.count
+			}
+			bvhquery := time.Since(start)
+
+			start = time.Now()
+			for i, window := range windows {
+				for _, b := range boxes {
+					if window.intersects(*b) {
+						results[i][1]++
+					}
+				}
+			}
+			linearquery := time.Since(start)
+
+			start = time.Now()
+			for i, window := range windows {
+				results[i][2] = g.count(window)
+			}
+			gridquery := time.Since(start)
+
+			total := 0
+			for i, r := range results {
+				if r[0] != r[1] || r[1] != r[2] {
+					return fmt.Errorf("query %d disagrees: bvh %d, linear %d, grid %d", i, r[0], r[1], r[2])
+				}
+				total += r[0]
+			}
+
+			avg := strconv.FormatFloat(float64(total)/float64(len(windows)), 'f', 2, 64)
+			row := func(method string, build time.Duration, query time.Duration) {
+				w.Write([]string{
+					strconv.Itoa(int(size)),
+					strconv.FormatFloat(selectivity, 'g', -1, 64),
+					distribution,
+					method,
+					strconv.FormatInt(build.Nanoseconds(), 10),
+					strconv.FormatInt(query.Nanoseconds()/int64(len(windows)), 10),
+					avg,
+				})
+			}
+			row("bvh", bvhbuild, bvhquery)
+			row("linear", 0, linearquery)
+			row("grid", gridbuild, gridquery)
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// ==============================================
+
+func main() {
+	sizesflag := flag.String("sizes", "1000,10000,100000", "comma-separated dataset sizes")
+	selectivityflag := flag.String("selectivity", "0.0001,0.001,0.01", "comma-separated fractions of the unit square covered by each query")
+	queries := flag.Int("queries", 200, "number of queries per measurement")
+	distribution := flag.String("distribution", "uniform", "uniform or clustered")
+	seed := flag.Int64("seed", 1, "random seed")
+	outpath := flag.String("out", "", "CSV output file (default: standard output)")
+	flag.Parse()
+
+	sizes, err := parseList(*sizesflag, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+	if err == nil && *queries < 1 {
+		err = fmt.Errorf("-queries must be positive")
+	}
+	var selectivities []float64
+	if err == nil {
+		selectivities, err = parseList(*selectivityflag, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gobvh-bench:", err)
+		os.Exit(2)
+	}
+
+	var out io.Writer = os.Stdout
+	if *outpath != "" {
+		file, err := os.Create(*outpath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "gobvh-bench:", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if err := run(out, sizes, selectivities, *queries, *distribution, *seed); err != nil {
+		fmt.Fprintln(os.Stderr, "gobvh-bench:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,49 @@
+// # gobvh-soak: run the GoBVH soak test from the command line.
+//
+// It runs soak.Run() for the given duration and writes the samples as CSV,
+// exiting with status 1 if an invariant is violated.  See package soak.
+//
+// Example:
+//
+//	go run ./cmd/gobvh-soak -duration 24h -sample 10m -out soak.csv
+//
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/drone115b/gobvh/soak"
+)
+
+// ==============================================
+
+func main() {
+	defaults := soak.DefaultConfig()
+	duration := flag.Duration("duration", defaults.Duration, "how long to run")
+	sample := flag.Duration("sample", defaults.SampleEvery, "how often to sample the tree")
+	elements := flag.Int("elements", defaults.MaxElements, "maximum number of elements")
+	check := flag.Int("check", defaults.CheckEvery, "operations between queries checked against brute force")
+	seed := flag.Int64("seed", defaults.Seed, "random seed")
+	outpath := flag.String("out", "", "CSV output file (default: standard output)")
+	flag.Parse()
+
+	var out io.Writer = os.Stdout
+	if *outpath != "" {
+		file, err := os.Create(*outpath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "gobvh-soak:", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	config := soak.Config{Duration: *duration, SampleEvery: *sample, MaxElements: *elements, CheckEvery: *check, Seed: *seed}
+	if _, err := soak.Run(config, out); err != nil {
+		fmt.Fprintln(os.Stderr, "gobvh-soak:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,43 @@
+package gobvh
+
+// ==============================================
+
+//
+// Collide(a, b, pairFn) calls pairFn(x, y) for every pair of elements, x
+// stored in a and y stored in b, whose bounds overlap (as the boxes given
+// by IntervalRange()).
+//
+// Both trees are descended simultaneously, so pairs of subtrees which do
+// not overlap are pruned together.  a's traits are used for the tests.
+// An error returned by pairFn stops the traversal and is returned.
+//
+func Collide[BoundType any](a *BVH[BoundType], b *BVH[BoundType], pairFn func(x Boundable[BoundType], y Boundable[BoundType]) error) error {
+	if len(a.root.children) == 0 || len(b.root.children) == 0 {
+		return nil
+	}
+	prune := func(x BoundType, y BoundType) bool {
+		return !boundsOverlap(a.boundtraits, x, y)
+	}
+	return crossPairs[BoundType](&a.root, &b.root, prune, pairFn)
+}
+
+// ..............................................
+
+//
+// BVH.SelfCollide(pairFn) calls pairFn(x, y) once for every unordered pair
+// of distinct stored elements whose bounds overlap (as the boxes given by
+// IntervalRange()), e.g. for a physics broadphase.
+//
+// Each node is paired with itself only through its distinct pairs of
+// children, so no element is paired with itself and no pair is reported
+// twice.  An error returned by pairFn stops the traversal and is returned.
+//
+func (bvh *BVH[BoundType]) SelfCollide(pairFn func(x Boundable[BoundType], y Boundable[BoundType]) error) error {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QuerySelfCollide})
+	}
+	prune := func(x BoundType, y BoundType) bool {
+		return !boundsOverlap(bvh.boundtraits, x, y)
+	}
+	return selfPairs(&bvh.root, prune, pairFn)
+}
@@ -0,0 +1,124 @@
+package gobvh
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+// Box2D is an element with extent:
+type Box2D struct {
+	B AABB2D
+}
+
+func (box *Box2D) GetBound() AABB2D {
+	return box.B
+}
+
+func randomBoxes(rng *rand.Rand, count int, size float64) []Boundable[AABB2D] {
+	boxes := make([]Boundable[AABB2D], count)
+	for i := range boxes {
+		x, y := rng.Float64()*100.0, rng.Float64()*100.0
+		boxes[i] = &Box2D{AABB2D{L: Point2D{x, y}, H: Point2D{x + rng.Float64()*size, y + rng.Float64()*size}}}
+	}
+	return boxes
+}
+
+// ........................................................
+
+func TestCollide(t *testing.T) {
+	rng := rand.New(rand.NewSource(17))
+	left, right := randomBoxes(rng, 600, 4.0), randomBoxes(rng, 400, 4.0)
+	a, b := New[AABB2D](Traits2D{}), New[AABB2D](Traits2D{})
+	for _, element := range left {
+		a.Insert(element)
+	}
+	for _, element := range right {
+		b.Insert(element)
+	}
+
+	expected := 0
+	for _, x := range left {
+		for _, y := range right {
+			if boundsOverlap[AABB2D](Traits2D{}, x.GetBound(), y.GetBound()) {
+				expected++
+			}
+		}
+	}
+
+	type pair struct{ x, y Boundable[AABB2D] }
+	seen := make(map[pair]bool)
+	err := Collide(a, b, func(x Boundable[AABB2D], y Boundable[AABB2D]) error {
+		if !boundsOverlap[AABB2D](Traits2D{}, x.GetBound(), y.GetBound()) {
+			t.Errorf("Collide reported non-overlapping pair")
+		}
+		if seen[pair{x, y}] {
+			t.Errorf("Collide reported a pair twice")
+		}
+		seen[pair{x, y}] = true
+		return nil
+	})
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if expected == 0 || len(seen) != expected {
+		t.Errorf("Expected %d overlapping pairs, found %d", expected, len(seen))
+	}
+
+	stop := errors.New("stop")
+	err = Collide(a, b, func(x Boundable[AABB2D], y Boundable[AABB2D]) error {
+		return stop
+	})
+	if err != stop {
+		t.Errorf("Expected the pair function's error, found %v", err)
+	}
+
+	err = Collide(New[AABB2D](Traits2D{}), b, func(x Boundable[AABB2D], y Boundable[AABB2D]) error {
+		t.Errorf("Unexpected pair with an empty tree")
+		return nil
+	})
+}
+
+// ........................................................
+
+func TestBVHSelfCollide(t *testing.T) {
+	rng := rand.New(rand.NewSource(23))
+	boxes := randomBoxes(rng, 800, 5.0)
+	bvh := New[AABB2D](Traits2D{})
+	for _, element := range boxes {
+		bvh.Insert(element)
+	}
+
+	expected := 0
+	for i, x := range boxes {
+		for _, y := range boxes[i+1:] {
+			if boundsOverlap[AABB2D](Traits2D{}, x.GetBound(), y.GetBound()) {
+				expected++
+			}
+		}
+	}
+
+	type pair struct{ x, y Boundable[AABB2D] }
+	seen := make(map[pair]bool)
+	err := bvh.SelfCollide(func(x Boundable[AABB2D], y Boundable[AABB2D]) error {
+		if x == y {
+			t.Errorf("SelfCollide paired an element with itself")
+		}
+		if !boundsOverlap[AABB2D](Traits2D{}, x.GetBound(), y.GetBound()) {
+			t.Errorf("SelfCollide reported non-overlapping pair")
+		}
+		if seen[pair{x, y}] || seen[pair{y, x}] {
+			t.Errorf("SelfCollide reported a pair twice")
+		}
+		seen[pair{x, y}] = true
+		return nil
+	})
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if expected == 0 || len(seen) != expected {
+		t.Errorf("Expected %d overlapping pairs, found %d", expected, len(seen))
+	}
+}
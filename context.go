@@ -0,0 +1,94 @@
+package gobvh
+
+import (
+	"context"
+)
+
+// ==============================================
+
+//
+// BVH.FindAllContext(ctx, searcher, options...) is FindAll() with cancellation.
+//
+// The context is checked periodically during the traversal; once it is
+// done, the search stops and ctx.Err() is returned.
+//
+func (bvh *BVH[BoundType]) FindAllContext(ctx context.Context, s Searcher[BoundType], opts ...QueryOption[BoundType]) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QuerySearchContext, Context: ctx, Searcher: s, Options: opts})
+	}
+	cs := contextSearcher[BoundType]{ctx: ctx, searcher: s}
+	return cs.result(bvh.findAll(&cs, opts))
+}
+
+// ..............................................
+
+//
+// BVH.FindNearestContext(ctx, searcher, here, options...) is FindNearest() with cancellation.
+//
+// The context is checked periodically during the traversal; once it is
+// done, the search stops and ctx.Err() is returned.
+//
+func (bvh *BVH[BoundType]) FindNearestContext(ctx context.Context, s Searcher[BoundType], here BoundType, opts ...QueryOption[BoundType]) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryNearestContext, Context: ctx, Bound: here, Searcher: s, Options: opts})
+	}
+	cs := contextSearcher[BoundType]{ctx: ctx, searcher: s}
+	return cs.result(bvh.findNearest(&cs, here, bvh.newTraversal(true, opts)))
+}
+
+// ==============================================
+
+// how many searcher calls pass between checks of the context
+const contextCheckInterval = 64
+
+// ..............................................
+
+// contextSearcher wraps a Searcher, cutting the search short once the context is done.
+type contextSearcher[BoundType any] struct {
+	ctx      context.Context
+	searcher Searcher[BoundType]
+	calls    int
+	err      error
+}
+
+func (cs *contextSearcher[BoundType]) DoesIntersect(bound BoundType) bool {
+	if cs.cancelled() {
+		return false
+	}
+	return cs.searcher.DoesIntersect(bound)
+}
+
+func (cs *contextSearcher[BoundType]) Evaluate(element Boundable[BoundType]) error {
+	if cs.cancelled() {
+		return cs.err
+	}
+	return cs.searcher.Evaluate(element)
+}
+
+// ..............................................
+
+func (cs *contextSearcher[BoundType]) cancelled() bool {
+	if cs.err == nil {
+		cs.calls++
+		if cs.calls%contextCheckInterval == 0 {
+			cs.err = cs.ctx.Err()
+		}
+	}
+	return cs.err != nil
+}
+
+// ..............................................
+
+// prefers the context's error over the result of the search
+func (cs *contextSearcher[BoundType]) result(err error) error {
+	if cs.err != nil {
+		return cs.err
+	}
+	return err
+}
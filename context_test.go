@@ -0,0 +1,71 @@
+package gobvh
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// ========================================================
+
+// a searcher which visits everything, cancelling a context partway through
+type CancellingSearcher struct {
+	Evaluated int
+	CancelAt  int
+	Cancel    context.CancelFunc
+}
+
+func (cs *CancellingSearcher) DoesIntersect(bound AABB2D) bool {
+	return true
+}
+
+func (cs *CancellingSearcher) Evaluate(element Boundable[AABB2D]) error {
+	cs.Evaluated++
+	if cs.Evaluated == cs.CancelAt {
+		cs.Cancel()
+	}
+	return nil
+}
+
+// ........................................................
+
+func TestBVHContextSearch(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	for x := 0; x < 50; x++ {
+		for y := 0; y < 50; y++ {
+			bvh.Insert(Point2D{float64(x), float64(y)})
+		}
+	}
+
+	// not cancelled:
+	searcher := CancellingSearcher{Cancel: func() {}}
+	err := bvh.FindAllContext(context.Background(), &searcher)
+	if err != nil || searcher.Evaluated != 2500 {
+		t.Errorf("Expected 2500 elements without error, found %d (%v)", searcher.Evaluated, err)
+	}
+
+	// cancelled during the search:
+	ctx, cancel := context.WithCancel(context.Background())
+	searcher = CancellingSearcher{CancelAt: 100, Cancel: cancel}
+	err = bvh.FindAllContext(ctx, &searcher)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, found %v", err)
+	}
+	if searcher.Evaluated >= 100+2*contextCheckInterval {
+		t.Errorf("Search continued for %d elements after cancellation", searcher.Evaluated-100)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	searcher = CancellingSearcher{CancelAt: 100, Cancel: cancel}
+	err = bvh.FindNearestContext(ctx, &searcher, Point2D{25, 25}.GetBound())
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from FindNearestContext, found %v", err)
+	}
+
+	// already cancelled:
+	searcher = CancellingSearcher{Cancel: func() {}}
+	err = bvh.FindAllContext(ctx, &searcher)
+	if !errors.Is(err, context.Canceled) || searcher.Evaluated != 0 {
+		t.Errorf("Expected an immediate context.Canceled, found %v after %d elements", err, searcher.Evaluated)
+	}
+}
@@ -0,0 +1,38 @@
+package gobvh
+
+// ==============================================
+
+//
+// BVH.Count(bound) returns the number of elements whose bounds intersect
+// bound (as the boxes given by IntervalRange()).
+//
+// It is a plain traversal: nothing is allocated and no callbacks are made,
+// which suits load estimation and level-of-detail decisions.
+//
+func (bvh *BVH[BoundType]) Count(bound BoundType) int {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryCount, Bound: bound})
+	}
+	if len(bvh.root.children) == 0 {
+		return 0
+	}
+	return bvh.countNode(&bvh.root, bound)
+}
+
+// ..............................................
+
+func (bvh *BVH[BoundType]) countNode(node *bvhNode[BoundType], bound BoundType) int {
+	if !boundsOverlap(bvh.boundtraits, bound, node.bound) {
+		return 0
+	}
+	count := 0
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			count += bvh.countNode(value, bound)
+		} else if child != nil && boundsOverlap(bvh.boundtraits, bound, child.GetBound()) {
+			bvh.hit(child)
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,42 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHCount(t *testing.T) {
+	rng := rand.New(rand.NewSource(41))
+	boxes := randomBoxes(rng, 1500, 3.0)
+	bvh := New[AABB2D](Traits2D{})
+	if bvh.Count(AABB2D{L: Point2D{0, 0}, H: Point2D{100, 100}}) != 0 {
+		t.Errorf("Expected an empty tree to count nothing")
+	}
+	for _, element := range boxes {
+		bvh.Insert(element)
+	}
+
+	for i := 0; i < 50; i++ {
+		x, y := rng.Float64()*100.0, rng.Float64()*100.0
+		query := AABB2D{L: Point2D{x, y}, H: Point2D{x + rng.Float64()*20.0, y + rng.Float64()*20.0}}
+		expected := 0
+		for _, element := range boxes {
+			if boundsOverlap[AABB2D](Traits2D{}, query, element.GetBound()) {
+				expected++
+			}
+		}
+		if count := bvh.Count(query); count != expected {
+			t.Errorf("Expected a count of %d, found %d", expected, count)
+		}
+	}
+
+	query := AABB2D{L: Point2D{10, 10}, H: Point2D{60, 60}}
+	allocs := testing.AllocsPerRun(10, func() {
+		bvh.Count(query)
+	})
+	if allocs > 0 {
+		t.Errorf("Expected Count() not to allocate, found %v allocations", allocs)
+	}
+}
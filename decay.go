@@ -0,0 +1,38 @@
+package gobvh
+
+// ==============================================
+
+// the fraction of its elements a subtree must see inserted or erased before
+// its quality is measured again, see Options.RebuildThreshold
+const decayCheckFraction = 2
+
+// ..............................................
+
+// counts a change below node on the path to the root, and rebuilds the
+// highest subtree on the path whose quality is due to be measured and has
+// decayed; returns node, or the rebuilt node if node was inside it.
+//
+// Measuring a subtree of n elements takes O(n log n), but is only due after
+// n / decayCheckFraction changes, so the cost per change is O(log n) for
+// each level of the path.
+func (bvh *BVH[BoundType]) rebuildDecayed(node *bvhNode[BoundType]) *bvhNode[BoundType] {
+	var decayed *bvhNode[BoundType]
+	for ancestor := node; ancestor != nil; ancestor = ancestor.parent {
+		ancestor.changes++
+		if ancestor.descendants == 0 || ancestor.changes*decayCheckFraction < ancestor.elements {
+			continue // a leaf is as good as a rebuild
+		}
+		ancestor.changes = 0
+		cost := bvh.sahCost(ancestor, nil)
+		fresh := bvh.planCost(collectElements(ancestor, nil), ancestor.bound)
+		if cost > bvh.options.RebuildThreshold*fresh {
+			decayed = ancestor
+		}
+	}
+	if decayed == nil {
+		return node
+	}
+	bvh.rebuildNode(decayed)
+	bvh.version++
+	return decayed
+}
@@ -0,0 +1,54 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestOptionsRebuildThreshold(t *testing.T) {
+	if options := NewWithOptions[AABB2D](Traits2D{}, Options{RebuildThreshold: 0.5}).Options(); options.RebuildThreshold != 1.0 {
+		t.Errorf("Expected a threshold below one to be raised to one, found %g", options.RebuildThreshold)
+	}
+
+	rng := rand.New(rand.NewSource(804))
+	plain := New[AABB2D](Traits2D{}, WithMinChildren(3))
+	repaired := New[AABB2D](Traits2D{}, WithRebuildThreshold(1.2), WithMinChildren(3))
+	live := make([]Boundable[AABB2D], 0, 8000)
+	for round := 0; round < 30000; round++ {
+		if len(live) > 5000 && rng.Intn(2) == 0 {
+			index := rng.Intn(len(live))
+			element := live[index]
+			live[index] = live[len(live)-1]
+			live = live[:len(live)-1]
+			if !plain.Erase(element) || !repaired.Erase(element) {
+				t.Fatalf("Failed to erase %v", element.GetBound())
+			}
+			continue
+		}
+		element := Point2D{float64(round)/400.0 + 3.0*rng.Float64(), 100.0 * rng.Float64()}
+		live = append(live, element)
+		plain.Insert(element)
+		repaired.InsertTagged(element, 2)
+	}
+
+	if err := repaired.Validate(); err != nil {
+		t.Errorf(err.Error())
+	}
+	if repaired.Len() != plain.Len() || repaired.ContentHash() != plain.ContentHash() {
+		t.Errorf("Expected rebuilds to keep the contents, found %d elements and %d", repaired.Len(), plain.Len())
+	}
+	for i := 0; i < 50; i++ {
+		region := randomRegion(rng, 5.0)
+		if repaired.Count(region) != plain.Count(region) {
+			t.Errorf("Search of the repaired tree disagrees with the plain tree in %v", region)
+		}
+	}
+	if len(repaired.FindAllWithTag(2, func(AABB2D) bool { return true })) != len(live) {
+		t.Errorf("Expected rebuilds to keep tags")
+	}
+	if repaired.SAHCost() >= 0.95*plain.SAHCost() {
+		t.Errorf("Expected rebuilds to lower the cost, found %g and %g", repaired.SAHCost(), plain.SAHCost())
+	}
+}
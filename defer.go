@@ -0,0 +1,123 @@
+package gobvh
+
+// ==============================================
+
+//
+// BVH.Defer(insertions, erasures) queues mutations, to be applied together
+// by the next Flush(); until then the bvh (and every query of it) is
+// unchanged.  An element whose insertion is already queued is not queued
+// again, and erasing an element whose insertion is still queued cancels
+// the insertion.
+//
+// Mutations accumulated over a frame, between the frame's queries, are
+// then applied in one pass rather than interleaved with the queries.
+//
+func (bvh *BVH[BoundType]) Defer(insertions []Boundable[BoundType], erasures []Boundable[BoundType]) {
+	for _, element := range erasures {
+		if index, ok := bvh.deferindex[element]; ok {
+			bvh.deferinserts[index] = nil
+			delete(bvh.deferindex, element)
+			continue
+		}
+		bvh.defererases = append(bvh.defererases, element)
+	}
+	if len(insertions) > 0 && bvh.deferindex == nil {
+		bvh.deferindex = make(map[Boundable[BoundType]]int)
+	}
+	for _, element := range insertions {
+		if _, ok := bvh.deferindex[element]; ok {
+			continue
+		}
+		bvh.deferindex[element] = len(bvh.deferinserts)
+		bvh.deferinserts = append(bvh.deferinserts, element)
+	}
+}
+
+// ..............................................
+
+//
+// BVH.Flush() applies the mutations queued by Defer(), and returns the
+// number of elements erased and inserted.
+//
+// The erased elements are removed from their nodes, and each inserted
+// element is added to the leaf Insert() would choose; the bounds of every
+// affected node are then recalculated once, and leaves which have
+// overflowed are rebuilt, rather than each mutation refitting its own path
+// and splitting as it goes.  Erased elements must not have moved since they
+// were inserted, as for Erase().
+//
+func (bvh *BVH[BoundType]) Flush() int {
+	applied := 0
+	containers := make([]*bvhNode[BoundType], 0, len(bvh.defererases)+len(bvh.deferinserts))
+	seen := make(map[*bvhNode[BoundType]]bool)
+	for _, element := range bvh.defererases {
+		bound := element.GetBound()
+		container := bvh.findContainer(&bvh.root, element, bound)
+		if container == nil {
+			continue
+		}
+		container = bvh.own(container)
+		if !bvh.removeChild(container, element) {
+			continue
+		}
+		bvh.erased(container, element, bound)
+		applied++
+		if !seen[container] {
+			seen[container] = true
+			containers = append(containers, container)
+		}
+	}
+
+	for _, element := range bvh.deferinserts {
+		if element == nil {
+			continue // cancelled
+		}
+		elembound := element.GetBound()
+		fatbound := bvh.fatten(elembound)
+		leaf := bvh.own(chooseLeaf(bvh, fatbound))
+		leaf.children = bvh.appendChild(leaf.children, element)
+		if len(leaf.children) == 1 {
+			leaf.bound = bvh.snap(fatbound)
+		} else {
+			leaf.bound = bvh.snap(bvh.boundtraits.Union(leaf.bound, fatbound))
+		}
+		bvh.inserted(leaf, element, elembound)
+		applied++
+		if !seen[leaf] {
+			seen[leaf] = true
+			containers = append(containers, leaf)
+		}
+	}
+
+	// keep the storage, for per-frame use:
+	for index := range bvh.deferinserts {
+		bvh.deferinserts[index] = nil
+	}
+	for index := range bvh.defererases {
+		bvh.defererases[index] = nil
+	}
+	bvh.deferinserts = bvh.deferinserts[:0]
+	bvh.defererases = bvh.defererases[:0]
+	for element := range bvh.deferindex {
+		delete(bvh.deferindex, element)
+	}
+
+	bvh.refitAffected(containers)
+	for _, container := range containers {
+		if len(container.children) >= bvh.options.MaxChildren && bvh.attached(container) {
+			bvh.rebuildNode(container)
+		}
+	}
+	if bvh.options.MinChildren > 0 {
+		for _, container := range containers {
+			if bvh.attached(container) {
+				bvh.condense(container)
+			}
+		}
+	}
+	bvh.evictOverCapacity()
+	if applied > 0 && bvh.options.TuneSteps > 0 {
+		bvh.Tune(bvh.options.TuneSteps)
+	}
+	return applied
+}
@@ -0,0 +1,90 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHDeferFlush(t *testing.T) {
+	for _, minchildren := range []int{0, 3} {
+		rng := rand.New(rand.NewSource(61))
+		boxes := randomBoxes(rng, 3000, 2.0)
+		bvh := New[AABB2D](Traits2D{}, WithMinChildren(minchildren))
+		for _, element := range boxes[:1000] {
+			bvh.Insert(element)
+		}
+
+		for frame := 0; frame < 4; frame++ {
+			expected := make(map[Boundable[AABB2D]]bool)
+			for _, element := range collectElements(&bvh.root, nil) {
+				expected[element] = true
+			}
+			var insertions, erasures []Boundable[AABB2D]
+			for _, element := range boxes[1000+frame*500 : 1500+frame*500] {
+				insertions = append(insertions, element)
+				expected[element] = true
+			}
+			for element := range expected {
+				if rng.Intn(4) == 0 {
+					erasures = append(erasures, element)
+				}
+			}
+			version := bvh.Version()
+			bvh.Defer(insertions, nil)
+			bvh.Defer(nil, erasures) // (cancelling some of the insertions)
+			if bvh.Version() != version || bvh.Len() != len(expected)-len(insertions) {
+				t.Errorf("Expected Defer() to leave the bvh unchanged")
+			}
+			for _, element := range erasures {
+				delete(expected, element)
+			}
+
+			if applied := bvh.Flush(); applied == 0 {
+				t.Errorf("Expected Flush() to apply the deferred mutations")
+			}
+			checkTree(t, bvh, &bvh.root)
+			if err := bvh.Validate(); err != nil {
+				t.Fatalf("Validate() failed: %v", err)
+			}
+			if !sameElements(collectElements(&bvh.root, nil), expected) || bvh.Len() != len(expected) {
+				t.Errorf("Expected %d elements after Flush(), found %d", len(expected), bvh.Len())
+			}
+			region := randomRegion(rng, 20.0)
+			found := intersecting(bvh, region)
+			for element := range expected {
+				if boundsOverlap(bvh.boundtraits, region, element.GetBound()) && !found[element] {
+					t.Errorf("Flushed element not found by a query")
+				}
+			}
+			if stats := bvh.Stats(); stats.MeanLeafSize > float64(bvh.options.MaxChildren) || stats.Depth > 12 {
+				t.Errorf("Expected Flush() to keep the tree in shape, found %+v", stats)
+			}
+		}
+
+		if applied := bvh.Flush(); applied != 0 {
+			t.Errorf("Expected an empty Flush() to apply nothing, applied %d", applied)
+		}
+	}
+}
+
+// ........................................................
+
+func TestBVHDeferDuplicates(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	element := Point2D{1.0, 2.0}
+
+	// an insertion queued twice is queued once, and cancelled by one erasure:
+	bvh.Defer([]Boundable[AABB2D]{element, element}, nil)
+	bvh.Defer([]Boundable[AABB2D]{element}, nil)
+	bvh.Defer(nil, []Boundable[AABB2D]{element})
+	if applied := bvh.Flush(); applied != 0 || bvh.Len() != 0 {
+		t.Errorf("Expected the erasure to cancel every queued insertion, applied %d", applied)
+	}
+
+	bvh.Defer([]Boundable[AABB2D]{element, element}, nil)
+	if applied := bvh.Flush(); applied != 1 || bvh.Len() != 1 {
+		t.Errorf("Expected a single insertion, applied %d", applied)
+	}
+}
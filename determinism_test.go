@@ -0,0 +1,91 @@
+package gobvh
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+// Golden digest of the tree and query results built by buildDeterminismDigest().
+// It must be identical on every platform (amd64, arm64, GOARCH=wasm, ...),
+// see the package documentation.
+const determinismGolden uint64 = 0xb32e989477d3858a
+
+// ........................................................
+
+type digest struct {
+	hash uint64
+}
+
+func (d *digest) add(values ...float64) {
+	hasher := fnv.New64a()
+	var buf [8]byte
+	for _, value := range values {
+		bits := math.Float64bits(value)
+		for i := range buf {
+			buf[i] = byte(bits >> (8 * i))
+		}
+		hasher.Write(buf[:])
+	}
+	d.hash = d.hash*1099511628211 ^ hasher.Sum64()
+}
+
+func (d *digest) addNode(node *bvhNode[AABB2D]) {
+	d.add(node.bound.L[0], node.bound.L[1], node.bound.H[0], node.bound.H[1], float64(len(node.children)))
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[AABB2D]); ok {
+			d.addNode(value)
+		} else {
+			p := child.(Point2D)
+			d.add(p[0], p[1])
+		}
+	}
+}
+
+// ........................................................
+
+func buildDeterminismDigest() uint64 {
+	rng := rand.New(rand.NewSource(1234))
+	bvh := New[AABB2D](Traits2D{})
+	points := make([]Point2D, 3000)
+	for i := range points {
+		points[i] = Point2D{rng.Float64()*200.0 - 100.0, rng.Float64() * 50.0}
+		bvh.Insert(points[i])
+	}
+	for i := 0; i < len(points); i += 7 {
+		bvh.Erase(points[i])
+	}
+
+	var d digest
+	d.addNode(&bvh.root)
+
+	distance := func(a AABB2D, b AABB2D) float64 {
+		return boxDistance[AABB2D](Traits2D{}, a, b)
+	}
+	for i := 0; i < 20; i++ {
+		target := Point2D{rng.Float64()*200.0 - 100.0, rng.Float64() * 50.0}.GetBound()
+		knn := NewKNearest(5, target, distance)
+		bvh.FindNearestBestFirst(knn, target)
+		d.add(knn.Distances()...)
+		for _, element := range knn.Results() {
+			p := element.(Point2D)
+			d.add(p[0], p[1])
+		}
+	}
+	return d.hash
+}
+
+// ........................................................
+
+func TestBVHDeterminism(t *testing.T) {
+	first := buildDeterminismDigest()
+	if second := buildDeterminismDigest(); first != second {
+		t.Errorf("Repeated builds differ: %#x != %#x", first, second)
+	}
+	if first != determinismGolden {
+		t.Errorf("Build digest %#x differs from the golden digest %#x", first, determinismGolden)
+	}
+}
@@ -0,0 +1,67 @@
+package gobvh
+
+// ==============================================
+
+//
+// BVH.DirtyBounds() reports the regions changed since the last ClearDirty().
+//
+// Each bound covers the elements inserted into or erased from one subtree
+// of the hierarchy, so renderers and replication layers can restrict their
+// own work (re-culling, re-sending) to the changed regions.
+// The bounds may overlap one another.  The result is empty when
+// nothing has changed.
+//
+func (bvh *BVH[BoundType]) DirtyBounds() []BoundType {
+	bounds := make([]BoundType, len(bvh.dirtybounds))
+	copy(bounds, bvh.dirtybounds)
+	return bounds
+}
+
+// ..............................................
+
+//
+// BVH.ClearDirty() forgets all changed regions, see DirtyBounds().
+//
+func (bvh *BVH[BoundType]) ClearDirty() {
+	// keep the storage, for per-frame use:
+	for node := range bvh.dirtyindex {
+		delete(bvh.dirtyindex, node)
+	}
+	bvh.dirtybounds = bvh.dirtybounds[:0]
+	bvh.dirtyepoch++
+}
+
+// ==============================================
+
+// records that bound, within the subtree rooted at node, has changed.
+func (bvh *BVH[BoundType]) markDirty(node *bvhNode[BoundType], bound BoundType) {
+	if bvh.dirtyindex == nil {
+		bvh.dirtyindex = make(map[*bvhNode[BoundType]]int)
+	}
+	index, ok := bvh.dirtyindex[node]
+	if ok {
+		bvh.dirtybounds[index] = bvh.boundtraits.Union(bvh.dirtybounds[index], bound)
+	} else {
+		bvh.dirtyindex[node] = len(bvh.dirtybounds)
+		bvh.dirtybounds = append(bvh.dirtybounds, bound)
+	}
+}
+
+// ..............................................
+
+// reports whether the two bounds have the same extent in every dimension
+func boundsEqual[BoundType any](bounder BoundTraits[BoundType], first BoundType, second BoundType) bool {
+	dims := bounder.Dimensions(first)
+	if dims != bounder.Dimensions(second) {
+		return false
+	}
+	var i uint
+	for i = 0; i < dims; i++ {
+		lo0, hi0 := bounder.IntervalRange(first, i)
+		lo1, hi1 := bounder.IntervalRange(second, i)
+		if lo0 != lo1 || hi0 != hi1 {
+			return false
+		}
+	}
+	return true
+}
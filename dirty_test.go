@@ -0,0 +1,56 @@
+package gobvh
+
+import (
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHDirtyBounds(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	var x, y float64
+	for x = 0.0; x < 16.0; x += 1.0 {
+		for y = 0.0; y < 16.0; y += 1.0 {
+			bvh.Insert(Point2D{x, y})
+		}
+	}
+	if len(bvh.DirtyBounds()) == 0 {
+		t.Errorf("Expected dirty regions after insertion")
+	}
+
+	bvh.ClearDirty()
+	if len(bvh.DirtyBounds()) != 0 {
+		t.Errorf("Expected no dirty regions after ClearDirty()")
+	}
+
+	bvh.Erase(Point2D{3.0, 4.0})
+	bvh.Insert(Point2D{12.5, 12.5})
+	dirty := bvh.DirtyBounds()
+	if len(dirty) == 0 || len(dirty) > 2 {
+		t.Fatalf("Expected one or two dirty regions, found %d", len(dirty))
+	}
+
+	// the changed elements must be covered, and nothing far from them:
+	covered := func(p Point2D) bool {
+		for _, b := range dirty {
+			if b.L[0] <= p[0] && p[0] <= b.H[0] && b.L[1] <= p[1] && p[1] <= b.H[1] {
+				return true
+			}
+		}
+		return false
+	}
+	if !covered(Point2D{3.0, 4.0}) || !covered(Point2D{12.5, 12.5}) {
+		t.Errorf("Dirty regions %v do not cover the changed elements", dirty)
+	}
+	if covered(Point2D{0.0, 15.0}) {
+		t.Errorf("Dirty regions %v cover an unchanged corner", dirty)
+	}
+
+	// warm-started rebuilds report the leaves that changed:
+	next := New[AABB2D](Traits2D{})
+	elements := collectElements(&bvh.root, nil)
+	next.RebuildFrom(bvh, elements[1:])
+	if len(next.DirtyBounds()) != 1 {
+		t.Errorf("Expected a single dirty region after dropping one element, found %d", len(next.DirtyBounds()))
+	}
+}
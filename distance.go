@@ -0,0 +1,202 @@
+package gobvh
+
+import (
+	"math"
+)
+
+// ==============================================
+
+//
+// DistanceTraits is an optional extension of BoundTraits for distance queries
+// (FindNearestBestFirst() and others).
+//
+// MinDistance(a, b) reports the minimum distance between two bounds, which
+// must never exceed the distance between anything contained in them; it is
+// zero for overlapping bounds.
+//
+// If your BoundTraits do not implement DistanceTraits, bounds are treated as
+// the axis-aligned boxes given by IntervalRange(), and the Euclidean gap
+// between the boxes is used.
+//
+type DistanceTraits[BoundType any] interface {
+	MinDistance(a BoundType, b BoundType) float64
+}
+
+// ==============================================
+
+//
+// BVH.FindNearestBestFirst(searcher, here, options...) is a best-first
+// alternative to FindNearest().
+//
+// Nodes and elements are visited in increasing order of their minimum
+// distance to here, driven by a priority queue.  The searcher is consulted
+// (DoesIntersect()) when a node reaches the front of the queue, so as the
+// searcher's region of interest shrinks, the remaining nodes are pruned
+// as early as possible.  This is the standard way to make nearest neighbor
+// queries near-optimal, at the cost of maintaining the queue.
+//
+// Distances come from the traits' DistanceTraits if available, see DistanceTraits.
+//
+func (bvh *BVH[BoundType]) FindNearestBestFirst(s Searcher[BoundType], here BoundType, opts ...QueryOption[BoundType]) error {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryNearestBestFirst, Bound: here, Searcher: s, Options: opts})
+	}
+	trav := bvh.newTraversal(false, opts)
+	distance := bvh.minDistance()
+	cutoff := math.Inf(1)
+
+	key := func(bound BoundType) (float64, bool) {
+		return distance(here, bound), true
+	}
+	enter := func(node *bvhNode[BoundType]) bool {
+		return trav.visit(node) && s.DoesIntersect(trav.bound(node))
+	}
+	return orderedDescent(&bvh.root, key, &cutoff, enter, func(element Boundable[BoundType], d float64) error {
+		if trav.accept(element) {
+			presented := trav.present(element)
+			trav.touch(s, element, presented)
+			return s.Evaluate(presented)
+		}
+		return nil
+	})
+}
+
+// ..............................................
+
+//
+// BVH.MinDistance(query) returns the minimum distance from query to the
+// bound of any stored element, or +Inf if the bvh is empty.
+//
+// Only the distance is computed, e.g. for clearance checks; nodes are
+// visited best-first and the search stops at the first element reached.
+// Distances come from the traits' DistanceTraits if available, see DistanceTraits.
+//
+func (bvh *BVH[BoundType]) MinDistance(query BoundType) float64 {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryMinDistance, Bound: query})
+	}
+	distance := bvh.minDistance()
+	nearest := math.Inf(1)
+	cutoff := math.Inf(1)
+
+	key := func(bound BoundType) (float64, bool) {
+		return distance(query, bound), true
+	}
+	orderedDescent(&bvh.root, key, &cutoff, nil, func(element Boundable[BoundType], d float64) error {
+		// elements arrive nearest first, so nothing further need be visited:
+		nearest = d
+		cutoff = math.Nextafter(d, math.Inf(-1))
+		return nil
+	})
+	return nearest
+}
+
+// ..............................................
+
+//
+// BVH.PopNearest(target) erases and returns the stored element whose bound
+// is nearest to target, or returns nil if the bvh is empty.
+//
+// This is the find-then-erase of matchmaking and task assignment loops in
+// one call: the nearest element is found as MinDistance() finds it, and is
+// erased from the node the search found it in, without searching again.
+//
+func (bvh *BVH[BoundType]) PopNearest(target BoundType) Boundable[BoundType] {
+	distance := bvh.minDistance()
+	var nearest Boundable[BoundType]
+	var container *bvhNode[BoundType]
+	cutoff := math.Inf(1)
+
+	key := func(bound BoundType) (float64, bool) {
+		return distance(target, bound), true
+	}
+	orderedContainerDescent(&bvh.root, key, &cutoff, nil, func(element Boundable[BoundType], node *bvhNode[BoundType], d float64) error {
+		nearest, container = element, node
+		cutoff = math.Nextafter(d, math.Inf(-1))
+		return nil
+	})
+	if nearest == nil {
+		return nil
+	}
+	elembound := nearest.GetBound()
+	diderase, erasenode := bvh.eraseChild(container, nearest, elembound)
+	if !diderase {
+		return nil
+	}
+	bvh.erased(erasenode, nearest, elembound)
+	bvh.settleErase(erasenode)
+	return nearest
+}
+
+// ..............................................
+
+//
+// BVH.NearestToRegion(region, k, distance) returns the k elements nearest
+// to an extended region (e.g. a box, rather than a point), nearest first,
+// and their distances.
+//
+// distance(region, bound) measures the distance from the region to an
+// element's bound; nil uses the traits' minimum distance (see DistanceTraits).
+// It must never be less than the minimum distance of the traits, which
+// orders and prunes the search: nodes are visited best-first by their
+// minimum distance to the region, and once k elements are found, nodes
+// further away than the k-th are never visited.  Elements overlapping
+// the region are at distance zero.
+//
+func (bvh *BVH[BoundType]) NearestToRegion(region BoundType, k int, distance DistanceFunc[BoundType]) ([]Boundable[BoundType], []float64) {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryNearestToRegion, Bound: region, K: k, Distance: distance})
+	}
+	if k < 1 {
+		return nil, nil
+	}
+	lowerbound := bvh.minDistance()
+	if distance == nil {
+		distance = lowerbound
+	}
+	knn := NewKNearest(k, region, distance)
+	cutoff := math.Inf(1)
+
+	key := func(bound BoundType) (float64, bool) {
+		return lowerbound(region, bound), true
+	}
+	orderedDescent(&bvh.root, key, &cutoff, nil, func(element Boundable[BoundType], d float64) error {
+		knn.Evaluate(element)
+		if len(knn.found) == k {
+			cutoff = knn.found[0].distance
+		}
+		return nil
+	})
+	results := knn.Results()
+	for _, element := range results {
+		bvh.hit(element)
+	}
+	return results, knn.Distances()
+}
+
+// ==============================================
+
+// returns the minimum distance function of the traits, see DistanceTraits
+func (bvh *BVH[BoundType]) minDistance() DistanceFunc[BoundType] {
+	if distancetraits, ok := bvh.boundtraits.(DistanceTraits[BoundType]); ok {
+		return distancetraits.MinDistance
+	}
+	return func(a BoundType, b BoundType) float64 {
+		return boxDistance(bvh.boundtraits, a, b)
+	}
+}
+
+// ..............................................
+
+// Euclidean gap between the axis-aligned boxes given by IntervalRange()
+func boxDistance[BoundType any](bounder BoundTraits[BoundType], first BoundType, second BoundType) float64 {
+	var sum float64
+	var i uint
+	for i = 0; i < bounder.Dimensions(first); i++ {
+		lo0, hi0 := bounder.IntervalRange(first, i)
+		lo1, hi1 := bounder.IntervalRange(second, i)
+		gap := math.Max(0.0, math.Max(lo0-hi1, lo1-hi0))
+		sum += float64(gap * gap) // no fused multiply-add, see package doc
+	}
+	return math.Sqrt(sum)
+}
@@ -0,0 +1,233 @@
+package gobvh
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// ========================================================
+
+// Traits2D with an explicit (L-infinity) DistanceTraits:
+type ChebyshevTraits2D struct {
+	Traits2D
+}
+
+func (bounder ChebyshevTraits2D) MinDistance(a AABB2D, b AABB2D) float64 {
+	var dist float64
+	for i := 0; i < 2; i++ {
+		if gap := a.L[i] - b.H[i]; gap > dist {
+			dist = gap
+		}
+		if gap := b.L[i] - a.H[i]; gap > dist {
+			dist = gap
+		}
+	}
+	return dist
+}
+
+// counts the elements evaluated by a nearest neighbor search
+type CountingNN struct {
+	NearestNeighbor2D
+	Evaluated int
+}
+
+func (cnn *CountingNN) Evaluate(element Boundable[AABB2D]) error {
+	cnn.Evaluated++
+	return cnn.NearestNeighbor2D.Evaluate(element)
+}
+
+// ........................................................
+
+func TestBVHFindNearestBestFirst(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	bvh := New[AABB2D](Traits2D{})
+	chebyshev := New[AABB2D](ChebyshevTraits2D{})
+	points := make([]Point2D, 2000)
+	for i := range points {
+		points[i] = Point2D{rng.Float64() * 100.0, rng.Float64() * 100.0}
+		bvh.Insert(points[i])
+		chebyshev.Insert(points[i])
+	}
+
+	for i := 0; i < 100; i++ {
+		target := Point2D{rng.Float64() * 100.0, rng.Float64() * 100.0}
+		var expected Point2D
+		best := 1e38
+		for _, p := range points {
+			if d := distance2D(p, target); d < best {
+				best, expected = d, p
+			}
+		}
+
+		for _, tree := range []*BVH[AABB2D]{bvh, chebyshev} {
+			searcher := CountingNN{NearestNeighbor2D: NearestNeighbor2D{Target: target, FoundDistance: 1e38, t: t}}
+			err := tree.FindNearestBestFirst(&searcher, target.GetBound())
+			if err != nil {
+				t.Errorf(err.Error())
+			}
+			if found, ok := searcher.Found.(Point2D); !ok || found != expected {
+				t.Errorf("Expected %v nearest to %v, found %v", expected, target, searcher.Found)
+			}
+			if searcher.Evaluated > 200 {
+				t.Errorf("Best-first search evaluated %d elements", searcher.Evaluated)
+			}
+		}
+	}
+}
+
+// ........................................................
+
+func TestBVHMinDistance(t *testing.T) {
+	rng := rand.New(rand.NewSource(72))
+	boxes := randomBoxes(rng, 1000, 2.0)
+	euclidean, chebyshev := New[AABB2D](Traits2D{}), New[AABB2D](ChebyshevTraits2D{})
+	if d := euclidean.MinDistance(AABB2D{}); !math.IsInf(d, 1) {
+		t.Errorf("Expected +Inf from an empty tree, found %v", d)
+	}
+	for _, element := range boxes {
+		euclidean.Insert(element)
+		chebyshev.Insert(element)
+	}
+
+	for i := 0; i < 100; i++ {
+		x, y := rng.Float64()*140.0-20.0, rng.Float64()*140.0-20.0
+		query := AABB2D{L: Point2D{x, y}, H: Point2D{x + 0.5, y + 0.5}}
+		expected, expectedcheb := math.Inf(1), math.Inf(1)
+		for _, element := range boxes {
+			expected = math.Min(expected, boxDistance[AABB2D](Traits2D{}, query, element.GetBound()))
+			expectedcheb = math.Min(expectedcheb, ChebyshevTraits2D{}.MinDistance(query, element.GetBound()))
+		}
+		if d := euclidean.MinDistance(query); d != expected {
+			t.Errorf("Expected a minimum distance of %v, found %v", expected, d)
+		}
+		if d := chebyshev.MinDistance(query); d != expectedcheb {
+			t.Errorf("Expected a minimum Chebyshev distance of %v, found %v", expectedcheb, d)
+		}
+	}
+}
+
+// ........................................................
+
+func TestBVHPopNearest(t *testing.T) {
+	rng := rand.New(rand.NewSource(73))
+	boxes := randomBoxes(rng, 500, 2.0)
+	bvh := New[AABB2D](Traits2D{})
+	if bvh.PopNearest(AABB2D{}) != nil {
+		t.Errorf("Expected nil from an empty tree")
+	}
+	for _, element := range boxes {
+		bvh.Insert(element)
+	}
+
+	remaining := make(map[Boundable[AABB2D]]bool)
+	for _, element := range boxes {
+		remaining[element] = true
+	}
+	for len(remaining) > 0 {
+		x, y := rng.Float64()*100.0, rng.Float64()*100.0
+		query := AABB2D{L: Point2D{x, y}, H: Point2D{x, y}}
+		expected := math.Inf(1)
+		for element := range remaining {
+			expected = math.Min(expected, boxDistance[AABB2D](Traits2D{}, query, element.GetBound()))
+		}
+		popped := bvh.PopNearest(query)
+		if popped == nil || !remaining[popped] {
+			t.Fatalf("Expected a remaining element, found %v", popped)
+		}
+		if d := boxDistance[AABB2D](Traits2D{}, query, popped.GetBound()); d != expected {
+			t.Errorf("Expected the nearest element at %v, found one at %v", expected, d)
+		}
+		delete(remaining, popped)
+		if bvh.Len() != len(remaining) {
+			t.Fatalf("Expected %d elements left, found %d", len(remaining), bvh.Len())
+		}
+	}
+	if err := bvh.Validate(); err != nil {
+		t.Errorf("Validate() failed: %v", err)
+	}
+	if bvh.PopNearest(AABB2D{}) != nil {
+		t.Errorf("Expected nil once every element is popped")
+	}
+
+	// pops erase from the node found, copying shared nodes and condensing:
+	condensed := New[AABB2D](Traits2D{}, WithMaxChildren(8), WithMinChildren(3))
+	for _, element := range boxes {
+		condensed.Insert(element)
+	}
+	view := condensed.Snapshot()
+	for i := 0; i < 400; i++ {
+		x, y := rng.Float64()*100.0, rng.Float64()*100.0
+		if condensed.PopNearest(AABB2D{L: Point2D{x, y}, H: Point2D{x, y}}) == nil {
+			t.Fatalf("Expected an element to pop")
+		}
+	}
+	if err := condensed.Validate(); err != nil {
+		t.Errorf("Validate() failed after popping: %v", err)
+	}
+	if smallest := smallestNode(&condensed.root, true); smallest < 3 {
+		t.Errorf("Expected every node to hold at least 3 children, found %d", smallest)
+	}
+	if condensed.Len() != len(boxes)-400 {
+		t.Errorf("Expected %d elements left, found %d", len(boxes)-400, condensed.Len())
+	}
+	if count := len(indexIntersecting(view, view.GetBound(), false)); count != len(boxes) {
+		t.Errorf("Expected the snapshot to keep every element, found %d", count)
+	}
+}
+
+// ........................................................
+
+func TestBVHNearestToRegion(t *testing.T) {
+	rng := rand.New(rand.NewSource(782))
+	boxes := randomBoxes(rng, 1500, 2.0)
+	bvh := New[AABB2D](Traits2D{})
+	for _, element := range boxes {
+		bvh.Insert(element)
+	}
+	// distance between centers, never less than the gap between the boxes:
+	centers := func(a AABB2D, b AABB2D) float64 {
+		ca := Point2D{0.5 * (a.L[0] + a.H[0]), 0.5 * (a.L[1] + a.H[1])}
+		cb := Point2D{0.5 * (b.L[0] + b.H[0]), 0.5 * (b.L[1] + b.H[1])}
+		return math.Max(distance2D(ca, cb), boxDistance[AABB2D](Traits2D{}, a, b))
+	}
+
+	for i := 0; i < 50; i++ {
+		x, y := rng.Float64()*120.0-10.0, rng.Float64()*120.0-10.0
+		region := AABB2D{L: Point2D{x, y}, H: Point2D{x + rng.Float64()*20.0, y + rng.Float64()*5.0}}
+		for _, distance := range []DistanceFunc[AABB2D]{nil, centers} {
+			measure := distance
+			if measure == nil {
+				measure = func(a AABB2D, b AABB2D) float64 {
+					return boxDistance[AABB2D](Traits2D{}, a, b)
+				}
+			}
+			all := make([]float64, len(boxes))
+			for index, element := range boxes {
+				all[index] = measure(region, element.GetBound())
+			}
+			sort.Float64s(all)
+
+			found, distances := bvh.NearestToRegion(region, 6, distance)
+			if len(found) != 6 || len(distances) != 6 {
+				t.Fatalf("Expected 6 elements near the region, found %d", len(found))
+			}
+			for index, element := range found {
+				if d := measure(region, element.GetBound()); d != all[index] || distances[index] != d {
+					t.Errorf("Expected neighbor %d of the region at %g, found %g", index, all[index], d)
+				}
+			}
+		}
+	}
+
+	for _, k := range []int{0, -1} {
+		if found, _ := bvh.NearestToRegion(AABB2D{}, k, nil); len(found) != 0 {
+			t.Errorf("Expected no elements for k = %d, found %d", k, len(found))
+		}
+	}
+	empty := New[AABB2D](Traits2D{})
+	if found, _ := empty.NearestToRegion(AABB2D{}, 3, nil); len(found) != 0 {
+		t.Errorf("Expected no elements from an empty tree, found %d", len(found))
+	}
+}
@@ -0,0 +1,157 @@
+package gobvh
+
+// ==============================================
+
+//
+// BVH.EraseIf(pred) removes every element for which pred(element) returns
+// true, and returns the number removed.
+//
+// All of them are found in one traversal of the hierarchy, and the bounds
+// of each affected node are recalculated once, on the way back up, so this
+// is far cheaper than calling Erase() for each, e.g. to expire thousands of
+// entities at once.  Nodes left with fewer than Options.MinChildren
+// children are dissolved and their elements reinserted, as Erase() does.
+// pred must not modify the bvh.
+//
+func (bvh *BVH[BoundType]) EraseIf(pred func(Boundable[BoundType]) bool) int {
+	walk := eraseWalk[BoundType]{pred: pred}
+	bvh.eraseMatching(&bvh.root, &walk)
+	bvh.adoptOrphans(&walk)
+	return len(walk.erased)
+}
+
+// ..............................................
+
+//
+// BVH.EraseAllIntersecting(region) removes every element whose bound
+// intersects region, and returns them, e.g. to clear a chunk of a map.
+//
+// It visits only the subtrees overlapping region, removes the subtrees
+// which region contains without testing their elements one by one, and
+// otherwise works as EraseIf() does.
+//
+func (bvh *BVH[BoundType]) EraseAllIntersecting(region BoundType) []Boundable[BoundType] {
+	walk := eraseWalk[BoundType]{
+		pred: func(element Boundable[BoundType]) bool {
+			return boundsOverlap(bvh.boundtraits, region, element.GetBound())
+		},
+		region: &region,
+	}
+	if len(bvh.root.children) > 0 {
+		bvh.eraseMatching(&bvh.root, &walk)
+	}
+	bvh.adoptOrphans(&walk)
+	return walk.erased
+}
+
+// ==============================================
+
+// the state of a bulk erasure
+type eraseWalk[BoundType any] struct {
+	pred    func(Boundable[BoundType]) bool
+	skip    func(*bvhNode[BoundType]) bool // if set, the subtrees it reports hold no match
+	region  *BoundType                     // if set, pred matches every element inside it, and none outside
+	erased  []Boundable[BoundType]         // elements erased so far
+	orphans []Boundable[BoundType]         // elements of dissolved nodes, to reinsert
+}
+
+// ..............................................
+
+// erases the elements of the subtree rooted at node which the walk matches,
+// discards emptied nodes, and recalculates the bounds of the changed nodes,
+// bottom up; a changed node left with fewer than Options.MinChildren
+// children is emptied, its elements (annotations kept) becoming orphans.
+// Returns node, or the copy which replaced it (see own()), and reports
+// whether the subtree changed.
+func (bvh *BVH[BoundType]) eraseMatching(node *bvhNode[BoundType], walk *eraseWalk[BoundType]) (*bvhNode[BoundType], bool) {
+	if walk.skip != nil && walk.skip(node) {
+		return node, false
+	}
+	if walk.region != nil {
+		if !boundsOverlap(bvh.boundtraits, *walk.region, node.bound) {
+			return node, false
+		}
+		if boundContainsBound(bvh.boundtraits, *walk.region, node.bound) {
+			// everything goes, without testing:
+			node = bvh.own(node)
+			start := len(walk.erased)
+			walk.erased = collectElements(node, walk.erased)
+			for _, element := range walk.erased[start:] {
+				bvh.erased(node, element, element.GetBound())
+			}
+			bvh.clearChildren(node)
+			if node.parent == nil {
+				bvh.recalculateBounds(node)
+			}
+			return node, true
+		}
+	}
+
+	// dropped children are cleared, then compacted away:
+	changed := false
+	for index, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			live, subchanged := bvh.eraseMatching(value, walk)
+			if !subchanged {
+				continue
+			}
+			node, changed = live.parent, true // (owned along with live)
+			if len(live.children) == 0 {
+				node.children[index] = nil
+			}
+		} else if walk.pred(child) {
+			node, changed = bvh.own(node), true
+			bvh.erased(node, child, child.GetBound())
+			walk.erased = append(walk.erased, child)
+			node.children[index] = nil
+		}
+	}
+	if !changed {
+		return node, false
+	}
+	retained := node.children[:0]
+	for _, child := range node.children {
+		if child != nil {
+			retained = append(retained, child)
+		}
+	}
+	for index := len(retained); index < len(node.children); index++ {
+		node.children[index] = nil // release dropped children
+	}
+	node.children = retained
+
+	if node.parent != nil && len(node.children) < bvh.options.MinChildren {
+		start := len(walk.orphans)
+		walk.orphans = collectElements(node, walk.orphans)
+		for _, orphan := range walk.orphans[start:] {
+			info := bvh.info[orphan]
+			bvh.erased(node, orphan, orphan.GetBound())
+			if info != nil {
+				bvh.info[orphan] = info
+			}
+		}
+		bvh.clearChildren(node)
+		return node, true
+	}
+	bvh.recalculateBounds(node)
+	return node, true
+}
+
+// ..............................................
+
+// reinserts the orphans of a bulk erasure
+func (bvh *BVH[BoundType]) adoptOrphans(walk *eraseWalk[BoundType]) {
+	for _, orphan := range walk.orphans {
+		bvh.Insert(orphan)
+	}
+}
+
+// ..............................................
+
+// empties node, releasing its children
+func (bvh *BVH[BoundType]) clearChildren(node *bvhNode[BoundType]) {
+	for index := range node.children {
+		node.children[index] = nil
+	}
+	node.children = node.children[:0]
+}
@@ -0,0 +1,110 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHEraseIf(t *testing.T) {
+	for _, minchildren := range []int{0, 3} {
+		rng := rand.New(rand.NewSource(47))
+		boxes := randomBoxes(rng, 2000, 2.0)
+		bvh := New[AABB2D](Traits2D{}, WithMinChildren(minchildren))
+		for index, element := range boxes {
+			bvh.InsertTagged(element, Tag(index%7))
+		}
+
+		expired := func(element Boundable[AABB2D]) bool {
+			return element.GetBound().L[0] < 40.0 || element.GetBound().L[1] > 80.0
+		}
+		expected := 0
+		kept := make(map[Boundable[AABB2D]]bool)
+		for _, element := range boxes {
+			if expired(element) {
+				expected++
+			} else {
+				kept[element] = true
+			}
+		}
+
+		if erased := bvh.EraseIf(expired); erased != expected {
+			t.Errorf("Expected %d elements erased, found %d", expected, erased)
+		}
+		checkTree(t, bvh, &bvh.root)
+		if err := bvh.Validate(); err != nil {
+			t.Errorf("Validate() failed: %v", err)
+		}
+		if bvh.Len() != len(kept) || !sameElements(collectElements(&bvh.root, nil), kept) {
+			t.Errorf("Expected the %d other elements to remain, found %d", len(kept), bvh.Len())
+		}
+		for index, element := range boxes {
+			if !expired(element) && !bvh.HasTag(element, Tag(index%7)) {
+				t.Errorf("Tag lost by EraseIf")
+			}
+		}
+
+		fresh := New[AABB2D](Traits2D{})
+		for index, element := range boxes {
+			if kept[element] {
+				fresh.InsertTagged(element, Tag(index%7))
+			}
+		}
+		if bvh.ContentHash() != fresh.ContentHash() {
+			t.Errorf("ContentHash() does not match that of the remaining elements inserted")
+		}
+
+		// nothing matches, then everything:
+		version := bvh.Version()
+		if erased := bvh.EraseIf(func(Boundable[AABB2D]) bool { return false }); erased != 0 || bvh.Version() != version {
+			t.Errorf("Expected EraseIf() matching nothing to change nothing")
+		}
+		if erased := bvh.EraseIf(func(Boundable[AABB2D]) bool { return true }); erased != len(kept) || bvh.Len() != 0 {
+			t.Errorf("Expected EraseIf() matching everything to empty the tree, %d remain", bvh.Len())
+		}
+	}
+}
+
+// ........................................................
+
+func TestBVHEraseAllIntersecting(t *testing.T) {
+	for _, minchildren := range []int{0, 3} {
+		rng := rand.New(rand.NewSource(53))
+		boxes := randomBoxes(rng, 2000, 2.0)
+		bvh := New[AABB2D](Traits2D{}, WithMinChildren(minchildren))
+		for _, element := range boxes {
+			bvh.Insert(element)
+		}
+
+		for trial := 0; trial < 10; trial++ {
+			region := randomRegion(rng, 30.0)
+			expected := intersecting(bvh, region)
+			erased := bvh.EraseAllIntersecting(region)
+			if !sameElements(erased, expected) {
+				t.Errorf("Expected the %d elements intersecting %v erased, found %d", len(expected), region, len(erased))
+			}
+			if len(intersecting(bvh, region)) != 0 {
+				t.Errorf("Elements intersecting %v remain", region)
+			}
+			checkTree(t, bvh, &bvh.root)
+			if err := bvh.Validate(); err != nil {
+				t.Errorf("Validate() failed: %v", err)
+			}
+		}
+
+		// a region containing everything:
+		remaining := bvh.Len()
+		everything := AABB2D{L: Point2D{-1000, -1000}, H: Point2D{1000, 1000}}
+		if erased := bvh.EraseAllIntersecting(everything); len(erased) != remaining || bvh.Len() != 0 {
+			t.Errorf("Expected all %d elements erased, %d remain", remaining, bvh.Len())
+		}
+		if erased := bvh.EraseAllIntersecting(everything); len(erased) != 0 {
+			t.Errorf("Expected nothing to erase from an empty tree")
+		}
+		bvh.Insert(boxes[0])
+		if err := bvh.Validate(); err != nil {
+			t.Errorf("Validate() failed after reuse: %v", err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package gobvh
+
+import (
+	"time"
+)
+
+// ==============================================
+
+//
+// BVH.InsertExpiring(element, expires) inserts the element, as Insert()
+// does, to be erased by the first call of Expire() at or after expires.
+//
+// Other annotations the element already has (e.g. tags) are kept.
+//
+func (bvh *BVH[BoundType]) InsertExpiring(element Boundable[BoundType], expires time.Time) {
+	bvh.infoFor(element).expires = expires.UnixNano()
+	bvh.Insert(element)
+}
+
+// ..............................................
+
+//
+// BVH.Expire(now) erases every element inserted by InsertExpiring() whose
+// expiration time is at or before now, and returns the number erased.
+//
+// Each node keeps the earliest expiration time beneath it, so the sweep
+// only visits the subtrees holding expired elements, rather than crawling
+// the whole tree; the erasure is otherwise as EraseIf() does it.
+//
+func (bvh *BVH[BoundType]) Expire(now time.Time) int {
+	cutoff := now.UnixNano()
+	walk := eraseWalk[BoundType]{
+		pred: func(element Boundable[BoundType]) bool {
+			expires := bvh.elementExpiry(element)
+			return expires != 0 && expires <= cutoff
+		},
+		skip: func(node *bvhNode[BoundType]) bool {
+			return node.expiry == 0 || node.expiry > cutoff
+		},
+	}
+	bvh.eraseMatching(&bvh.root, &walk)
+	bvh.adoptOrphans(&walk)
+	return len(walk.erased)
+}
+
+// ==============================================
+
+// expiration time contributed by a child of a node (0 if none)
+func (bvh *BVH[BoundType]) elementExpiry(child Boundable[BoundType]) int64 {
+	if node, ok := child.(*bvhNode[BoundType]); ok {
+		return node.expiry
+	}
+	if info, ok := bvh.info[child]; ok {
+		return info.expires
+	}
+	return 0
+}
+
+// ..............................................
+
+// the earlier of two expiration times, where 0 is never
+func earlierExpiry(a int64, b int64) int64 {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
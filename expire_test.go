@@ -0,0 +1,74 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// ========================================================
+
+// checks that every node keeps the earliest expiration time beneath it
+func checkExpiry(t *testing.T, bvh *BVH[AABB2D], node *bvhNode[AABB2D]) int64 {
+	earliest := int64(0)
+	for _, child := range node.children {
+		expiry := bvh.elementExpiry(child)
+		if value, ok := child.(*bvhNode[AABB2D]); ok {
+			expiry = checkExpiry(t, bvh, value)
+		}
+		earliest = earlierExpiry(earliest, expiry)
+	}
+	if earliest != node.expiry {
+		t.Errorf("Node expiry %d does not match its contents %d", node.expiry, earliest)
+	}
+	return earliest
+}
+
+// ........................................................
+
+func TestBVHExpire(t *testing.T) {
+	rng := rand.New(rand.NewSource(83))
+	boxes := randomBoxes(rng, 2000, 2.0)
+	start := time.Unix(1700000000, 0)
+	bvh := New[AABB2D](Traits2D{}, WithMinChildren(3))
+	expires := make(map[Boundable[AABB2D]]time.Time)
+	for index, element := range boxes {
+		if index%5 == 0 {
+			bvh.InsertTagged(element, Tag(1))
+			continue
+		}
+		when := start.Add(time.Duration(rng.Intn(1000)) * time.Millisecond)
+		expires[element] = when
+		bvh.InsertExpiring(element, when)
+	}
+	checkExpiry(t, bvh, &bvh.root)
+
+	for _, elapsed := range []time.Duration{0, 250, 500, 999, 5000} {
+		now := start.Add(elapsed * time.Millisecond)
+		expected := 0
+		for element, when := range expires {
+			if !when.After(now) {
+				expected++
+				delete(expires, element)
+			}
+		}
+		if erased := bvh.Expire(now); erased != expected {
+			t.Errorf("Expected %d elements to expire by %v, found %d", expected, elapsed, erased)
+		}
+		checkExpiry(t, bvh, &bvh.root)
+		if err := bvh.Validate(); err != nil {
+			t.Fatalf("Validate() failed: %v", err)
+		}
+		if bvh.Len() != len(boxes)/5+len(expires) {
+			t.Errorf("Expected %d elements left, found %d", len(boxes)/5+len(expires), bvh.Len())
+		}
+	}
+	for index, element := range boxes {
+		if index%5 == 0 && !bvh.HasTag(element, Tag(1)) {
+			t.Errorf("Expected elements without an expiration time to remain")
+		}
+	}
+	if bvh.root.expiry != 0 {
+		t.Errorf("Expected no expiration time to remain")
+	}
+}
@@ -0,0 +1,124 @@
+package gobvh
+
+import (
+	"math"
+)
+
+// ==============================================
+
+//
+// MaxDistanceTraits is an optional extension of BoundTraits for farthest
+// element queries (FindFarthest() and Farthest()).
+//
+// MaxDistance(a, b) reports the maximum distance between two bounds, which
+// must never be less than the distance between anything contained in them.
+//
+// If your BoundTraits do not implement MaxDistanceTraits, bounds are treated
+// as the axis-aligned boxes given by IntervalRange(), and the Euclidean
+// distance between their farthest corners is used.
+//
+type MaxDistanceTraits[BoundType any] interface {
+	MaxDistance(a BoundType, b BoundType) float64
+}
+
+// ==============================================
+
+//
+// BVH.FindFarthest(searcher, here, options...) is the counterpart of
+// FindNearestBestFirst() for the elements farthest from here, e.g. for
+// diameters or for spreading out spawn points.
+//
+// Nodes and elements are visited in decreasing order of their maximum
+// distance to here, driven by a priority queue.  The searcher is consulted
+// (DoesIntersect()) when a node reaches the front of the queue, so a
+// searcher which has found an element farther away than anything a node
+// could hold prunes it, and with it everything remaining.
+//
+// Distances come from the traits' MaxDistanceTraits if available, see MaxDistanceTraits.
+//
+func (bvh *BVH[BoundType]) FindFarthest(s Searcher[BoundType], here BoundType, opts ...QueryOption[BoundType]) error {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryFarthest, Bound: here, Searcher: s, Options: opts})
+	}
+	trav := bvh.newTraversal(false, opts)
+	distance := bvh.maxDistance()
+	cutoff := math.Inf(1)
+
+	// the queue is ordered by increasing key, so farthest first:
+	key := func(bound BoundType) (float64, bool) {
+		return -distance(here, bound), true
+	}
+	enter := func(node *bvhNode[BoundType]) bool {
+		return trav.visit(node) && s.DoesIntersect(trav.bound(node))
+	}
+	return orderedDescent(&bvh.root, key, &cutoff, enter, func(element Boundable[BoundType], d float64) error {
+		if trav.accept(element) {
+			presented := trav.present(element)
+			trav.touch(s, element, presented)
+			return s.Evaluate(presented)
+		}
+		return nil
+	})
+}
+
+// ..............................................
+
+//
+// BVH.Farthest(query) returns the stored element whose bound is farthest
+// from query, and the maximum distance between the two bounds; or nil and
+// -Inf if the bvh is empty.
+//
+// Nodes are visited farthest-first and the search stops at the first
+// element reached.  Distances come from the traits' MaxDistanceTraits if
+// available, see MaxDistanceTraits.
+//
+func (bvh *BVH[BoundType]) Farthest(query BoundType) (Boundable[BoundType], float64) {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryFarthestElement, Bound: query})
+	}
+	distance := bvh.maxDistance()
+	var farthest Boundable[BoundType]
+	d := math.Inf(-1)
+	cutoff := math.Inf(1)
+
+	key := func(bound BoundType) (float64, bool) {
+		return -distance(query, bound), true
+	}
+	orderedDescent(&bvh.root, key, &cutoff, nil, func(element Boundable[BoundType], key float64) error {
+		// elements arrive farthest first, so nothing else need be visited:
+		farthest, d = element, -key
+		cutoff = math.Nextafter(key, math.Inf(-1))
+		return nil
+	})
+	if farthest != nil {
+		bvh.hit(farthest)
+	}
+	return farthest, d
+}
+
+// ==============================================
+
+// returns the maximum distance function of the traits, see MaxDistanceTraits
+func (bvh *BVH[BoundType]) maxDistance() DistanceFunc[BoundType] {
+	if distancetraits, ok := bvh.boundtraits.(MaxDistanceTraits[BoundType]); ok {
+		return distancetraits.MaxDistance
+	}
+	return func(a BoundType, b BoundType) float64 {
+		return boxMaxDistance(bvh.boundtraits, a, b)
+	}
+}
+
+// ..............................................
+
+// Euclidean distance between the farthest corners of the axis-aligned boxes given by IntervalRange()
+func boxMaxDistance[BoundType any](bounder BoundTraits[BoundType], first BoundType, second BoundType) float64 {
+	var sum float64
+	var i uint
+	for i = 0; i < bounder.Dimensions(first); i++ {
+		lo0, hi0 := bounder.IntervalRange(first, i)
+		lo1, hi1 := bounder.IntervalRange(second, i)
+		span := math.Max(hi1-lo0, hi0-lo1)
+		sum += float64(span * span) // no fused multiply-add, see package doc
+	}
+	return math.Sqrt(sum)
+}
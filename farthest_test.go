@@ -0,0 +1,81 @@
+package gobvh
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+// finds the point farthest from Here, pruning nodes which cannot beat it
+type FarthestSearcher2D struct {
+	Here      Point2D
+	Best      Boundable[AABB2D]
+	Distance  float64
+	Evaluated int
+}
+
+func (fs *FarthestSearcher2D) DoesIntersect(bound AABB2D) bool {
+	return boxMaxDistance[AABB2D](Traits2D{}, fs.Here.GetBound(), bound) > fs.Distance
+}
+
+func (fs *FarthestSearcher2D) Evaluate(element Boundable[AABB2D]) error {
+	fs.Evaluated++
+	if d := distance2D(fs.Here, element.(Point2D)); d > fs.Distance {
+		fs.Best, fs.Distance = element, d
+	}
+	return nil
+}
+
+// ........................................................
+
+func TestBVHFindFarthest(t *testing.T) {
+	rng := rand.New(rand.NewSource(777))
+	bvh := New[AABB2D](Traits2D{})
+	points := make([]Point2D, 2000)
+	for i := range points {
+		points[i] = Point2D{rng.Float64() * 100.0, rng.Float64() * 100.0}
+		bvh.Insert(points[i])
+	}
+
+	for trial := 0; trial < 20; trial++ {
+		here := Point2D{rng.Float64() * 100.0, rng.Float64() * 100.0}
+		expected := 0.0
+		for _, p := range points {
+			expected = math.Max(expected, distance2D(here, p))
+		}
+
+		searcher := FarthestSearcher2D{Here: here, Distance: -1.0}
+		if err := bvh.FindFarthest(&searcher, here.GetBound()); err != nil {
+			t.Fatal(err)
+		}
+		if searcher.Distance != expected {
+			t.Errorf("Expected the farthest point at %g, found %g", expected, searcher.Distance)
+		}
+		if searcher.Evaluated >= len(points)/4 {
+			t.Errorf("Expected the search to prune most points, evaluated %d", searcher.Evaluated)
+		}
+
+		farthest, d := bvh.Farthest(here.GetBound())
+		if d != expected || distance2D(here, farthest.(Point2D)) != expected {
+			t.Errorf("Expected Farthest() at %g, found %v at %g", expected, farthest, d)
+		}
+	}
+
+	// options apply as for other searches:
+	here := Point2D{0.0, 0.0}
+	searcher := FarthestSearcher2D{Here: here, Distance: -1.0}
+	bvh.FindFarthest(&searcher, here.GetBound(), WithElementFilter(func(element Boundable[AABB2D]) bool {
+		p := element.(Point2D)
+		return p[0] < 50.0 && p[1] < 50.0
+	}))
+	if p := searcher.Best.(Point2D); p[0] >= 50.0 || p[1] >= 50.0 {
+		t.Errorf("Expected the farthest point in the lower quadrant, found %v", p)
+	}
+
+	empty := New[AABB2D](Traits2D{})
+	if element, d := empty.Farthest(here.GetBound()); element != nil || !math.IsInf(d, -1) {
+		t.Errorf("Expected nothing farthest in an empty tree, found %v at %g", element, d)
+	}
+}
@@ -0,0 +1,113 @@
+package gobvh
+
+// ==============================================
+
+//
+// FlatBuffer is a GPU-friendly, pointer-free layout of the hierarchy,
+// produced by BVH.ExportFlat().
+//
+// Nodes are numbered breadth-first from the root (node 0), so the child
+// nodes of any node occupy a contiguous index range.
+//
+// NodeLo[dim][node] and NodeHi[dim][node] hold the node bounds, one array
+// per dimension (structure of arrays), as reported by IntervalRange().
+//
+// ChildStart[node] and ChildCount[node] give the range of child nodes;
+// ChildStart is -1 when a node has no child nodes.
+//
+// ElementStart[node] and ElementCount[node] give the range of ElementIndex
+// holding the elements stored directly in the node.
+//
+// ElementIndex holds element indices: either those reported by the caller's
+// index function, or positions in Elements.
+//
+type FlatBuffer[BoundType any] struct {
+	Dimensions   uint
+	NodeLo       [][]float32
+	NodeHi       [][]float32
+	ChildStart   []int32
+	ChildCount   []int32
+	ElementStart []int32
+	ElementCount []int32
+	ElementIndex []int32
+	Elements     []Boundable[BoundType]
+}
+
+// ..............................................
+
+//
+// FlatBuffer.NodeCount() reports the number of nodes in the buffer.
+//
+func (fb *FlatBuffer[BoundType]) NodeCount() int {
+	return len(fb.ChildStart)
+}
+
+// ..............................................
+
+//
+// BVH.ExportFlat(indexof) flattens the hierarchy into a FlatBuffer
+// suitable for upload to compute shaders.
+//
+// If indexof is nil, elements are gathered into FlatBuffer.Elements and
+// ElementIndex refers to positions in that slice.  Otherwise indexof(element)
+// supplies the index of each element in a buffer of your own (e.g. your
+// primitive array), and FlatBuffer.Elements is left empty.
+//
+// An empty hierarchy produces a buffer with no nodes.
+//
+func (bvh *BVH[BoundType]) ExportFlat(indexof func(Boundable[BoundType]) int32) *FlatBuffer[BoundType] {
+	fb := &FlatBuffer[BoundType]{}
+	if len(bvh.root.children) == 0 {
+		return fb
+	}
+
+	fb.Dimensions = bvh.boundtraits.Dimensions(bvh.root.bound)
+	fb.NodeLo = make([][]float32, fb.Dimensions)
+	fb.NodeHi = make([][]float32, fb.Dimensions)
+
+	queue := []*bvhNode[BoundType]{&bvh.root}
+	for head := 0; head < len(queue); head++ {
+		node := queue[head]
+
+		var i uint
+		for i = 0; i < fb.Dimensions; i++ {
+			lo, hi := bvh.boundtraits.IntervalRange(node.bound, i)
+			fb.NodeLo[i] = append(fb.NodeLo[i], float32(lo))
+			fb.NodeHi[i] = append(fb.NodeHi[i], float32(hi))
+		}
+
+		childstart := int32(-1)
+		var childcount int32
+		elemstart := int32(len(fb.ElementIndex))
+		var elemcount int32
+
+		for _, child := range node.children {
+			if child == nil {
+				continue
+			}
+			value, ok := child.(*bvhNode[BoundType])
+			if ok {
+				if childstart < 0 {
+					childstart = int32(len(queue))
+				}
+				queue = append(queue, value)
+				childcount++
+			} else {
+				if indexof != nil {
+					fb.ElementIndex = append(fb.ElementIndex, indexof(child))
+				} else {
+					fb.ElementIndex = append(fb.ElementIndex, int32(len(fb.Elements)))
+					fb.Elements = append(fb.Elements, child)
+				}
+				elemcount++
+			}
+		}
+
+		fb.ChildStart = append(fb.ChildStart, childstart)
+		fb.ChildCount = append(fb.ChildCount, childcount)
+		fb.ElementStart = append(fb.ElementStart, elemstart)
+		fb.ElementCount = append(fb.ElementCount, elemcount)
+	}
+
+	return fb
+}
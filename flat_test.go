@@ -0,0 +1,64 @@
+package gobvh
+
+import (
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHExportFlat(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	if New[AABB2D](Traits2D{}).ExportFlat(nil).NodeCount() != 0 {
+		t.Errorf("Expected no nodes exported from an empty tree")
+	}
+
+	var x, y float64
+	for x = 0.0; x < 16.0; x += 1.0 {
+		for y = 0.0; y < 16.0; y += 1.0 {
+			bvh.Insert(Point2D{x, y})
+		}
+	}
+
+	fb := bvh.ExportFlat(nil)
+	if fb.Dimensions != 2 {
+		t.Errorf("Expected 2 dimensions, found %d", fb.Dimensions)
+	}
+	if len(fb.Elements) != 256 || len(fb.ElementIndex) != 256 {
+		t.Errorf("Expected 256 elements, found %d (%d indices)", len(fb.Elements), len(fb.ElementIndex))
+	}
+
+	// walk the flat buffer from the root like a shader would:
+	seen := 0
+	stack := []int32{0}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for child := fb.ChildStart[node]; child >= 0 && child < fb.ChildStart[node]+fb.ChildCount[node]; child++ {
+			for dim := 0; dim < 2; dim++ {
+				if fb.NodeLo[dim][child] < fb.NodeLo[dim][node] || fb.NodeHi[dim][child] > fb.NodeHi[dim][node] {
+					t.Errorf("Node %d exceeds the bound of its parent %d", child, node)
+				}
+			}
+			stack = append(stack, child)
+		}
+		for index := fb.ElementStart[node]; index < fb.ElementStart[node]+fb.ElementCount[node]; index++ {
+			p := fb.Elements[fb.ElementIndex[index]].(Point2D)
+			if float32(p[0]) < fb.NodeLo[0][node] || float32(p[0]) > fb.NodeHi[0][node] {
+				t.Errorf("Element %v exceeds the bound of node %d", p, node)
+			}
+			seen++
+		}
+	}
+	if seen != 256 {
+		t.Errorf("Expected to reach 256 elements from the root, reached %d", seen)
+	}
+
+	// caller supplied indices:
+	fb = bvh.ExportFlat(func(element Boundable[AABB2D]) int32 {
+		p := element.(Point2D)
+		return int32(p[0]*16.0 + p[1])
+	})
+	if len(fb.Elements) != 0 || len(fb.ElementIndex) != 256 {
+		t.Errorf("Expected only element indices to be exported")
+	}
+}
@@ -0,0 +1,105 @@
+package gobvh
+
+// ==============================================
+
+//
+// Forest is a set of independent hierarchies ("worlds"), e.g. levels,
+// floors or shards, which share traits and options.
+//
+// Keeping disjoint spaces in separate worlds avoids one giant root bound
+// spanning all of them, so a search in one world never descends into
+// another.  Each world is an ordinary BVH; it keeps its own node arena,
+// so ResetArena() on one world does not disturb the others.
+//
+type Forest[BoundType any] struct {
+	boundtraits BoundTraits[BoundType]
+	options     Options
+	worlds      map[string]*BVH[BoundType]
+	names       []string
+}
+
+// ..............................................
+
+//
+// NewForest(traits, options) returns an empty forest; its worlds are
+// created with NewWithOptions(traits, options).
+//
+func NewForest[BoundType any](boundtraits BoundTraits[BoundType], options Options) *Forest[BoundType] {
+	return &Forest[BoundType]{
+		boundtraits: boundtraits,
+		options:     options,
+		worlds:      make(map[string]*BVH[BoundType]),
+	}
+}
+
+// ..............................................
+
+//
+// Forest.AddWorld(name) returns the world with the given name, creating an
+// empty one if there is none.
+//
+func (forest *Forest[BoundType]) AddWorld(name string) *BVH[BoundType] {
+	if world, ok := forest.worlds[name]; ok {
+		return world
+	}
+	world := NewWithOptions(forest.boundtraits, forest.options)
+	forest.worlds[name] = world
+	forest.names = append(forest.names, name)
+	return world
+}
+
+// ..............................................
+
+//
+// Forest.World(name) returns the world with the given name, and whether it exists.
+//
+func (forest *Forest[BoundType]) World(name string) (*BVH[BoundType], bool) {
+	world, ok := forest.worlds[name]
+	return world, ok
+}
+
+// ..............................................
+
+//
+// Forest.RemoveWorld(name) discards a world, reporting whether it existed.
+//
+func (forest *Forest[BoundType]) RemoveWorld(name string) bool {
+	if _, ok := forest.worlds[name]; !ok {
+		return false
+	}
+	delete(forest.worlds, name)
+	for index, other := range forest.names {
+		if other == name {
+			forest.names = append(forest.names[:index], forest.names[index+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// ..............................................
+
+//
+// Forest.Worlds() returns the names of the worlds, in the order they were added.
+//
+func (forest *Forest[BoundType]) Worlds() []string {
+	names := make([]string, len(forest.names))
+	copy(names, forest.names)
+	return names
+}
+
+// ..............................................
+
+//
+// Forest.ForEachWorld(fn) calls fn(name, world) for every world, in the
+// order they were added.  An error returned by fn stops the iteration and is returned.
+//
+func (forest *Forest[BoundType]) ForEachWorld(fn func(name string, world *BVH[BoundType]) error) error {
+	for _, name := range forest.names {
+		err := fn(name, forest.worlds[name])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package gobvh
+
+import (
+	"testing"
+)
+
+// ========================================================
+
+func TestForest(t *testing.T) {
+	options := DefaultOptions()
+	options.MaxChildren = 8
+	forest := NewForest[AABB2D](Traits2D{}, options)
+
+	ground := forest.AddWorld("ground")
+	upstairs := forest.AddWorld("upstairs")
+	if forest.AddWorld("ground") != ground {
+		t.Errorf("Expected AddWorld() to return the existing world")
+	}
+	if ground.Options().MaxChildren != 8 {
+		t.Errorf("Expected worlds to share the forest's options")
+	}
+
+	for i := 0; i < 100; i++ {
+		ground.Insert(Point2D{float64(i), 0.0})
+		upstairs.Insert(Point2D{float64(i), 1000.0})
+	}
+	if ground.Count(AABB2D{L: Point2D{0, -1}, H: Point2D{1000, 2000}}) != 100 {
+		t.Errorf("Expected the worlds to be independent")
+	}
+	upstairs.ResetArena()
+	if ground.Count(AABB2D{L: Point2D{0, -1}, H: Point2D{1000, 1}}) != 100 {
+		t.Errorf("Expected resetting one world not to disturb another")
+	}
+
+	forest.AddWorld("basement")
+	if !forest.RemoveWorld("upstairs") || forest.RemoveWorld("upstairs") {
+		t.Errorf("Expected RemoveWorld() to remove a world once")
+	}
+	if _, ok := forest.World("upstairs"); ok {
+		t.Errorf("Expected the removed world to be gone")
+	}
+	names := []string{}
+	forest.ForEachWorld(func(name string, world *BVH[AABB2D]) error {
+		names = append(names, name)
+		return nil
+	})
+	if len(names) != 2 || names[0] != "ground" || names[1] != "basement" || len(forest.Worlds()) != 2 {
+		t.Errorf("Expected the worlds in order, found %v", names)
+	}
+}
@@ -6,6 +6,7 @@
 //  - Arbitrary dimensions
 //  - Arbitrary operations (nearest neighbor, raytracing, collision detection)
 //  - Fully dynamic (insertions, deletions, searches can be interleaved)
+//  - Deterministic (see below)
 //
 // You might have to write some glue code (e.g. concrete Bounding Volume)
 // but I hope you find this implementation to be extremely flexible.
@@ -14,6 +15,17 @@
 // search in two dimensions, if you need an example for implementing your own
 // searches.
 //
+// ## DETERMINISM
+//
+// The same sequence of insertions and erasures builds the same tree, and the
+// same queries give the same results in the same order, on every platform
+// (including GOARCH=wasm), provided your BoundTraits are deterministic too.
+// Construction uses only IEEE additions, comparisons and divisions, and
+// expressions which a compiler could fuse into a multiply-add (as Go may on
+// arm64, ppc64 and s390x) are explicitly rounded.  No DeterministicMath option
+// is needed.  Advise() is the exception: its heuristics use math.Exp and
+// math.Pow, which may differ in the last bit between platforms.
+//
 // ## LICENSE
 //
 // Copyright 2023 Mayur Patel
@@ -51,6 +63,10 @@ import (
 //
 // Dimensions(bound) reports on the number of dimensions for this kind of bound.
 //
+// Bounds may be unbounded in some dimensions (e.g. "any time" in
+// spatio-temporal data): IntervalRange() may report math.Inf(-1) and
+// math.Inf(1) for such dimensions.
+//
 type BoundTraits[BoundType any] interface {
 	IntervalRange(bound BoundType, dim uint) (float64, float64)
 	Union(a BoundType, b BoundType) BoundType
@@ -84,13 +100,30 @@ type Boundable[BoundType any] interface {
 // it might be helpful to record the closest distance between them.
 // That closest distance will be used in the DoesIntersect() command, becoming
 // a smaller and smaller value as the search progresses.  As such, the searcher
-// will need to be "reset" between searches.
+// will need to be "reset" between searches.  Alternatively, a ContextSearcher
+// keeps that state in a QueryContext, so one searcher can serve concurrent searches.
 //
 type Searcher[BoundType any] interface {
 	DoesIntersect(bound BoundType) bool
 	Evaluate(element Boundable[BoundType]) error
 }
 
+//
+// Coster is an optional interface for elements whose evaluation is
+// not uniformly expensive.
+//
+// EvaluationCost() reports the relative cost of evaluating the element
+// (e.g. a mesh-level exact test versus a trivial point test).  Elements
+// which do not implement Coster are assumed to cost 1.0.  Every node in the
+// hierarchy tracks the summed cost of its contents, and ordered traversals
+// (FindNearest()) visit cheaper subtrees first when they are otherwise
+// equally good candidates for pruning, i.e. equally near the start of the
+// search.
+//
+type Coster interface {
+	EvaluationCost() float64
+}
+
 // ==============================================
 
 //
@@ -101,6 +134,44 @@ type Searcher[BoundType any] interface {
 type BVH[BoundType any] struct {
 	root        bvhNode[BoundType]
 	boundtraits BoundTraits[BoundType]
+	snaptraits  SnapTraits[BoundType]   // only if node bounds are snapped, see Options.SnapGrid
+	fattraits   ExpandTraits[BoundType] // only if element bounds are fattened, see Options.Margin
+	options     Options
+	arena       nodeArena[BoundType]
+	count       int    // number of elements stored
+	contenthash uint64 // sum of the element hashes, see ContentHash()
+	version     uint64 // counts insertions and erasures, see Version()
+	scratch     []Boundable[BoundType]
+	sorter      medianSorter[BoundType]
+
+	// per-element annotations (e.g. tags), only for elements that have them:
+	info map[Boundable[BoundType]]*elementInfo
+
+	// capacity bound, see SetCapacity():
+	capacity int
+	evictor  Evictor[BoundType]
+	onevict  func(Boundable[BoundType])
+
+	// regions touched by mutations since the last ClearDirty():
+	dirtyindex  map[*bvhNode[BoundType]]int
+	dirtybounds []BoundType
+	dirtyepoch  uint64 // counts calls to ClearDirty()
+
+	recorder *QueryRecorder[BoundType]  // see SetRecorder()
+	splitter SplitStrategy[BoundType]   // see SetSplitStrategy()
+	inserter InsertHeuristic[BoundType] // see SetInsertHeuristic()
+
+	// incremental restructuring, see Tune():
+	tunestate uint64
+	tuning    bool
+
+	// mutations awaiting Flush(), see Defer():
+	deferinserts []Boundable[BoundType]
+	deferindex   map[Boundable[BoundType]]int // index of each in deferinserts
+	defererases  []Boundable[BoundType]
+	// mutations since the last ConsumeChanges(), see SetJournal():
+	journal    []Change[BoundType]
+	journaling bool
 }
 
 // ..............................................
@@ -115,20 +186,24 @@ func (bvh *BVH[BoundType]) GetBound() BoundType {
 // ..............................................
 
 //
-// New(traits) returns a pointer to a new bounding volume hierarchy data structure.
+// New(traits, options...) returns a pointer to a new bounding volume hierarchy data structure.
 //
 // Please supply traits so that the bvh knows how to use the BoundType.
+// Options (see Option) adjust the DefaultOptions(), e.g.
+// New(traits, WithMaxChildren(8), WithStableErase()).
 //
-func New[BoundType any](boundtraits BoundTraits[BoundType]) *BVH[BoundType] {
-	return &BVH[BoundType]{
-		boundtraits: boundtraits,
+func New[BoundType any](boundtraits BoundTraits[BoundType], opts ...Option) *BVH[BoundType] {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(&options)
 	}
+	return NewWithOptions(boundtraits, options)
 }
 
 // ..............................................
 
 //
-// BVH.FindAll(searcher) is one method of search.
+// BVH.FindAll(searcher, options...) is one method of search.
 //
 // It is useful when
 // we want all elements intersecting a particular area.  For example,
@@ -141,10 +216,19 @@ func New[BoundType any](boundtraits BoundTraits[BoundType]) *BVH[BoundType] {
 // shrink; we would want to focus attention to the local area around the
 // target first to optimize the search, so FindNearest() is more appropriate.
 //
-func (bvh *BVH[BoundType]) FindAll(s Searcher[BoundType]) error {
+// Options (see QueryOption) adjust how the hierarchy is presented to the searcher.
+//
+func (bvh *BVH[BoundType]) FindAll(s Searcher[BoundType], opts ...QueryOption[BoundType]) error {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QuerySearch, Searcher: s, Options: opts})
+	}
+	return bvh.findAll(s, opts)
+}
+
+func (bvh *BVH[BoundType]) findAll(s Searcher[BoundType], opts []QueryOption[BoundType]) error {
 	var err error = nil
 	if len(bvh.root.children) > 0 {
-		err = findDown(s, &bvh.root, nil)
+		err = findDown(s, &bvh.root, nil, bvh.newTraversal(false, opts))
 	}
 	return err
 }
@@ -152,7 +236,7 @@ func (bvh *BVH[BoundType]) FindAll(s Searcher[BoundType]) error {
 // ..............................................
 
 //
-// BVH.FindNearest(searcher, here) is another method of search.
+// BVH.FindNearest(searcher, here, options...) is another method of search.
 //
 // It is useful when
 // we want to focus the search around a local area.
@@ -163,12 +247,29 @@ func (bvh *BVH[BoundType]) FindAll(s Searcher[BoundType]) error {
 // Contrast this with collision detection, where the order of evaluation
 // doesn't matter; in that case, FindAll() would be a better choice.
 //
-func (bvh *BVH[BoundType]) FindNearest(s Searcher[BoundType], here BoundType) error {
-	// start at the leaf of the hierarchy:
-	lastnode := chooseLeaf(bvh, here)
+// Options (see QueryOption) adjust how the hierarchy is presented to the searcher;
+// here is always given in the space of the hierarchy.
+//
+func (bvh *BVH[BoundType]) FindNearest(s Searcher[BoundType], here BoundType, opts ...QueryOption[BoundType]) error {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryNearest, Bound: here, Searcher: s, Options: opts})
+	}
+	return bvh.findNearest(s, here, bvh.newTraversal(true, opts))
+}
+
+func (bvh *BVH[BoundType]) findNearest(s Searcher[BoundType], here BoundType, trav *traversal[BoundType]) error {
+	trav.here = here
+
+	// start at the leaf of the hierarchy, keeping the path to it
+	// (the nodes of a snapshot cannot follow their parent pointers, see Snapshot()):
+	var buffer [32]*bvhNode[BoundType]
+	path := append(buffer[:0], &bvh.root)
+	for next := bvh.chooseNext(&bvh.root, here); next != nil; next = bvh.chooseNext(next, here) {
+		path = append(path, next)
+	}
 
 	// move up from the bottom:
-	return findUp(s, lastnode, nil)
+	return findUp(s, path, trav)
 }
 
 // ..............................................
@@ -184,26 +285,59 @@ func (bvh *BVH[BoundType]) Insert(element Boundable[BoundType]) {
 
 	if len(bvh.root.children) == 0 {
 		// first insertion is a special case:
-		bvh.root.children = append(bvh.root.children, element)
-		bvh.root.bound = elembound
+		bvh.own(&bvh.root)
+		bvh.root.children = bvh.appendChild(bvh.root.children, element)
+		bvh.root.bound = bvh.snap(bvh.fatten(elembound))
+		bvh.root.cost = elementCost(element)
+		bvh.root.elements = 1
+		bvh.root.bloom = bvh.elementBloom(element)
+		bvh.root.layers = bvh.elementLayers(element)
+		bvh.root.expiry = bvh.elementExpiry(element)
+		bvh.inserted(&bvh.root, element, elembound)
 
 	} else {
 
 		// find appropriate leaf and insert it there:
-		chosen := chooseLeaf(bvh, elembound)
-		chosen.children = append(chosen.children, element)
-		chosen.bound = (*bvh).boundtraits.Union(chosen.bound, elembound)
+		elemcost := elementCost(element)
+		elembloom := bvh.elementBloom(element)
+		elemlayers := bvh.elementLayers(element)
+		elemexpiry := bvh.elementExpiry(element)
+		fatbound := bvh.fatten(elembound)
+		chosen := bvh.own(chooseLeaf(bvh, fatbound))
+		chosen.children = bvh.appendChild(chosen.children, element)
+		chosen.bound = bvh.snap((*bvh).boundtraits.Union(chosen.bound, fatbound))
+		chosen.cost += elemcost
+		chosen.elements++
+		chosen.bloom |= elembloom
+		chosen.layers |= elemlayers
+		chosen.expiry = earlierExpiry(chosen.expiry, elemexpiry)
 
 		// update ancestors' bounds:
 		updatenode := chosen.parent
 		for updatenode != nil {
-			(*updatenode).bound = bvh.boundtraits.Union((*updatenode).bound, elembound)
+			(*updatenode).bound = bvh.snap(bvh.boundtraits.Union((*updatenode).bound, fatbound))
+			(*updatenode).cost += elemcost
+			(*updatenode).elements++
+			(*updatenode).bloom |= elembloom
+			(*updatenode).layers |= elemlayers
+			(*updatenode).expiry = earlierExpiry((*updatenode).expiry, elemexpiry)
 			updatenode = updatenode.parent
 		}
 
-		splitNode(bvh.boundtraits, chosen, &bvh.root)
+		bvh.inserted(chosen, element, elembound)
+		bvh.splitNode(chosen, &bvh.root)
+		if bvh.options.Rotations {
+			bvh.rotateInserted(chosen, element, elembound)
+		}
+		if bvh.options.RebuildThreshold > 0.0 {
+			bvh.rebuildDecayed(chosen)
+		}
 	} // end if insert into non-root
 
+	bvh.evictOverCapacity()
+	if bvh.options.TuneSteps > 0 {
+		bvh.Tune(bvh.options.TuneSteps)
+	}
 	return
 }
 
@@ -213,20 +347,198 @@ func (bvh *BVH[BoundType]) Insert(element Boundable[BoundType]) {
 // BVH.Erase(element) removes a Boundable object from the data structure.
 //
 // It returns a boolean to indicate whether or not the erasure actually occurred.
+// The element is searched for by its bound, so it must not have moved since
+// it was inserted; see EraseHandle() otherwise.
 //
 func (bvh *BVH[BoundType]) Erase(element Boundable[BoundType]) bool {
-	diderase, erasenode := eraseChild(bvh.boundtraits, &bvh.root, element, element.GetBound())
-	for erasenode != nil {
-		eraseparent := erasenode.parent
-		if eraseparent != nil && len(erasenode.children) == 0 {
-			var toerase Boundable[BoundType] = erasenode
-			eraseChild(bvh.boundtraits, eraseparent, toerase, toerase.GetBound())
-		} else {
-			break
+	elembound := element.GetBound()
+	diderase, erasenode := bvh.eraseChild(&bvh.root, element, elembound)
+	if !diderase {
+		return false
+	}
+	bvh.erased(erasenode, element, elembound)
+	bvh.settleErase(erasenode)
+	return true
+}
+
+// ..............................................
+
+//
+// BVH.EraseFunc(bound, eq) erases the first stored element near bound for
+// which eq(element) reports true, and reports whether one was erased.
+//
+// Erase() matches the element itself (by interface identity), which fails
+// for value-typed elements reconstructed from data; EraseFunc() lets the
+// caller match by key or by value instead.  Only the nodes whose bounds
+// intersect bound are searched, so bound should be the bound the element
+// was stored with.
+//
+func (bvh *BVH[BoundType]) EraseFunc(bound BoundType, eq func(Boundable[BoundType]) bool) bool {
+	container, element := bvh.findMatch(&bvh.root, bound, eq)
+	if container == nil {
+		return false
+	}
+	container = bvh.own(container)
+	bvh.removeChild(container, element)
+	for node := container; node != nil; node = node.parent {
+		bvh.recalculateBounds(node)
+	}
+	bvh.erased(container, element, element.GetBound())
+	bvh.settleErase(container)
+	return true
+}
+
+// ..............................................
+
+// settleErase() is called after an element has been erased from the container
+// node: it removes the nodes left empty, then restructures as the options ask.
+func (bvh *BVH[BoundType]) settleErase(erasenode *bvhNode[BoundType]) {
+	erasenode = bvh.pruneEmpty(erasenode)
+	if bvh.options.RebuildThreshold > 0.0 {
+		erasenode = bvh.rebuildDecayed(erasenode)
+	}
+	if bvh.options.MinChildren > 0 {
+		bvh.condense(erasenode)
+	}
+	if bvh.options.TuneSteps > 0 {
+		bvh.Tune(bvh.options.TuneSteps)
+	}
+}
+
+// ..............................................
+
+// removes node, and then each ancestor, while it is empty (other than the
+// root); returns the lowest node remaining.
+func (bvh *BVH[BoundType]) pruneEmpty(node *bvhNode[BoundType]) *bvhNode[BoundType] {
+	for node.parent != nil && len(node.children) == 0 {
+		parent := node.parent
+		var toerase Boundable[BoundType] = node
+		bvh.eraseChild(parent, toerase, toerase.GetBound())
+		node = parent
+	}
+	return node
+}
+
+// ..............................................
+
+//
+// BVH.Contains(element) reports whether the element is stored in the data structure.
+//
+// Like Erase(), it only searches the nodes whose bounds intersect the
+// element's bound, so the element must not have moved since it was inserted.
+//
+func (bvh *BVH[BoundType]) Contains(element Boundable[BoundType]) bool {
+	return bvh.findContainer(&bvh.root, element, element.GetBound()) != nil
+}
+
+// ..............................................
+
+//
+// BVH.Len() returns the number of elements stored, in O(1).
+//
+func (bvh *BVH[BoundType]) Len() int {
+	return bvh.count
+}
+
+// ..............................................
+
+// inserted() is called after element (with bound elembound) has been added to the leaf node.
+func (bvh *BVH[BoundType]) inserted(leaf *bvhNode[BoundType], element Boundable[BoundType], elembound BoundType) {
+	bvh.markDirty(leaf, elembound)
+	bvh.count++
+	bvh.version++
+	bvh.contenthash += bvh.elementHash(element, elembound)
+	if bvh.evictor != nil {
+		bvh.evictor.Inserted(element)
+	}
+	bvh.logChange(ChangeInserted, element, elembound)
+}
+
+// erased() is called after element (with bound elembound) has been removed from the container node.
+func (bvh *BVH[BoundType]) erased(container *bvhNode[BoundType], element Boundable[BoundType], elembound BoundType) {
+	bvh.markDirty(container, elembound)
+	delete(bvh.info, element)
+	bvh.count--
+	bvh.version++
+	bvh.contenthash -= bvh.elementHash(element, elembound)
+	if bvh.evictor != nil {
+		bvh.evictor.Erased(element)
+	}
+	bvh.logChange(ChangeErased, element, elembound)
+}
+
+// condense() dissolves node and its ancestors (other than the root) which
+// hold fewer than Options.MinChildren children, as an R-tree's CondenseTree
+// does: the children of each are merged into a sibling node with room for
+// them, where there is one, and otherwise the elements of its subtree are
+// reinserted, keeping their annotations (as UpdateBatch() does).
+func (bvh *BVH[BoundType]) condense(node *bvhNode[BoundType]) {
+	orphans := make([]Boundable[BoundType], 0, bvh.options.MinChildren)
+	changed := false
+	for ; node.parent != nil; node = node.parent {
+		if len(node.children) >= bvh.options.MinChildren {
+			if changed {
+				bvh.recalculateBounds(node) // (before a merge above it moves it)
+			}
+			continue
+		}
+		changed = true
+		if sibling := bvh.mergeTarget(node); sibling != nil {
+			sibling = bvh.own(sibling)
+			for _, child := range node.children {
+				sibling.children = bvh.appendChild(sibling.children, child)
+				if value, ok := child.(*bvhNode[BoundType]); ok {
+					value.parent = sibling
+				}
+			}
+			bvh.clearChildren(node)
+			bvh.removeChild(node.parent, node)
+			bvh.recalculateBounds(sibling)
+			continue
 		}
-		erasenode = eraseparent
+		start := len(orphans)
+		orphans = collectElements(node, orphans)
+		bvh.removeChild(node.parent, node)
+		for _, orphan := range orphans[start:] {
+			info := bvh.info[orphan]
+			bvh.erased(node, orphan, orphan.GetBound())
+			if info != nil {
+				bvh.info[orphan] = info
+			}
+		}
+	}
+	if !changed {
+		return
+	}
+
+	bvh.version++
+	bvh.recalculateBounds(&bvh.root)
+	for _, orphan := range orphans {
+		bvh.Insert(orphan)
 	}
-	return diderase
+}
+
+// ..............................................
+
+// the sibling node of node which can take all of node's children without
+// being split, and whose bound they enlarge least; nil if there is none
+func (bvh *BVH[BoundType]) mergeTarget(node *bvhNode[BoundType]) *bvhNode[BoundType] {
+	if len(node.children) == 0 {
+		return nil
+	}
+	var target *bvhNode[BoundType]
+	best := 0.0
+	for _, child := range node.parent.children {
+		sibling, ok := child.(*bvhNode[BoundType])
+		if !ok || sibling == node || len(sibling.children)+len(node.children) >= bvh.options.MaxChildren {
+			continue
+		}
+		union := bvh.boundtraits.Union(sibling.bound, node.bound)
+		if growth := bvh.measure(union) - bvh.measure(sibling.bound); target == nil || growth < best {
+			target, best = sibling, growth
+		}
+	}
+	return target
 }
 
 // ==============================================
@@ -255,13 +567,20 @@ type BVHCrawler[BoundType any] interface {
 // You will need to implement a concrete struct for
 // your crawler, to perform your actions.
 //
+// If the crawler is also a NodeCrawler, crawler.BeginNode(node) is called
+// just before crawler.BeginBound(bound), describing the node.
+//
 func (bvh *BVH[BoundType]) ForEach(crawler BVHCrawler[BoundType]) error {
-	return forEachNode(crawler, &bvh.root)
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryCrawl, Crawler: crawler})
+	}
+	return forEachNode(crawler, &bvh.root, &bvh.root, false)
 }
 
 // ..............................................
 
-func forEachNode[BoundType any](crawler BVHCrawler[BoundType], node *bvhNode[BoundType]) error {
+// (frozen is set for the nodes of a snapshot, see NodeRef)
+func forEachNode[BoundType any](crawler BVHCrawler[BoundType], node *bvhNode[BoundType], root *bvhNode[BoundType], frozen bool) error {
 	if node != nil {
 		var err error
 		var crawlhere bool = false
@@ -270,7 +589,7 @@ func forEachNode[BoundType any](crawler BVHCrawler[BoundType], node *bvhNode[Bou
 			if child != nil {
 				value, ok := child.(*bvhNode[BoundType])
 				if ok {
-					err = forEachNode(crawler, value)
+					err = forEachNode(crawler, value, root, frozen)
 					if err != nil {
 						return err
 					}
@@ -281,6 +600,12 @@ func forEachNode[BoundType any](crawler BVHCrawler[BoundType], node *bvhNode[Bou
 		} // end for
 
 		if crawlhere {
+			if nodecrawler, ok := crawler.(NodeCrawler[BoundType]); ok {
+				err = nodecrawler.BeginNode(NodeRef[BoundType]{node: node, root: root, frozen: frozen})
+				if err != nil {
+					return err
+				}
+			}
 			err = crawler.BeginBound(node.bound)
 			if err != nil {
 				return err
@@ -310,12 +635,40 @@ func forEachNode[BoundType any](crawler BVHCrawler[BoundType], node *bvhNode[Bou
 	return nil
 }
 
+// ..............................................
+
+// appends every element in the subtree rooted at node to elements
+func collectElements[BoundType any](node *bvhNode[BoundType], elements []Boundable[BoundType]) []Boundable[BoundType] {
+	if node != nil {
+		for _, child := range node.children {
+			if child != nil {
+				value, ok := child.(*bvhNode[BoundType])
+				if ok {
+					elements = collectElements(value, elements)
+				} else {
+					elements = append(elements, child)
+				}
+			}
+		}
+	}
+	return elements
+}
+
 // ==============================================
 
 type bvhNode[BoundType any] struct {
-	bound    BoundType
-	children []Boundable[BoundType]
-	parent   *bvhNode[BoundType]
+	bound       BoundType
+	children    []Boundable[BoundType]
+	parent      *bvhNode[BoundType]
+	cost        float64 // summed evaluation cost of contained elements
+	bloom       uint64  // bloom filter of the tags of contained elements
+	layers      uint64  // union of the collision layers of contained elements
+	level       int     // depth plus the level of the root, see nodeDepth()
+	elements    int     // number of elements in the subtree
+	descendants int     // number of nodes in the subtree, excluding the node itself
+	changes     int     // insertions and erasures in the subtree, see Options.RebuildThreshold
+	expiry      int64   // earliest expiration time in the subtree (0 if none), see Expire()
+	epoch       uint64  // the arena's epoch when allocated; older nodes may be a snapshot's, see own()
 }
 
 // ..............................................
@@ -325,43 +678,170 @@ func (node *bvhNode[BoundType]) GetBound() BoundType {
 	return node.bound
 }
 
+// makes bvhNode a Coster:
+func (node *bvhNode[BoundType]) EvaluationCost() float64 {
+	return node.cost
+}
+
 // ..............................................
 
-func findUp[BoundType any](s Searcher[BoundType], node *bvhNode[BoundType], skip *bvhNode[BoundType]) error {
-	if node != nil {
-		err := findDown(s, node, skip)
-		if err != nil {
-			return err
+// evaluation cost of a child of a node, defaults to 1.0 for elements that aren't Costers
+func elementCost[BoundType any](child Boundable[BoundType]) float64 {
+	if coster, ok := child.(Coster); ok {
+		return coster.EvaluationCost()
+	}
+	return 1.0
+}
+
+// ..............................................
+
+// traversal carries the per-query settings through findUp() and findDown()
+type traversal[BoundType any] struct {
+	costordered bool                                 // visit children nearer here first, see order()
+	here        BoundType                            // the start of a cost-ordered search, see findNearest()
+	boundtraits BoundTraits[BoundType]               // measures the distance to here
+	scratch     []Boundable[BoundType]               // stack of reordered children, see order()
+	nodefilter  func(*bvhNode[BoundType]) bool       // if set, only nodes passing the filter are visited
+	elemfilter  func(Boundable[BoundType]) bool      // if set, only elements passing the filter are evaluated
+	transform   func(BoundType) BoundType            // if set, applied to node bounds before the searcher sees them
+	project     func(Boundable[BoundType]) BoundType // if set, elements are presented to the searcher as *Projected
+	hit         func(Boundable[BoundType])           // if set, told of the elements the searcher intersects, see touch()
+}
+
+func (bvh *BVH[BoundType]) newTraversal(costordered bool, opts []QueryOption[BoundType]) *traversal[BoundType] {
+	trav := &traversal[BoundType]{costordered: costordered, boundtraits: bvh.boundtraits}
+	for _, opt := range opts {
+		opt(trav)
+	}
+	if bvh.evictor != nil {
+		trav.hit = bvh.evictor.Hit
+	}
+	return trav
+}
+
+// the bound of node, as presented to the searcher
+func (trav *traversal[BoundType]) bound(node *bvhNode[BoundType]) BoundType {
+	if trav.transform != nil {
+		return trav.transform(node.bound)
+	}
+	return node.bound
+}
+
+// reports whether the traversal should consider node
+func (trav *traversal[BoundType]) visit(node *bvhNode[BoundType]) bool {
+	return trav.nodefilter == nil || trav.nodefilter(node)
+}
+
+// reports whether the traversal should evaluate element
+func (trav *traversal[BoundType]) accept(element Boundable[BoundType]) bool {
+	return trav.elemfilter == nil || trav.elemfilter(element)
+}
+
+// returns element as presented to the searcher
+func (trav *traversal[BoundType]) present(element Boundable[BoundType]) Boundable[BoundType] {
+	if trav.project != nil {
+		return &Projected[BoundType]{Element: element, Bound: trav.project(element)}
+	}
+	return element
+}
+
+// reports element, about to be evaluated, to the hit function if the
+// searcher intersects it as presented: the rest of a leaf's elements are
+// evaluated only to be rejected, and are not hits
+func (trav *traversal[BoundType]) touch(s Searcher[BoundType], element Boundable[BoundType], presented Boundable[BoundType]) {
+	if trav.hit != nil && s.DoesIntersect(presented.GetBound()) {
+		trav.hit(element)
+	}
+}
+
+// returns the children of node in the order the traversal should visit
+// them, and the depth of the scratch stack to restore once they have been.
+// A cost-ordered traversal visits the children nearer here (by the metric
+// chooseChild() uses) first, and the cheaper of equally near ones first,
+// where a Coster makes costs differ; otherwise the node's own slice is
+// returned, unsorted.
+func (trav *traversal[BoundType]) order(node *bvhNode[BoundType]) ([]Boundable[BoundType], int) {
+	mark := len(trav.scratch)
+	if !trav.costordered || !costVaries(node) {
+		return node.children, mark
+	}
+
+	// an insertion sort, as nodes have few children, into the stack:
+	trav.scratch = append(trav.scratch, node.children...)
+	ordered := trav.scratch[mark:]
+	for index := 1; index < len(ordered); index++ {
+		for other := index; other > 0 && trav.before(ordered[other], ordered[other-1]); other-- {
+			ordered[other], ordered[other-1] = ordered[other-1], ordered[other]
 		}
-		err = findUp(s, node.parent, node)
+	}
+	return ordered, mark
+}
+
+// reports whether the traversal visits child a before child b, see order()
+func (trav *traversal[BoundType]) before(a Boundable[BoundType], b Boundable[BoundType]) bool {
+	_, ametric := furthestDistanceMetric(trav.boundtraits, a.GetBound(), trav.here)
+	_, bmetric := furthestDistanceMetric(trav.boundtraits, b.GetBound(), trav.here)
+	if ametric != bmetric {
+		return ametric < bmetric
+	}
+	return elementCost(a) < elementCost(b)
+}
+
+// reports whether the children of node may differ in cost per element:
+// whether any is a Coster, or a subtree whose cost is not its element count
+func costVaries[BoundType any](node *bvhNode[BoundType]) bool {
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			if value.cost != float64(value.elements) {
+				return true
+			}
+		} else if _, ok := child.(Coster); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ..............................................
+
+// searches each node of path, from the last up, skipping the subtree already searched
+func findUp[BoundType any](s Searcher[BoundType], path []*bvhNode[BoundType], trav *traversal[BoundType]) error {
+	var skip *bvhNode[BoundType]
+	for index := len(path) - 1; index >= 0; index-- {
+		err := findDown(s, path[index], skip, trav)
 		if err != nil {
 			return err
 		}
+		skip = path[index]
 	}
 	return nil
 }
 
 // ..............................................
 
-func findDown[BoundType any](s Searcher[BoundType], node *bvhNode[BoundType], skip *bvhNode[BoundType]) error {
+func findDown[BoundType any](s Searcher[BoundType], node *bvhNode[BoundType], skip *bvhNode[BoundType], trav *traversal[BoundType]) error {
 	if node != nil {
 		var err error
-		if s.DoesIntersect(node.GetBound()) {
-			for _, child := range node.children {
+		if trav.visit(node) && s.DoesIntersect(trav.bound(node)) {
+			children, mark := trav.order(node)
+			for _, child := range children {
 				if child != nil {
 					value, ok := child.(*bvhNode[BoundType])
 					if ok {
 						if value != skip {
-							err = findDown(s, value, skip)
+							err = findDown(s, value, skip, trav)
 						}
-					} else {
-						err = s.Evaluate(child)
+					} else if trav.accept(child) {
+						presented := trav.present(child)
+						trav.touch(s, child, presented)
+						err = s.Evaluate(presented)
 					}
 				}
 				if err != nil {
 					return err
 				}
 			}
+			trav.scratch = trav.scratch[:mark]
 		}
 	}
 	return nil
@@ -370,8 +850,8 @@ func findDown[BoundType any](s Searcher[BoundType], node *bvhNode[BoundType], sk
 // ..............................................
 
 // from the given node, select the immediate child "closest" to the given bound, b
-func chooseChild[BoundType any](bounder BoundTraits[BoundType], node *bvhNode[BoundType], b BoundType) *bvhNode[BoundType] {
-	choosemetric := 1e38
+func chooseChild[BoundType any](bounder BoundTraits[BoundType], node *bvhNode[BoundType], b BoundType, limit float64) *bvhNode[BoundType] {
+	choosemetric := limit
 	var chosen *bvhNode[BoundType] = nil
 	if node != nil {
 		for _, child := range node.children {
@@ -393,7 +873,7 @@ func chooseLeaf[BoundType any](tree *BVH[BoundType], b BoundType) *bvhNode[Bound
 	node := &tree.root
 	lastnode := &tree.root
 	for node != nil {
-		chosen := chooseChild(tree.boundtraits, node, b)
+		chosen := tree.chooseNext(node, b)
 		lastnode = node
 		node = chosen
 	} // end for
@@ -403,19 +883,19 @@ func chooseLeaf[BoundType any](tree *BVH[BoundType], b BoundType) *bvhNode[Bound
 // ..............................................
 
 // erase node from subtree rooted at parent; and update parent and all other ancestor bounds.
-func eraseChild[BoundType any](bounder BoundTraits[BoundType], parent *bvhNode[BoundType], element Boundable[BoundType], elembound BoundType) (bool, *bvhNode[BoundType]) {
+func (bvh *BVH[BoundType]) eraseChild(parent *bvhNode[BoundType], element Boundable[BoundType], elembound BoundType) (bool, *bvhNode[BoundType]) {
 	erased := false
 	erasedhere := false
 	var container *bvhNode[BoundType]
 
 	if parent != nil {
-		doesintersect, _ := furthestDistanceMetric(bounder, elembound, parent.bound)
+		doesintersect, _ := furthestDistanceMetric(bvh.boundtraits, elembound, parent.bound)
 		if doesintersect {
 
 			for index, child := range parent.children {
 				value, ok := child.(*bvhNode[BoundType])
 				if ok {
-					erased, container = eraseChild(bounder, value, element, elembound)
+					erased, container = bvh.eraseChild(value, element, elembound)
 					if erased {
 						break // for
 					}
@@ -423,8 +903,8 @@ func eraseChild[BoundType any](bounder BoundTraits[BoundType], parent *bvhNode[B
 
 				if child == element {
 					// erase node from parent.children slice
-					parent.children[index] = parent.children[len(parent.children)-1]
-					parent.children = parent.children[:len(parent.children)-1]
+					parent = bvh.own(parent)
+					parent.children = bvh.removeAt(parent.children, index)
 					container = parent
 					erasedhere = true
 					break // for
@@ -434,7 +914,7 @@ func eraseChild[BoundType any](bounder BoundTraits[BoundType], parent *bvhNode[B
 			if true == erasedhere {
 				updatenode := container
 				for updatenode != nil {
-					recalculateBounds(bounder, updatenode)
+					bvh.recalculateBounds(updatenode)
 					updatenode = updatenode.parent
 				} // end for update ancestors' bounds
 			} // if erased here
@@ -446,16 +926,38 @@ func eraseChild[BoundType any](bounder BoundTraits[BoundType], parent *bvhNode[B
 
 // ..............................................
 
-func recalculateBounds[BoundType any](bounder BoundTraits[BoundType], node *bvhNode[BoundType]) {
+// recalculates the bound (and other aggregates) of node from its immediate children.
+func (bvh *BVH[BoundType]) recalculateBounds(node *bvhNode[BoundType]) {
 	initialized := false
+	node.cost = 0.0
+	node.bloom = 0
+	node.layers = 0
+	node.expiry = 0
+	node.elements = 0
+	node.descendants = 0
 	for _, child := range node.children {
+		node.cost += elementCost(child)
+		node.bloom |= bvh.elementBloom(child)
+		node.layers |= bvh.elementLayers(child)
+		node.expiry = earlierExpiry(node.expiry, bvh.elementExpiry(child))
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			node.elements += value.elements
+			node.descendants += 1 + value.descendants
+		} else if child != nil {
+			node.elements++
+		}
+		bound := child.GetBound()
+		if _, ok := child.(*bvhNode[BoundType]); !ok {
+			bound = bvh.fatten(bound)
+		}
 		if initialized {
-			node.bound = bounder.Union(child.GetBound(), node.bound)
+			node.bound = bvh.boundtraits.Union(bound, node.bound)
 		} else {
 			initialized = true
-			node.bound = child.GetBound()
+			node.bound = bound
 		}
 	}
+	node.bound = bvh.snap(node.bound)
 }
 
 // ..............................................
@@ -474,50 +976,81 @@ func fixParentPointers[BoundType any](node *bvhNode[BoundType]) {
 // ..............................................
 
 //
-func splitNode[BoundType any](bounder BoundTraits[BoundType], node *bvhNode[BoundType], root *bvhNode[BoundType]) {
+func (bvh *BVH[BoundType]) splitNode(node *bvhNode[BoundType], root *bvhNode[BoundType]) {
 	parent := node
-	for parent != nil && len(parent.children)%16 == 0 && len(parent.children) > 0 {
+	for parent != nil && len(parent.children)%bvh.options.MaxChildren == 0 && len(parent.children) > 0 {
 		if root == parent {
 			// splitting the root is a special case
 			// move root children to new node:
-			newnode := bvhNode[BoundType]{
-				children: root.children[:],
-				parent:   root,
-				bound:    root.bound,
-			}
+			// (the new node's recycled children slice goes to the root)
+			newnode := bvh.arena.alloc()
+			newnode.children, root.children = root.children, newnode.children
+			newnode.parent = root
+			newnode.bound = root.bound
+			newnode.cost = root.cost
+			newnode.bloom = root.bloom
+			newnode.layers = root.layers
+			newnode.expiry = root.expiry
+			newnode.level = root.level
+			newnode.elements = root.elements
+			newnode.descendants = root.descendants
+			root.level-- // the whole tree is one level deeper
+			root.descendants++
+
 			// fix parent pointers for moved children:
-			fixParentPointers(&newnode)
+			fixParentPointers(newnode)
 
 			// make new children for root and split the new node:
-			root.children = make([]Boundable[BoundType], 0, 8)
-			root.children = append(root.children, &newnode)
-			parent = &newnode
+			root.children = bvh.appendChild(root.children, newnode)
+			parent = newnode
 
 		} else {
 			// splitting a "normal" node, not the root
 			// assert that parent.parent != nil
 
-			// get opposing corners of the bound:
-			bound0, bound1 := getSplitBounds(bounder, parent)
-
 			// reuse node "parent" as node1, create a new node0
-			node0 := &(bvhNode[BoundType]{parent: parent.parent})
+			node0 := bvh.arena.alloc()
+			node0.parent = parent.parent
+			node0.level = parent.level
 			node1 := parent
 
 			// divide children of "parent" between node0 and node1
-			node0.children, node1.children = partitionSplit(bounder, parent, bound0, bound1)
+			// (reusing the storage of both)
+			bvh.scratch = append(bvh.scratch[:0], parent.children...)
+			if bvh.splitter != nil {
+				node0.children, node1.children = bvh.partitionStrategy(bvh.scratch, node0.children, parent.children[:0])
+			} else if bvh.medianSplit(parent) {
+				node0.children, node1.children = bvh.partitionMedian(bvh.scratch, node0.children, parent.children[:0])
+			} else {
+				// get opposing corners of the bound:
+				bound0, bound1 := getSplitBounds(bvh.boundtraits, bvh.scratch)
+				node0.children, node1.children = bvh.partitionSplit(bvh.scratch, bound0, bound1, node0.children, parent.children[:0])
+				if bvh.options.RefineSplits {
+					node0.children, node1.children = bvh.refineSplit(node0.children, node1.children)
+				}
+			}
+			for index := range bvh.scratch {
+				bvh.scratch[index] = nil
+			}
 
 			// if a minimally useful split occurred, then commit; otherwise revert:
-			if len(node0.children) > 1 && len(node1.children) > 1 {
+			minimum := bvh.options.MinSplitChildren
+			if len(node0.children) >= minimum && len(node1.children) >= minimum {
 				fixParentPointers(node0)
-				parent.parent.children = append(parent.parent.children, node0)
+				parent.parent.children = bvh.appendChild(parent.parent.children, node0)
+				for ancestor := parent.parent; ancestor != nil; ancestor = ancestor.parent {
+					ancestor.descendants++
+				}
 
-				recalculateBounds(bounder, node0)
-				recalculateBounds(bounder, node1)
+				bvh.recalculateBounds(node0)
+				bvh.recalculateBounds(node1)
 
 			} else {
 				// revert the node split:
-				node1.children = append(node1.children, node0.children...)
+				for _, child := range node0.children {
+					node1.children = bvh.appendChild(node1.children, child)
+				}
+				bvh.arena.release(node0)
 			}
 
 			parent = parent.parent
@@ -529,20 +1062,20 @@ func splitNode[BoundType any](bounder BoundTraits[BoundType], node *bvhNode[Boun
 
 // ..............................................
 
-func getSplitBounds[BoundType any](bounder BoundTraits[BoundType], node *bvhNode[BoundType]) (BoundType, BoundType) {
+func getSplitBounds[BoundType any](bounder BoundTraits[BoundType], children []Boundable[BoundType]) (BoundType, BoundType) {
 	var b0 BoundType
 	var b1 BoundType
 	var chosenmetric float64
 
-	if len(node.children) > 0 {
-		b0 = node.children[0].GetBound()
+	if len(children) > 0 {
+		b0 = children[0].GetBound()
 	}
-	if len(node.children) > 1 {
-		b1 = node.children[1].GetBound()
+	if len(children) > 1 {
+		b1 = children[1].GetBound()
 		_, chosenmetric = furthestDistanceMetric(bounder, b0, b1)
 	}
-	for index := 2; index < len(node.children); index++ {
-		thisbound := node.children[index].GetBound()
+	for index := 2; index < len(children); index++ {
+		thisbound := children[index].GetBound()
 		_, metric0 := furthestDistanceMetric(bounder, thisbound, b1)
 		_, metric1 := furthestDistanceMetric(bounder, thisbound, b0)
 
@@ -562,19 +1095,18 @@ func getSplitBounds[BoundType any](bounder BoundTraits[BoundType], node *bvhNode
 
 // ..............................................
 
-// returns two slices, each contains elements proximate to either bound0 or bound1 respectfully.
-func partitionSplit[BoundType any](bounder BoundTraits[BoundType], node *bvhNode[BoundType], bound0 BoundType, bound1 BoundType) ([]Boundable[BoundType], []Boundable[BoundType]) {
-	store0 := make([]Boundable[BoundType], 0, 8)
-	store1 := make([]Boundable[BoundType], 0, 8)
+// appends children to store0 or store1, whichever is proximate to bound0 or bound1 respectfully.
+func (bvh *BVH[BoundType]) partitionSplit(children []Boundable[BoundType], bound0 BoundType, bound1 BoundType, store0 []Boundable[BoundType], store1 []Boundable[BoundType]) ([]Boundable[BoundType], []Boundable[BoundType]) {
+	bounder := bvh.boundtraits
 
-	for _, child := range node.children {
+	for _, child := range children {
 		thisbound := child.GetBound()
 		_, metric0 := furthestDistanceMetric(bounder, thisbound, bound0)
 		_, metric1 := furthestDistanceMetric(bounder, thisbound, bound1)
 		if metric0 < metric1 {
-			store0 = append(store0, child)
+			store0 = bvh.appendChild(store0, child)
 		} else {
-			store1 = append(store1, child)
+			store1 = bvh.appendChild(store1, child)
 		}
 	} // end for
 	return store0, store1
@@ -585,6 +1117,7 @@ func partitionSplit[BoundType any](bounder BoundTraits[BoundType], node *bvhNode
 // To maximize overlap between the bounding volumes, minimize this metric
 // from "Similarity metrics for bounding volumes", SIGGRAPH '07: ACM SIGGRAPH 2007 posters
 // This uses the L1 metric which scales well to high dimensions
+// (It guides tree maintenance; it is not a distance bound, see minDistance() for searches.)
 func furthestDistanceMetric[BoundType any](bounder BoundTraits[BoundType], first BoundType, second BoundType) (bool, float64) {
 	var metric float64 = 0.0
 	doesintersect := false
@@ -248,3 +248,211 @@ func TestBVHNearestNeighbor2D(t *testing.T) {
 
 	return
 }
+
+// ========================================================
+
+// Point2D with an advertised evaluation cost:
+type CostedPoint2D struct {
+	P    Point2D
+	Cost float64
+}
+
+func (cp *CostedPoint2D) GetBound() AABB2D {
+	return cp.P.GetBound()
+}
+
+func (cp *CostedPoint2D) EvaluationCost() float64 {
+	return cp.Cost
+}
+
+// records the order of evaluation:
+type RecordOrder struct {
+	Order []Boundable[AABB2D]
+}
+
+func (ro *RecordOrder) DoesIntersect(aabb AABB2D) bool {
+	return true
+}
+
+func (ro *RecordOrder) Evaluate(element Boundable[AABB2D]) error {
+	ro.Order = append(ro.Order, element)
+	return nil
+}
+
+func TestBVHCostOrdering(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+
+	var x, y float64
+	var total float64
+	for x = 0.0; x < 8.0; x += 1.0 {
+		for y = 0.0; y < 8.0; y += 1.0 {
+			cost := 1.0 + float64(int(x+y)%5)
+			total += cost
+			bvh.Insert(&CostedPoint2D{Point2D{x, y}, cost})
+		}
+	}
+	if bvh.root.cost != total {
+		t.Errorf("Expected total cost %f but root reports %f", total, bvh.root.cost)
+	}
+
+	// within any single leaf, nearer elements must be evaluated first, and
+	// the cheaper of equally near ones:
+	here := Point2D{3.0, 3.0}.GetBound()
+	ro := RecordOrder{}
+	err := bvh.FindNearest(&ro, here)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if len(ro.Order) != 64 {
+		t.Errorf("Expected 64 evaluations, found %d", len(ro.Order))
+	}
+	leaf := chooseLeaf(bvh, here)
+	ties := 0
+	for index := 1; index < len(leaf.children); index++ {
+		_, before := furthestDistanceMetric[AABB2D](Traits2D{}, ro.Order[index-1].GetBound(), here)
+		_, after := furthestDistanceMetric[AABB2D](Traits2D{}, ro.Order[index].GetBound(), here)
+		if before > after {
+			t.Errorf("Element %d (at %f) evaluated before a nearer sibling (at %f)", index-1, before, after)
+		}
+		if before == after {
+			ties++
+			if elementCost(ro.Order[index-1]) > elementCost(ro.Order[index]) {
+				t.Errorf("Element %d (cost %f) evaluated before an equally near, cheaper sibling (cost %f)", index-1, elementCost(ro.Order[index-1]), elementCost(ro.Order[index]))
+			}
+		}
+	}
+	if ties == 0 {
+		t.Errorf("Expected equally near elements in the leaf")
+	}
+
+	// elements without costs are visited in place, without reordering:
+	plain := New[AABB2D](Traits2D{})
+	for x = 0.0; x < 8.0; x += 1.0 {
+		for y = 0.0; y < 8.0; y += 1.0 {
+			plain.Insert(Point2D{x, y})
+		}
+	}
+	trav := plain.newTraversal(true, nil)
+	trav.here = here
+	for _, node := range []*bvhNode[AABB2D]{&plain.root, chooseLeaf(plain, here)} {
+		if children, _ := trav.order(node); &children[0] != &node.children[0] || len(trav.scratch) != 0 {
+			t.Errorf("Expected the children of a node without costs not to be reordered")
+		}
+	}
+
+	// cost is maintained through erasure:
+	for _, element := range ro.Order[:32] {
+		total -= elementCost(element)
+		bvh.Erase(element)
+	}
+	if bvh.root.cost != total {
+		t.Errorf("Expected total cost %f after erasure but root reports %f", total, bvh.root.cost)
+	}
+}
+
+// ========================================================
+
+func TestBVHContains(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	inside := make([]*CostedPoint2D, 0, 500)
+	outside := make([]*CostedPoint2D, 0, 500)
+	for i := 0; i < 1000; i++ {
+		p := &CostedPoint2D{P: Point2D{float64(i % 37), float64(i % 41)}}
+		if i%2 == 0 {
+			bvh.Insert(p)
+			inside = append(inside, p)
+		} else {
+			outside = append(outside, p)
+		}
+	}
+	for _, p := range inside {
+		if !bvh.Contains(p) {
+			t.Errorf("Expected %v to be contained", p.P)
+		}
+	}
+	for _, p := range outside {
+		if bvh.Contains(p) {
+			t.Errorf("Expected %v not to be contained", p.P)
+		}
+	}
+	bvh.Erase(inside[0])
+	if bvh.Contains(inside[0]) {
+		t.Errorf("Expected an erased element not to be contained")
+	}
+}
+
+// ........................................................
+
+func TestBVHEraseFunc(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	stored := make([]*CostedPoint2D, 0, 500)
+	for i := 0; i < 500; i++ {
+		p := &CostedPoint2D{P: Point2D{float64(i % 37), float64(i % 41)}, Cost: float64(i)}
+		bvh.Insert(p)
+		stored = append(stored, p)
+	}
+
+	// a copy reconstructed from data is not the element, but matches it:
+	for _, p := range stored[:250] {
+		copied := &CostedPoint2D{P: p.P, Cost: p.Cost}
+		if bvh.Erase(copied) {
+			t.Fatalf("Expected Erase() of a copy to fail")
+		}
+		same := func(element Boundable[AABB2D]) bool {
+			other, ok := element.(*CostedPoint2D)
+			return ok && other.P == copied.P && other.Cost == copied.Cost
+		}
+		if !bvh.EraseFunc(copied.GetBound(), same) {
+			t.Errorf("Expected EraseFunc() to erase %v", p.P)
+		}
+		if bvh.Contains(p) || bvh.EraseFunc(copied.GetBound(), same) {
+			t.Errorf("Expected %v to be erased once", p.P)
+		}
+	}
+	if err := bvh.Validate(); err != nil {
+		t.Errorf("Validate() failed: %v", err)
+	}
+	if bvh.Len() != 250 {
+		t.Errorf("Expected 250 elements, found %d", bvh.Len())
+	}
+}
+
+// ========================================================
+
+func TestBVHStableErase(t *testing.T) {
+	for _, stable := range []bool{false, true} {
+		options := DefaultOptions()
+		options.StableErase = stable
+		bvh := NewWithOptions[AABB2D](Traits2D{}, options)
+		points := make([]*CostedPoint2D, 500)
+		for i := range points {
+			points[i] = &CostedPoint2D{P: Point2D{float64(i % 23), float64(i % 29)}}
+			bvh.Insert(points[i])
+		}
+
+		for i := 0; i < 200; i += 3 {
+			container := bvh.findContainer(&bvh.root, points[i], points[i].GetBound())
+			expected := make([]Boundable[AABB2D], 0, len(container.children))
+			for _, child := range container.children {
+				if child != points[i] {
+					expected = append(expected, child)
+				}
+			}
+			if len(expected) < 2 || container.children[len(container.children)-1] == points[i] || container.children[len(container.children)-2] == points[i] {
+				continue // erasing it cannot reorder anything
+			}
+			bvh.Erase(points[i])
+
+			same := len(container.children) == len(expected)
+			for index := 0; same && index < len(expected); index++ {
+				same = container.children[index] == expected[index]
+			}
+			if stable && !same {
+				t.Errorf("Expected erasure to keep the order of the remaining children")
+			}
+			if !stable && same {
+				t.Errorf("Expected erasure to move the last child into the vacated slot")
+			}
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package gobvh
+
+// ==============================================
+
+//
+// Handle refers to an element stored in a BVH, remembering the leaf which
+// holds it and the bound it was stored with, see BVH.InsertHandle().
+//
+// Erasing or updating an element through its handle skips the search from
+// the root which Erase() and Update() make, and works even if the element's
+// bound has changed since it was stored.  A handle is only for the bvh which
+// returned it.  The zero Handle refers to no element.
+//
+type Handle[BoundType any] struct {
+	element Boundable[BoundType]
+	bound   BoundType           // the bound the element is stored with
+	leaf    *bvhNode[BoundType] // the node last known to hold it (nil if unknown)
+}
+
+// ..............................................
+
+//
+// Handle.Element() returns the element the handle refers to (nil for the zero
+// Handle).
+//
+func (h Handle[BoundType]) Element() Boundable[BoundType] {
+	return h.element
+}
+
+// ==============================================
+
+//
+// BVH.InsertHandle(element) inserts the element as Insert() does, and returns
+// a handle for erasing or updating it later.
+//
+func (bvh *BVH[BoundType]) InsertHandle(element Boundable[BoundType]) Handle[BoundType] {
+	bvh.Insert(element)
+	return bvh.handleFor(element)
+}
+
+// ..............................................
+
+//
+// BVH.EraseHandle(h) erases the element h refers to, as Erase() does, and
+// reports whether it was erased.
+//
+// The leaf recorded in h is checked, by walking up to the root, rather than
+// searched for; only if the hierarchy has been restructured around the
+// element since (by a split, a rebuild or the like) is the element searched
+// for, by its stored bound.
+//
+func (bvh *BVH[BoundType]) EraseHandle(h *Handle[BoundType]) bool {
+	container := bvh.handleLeaf(h)
+	if container == nil {
+		return false
+	}
+	bvh.removeChild(container, h.element)
+	for node := container; node != nil; node = node.parent {
+		bvh.recalculateBounds(node)
+	}
+	bvh.erased(container, h.element, h.bound)
+	*h = Handle[BoundType]{}
+	bvh.settleErase(container)
+	return true
+}
+
+// ..............................................
+
+//
+// BVH.UpdateHandle(h, newbound) moves the element h refers to, whose bound
+// is now newbound (which element.GetBound() must also return), as Update()
+// does, and reports whether it was found.  h is updated to match.
+//
+// If newbound still fits in the element's leaf, only the bounds along the
+// path to the root are recalculated, without any search.
+//
+func (bvh *BVH[BoundType]) UpdateHandle(h *Handle[BoundType], newbound BoundType) bool {
+	container := bvh.handleLeaf(h)
+	if container == nil {
+		return false
+	}
+	element := h.element
+	if boundContainsBound(bvh.boundtraits, container.bound, newbound) {
+		bvh.movedInPlace(container, element, h.bound, newbound)
+		for node := container; node != nil; node = node.parent {
+			bvh.recalculateBounds(node)
+		}
+		h.bound = newbound
+		return true
+	}
+
+	// remove, keeping the annotations, and reinsert:
+	bvh.removeChild(container, element)
+	for node := container; node != nil; node = node.parent {
+		bvh.recalculateBounds(node)
+	}
+	info := bvh.info[element]
+	bvh.erased(container, element, h.bound)
+	if info != nil {
+		bvh.info[element] = info
+	}
+	bvh.settleErase(container)
+	*h = bvh.InsertHandle(element)
+	return true
+}
+
+// ==============================================
+
+// a handle for element, stored (with its current bound) in the bvh
+func (bvh *BVH[BoundType]) handleFor(element Boundable[BoundType]) Handle[BoundType] {
+	bound := element.GetBound()
+	return Handle[BoundType]{
+		element: element,
+		bound:   bound,
+		leaf:    bvh.findContainer(&bvh.root, element, bound),
+	}
+}
+
+// ..............................................
+
+// the node holding the element h refers to, checking the leaf it records
+// first, ready to be modified (see own()); records the node in h, and
+// returns nil if the element is not stored
+func (bvh *BVH[BoundType]) handleLeaf(h *Handle[BoundType]) *bvhNode[BoundType] {
+	if h.element == nil {
+		return nil
+	}
+	if h.leaf == nil || !holdsChild(h.leaf, h.element) || !bvh.attached(h.leaf) {
+		h.leaf = bvh.findContainer(&bvh.root, h.element, h.bound)
+	}
+	if h.leaf != nil {
+		h.leaf = bvh.own(h.leaf)
+	}
+	return h.leaf
+}
+
+// ..............................................
+
+// reports whether node is still part of the hierarchy, by walking up to the root
+func (bvh *BVH[BoundType]) attached(node *bvhNode[BoundType]) bool {
+	for ; node != &bvh.root; node = node.parent {
+		if node.parent == nil || !holdsChild[BoundType](node.parent, node) {
+			return false
+		}
+	}
+	return true
+}
+
+// ..............................................
+
+// reports whether child is one of the children of node
+func holdsChild[BoundType any](node *bvhNode[BoundType], child Boundable[BoundType]) bool {
+	for _, other := range node.children {
+		if other == child {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHHandles(t *testing.T) {
+	rng := rand.New(rand.NewSource(59))
+	boxes := randomBoxes(rng, 1000, 2.0)
+	bvh := New[AABB2D](Traits2D{})
+	handles := make([]Handle[AABB2D], len(boxes))
+	for index, element := range boxes {
+		if index == 1 {
+			bvh.InsertTagged(element, Tag(1))
+			handles[index] = bvh.handleFor(element)
+		} else {
+			handles[index] = bvh.InsertHandle(element)
+		}
+		if handles[index].Element() != element {
+			t.Fatalf("Expected the handle to refer to the element inserted")
+		}
+	}
+
+	// moves, small and large, through the handles:
+	for tick := 0; tick < 5; tick++ {
+		for index, element := range boxes {
+			box := element.(*Box2D)
+			step := 0.5
+			if rng.Intn(10) == 0 {
+				step = 40.0
+			}
+			dx, dy := (rng.Float64()*2.0-1.0)*step, (rng.Float64()*2.0-1.0)*step
+			box.B = AABB2D{L: Point2D{box.B.L[0] + dx, box.B.L[1] + dy}, H: Point2D{box.B.H[0] + dx, box.B.H[1] + dy}}
+			if !bvh.UpdateHandle(&handles[index], box.B) {
+				t.Fatalf("UpdateHandle() failed to find element %d", index)
+			}
+		}
+		checkTree(t, bvh, &bvh.root)
+		if err := bvh.Validate(); err != nil {
+			t.Fatalf("Validate() failed: %v", err)
+		}
+	}
+	if !bvh.HasTag(boxes[1], Tag(1)) {
+		t.Errorf("Tag lost by UpdateHandle")
+	}
+	fresh := New[AABB2D](Traits2D{})
+	for index, element := range boxes {
+		if index == 1 {
+			fresh.InsertTagged(element, Tag(1))
+		} else {
+			fresh.Insert(element)
+		}
+	}
+	if bvh.ContentHash() != fresh.ContentHash() {
+		t.Errorf("ContentHash() does not match that of the same elements inserted")
+	}
+
+	// erasures after the elements moved behind the bvh's back:
+	for index := 0; index < len(boxes); index += 2 {
+		box := boxes[index].(*Box2D)
+		box.B = AABB2D{L: Point2D{box.B.L[0] + 500.0, box.B.L[1] + 500.0}, H: Point2D{box.B.H[0] + 500.0, box.B.H[1] + 500.0}}
+		if bvh.Erase(box) {
+			t.Errorf("Expected Erase() of a moved element to fail")
+		}
+		if !bvh.EraseHandle(&handles[index]) {
+			t.Errorf("EraseHandle() failed to find element %d", index)
+		}
+		if bvh.EraseHandle(&handles[index]) {
+			t.Errorf("Expected a second EraseHandle() to fail")
+		}
+	}
+	checkTree(t, bvh, &bvh.root)
+	if err := bvh.Validate(); err != nil {
+		t.Errorf("Validate() failed: %v", err)
+	}
+	if bvh.Len() != len(boxes)/2 {
+		t.Errorf("Expected %d elements, found %d", len(boxes)/2, bvh.Len())
+	}
+
+	var zero Handle[AABB2D]
+	if bvh.EraseHandle(&zero) || bvh.UpdateHandle(&zero, AABB2D{}) {
+		t.Errorf("Expected the zero Handle to refer to no element")
+	}
+
+	// moves out of a leaf condense the nodes they leave, as Erase() does:
+	condensed := New[AABB2D](Traits2D{}, WithMaxChildren(8), WithMinChildren(3))
+	for index, element := range boxes {
+		handles[index] = condensed.InsertHandle(element)
+	}
+	for index, element := range boxes {
+		box := element.(*Box2D)
+		dx, dy := (rng.Float64()*2.0-1.0)*40.0, (rng.Float64()*2.0-1.0)*40.0
+		box.B = AABB2D{L: Point2D{box.B.L[0] + dx, box.B.L[1] + dy}, H: Point2D{box.B.H[0] + dx, box.B.H[1] + dy}}
+		if !condensed.UpdateHandle(&handles[index], box.B) {
+			t.Fatalf("UpdateHandle() failed to find element %d", index)
+		}
+	}
+	if err := condensed.Validate(); err != nil {
+		t.Errorf("Validate() failed: %v", err)
+	}
+	if smallest := smallestNode(&condensed.root, true); smallest < 3 {
+		t.Errorf("Expected every node to hold at least 3 children, found %d", smallest)
+	}
+}
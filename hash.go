@@ -0,0 +1,63 @@
+package gobvh
+
+import (
+	"math"
+)
+
+// ==============================================
+
+//
+// Hasher may be implemented by elements to contribute their own hash to
+// BVH.ContentHash(), e.g. a hash of an identifier or of the payload.
+//
+// Elements which are not Hashers are hashed by their bound (as given by
+// IntervalRange()), so distinct elements with identical bounds are
+// indistinguishable to ContentHash().
+//
+type Hasher interface {
+	Hash64() uint64
+}
+
+// ..............................................
+
+//
+// BVH.ContentHash() returns a coarse signature of the elements stored, in O(1).
+//
+// The hash is maintained incrementally by Insert() and Erase() and does
+// not depend on the order of insertion or on the shape of the hierarchy,
+// so two indexes (or an index and its snapshot) holding the same elements
+// report the same hash.  Differing hashes prove the contents differ; equal
+// hashes only suggest they are the same.  An empty bvh hashes to 0.
+//
+func (bvh *BVH[BoundType]) ContentHash() uint64 {
+	return bvh.contenthash
+}
+
+// ==============================================
+
+// the contribution of one element (with bound elembound) to the content hash
+func (bvh *BVH[BoundType]) elementHash(element Boundable[BoundType], elembound BoundType) uint64 {
+	if hasher, ok := element.(Hasher); ok {
+		return mixHash(hasher.Hash64())
+	}
+	hash := uint64(0x9e3779b97f4a7c15)
+	dimensions := bvh.boundtraits.Dimensions(elembound)
+	for i := uint(0); i < dimensions; i++ {
+		low, high := bvh.boundtraits.IntervalRange(elembound, i)
+		hash = mixHash(hash ^ math.Float64bits(low))
+		hash = mixHash(hash ^ math.Float64bits(high))
+	}
+	return mixHash(hash)
+}
+
+// ..............................................
+
+// the splitmix64 finalizer, which spreads every input bit over the output
+func mixHash(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
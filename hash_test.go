@@ -0,0 +1,90 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+type HashedPoint2D struct {
+	P  Point2D
+	ID uint64
+}
+
+func (h *HashedPoint2D) GetBound() AABB2D {
+	return AABB2D{L: h.P, H: h.P}
+}
+
+func (h *HashedPoint2D) Hash64() uint64 {
+	return h.ID
+}
+
+// ........................................................
+
+func TestBVHContentHash(t *testing.T) {
+	rng := rand.New(rand.NewSource(66))
+	boxes := randomBoxes(rng, 800, 3.0)
+	a, b := New[AABB2D](Traits2D{}), New[AABB2D](Traits2D{})
+	if a.ContentHash() != 0 {
+		t.Errorf("Expected an empty tree to hash to 0")
+	}
+	for _, element := range boxes {
+		a.Insert(element)
+	}
+	for i := len(boxes) - 1; i >= 0; i-- {
+		b.Insert(boxes[i])
+	}
+	if a.ContentHash() != b.ContentHash() {
+		t.Errorf("Expected the hash not to depend on insertion order")
+	}
+
+	before := a.ContentHash()
+	a.Erase(boxes[10])
+	if a.ContentHash() == before {
+		t.Errorf("Expected erasing an element to change the hash")
+	}
+	a.Insert(boxes[10])
+	if a.ContentHash() != before {
+		t.Errorf("Expected reinserting the element to restore the hash")
+	}
+
+	// a moved element changes the hash:
+	box := boxes[20].(*Box2D)
+	oldbound := box.B
+	box.B.L[0] += 1.0
+	box.B.H[0] += 1.0
+	a.Update(box, oldbound)
+	if a.ContentHash() == before {
+		t.Errorf("Expected moving an element to change the hash")
+	}
+
+	rebuilt := New[AABB2D](Traits2D{})
+	rebuilt.RebuildFrom(a, boxes)
+	if rebuilt.ContentHash() != a.ContentHash() {
+		t.Errorf("Expected RebuildFrom() to reproduce the hash")
+	}
+
+	for _, element := range boxes {
+		a.Erase(element)
+	}
+	if a.ContentHash() != 0 {
+		t.Errorf("Expected a tree emptied by Erase() to hash to 0, found %x", a.ContentHash())
+	}
+	b.ResetArena()
+	if b.ContentHash() != 0 {
+		t.Errorf("Expected ResetArena() to reset the hash")
+	}
+}
+
+// ........................................................
+
+func TestBVHContentHashHasher(t *testing.T) {
+	// elements at the same position are told apart by their Hash64():
+	a, b := New[AABB2D](Traits2D{}), New[AABB2D](Traits2D{})
+	a.Insert(&HashedPoint2D{P: Point2D{1, 1}, ID: 1})
+	b.Insert(&HashedPoint2D{P: Point2D{1, 1}, ID: 2})
+	if a.ContentHash() == b.ContentHash() {
+		t.Errorf("Expected different Hash64() values to give different hashes")
+	}
+}
@@ -0,0 +1,52 @@
+package gobvh
+
+// ==============================================
+
+// the position of the quantized centroid of bound (relative to frame)
+// along a Hilbert curve, as a 64-bit code.  Unlike Morton order, consecutive
+// codes are always adjacent cells, so runs of codes are compact regions.
+func hilbertCode[BoundType any](bounder BoundTraits[BoundType], bound BoundType, frame BoundType) uint64 {
+	quantized, bits := quantizeCentroid(bounder, bound, frame)
+	if len(quantized) > 1 {
+		hilbertTranspose(quantized, bits)
+	}
+	return interleaveBits(quantized, bits)
+}
+
+// ..............................................
+
+// converts coordinates (in place) to the "transpose" of their Hilbert index,
+// whose bits interleave into the index: J. Skilling, "Programming the
+// Hilbert curve", AIP Conference Proceedings 707 (2004).
+func hilbertTranspose(x []uint64, bits uint) {
+	n := len(x)
+	top := uint64(1) << (bits - 1)
+
+	// inverse undo excess work:
+	for q := top; q > 1; q >>= 1 {
+		p := q - 1
+		for i := 0; i < n; i++ {
+			if x[i]&q != 0 {
+				x[0] ^= p
+			} else {
+				t := (x[0] ^ x[i]) & p
+				x[0] ^= t
+				x[i] ^= t
+			}
+		}
+	}
+
+	// Gray encode:
+	for i := 1; i < n; i++ {
+		x[i] ^= x[i-1]
+	}
+	var t uint64
+	for q := top; q > 1; q >>= 1 {
+		if x[n-1]&q != 0 {
+			t ^= q - 1
+		}
+	}
+	for i := range x {
+		x[i] ^= t
+	}
+}
@@ -0,0 +1,36 @@
+package gobvh
+
+import (
+	"sort"
+	"testing"
+)
+
+// ========================================================
+
+func TestHilbertCode(t *testing.T) {
+	// the cells of an 8x8 lattice, in curve order, are each adjacent to the last:
+	frame := AABB2D{L: Point2D{0.0, 0.0}, H: Point2D{7.0, 7.0}}
+	points := make([]Point2D, 0, 64)
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			points = append(points, Point2D{float64(x), float64(y)})
+		}
+	}
+	// (each point falls in its own cell of the top three bits of the curve)
+	codes := make(map[Point2D]uint64)
+	for _, p := range points {
+		codes[p] = hilbertCode[AABB2D](Traits2D{}, p.GetBound(), frame)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return codes[points[i]] < codes[points[j]]
+	})
+	for i := 1; i < len(points); i++ {
+		dx, dy := points[i][0]-points[i-1][0], points[i][1]-points[i-1][1]
+		if dx*dx+dy*dy != 1.0 {
+			t.Errorf("Expected %v to follow %v along the curve", points[i], points[i-1])
+		}
+	}
+	if points[0] != (Point2D{0.0, 0.0}) {
+		t.Errorf("Expected the curve to start at (0 0), found %v", points[0])
+	}
+}
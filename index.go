@@ -0,0 +1,32 @@
+package gobvh
+
+// ==============================================
+
+//
+// Index is the query-only view of a spatial index.
+//
+// Accept an Index in APIs which only search, so that any of the package's
+// implementations can be supplied.  BVH implements Index.
+//
+type Index[BoundType any] interface {
+	GetBound() BoundType
+	FindAll(s Searcher[BoundType], opts ...QueryOption[BoundType]) error
+	FindNearest(s Searcher[BoundType], here BoundType, opts ...QueryOption[BoundType]) error
+	ForEach(crawler BVHCrawler[BoundType]) error
+}
+
+// ..............................................
+
+//
+// MutableIndex is a spatial index which can also be modified.
+// BVH implements MutableIndex.
+//
+type MutableIndex[BoundType any] interface {
+	Index[BoundType]
+	Insert(element Boundable[BoundType])
+	Erase(element Boundable[BoundType]) bool
+}
+
+// ..............................................
+
+var _ MutableIndex[struct{}] = (*BVH[struct{}])(nil)
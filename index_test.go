@@ -0,0 +1,26 @@
+package gobvh
+
+import (
+	"testing"
+)
+
+// ========================================================
+
+// counts the elements of any index
+func countIndex(index Index[AABB2D]) int {
+	ro := RecordOrder{}
+	index.FindAll(&ro)
+	return len(ro.Order)
+}
+
+func TestBVHIndexInterfaces(t *testing.T) {
+	var index MutableIndex[AABB2D] = New[AABB2D](Traits2D{})
+	var x float64
+	for x = 0.0; x < 20.0; x += 1.0 {
+		index.Insert(Point2D{x, x})
+	}
+	index.Erase(Point2D{3.0, 3.0})
+	if n := countIndex(index); n != 19 {
+		t.Errorf("Expected 19 elements through the Index interface, found %d", n)
+	}
+}
@@ -0,0 +1,105 @@
+package gobvh
+
+// ==============================================
+
+//
+// InsertHeuristic chooses the path an insertion descends, replacing the
+// built-in furthest-distance metric (and Options.ChooseLimit), see
+// BVH.SetInsertHeuristic().
+//
+// Choose(traits, children, bound) returns the index among children (the
+// children of the node being descended, a mix of elements and nodes) of the
+// node to descend into with an element whose bound is bound, or -1 to stop
+// and put the element in the node itself.  An index which is not that of a
+// node also stops the descent.
+//
+// The package provides DistanceInsert (the built-in heuristic) and
+// EnlargementInsert (the R-tree heuristic of least enlargement).
+//
+type InsertHeuristic[BoundType any] interface {
+	Choose(boundtraits BoundTraits[BoundType], children []Boundable[BoundType], bound BoundType) int
+}
+
+// ..............................................
+
+//
+// BVH.SetInsertHeuristic(heuristic) places later insertions with heuristic,
+// or with the built-in metric again if heuristic is nil.  Elements already
+// placed are not moved; see Optimize() or Tune() to restructure them.
+//
+func (bvh *BVH[BoundType]) SetInsertHeuristic(heuristic InsertHeuristic[BoundType]) {
+	bvh.inserter = heuristic
+}
+
+// ==============================================
+
+//
+// DistanceInsert descends into the child node nearest the bound by the L1
+// metric of their furthest corners, as the built-in heuristic does, but
+// stops at nodes with no child nearer than Limit (zero selects the default
+// of Options.ChooseLimit, which is effectively unlimited).
+//
+type DistanceInsert[BoundType any] struct {
+	Limit float64
+}
+
+func (heuristic DistanceInsert[BoundType]) Choose(boundtraits BoundTraits[BoundType], children []Boundable[BoundType], bound BoundType) int {
+	best := heuristic.Limit
+	if best <= 0.0 {
+		best = defaultChooseLimit
+	}
+	chosen := -1
+	for index, child := range children {
+		if _, ok := child.(*bvhNode[BoundType]); ok {
+			if _, metric := furthestDistanceMetric(boundtraits, child.GetBound(), bound); metric < best {
+				chosen, best = index, metric
+			}
+		}
+	}
+	return chosen
+}
+
+// ..............................................
+
+//
+// EnlargementInsert is the R-tree heuristic: it descends into the child node
+// whose volume (the product of the extents given by IntervalRange()) the
+// bound enlarges least, then the one with the least volume.  Unlike the
+// built-in metric, which favours the nearest node whatever its size, it keeps
+// clustered data from piling into one large branch.
+//
+type EnlargementInsert[BoundType any] struct{}
+
+func (EnlargementInsert[BoundType]) Choose(boundtraits BoundTraits[BoundType], children []Boundable[BoundType], bound BoundType) int {
+	chosen := -1
+	var leastgrowth, leastvolume float64
+	for index, child := range children {
+		if _, ok := child.(*bvhNode[BoundType]); !ok {
+			continue
+		}
+		childbound := child.GetBound()
+		union := boundtraits.Union(childbound, bound)
+		volume := boxOverlap(boundtraits, childbound, childbound)
+		growth := boxOverlap(boundtraits, union, union) - volume
+		if chosen < 0 || growth < leastgrowth || (growth == leastgrowth && volume < leastvolume) {
+			chosen, leastgrowth, leastvolume = index, growth, volume
+		}
+	}
+	return chosen
+}
+
+// ==============================================
+
+// the child node an insertion of bound b descends into from node, or nil to
+// stop at node
+func (bvh *BVH[BoundType]) chooseNext(node *bvhNode[BoundType], b BoundType) *bvhNode[BoundType] {
+	if bvh.inserter == nil {
+		return chooseChild(bvh.boundtraits, node, b, bvh.options.ChooseLimit)
+	}
+	index := bvh.inserter.Choose(bvh.boundtraits, node.children, b)
+	if index < 0 || index >= len(node.children) {
+		return nil
+	}
+	value, _ := node.children[index].(*bvhNode[BoundType])
+	return value
+}
@@ -0,0 +1,81 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+// stops every insertion at the root
+type rootInsert struct{}
+
+func (rootInsert) Choose(boundtraits BoundTraits[AABB2D], children []Boundable[AABB2D], bound AABB2D) int {
+	return -1
+}
+
+// ........................................................
+
+func TestInsertHeuristics(t *testing.T) {
+	rng := rand.New(rand.NewSource(802))
+	elements := make([]Boundable[AABB2D], 20000)
+	for i := range elements { // tight clusters along a diagonal
+		c := float64(rng.Intn(20))
+		elements[i] = Point2D{5.0*c + 0.3*rng.NormFloat64(), 3.0*c + 0.3*rng.NormFloat64()}
+	}
+	reference := New[AABB2D](Traits2D{})
+	for _, element := range elements {
+		reference.Insert(element)
+	}
+
+	heuristics := map[string]InsertHeuristic[AABB2D]{
+		"distance":    DistanceInsert[AABB2D]{},
+		"enlargement": EnlargementInsert[AABB2D]{},
+		"root":        rootInsert{},
+	}
+	for name, heuristic := range heuristics {
+		bvh := New[AABB2D](Traits2D{})
+		bvh.SetInsertHeuristic(heuristic)
+		for _, element := range elements {
+			bvh.Insert(element)
+		}
+		if err := bvh.Validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		for i := 0; i < 50; i++ {
+			region := randomRegion(rng, 5.0)
+			if bvh.Count(region) != reference.Count(region) {
+				t.Errorf("%s: search disagrees with the reference tree in %v", name, region)
+			}
+		}
+		for _, element := range elements[:1000] {
+			if !bvh.Erase(element) {
+				t.Errorf("%s: failed to erase %v", name, element.GetBound())
+				break
+			}
+		}
+		if name == "enlargement" && bvh.SAHCost() >= 0.75*reference.SAHCost() {
+			t.Errorf("Expected least enlargement to give a much cheaper tree on clusters, found %g and %g", bvh.SAHCost(), reference.SAHCost())
+		}
+	}
+
+	// DistanceInsert, and no heuristic, place elements as the built-in metric:
+	distance := New[AABB2D](Traits2D{})
+	distance.SetInsertHeuristic(DistanceInsert[AABB2D]{})
+	restored := New[AABB2D](Traits2D{})
+	restored.SetInsertHeuristic(rootInsert{})
+	restored.SetInsertHeuristic(nil)
+	builtin := New[AABB2D](Traits2D{})
+	for _, element := range elements[:5000] {
+		distance.Insert(element)
+		restored.Insert(element)
+		builtin.Insert(element)
+	}
+	var expected, fromdistance, fromrestored digest
+	expected.addNode(&builtin.root)
+	fromdistance.addNode(&distance.root)
+	fromrestored.addNode(&restored.root)
+	if fromdistance.hash != expected.hash || fromrestored.hash != expected.hash {
+		t.Errorf("Expected DistanceInsert, and no heuristic, to build the tree of the built-in metric")
+	}
+}
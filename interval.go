@@ -0,0 +1,220 @@
+package gobvh
+
+import (
+	"math"
+)
+
+// ==============================================
+
+//
+// Interval is a closed range [Lo, Hi] along one dimension.
+//
+// Either end may be infinite; Unbounded() is the interval covering
+// the whole dimension.
+//
+type Interval struct {
+	Lo float64
+	Hi float64
+}
+
+// ..............................................
+
+//
+// Unbounded() returns the interval (-Inf, +Inf).
+//
+func Unbounded() Interval {
+	return Interval{Lo: math.Inf(-1), Hi: math.Inf(1)}
+}
+
+// ..............................................
+
+//
+// Interval.Overlaps(lo, hi) reports whether the interval intersects the range [lo, hi].
+//
+func (iv Interval) Overlaps(lo float64, hi float64) bool {
+	return lo <= iv.Hi && iv.Lo <= hi
+}
+
+// ==============================================
+
+//
+// BVH.FindConstrained(constraints, fn) calls fn(element) for every element
+// whose bound intersects the given intervals.
+//
+// Only the dimensions present in constraints are tested, all other
+// dimensions are unbounded.  For example, with (x, y, time) bounds,
+// constraints on dimensions 0 and 1 alone find "this spatial region at any
+// time", without building a bound with fake infinite extents.
+// Constraints on dimensions a bound does not have are ignored.
+//
+// An error returned by fn stops the search and is returned.
+//
+func (bvh *BVH[BoundType]) FindConstrained(constraints map[uint]Interval, fn func(Boundable[BoundType]) error) error {
+	searcher := predicateSearcher[BoundType]{
+		pred: func(bound BoundType) bool {
+			return satisfiesConstraints(bvh.boundtraits, bound, constraints)
+		},
+		fn: fn,
+	}
+	return bvh.FindAll(&searcher)
+}
+
+// ..............................................
+
+//
+// BVH.FindAllIntersecting(region, fn) calls fn(element) for every element
+// whose bound intersects region (as the boxes given by IntervalRange()).
+//
+// This is the common "everything overlapping this box" search, without
+// writing a Searcher.  An error returned by fn stops the search and is returned.
+//
+func (bvh *BVH[BoundType]) FindAllIntersecting(region BoundType, fn func(Boundable[BoundType]) error) error {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryIntersecting, Bound: region})
+	}
+	return bvh.findAllIntersecting(region, fn)
+}
+
+func (bvh *BVH[BoundType]) findAllIntersecting(region BoundType, fn func(Boundable[BoundType]) error) error {
+	searcher := predicateSearcher[BoundType]{
+		pred: func(bound BoundType) bool {
+			return boundsOverlap(bvh.boundtraits, region, bound)
+		},
+		fn: fn,
+	}
+	return bvh.findAll(&searcher, nil)
+}
+
+// ..............................................
+
+//
+// BVH.FindAllIntersectingSorted(region, axis, descending, fn) is
+// FindAllIntersecting() with the elements delivered in order along axis:
+// by increasing lower edge, or with descending by decreasing upper edge,
+// e.g. left-to-right for a virtualized list of results.
+//
+// The order comes from the traversal itself, which visits nodes best-first
+// by their edge along axis, so the first results arrive without collecting
+// and sorting everything; an error returned by fn (e.g. once a page of
+// results is full) stops the search and is returned.  Elements with equal
+// edges are delivered in no particular order.
+//
+func (bvh *BVH[BoundType]) FindAllIntersectingSorted(region BoundType, axis uint, descending bool, fn func(Boundable[BoundType]) error) error {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryIntersectingSorted, Bound: region, Axis: axis, Descending: descending})
+	}
+	cutoff := math.Inf(1)
+	key := func(bound BoundType) (float64, bool) {
+		if !boundsOverlap(bvh.boundtraits, region, bound) {
+			return 0.0, false
+		}
+		lo, hi := bvh.boundtraits.IntervalRange(bound, axis)
+		if descending {
+			return -hi, true
+		}
+		return lo, true
+	}
+	return orderedDescent(&bvh.root, key, &cutoff, nil, func(element Boundable[BoundType], edge float64) error {
+		bvh.hit(element)
+		return fn(element)
+	})
+}
+
+// ..............................................
+
+//
+// BVH.FindAllOutside(region, fn) calls fn(element) for every element whose
+// bound does not intersect region (as the boxes given by IntervalRange()),
+// the complement of FindAllIntersecting().
+//
+// Subtrees inside region are skipped whole, and the elements of subtrees
+// clear of it are reported without testing them.  An error returned by fn
+// stops the search and is returned.
+//
+func (bvh *BVH[BoundType]) FindAllOutside(region BoundType, fn func(Boundable[BoundType]) error) error {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryOutside, Bound: region})
+	}
+	if len(bvh.root.children) == 0 {
+		return nil
+	}
+	if bvh.evictor != nil {
+		report := fn
+		fn = func(element Boundable[BoundType]) error {
+			bvh.hit(element)
+			return report(element)
+		}
+	}
+	return bvh.outsideNode(&bvh.root, region, fn)
+}
+
+// ==============================================
+
+func satisfiesConstraints[BoundType any](bounder BoundTraits[BoundType], bound BoundType, constraints map[uint]Interval) bool {
+	dims := bounder.Dimensions(bound)
+	for dim, iv := range constraints {
+		if dim < dims {
+			lo, hi := bounder.IntervalRange(bound, dim)
+			if !iv.Overlaps(lo, hi) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ..............................................
+
+// reports whether the axis-aligned boxes given by IntervalRange() intersect
+func boundsOverlap[BoundType any](bounder BoundTraits[BoundType], first BoundType, second BoundType) bool {
+	var i uint
+	for i = 0; i < bounder.Dimensions(first); i++ {
+		lo0, hi0 := bounder.IntervalRange(first, i)
+		lo1, hi1 := bounder.IntervalRange(second, i)
+		if !(Interval{Lo: lo0, Hi: hi0}).Overlaps(lo1, hi1) {
+			return false
+		}
+	}
+	return true
+}
+
+// ..............................................
+
+func (bvh *BVH[BoundType]) outsideNode(node *bvhNode[BoundType], region BoundType, fn func(Boundable[BoundType]) error) error {
+	if !boundsOverlap(bvh.boundtraits, region, node.bound) {
+		return forEachElement(node, fn) // everything is outside
+	}
+	if boundContainsBound(bvh.boundtraits, region, node.bound) {
+		return nil // everything is inside
+	}
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			if err := bvh.outsideNode(value, region, fn); err != nil {
+				return err
+			}
+		} else if child != nil && !boundsOverlap(bvh.boundtraits, region, child.GetBound()) {
+			if err := fn(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ..............................................
+
+// calls fn(element) for every element in the subtree rooted at node
+func forEachElement[BoundType any](node *bvhNode[BoundType], fn func(Boundable[BoundType]) error) error {
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			if err := forEachElement(value, fn); err != nil {
+				return err
+			}
+		} else if child != nil {
+			if err := fn(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package gobvh
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+// BoundType with a time interval:
+type SpaceTime struct {
+	L [3]float64
+	H [3]float64
+}
+
+type SpaceTimeEvent SpaceTime
+
+func (ev *SpaceTimeEvent) GetBound() SpaceTime {
+	return SpaceTime(*ev)
+}
+
+type SpaceTimeTraits struct{}
+
+func (bounder SpaceTimeTraits) IntervalRange(bound SpaceTime, dim uint) (float64, float64) {
+	return bound.L[dim], bound.H[dim]
+}
+
+func (bounder SpaceTimeTraits) Union(a SpaceTime, b SpaceTime) SpaceTime {
+	var result SpaceTime
+	for i := 0; i < 3; i++ {
+		result.L[i] = math.Min(a.L[i], b.L[i])
+		result.H[i] = math.Max(a.H[i], b.H[i])
+	}
+	return result
+}
+
+func (bounder SpaceTimeTraits) Dimensions(bound SpaceTime) uint {
+	return 3
+}
+
+// ........................................................
+
+func TestBVHFindConstrained(t *testing.T) {
+	bvh := New[SpaceTime](SpaceTimeTraits{})
+	var x, y float64
+	for x = 0.0; x < 8.0; x += 1.0 {
+		for y = 0.0; y < 8.0; y += 1.0 {
+			// an event at each position, during [x+y, x+y+1]
+			bvh.Insert(&SpaceTimeEvent{L: [3]float64{x, y, x + y}, H: [3]float64{x, y, x + y + 1.0}})
+		}
+	}
+	// an event at (3 3) that lasts forever:
+	bvh.Insert(&SpaceTimeEvent{L: [3]float64{3.0, 3.0, math.Inf(-1)}, H: [3]float64{3.0, 3.0, math.Inf(1)}})
+
+	count := func(constraints map[uint]Interval) int {
+		n := 0
+		err := bvh.FindConstrained(constraints, func(element Boundable[SpaceTime]) error {
+			n++
+			return nil
+		})
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+		return n
+	}
+
+	// this spatial region, any time:
+	if n := count(map[uint]Interval{0: {2.0, 3.0}, 1: {2.0, 3.0}}); n != 5 {
+		t.Errorf("Expected 5 events in the region, found %d", n)
+	}
+	// this time, anywhere:
+	if n := count(map[uint]Interval{2: {100.0, 200.0}}); n != 1 {
+		t.Errorf("Expected only the unbounded event at time 100, found %d", n)
+	}
+	if n := count(map[uint]Interval{0: Unbounded(), 2: {0.5, 1.5}}); n != 4 {
+		t.Errorf("Expected 4 events during [0.5 1.5], found %d", n)
+	}
+	if n := count(nil); n != 65 {
+		t.Errorf("Expected all 65 events without constraints, found %d", n)
+	}
+}
+
+// ........................................................
+
+func TestBVHFindAllIntersecting(t *testing.T) {
+	rng := rand.New(rand.NewSource(61))
+	boxes := randomBoxes(rng, 800, 5.0)
+	bvh := New[AABB2D](Traits2D{})
+	for _, element := range boxes {
+		bvh.Insert(element)
+	}
+
+	for i := 0; i < 20; i++ {
+		x, y := rng.Float64()*100.0, rng.Float64()*100.0
+		region := AABB2D{L: Point2D{x, y}, H: Point2D{x + 15.0, y + 10.0}}
+		found := 0
+		err := bvh.FindAllIntersecting(region, func(element Boundable[AABB2D]) error {
+			if !boundsOverlap[AABB2D](Traits2D{}, region, element.GetBound()) {
+				t.Errorf("Element %v does not intersect %v", element.GetBound(), region)
+			}
+			found++
+			return nil
+		})
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+		if expected := bvh.Count(region); found != expected {
+			t.Errorf("Expected %d intersecting elements, found %d", expected, found)
+		}
+	}
+}
+
+// ........................................................
+
+func TestBVHFindAllIntersectingSorted(t *testing.T) {
+	rng := rand.New(rand.NewSource(783))
+	boxes := randomBoxes(rng, 1500, 5.0)
+	bvh := New[AABB2D](Traits2D{})
+	for _, element := range boxes {
+		bvh.Insert(element)
+	}
+
+	for i := 0; i < 20; i++ {
+		x, y := rng.Float64()*80.0, rng.Float64()*80.0
+		region := AABB2D{L: Point2D{x, y}, H: Point2D{x + 20.0, y + 20.0}}
+		for axis := uint(0); axis < 2; axis++ {
+			for _, descending := range []bool{false, true} {
+				found := 0
+				previous := math.Inf(-1)
+				if descending {
+					previous = math.Inf(1)
+				}
+				bvh.FindAllIntersectingSorted(region, axis, descending, func(element Boundable[AABB2D]) error {
+					bound := element.GetBound()
+					if !boundsOverlap[AABB2D](Traits2D{}, region, bound) {
+						t.Errorf("Element %v does not intersect %v", bound, region)
+					}
+					if !descending && bound.L[axis] < previous || descending && bound.H[axis] > previous {
+						t.Errorf("Element %v out of order along axis %d", bound, axis)
+					}
+					previous = bound.L[axis]
+					if descending {
+						previous = bound.H[axis]
+					}
+					found++
+					return nil
+				})
+				if expected := bvh.Count(region); found != expected {
+					t.Errorf("Expected %d intersecting elements, found %d", expected, found)
+				}
+			}
+		}
+	}
+
+	// a page of results stops the search early:
+	page := errors.New("page full")
+	found := 0
+	err := bvh.FindAllIntersectingSorted(AABB2D{L: Point2D{0.0, 0.0}, H: Point2D{100.0, 100.0}}, 0, false, func(Boundable[AABB2D]) error {
+		found++
+		if found == 10 {
+			return page
+		}
+		return nil
+	})
+	if err != page || found != 10 {
+		t.Errorf("Expected the search to stop after a page, found %d", found)
+	}
+}
+
+// ........................................................
+
+func TestBVHFindAllOutside(t *testing.T) {
+	rng := rand.New(rand.NewSource(784))
+	boxes := randomBoxes(rng, 1500, 3.0)
+	bvh := New[AABB2D](Traits2D{})
+	for _, element := range boxes {
+		bvh.Insert(element)
+	}
+
+	for i := 0; i < 20; i++ {
+		x, y := rng.Float64()*80.0, rng.Float64()*80.0
+		region := AABB2D{L: Point2D{x, y}, H: Point2D{x + rng.Float64()*60.0, y + rng.Float64()*60.0}}
+		found := make(map[Boundable[AABB2D]]bool)
+		err := bvh.FindAllOutside(region, func(element Boundable[AABB2D]) error {
+			if boundsOverlap[AABB2D](Traits2D{}, region, element.GetBound()) || found[element] {
+				t.Errorf("Element %v intersects %v, or was reported twice", element.GetBound(), region)
+			}
+			found[element] = true
+			return nil
+		})
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+		if expected := len(boxes) - bvh.Count(region); len(found) != expected {
+			t.Errorf("Expected %d elements outside the region, found %d", expected, len(found))
+		}
+	}
+
+	everything := AABB2D{L: Point2D{-10.0, -10.0}, H: Point2D{110.0, 110.0}}
+	bvh.FindAllOutside(everything, func(element Boundable[AABB2D]) error {
+		t.Errorf("Expected nothing outside %v, found %v", everything, element.GetBound())
+		return nil
+	})
+	stop := errors.New("stop")
+	if err := bvh.FindAllOutside(AABB2D{L: Point2D{200.0, 200.0}, H: Point2D{201.0, 201.0}}, func(Boundable[AABB2D]) error {
+		return stop
+	}); err != stop {
+		t.Errorf("Expected the search to stop at the first error, found %v", err)
+	}
+}
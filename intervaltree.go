@@ -0,0 +1,83 @@
+package gobvh
+
+import (
+	"math"
+)
+
+// ==============================================
+
+//
+// IntervalTraits implements BoundTraits[Interval], for one-dimensional data
+// such as time intervals.  An Interval is itself Boundable, so plain
+// intervals can be stored (though equal intervals are then the same
+// element), as well as objects whose GetBound() returns one.
+//
+// See NewIntervalTree(), IntervalsAt() and IntervalJoin().
+//
+type IntervalTraits struct{}
+
+func (traits IntervalTraits) IntervalRange(bound Interval, dim uint) (float64, float64) {
+	return bound.Lo, bound.Hi
+}
+
+func (traits IntervalTraits) Union(a Interval, b Interval) Interval {
+	return Interval{Lo: math.Min(a.Lo, b.Lo), Hi: math.Max(a.Hi, b.Hi)}
+}
+
+func (traits IntervalTraits) Dimensions(bound Interval) uint {
+	return 1
+}
+
+// makes Interval a Boundable[Interval]:
+func (iv Interval) GetBound() Interval {
+	return iv
+}
+
+// ..............................................
+
+//
+// NewIntervalTree() returns an empty BVH of intervals.
+//
+// Nodes are split at the median of the interval centers (SplitMedian), which
+// keeps the tree balanced however the intervals nest or overlap.
+//
+func NewIntervalTree() *BVH[Interval] {
+	options := DefaultOptions()
+	options.SplitPolicy = SplitMedian
+	return NewWithOptions[Interval](IntervalTraits{}, options)
+}
+
+// ==============================================
+
+//
+// IntervalsAt(tree, t, fn) calls fn(element) for every element whose
+// interval contains t (ends included), e.g. everything happening at a time.
+// An error returned by fn stops the search and is returned.
+//
+func IntervalsAt(tree *BVH[Interval], t float64, fn func(Boundable[Interval]) error) error {
+	point := [1]float64{t}
+	return tree.Stab(point[:], fn)
+}
+
+// ..............................................
+
+//
+// IntervalsOverlapping(tree, lo, hi, fn) calls fn(element) for every element
+// whose interval intersects [lo, hi].  An error returned by fn stops the
+// search and is returned.
+//
+func IntervalsOverlapping(tree *BVH[Interval], lo float64, hi float64, fn func(Boundable[Interval]) error) error {
+	return tree.FindAllIntersecting(Interval{Lo: lo, Hi: hi}, fn)
+}
+
+// ..............................................
+
+//
+// IntervalJoin(a, b, pairFn) calls pairFn(x, y) for every pair of elements,
+// x stored in a and y stored in b, whose intervals overlap, e.g. to match
+// bookings against outages.  Use BVH.SelfCollide() to join a tree with itself.
+// An error returned by pairFn stops the join and is returned.
+//
+func IntervalJoin(a *BVH[Interval], b *BVH[Interval], pairFn func(x Boundable[Interval], y Boundable[Interval]) error) error {
+	return Collide(a, b, pairFn)
+}
@@ -0,0 +1,93 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestIntervalTree(t *testing.T) {
+	rng := rand.New(rand.NewSource(776))
+	tree := NewIntervalTree()
+	intervals := make([]Interval, 0, 3000)
+	for i := 0; i < 2000; i++ {
+		lo := rng.Float64() * 1000.0
+		intervals = append(intervals, Interval{Lo: lo, Hi: lo + rng.ExpFloat64()*5.0})
+	}
+	for i := 0; i < 1000; i++ {
+		// deeply nested intervals, which defeat a volume split:
+		intervals = append(intervals, Interval{Lo: 500.0 - float64(i)*0.5, Hi: 500.0 + float64(i)*0.5})
+	}
+	for _, iv := range intervals {
+		tree.Insert(iv)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if stats := tree.Stats(); stats.Depth > 8 {
+		t.Errorf("Expected a balanced tree, found depth %d", stats.Depth)
+	}
+
+	for trial := 0; trial < 50; trial++ {
+		at := rng.Float64() * 1000.0
+		found := 0
+		IntervalsAt(tree, at, func(element Boundable[Interval]) error {
+			if iv := element.GetBound(); iv.Lo > at || iv.Hi < at {
+				t.Errorf("Interval %v does not contain %g", iv, at)
+			}
+			found++
+			return nil
+		})
+		expected := 0
+		for _, iv := range intervals {
+			if iv.Overlaps(at, at) {
+				expected++
+			}
+		}
+		if found != expected {
+			t.Errorf("Expected %d intervals at %g, found %d", expected, at, found)
+		}
+
+		lo, hi := at, at+rng.Float64()*20.0
+		found = 0
+		IntervalsOverlapping(tree, lo, hi, func(Boundable[Interval]) error {
+			found++
+			return nil
+		})
+		expected = 0
+		for _, iv := range intervals {
+			if iv.Overlaps(lo, hi) {
+				expected++
+			}
+		}
+		if found != expected {
+			t.Errorf("Expected %d intervals overlapping [%g, %g], found %d", expected, lo, hi, found)
+		}
+	}
+
+	// overlap join against brute force:
+	other := NewIntervalTree()
+	outages := make([]Interval, 0, 200)
+	for i := 0; i < 200; i++ {
+		lo := rng.Float64() * 1000.0
+		outages = append(outages, Interval{Lo: lo, Hi: lo + rng.Float64()*3.0})
+		other.Insert(outages[i])
+	}
+	pairs := 0
+	IntervalJoin(tree, other, func(x Boundable[Interval], y Boundable[Interval]) error {
+		pairs++
+		return nil
+	})
+	expected := 0
+	for _, iv := range intervals {
+		for _, outage := range outages {
+			if iv.Overlaps(outage.Lo, outage.Hi) {
+				expected++
+			}
+		}
+	}
+	if pairs != expected {
+		t.Errorf("Expected %d overlapping pairs, found %d", expected, pairs)
+	}
+}
@@ -0,0 +1,76 @@
+//go:build go1.23
+
+package gobvh
+
+import (
+	"errors"
+	"iter"
+)
+
+// ==============================================
+
+//
+// BVH.Query(bound) returns an iterator over the elements whose bounds
+// intersect bound (as the boxes given by IntervalRange()).
+//
+// The search runs as the iterator is consumed, so breaking out of a range
+// loop stops it without visiting the rest of the hierarchy:
+//
+//	for element := range bvh.Query(region) {
+//		...
+//	}
+//
+// The hierarchy must not be modified while iterating.
+//
+func (bvh *BVH[BoundType]) Query(bound BoundType) iter.Seq[Boundable[BoundType]] {
+	return func(yield func(Boundable[BoundType]) bool) {
+		if bvh.recorder != nil {
+			bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryIterate, Bound: bound})
+		}
+		bvh.findAllIntersecting(bound, func(element Boundable[BoundType]) error {
+			if !yield(element) {
+				return errStopIteration
+			}
+			return nil
+		})
+	}
+}
+
+// ..............................................
+
+//
+// BVH.All() returns an iterator over every element in the hierarchy.
+//
+// The hierarchy must not be modified while iterating.
+//
+func (bvh *BVH[BoundType]) All() iter.Seq[Boundable[BoundType]] {
+	return func(yield func(Boundable[BoundType]) bool) {
+		if bvh.recorder != nil {
+			bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryElements})
+		}
+		yieldElements(&bvh.root, yield)
+	}
+}
+
+// ==============================================
+
+var errStopIteration = errors.New("gobvh: iteration stopped")
+
+// ..............................................
+
+// yields the elements in the subtree rooted at node, returns false when stopped
+func yieldElements[BoundType any](node *bvhNode[BoundType], yield func(Boundable[BoundType]) bool) bool {
+	for _, child := range node.children {
+		if child == nil {
+			continue
+		}
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			if !yieldElements(value, yield) {
+				return false
+			}
+		} else if !yield(child) {
+			return false
+		}
+	}
+	return true
+}
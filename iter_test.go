@@ -0,0 +1,69 @@
+//go:build go1.23
+
+package gobvh
+
+import (
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHIterators(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	for x := 0; x < 20; x++ {
+		for y := 0; y < 20; y++ {
+			bvh.Insert(Point2D{float64(x), float64(y)})
+		}
+	}
+
+	count := 0
+	for range bvh.All() {
+		count++
+	}
+	if count != 400 {
+		t.Errorf("Expected 400 elements from All(), found %d", count)
+	}
+
+	region := AABB2D{L: Point2D{2.5, 2.5}, H: Point2D{5.5, 7.5}}
+	count = 0
+	for element := range bvh.Query(region) {
+		p := element.(Point2D)
+		if p[0] < 2.5 || p[0] > 5.5 || p[1] < 2.5 || p[1] > 7.5 {
+			t.Errorf("Query returned %v outside of the region", p)
+		}
+		count++
+	}
+	if count != 15 {
+		t.Errorf("Expected 15 elements from Query(), found %d", count)
+	}
+
+	// stopping early:
+	count = 0
+	for range bvh.Query(region) {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	if count != 3 {
+		t.Errorf("Expected to stop after 3 elements, found %d", count)
+	}
+
+	// iterations are recorded as they run, and replayed in full:
+	recorder := NewQueryRecorder[AABB2D]()
+	bvh.SetRecorder(recorder)
+	bvh.Query(region) // never run, so not recorded
+	for range bvh.All() {
+		break
+	}
+	for range bvh.Query(region) {
+	}
+	bvh.SetRecorder(nil)
+	if len(recorder.Queries) != 2 || recorder.Queries[0].Kind != QueryElements || recorder.Queries[1].Kind != QueryIterate {
+		t.Fatalf("Expected All() and Query() recorded as they ran, found %d queries", len(recorder.Queries))
+	}
+	results := recorder.Replay(bvh)
+	if results[0].Found != 400 || results[1].Found != 15 {
+		t.Errorf("Expected the iterations to replay 400 and 15 elements, found %d and %d", results[0].Found, results[1].Found)
+	}
+}
@@ -0,0 +1,80 @@
+package gobvh
+
+// ==============================================
+
+//
+// ChangeKind identifies the mutation a Change records.
+//
+type ChangeKind int
+
+const (
+	ChangeInserted ChangeKind = iota // the element was added, with Bound
+	ChangeErased                     // the element, stored with Bound, was removed
+)
+
+// ..............................................
+
+//
+// Change is one entry of the journal of a BVH, see BVH.SetJournal().
+//
+type Change[BoundType any] struct {
+	Kind    ChangeKind
+	Element Boundable[BoundType]
+	Bound   BoundType
+}
+
+// ..............................................
+
+//
+// BVH.SetJournal(enabled) starts (or stops, discarding it) a journal of
+// every insertion and erasure, for ConsumeChanges() to report.
+//
+// An element which moves (see Update()) or is replaced (see Replace())
+// appears as an erasure followed by an insertion, as do elements reinserted
+// by restructuring (see Options.MinChildren).  Replacing the contents
+// wholesale (Build(), ResetArena()) records the erasure of every previous
+// element.  RebuildFrom() records the erasure of each element it drops and
+// the insertion of each new one, after (if prev is another tree) the
+// replacement of the contents by prev's.  Refit() records nothing, as no
+// element is added or removed, though its regions are reported as dirty.
+//
+func (bvh *BVH[BoundType]) SetJournal(enabled bool) {
+	bvh.journaling = enabled
+	bvh.journal = nil
+}
+
+// ..............................................
+
+//
+// BVH.ConsumeChanges() reports what has changed since it was last called
+// (or since ClearDirty()), and starts over: dirty is the union of the
+// regions DirtyBounds() reports, changed is false (and dirty unset) if
+// nothing has changed, and changes is the journal, if one is kept (see
+// SetJournal()), oldest first.
+//
+// Renderers and replication layers can then restrict each tick's work to
+// the changed region, or replay the changes, without diffing the tree.
+//
+func (bvh *BVH[BoundType]) ConsumeChanges() (dirty BoundType, changes []Change[BoundType], changed bool) {
+	for index, bound := range bvh.dirtybounds {
+		if index == 0 {
+			dirty = bound
+		} else {
+			dirty = bvh.boundtraits.Union(dirty, bound)
+		}
+	}
+	changed = len(bvh.dirtybounds) > 0 || len(bvh.journal) > 0
+	changes = bvh.journal
+	bvh.journal = nil
+	bvh.ClearDirty()
+	return dirty, changes, changed
+}
+
+// ==============================================
+
+// adds a change to the journal, if one is kept
+func (bvh *BVH[BoundType]) logChange(kind ChangeKind, element Boundable[BoundType], bound BoundType) {
+	if bvh.journaling {
+		bvh.journal = append(bvh.journal, Change[BoundType]{Kind: kind, Element: element, Bound: bound})
+	}
+}
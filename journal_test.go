@@ -0,0 +1,113 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHJournal(t *testing.T) {
+	rng := rand.New(rand.NewSource(89))
+	boxes := randomBoxes(rng, 1200, 2.0)
+	bvh := New[AABB2D](Traits2D{}, WithMinChildren(3))
+	bvh.Build(boxes[:1000])
+	if _, changes, changed := bvh.ConsumeChanges(); !changed || changes != nil {
+		t.Errorf("Expected the build to be dirty, without a journal")
+	}
+	if _, _, changed := bvh.ConsumeChanges(); changed {
+		t.Errorf("Expected nothing to have changed since ConsumeChanges()")
+	}
+
+	// a replica follows the tree by replaying the journal:
+	replica := make(map[Boundable[AABB2D]]bool)
+	for _, element := range boxes[:1000] {
+		replica[element] = true
+	}
+	bvh.SetJournal(true)
+	for tick := 0; tick < 3; tick++ {
+		for _, element := range boxes[1000+tick*50 : 1050+tick*50] {
+			bvh.Insert(element)
+		}
+		for index := 0; index < 100; index++ {
+			bvh.Erase(boxes[rng.Intn(1000)])
+		}
+		updates := make([]ElementUpdate[AABB2D], 0, 100)
+		for _, element := range collectElements(&bvh.root, nil)[:100] {
+			box := element.(*Box2D)
+			old := box.B
+			dx := rng.Float64()*4.0 - 2.0
+			box.B = AABB2D{L: Point2D{old.L[0] + dx, old.L[1]}, H: Point2D{old.H[0] + dx, old.H[1]}}
+			updates = append(updates, ElementUpdate[AABB2D]{Element: element, OldBound: old})
+		}
+		bvh.UpdateBatch(updates)
+
+		dirty, changes, changed := bvh.ConsumeChanges()
+		if !changed || len(changes) == 0 {
+			t.Fatalf("Expected changes to be reported")
+		}
+		for _, change := range changes {
+			if !boundContainsBound(bvh.boundtraits, dirty, change.Bound) {
+				t.Errorf("Expected the dirty bound %v to contain the change at %v", dirty, change.Bound)
+			}
+			switch change.Kind {
+			case ChangeInserted:
+				if replica[change.Element] {
+					t.Errorf("Journal inserts an element already present")
+				}
+				replica[change.Element] = true
+			case ChangeErased:
+				if !replica[change.Element] {
+					t.Errorf("Journal erases an element not present")
+				}
+				delete(replica, change.Element)
+			}
+		}
+		if !sameElements(collectElements(&bvh.root, nil), replica) {
+			t.Errorf("Expected the replayed journal to match the contents")
+		}
+	}
+
+	// as does a rebuild, from the tree itself or from another:
+	replay := func(changes []Change[AABB2D]) {
+		for _, change := range changes {
+			switch change.Kind {
+			case ChangeInserted:
+				if replica[change.Element] {
+					t.Errorf("Journal inserts an element already present")
+				}
+				replica[change.Element] = true
+			case ChangeErased:
+				if !replica[change.Element] {
+					t.Errorf("Journal erases an element not present")
+				}
+				delete(replica, change.Element)
+			}
+		}
+	}
+	elements := append(collectElements(&bvh.root, nil)[200:], boxes[1150:]...)
+	bvh.RebuildFrom(bvh, elements)
+	_, changes, _ := bvh.ConsumeChanges()
+	replay(changes)
+	if !sameElements(collectElements(&bvh.root, nil), replica) {
+		t.Errorf("Expected the replayed journal to match the rebuilt contents")
+	}
+	prev := New[AABB2D](Traits2D{})
+	prev.Build(boxes[:600])
+	bvh.RebuildFrom(prev, boxes[300:700])
+	_, changes, _ = bvh.ConsumeChanges()
+	replay(changes)
+	if !sameElements(collectElements(&bvh.root, nil), replica) {
+		t.Errorf("Expected the replayed journal to match the contents rebuilt from another tree")
+	}
+
+	bvh.ResetArena()
+	if _, changes, _ := bvh.ConsumeChanges(); len(changes) != len(replica) {
+		t.Errorf("Expected ResetArena() to journal %d erasures, found %d", len(replica), len(changes))
+	}
+	bvh.SetJournal(false)
+	bvh.Insert(boxes[0])
+	if _, changes, changed := bvh.ConsumeChanges(); !changed || changes != nil {
+		t.Errorf("Expected no journal once disabled")
+	}
+}
@@ -0,0 +1,174 @@
+package gobvh
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// ==============================================
+
+//
+// KNearest is a Searcher which finds the k elements nearest to a target.
+//
+// Create one with NewKNearest(), pass it to FindNearest() (with the target
+// as the starting location), then read Results() and Distances().
+// Element distances are measured between the target and the element bounds.
+//
+// Internally a bounded max-heap holds the best k elements found so far;
+// once it is full, subtrees further away than the worst of them are pruned.
+// Call Reset() before reusing a KNearest for another search.
+//
+// If MaxRadius is positive, elements further than MaxRadius from the target
+// are ignored and subtrees beyond it are never visited, so there may be
+// fewer than K results; see NewKNearestWithin().
+//
+type KNearest[BoundType any] struct {
+	K         int
+	Target    BoundType
+	Distance  DistanceFunc[BoundType]
+	MaxRadius float64
+
+	found knnHeap[BoundType]
+}
+
+// ..............................................
+
+//
+// NewKNearest(k, target, distance) creates a searcher for the k elements
+// nearest to target; it finds nothing if k < 1.
+//
+func NewKNearest[BoundType any](k int, target BoundType, distance DistanceFunc[BoundType]) *KNearest[BoundType] {
+	return &KNearest[BoundType]{
+		K:        k,
+		Target:   target,
+		Distance: distance,
+		found:    make(knnHeap[BoundType], 0, maxInt(k, 0)),
+	}
+}
+
+// ..............................................
+
+//
+// NewKNearestWithin(k, target, radius, distance) creates a searcher for the
+// k elements nearest to target, within radius of it.
+//
+func NewKNearestWithin[BoundType any](k int, target BoundType, radius float64, distance DistanceFunc[BoundType]) *KNearest[BoundType] {
+	knn := NewKNearest(k, target, distance)
+	knn.MaxRadius = radius
+	return knn
+}
+
+// ..............................................
+
+//
+// KNearest.Reset() forgets the results of the previous search.
+//
+func (knn *KNearest[BoundType]) Reset() {
+	knn.found = knn.found[:0]
+}
+
+// ..............................................
+
+// makes KNearest a Searcher:
+func (knn *KNearest[BoundType]) DoesIntersect(bound BoundType) bool {
+	if knn.K < 1 {
+		return false
+	}
+	if len(knn.found) < knn.K {
+		return knn.MaxRadius <= 0.0 || knn.Distance(knn.Target, bound) <= knn.MaxRadius
+	}
+	return knn.Distance(knn.Target, bound) < knn.found[0].distance
+}
+
+// makes KNearest a Searcher:
+func (knn *KNearest[BoundType]) Evaluate(element Boundable[BoundType]) error {
+	if knn.K < 1 {
+		return nil
+	}
+	dist := knn.Distance(knn.Target, element.GetBound())
+	if knn.MaxRadius > 0.0 && dist > knn.MaxRadius {
+		return nil
+	}
+	if len(knn.found) < knn.K {
+		heap.Push(&knn.found, knnEntry[BoundType]{element: element, distance: dist})
+	} else if dist < knn.found[0].distance {
+		knn.found[0] = knnEntry[BoundType]{element: element, distance: dist}
+		heap.Fix(&knn.found, 0)
+	}
+	return nil
+}
+
+// ..............................................
+
+//
+// KNearest.Results() returns the elements found, nearest first.
+// There are fewer than K results when the hierarchy holds fewer than K elements
+// (or, with MaxRadius, fewer than K within the radius).
+//
+func (knn *KNearest[BoundType]) Results() []Boundable[BoundType] {
+	sorted := knn.sorted()
+	results := make([]Boundable[BoundType], len(sorted))
+	for index, entry := range sorted {
+		results[index] = entry.element
+	}
+	return results
+}
+
+// ..............................................
+
+//
+// KNearest.Distances() returns the distances of the elements found,
+// in the same order as Results().
+//
+func (knn *KNearest[BoundType]) Distances() []float64 {
+	sorted := knn.sorted()
+	distances := make([]float64, len(sorted))
+	for index, entry := range sorted {
+		distances[index] = entry.distance
+	}
+	return distances
+}
+
+// ..............................................
+
+func (knn *KNearest[BoundType]) sorted() []knnEntry[BoundType] {
+	sorted := make([]knnEntry[BoundType], len(knn.found))
+	copy(sorted, knn.found)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].distance < sorted[j].distance
+	})
+	return sorted
+}
+
+// ==============================================
+
+type knnEntry[BoundType any] struct {
+	element  Boundable[BoundType]
+	distance float64
+}
+
+// max-heap on distance, implements heap.Interface
+type knnHeap[BoundType any] []knnEntry[BoundType]
+
+func (h knnHeap[BoundType]) Len() int {
+	return len(h)
+}
+
+func (h knnHeap[BoundType]) Less(i, j int) bool {
+	return h[i].distance > h[j].distance
+}
+
+func (h knnHeap[BoundType]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *knnHeap[BoundType]) Push(x any) {
+	*h = append(*h, x.(knnEntry[BoundType]))
+}
+
+func (h *knnHeap[BoundType]) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
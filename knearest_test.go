@@ -0,0 +1,127 @@
+package gobvh
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHKNearest(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	points := make([]Point2D, 0, 1024)
+	var x, y float64
+	for x = 0.0; x < 32.0; x += 1.0 {
+		for y = 0.0; y < 32.0; y += 1.0 {
+			points = append(points, Point2D{x, y})
+			bvh.Insert(Point2D{x, y})
+		}
+	}
+
+	target := Point2D{10.3, 20.6}
+	knn := NewKNearest(5, target.GetBound(), distanceBoxBox2D)
+	for pass := 0; pass < 2; pass++ {
+		knn.Reset()
+		err := bvh.FindNearest(knn, target.GetBound())
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+
+		// brute force:
+		sort.SliceStable(points, func(i, j int) bool {
+			return distance2D(points[i], target) < distance2D(points[j], target)
+		})
+		results := knn.Results()
+		distances := knn.Distances()
+		if len(results) != 5 || len(distances) != 5 {
+			t.Fatalf("Expected 5 results, found %d", len(results))
+		}
+		for index := range results {
+			if results[index].(Point2D) != points[index] {
+				t.Errorf("Result %d: expected %v but found %v", index, points[index], results[index])
+			}
+			if distances[index] != distance2D(points[index], target) {
+				t.Errorf("Result %d: expected distance %f but found %f", index, distance2D(points[index], target), distances[index])
+			}
+		}
+	}
+
+	// fewer elements than k:
+	small := New[AABB2D](Traits2D{})
+	small.Insert(Point2D{1.0, 1.0})
+	small.Insert(Point2D{2.0, 2.0})
+	knn = NewKNearest(5, target.GetBound(), distanceBoxBox2D)
+	small.FindNearest(knn, target.GetBound())
+	if len(knn.Results()) != 2 {
+		t.Errorf("Expected 2 results from a tree of 2 elements, found %d", len(knn.Results()))
+	}
+
+	// no elements for k < 1:
+	for _, k := range []int{0, -3} {
+		knn = NewKNearestWithin(k, target.GetBound(), 10.0, distanceBoxBox2D)
+		small.FindNearest(knn, target.GetBound())
+		if len(knn.Results()) != 0 {
+			t.Errorf("Expected no results for k = %d, found %d", k, len(knn.Results()))
+		}
+	}
+}
+
+// ........................................................
+
+// counts the elements evaluated by a k-nearest search
+type CountingKNN struct {
+	*KNearest[AABB2D]
+	Evaluated int
+}
+
+func (cknn *CountingKNN) Evaluate(element Boundable[AABB2D]) error {
+	cknn.Evaluated++
+	return cknn.KNearest.Evaluate(element)
+}
+
+func TestBVHKNearestWithin(t *testing.T) {
+	rng := rand.New(rand.NewSource(75))
+	bvh := New[AABB2D](Traits2D{})
+	points := make([]Point2D, 5000)
+	for i := range points {
+		points[i] = Point2D{rng.Float64() * 100.0, rng.Float64() * 100.0}
+		bvh.Insert(points[i])
+	}
+
+	for _, radius := range []float64{0.5, 2.0, 5.0} {
+		target := Point2D{rng.Float64() * 100.0, rng.Float64() * 100.0}
+		within := make([]Point2D, 0, 64)
+		for _, p := range points {
+			if distance2D(p, target) <= radius {
+				within = append(within, p)
+			}
+		}
+		sort.SliceStable(within, func(i, j int) bool {
+			return distance2D(within[i], target) < distance2D(within[j], target)
+		})
+
+		limited := &CountingKNN{KNearest: NewKNearestWithin(8, target.GetBound(), radius, distanceBoxBox2D)}
+		bvh.FindNearest(limited, target.GetBound())
+		results := limited.Results()
+		expected := len(within)
+		if expected > 8 {
+			expected = 8
+		}
+		if len(results) != expected {
+			t.Fatalf("Expected %d results within %v, found %d", expected, radius, len(results))
+		}
+		for index := range results {
+			if results[index].(Point2D) != within[index] {
+				t.Errorf("Result %d: expected %v but found %v", index, within[index], results[index])
+			}
+		}
+
+		// the radius prunes the search, even before k elements are found:
+		unlimited := &CountingKNN{KNearest: NewKNearest(8, target.GetBound(), distanceBoxBox2D)}
+		bvh.FindNearest(unlimited, target.GetBound())
+		if limited.Evaluated > unlimited.Evaluated {
+			t.Errorf("Expected the radius to evaluate no more elements, found %d > %d", limited.Evaluated, unlimited.Evaluated)
+		}
+	}
+}
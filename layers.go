@@ -0,0 +1,98 @@
+package gobvh
+
+// ==============================================
+
+//
+// BVH.InsertLayered(element, layers) puts a Boundable object into the data
+// structure, in the given collision layers (a mask, one bit per layer).
+//
+// Each node of the hierarchy keeps the union of the layers stored beneath
+// it, so searches given the WithLayerMask() option skip whole subtrees
+// holding nothing in the requested layers.  Elements inserted otherwise are
+// in every layer (mask ^uint64(0)).
+//
+func (bvh *BVH[BoundType]) InsertLayered(element Boundable[BoundType], layers uint64) {
+	bvh.setLayers(element, layers)
+	bvh.Insert(element)
+}
+
+// ..............................................
+
+//
+// BVH.SetLayers(element, layers) changes the collision layers of an element,
+// which may already be stored; the layers of the nodes above it are
+// updated.  The setting is forgotten when the element is erased.
+//
+func (bvh *BVH[BoundType]) SetLayers(element Boundable[BoundType], layers uint64) {
+	if bvh.Layers(element) == layers {
+		return
+	}
+	bvh.setLayers(element, layers)
+	container := bvh.findContainer(&bvh.root, element, element.GetBound())
+	if container != nil {
+		container = bvh.own(container)
+	}
+	for node := container; node != nil; node = node.parent {
+		previous := node.layers
+		node.layers = 0
+		for _, child := range node.children {
+			node.layers |= bvh.elementLayers(child)
+		}
+		if node.layers == previous && node != container {
+			break
+		}
+	}
+}
+
+// ..............................................
+
+//
+// BVH.Layers(element) returns the collision layers of the element, see
+// InsertLayered().
+//
+func (bvh *BVH[BoundType]) Layers(element Boundable[BoundType]) uint64 {
+	if info, ok := bvh.info[element]; ok {
+		return ^info.nolayers
+	}
+	return ^uint64(0)
+}
+
+// ..............................................
+
+//
+// BVH.WithLayerMask(mask) is a QueryOption which only visits the elements in
+// at least one of the layers of mask, e.g. the layers a body collides with.
+// Subtrees with no element in those layers are not visited.
+//
+func (bvh *BVH[BoundType]) WithLayerMask(mask uint64) QueryOption[BoundType] {
+	return func(trav *traversal[BoundType]) {
+		withNodeFilter(func(node *bvhNode[BoundType]) bool {
+			return node.layers&mask != 0
+		})(trav)
+		WithElementFilter(func(element Boundable[BoundType]) bool {
+			return bvh.Layers(element)&mask != 0
+		})(trav)
+	}
+}
+
+// ==============================================
+
+func (bvh *BVH[BoundType]) setLayers(element Boundable[BoundType], layers uint64) {
+	if layers == ^uint64(0) {
+		if info, ok := bvh.info[element]; ok {
+			info.nolayers = 0
+		}
+		return
+	}
+	bvh.infoFor(element).nolayers = ^layers
+}
+
+// ..............................................
+
+// collision layers contributed by a child of a node
+func (bvh *BVH[BoundType]) elementLayers(child Boundable[BoundType]) uint64 {
+	if node, ok := child.(*bvhNode[BoundType]); ok {
+		return node.layers
+	}
+	return bvh.Layers(child)
+}
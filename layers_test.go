@@ -0,0 +1,100 @@
+package gobvh
+
+import (
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHLayers(t *testing.T) {
+	const (
+		terrain uint64 = 1 << 0
+		players uint64 = 1 << 1
+		bullets uint64 = 1 << 2
+	)
+	bvh := New[AABB2D](Traits2D{})
+	var x, y float64
+	for x = 0.0; x < 32.0; x += 1.0 {
+		for y = 0.0; y < 32.0; y += 1.0 {
+			switch {
+			case x < 4.0 && y < 4.0:
+				bvh.InsertLayered(Point2D{x, y}, players)
+			case y >= 16.0:
+				bvh.InsertLayered(Point2D{x, y}, terrain|bullets)
+			default:
+				bvh.InsertLayered(Point2D{x, y}, terrain)
+			}
+		}
+	}
+	bvh.Insert(Point2D{40.0, 40.0}) // in every layer
+	if err := bvh.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	everywhere := AABB2D{Point2D{-1.0, -1.0}, Point2D{50.0, 50.0}}
+	count := func(mask uint64) int {
+		collector := BoxCollector{Box: everywhere}
+		bvh.FindAll(&collector, bvh.WithLayerMask(mask))
+		for _, element := range collector.Found {
+			if bvh.Layers(element)&mask == 0 {
+				t.Errorf("Found %v outside layers %x", element, mask)
+			}
+		}
+		return len(collector.Found)
+	}
+	if n := count(players); n != 17 {
+		t.Errorf("Expected 17 elements in the players layer, found %d", n)
+	}
+	if n := count(bullets); n != 32*16+1 {
+		t.Errorf("Expected %d elements in the bullets layer, found %d", 32*16+1, n)
+	}
+	if n := count(players | bullets); n != 16+32*16+1 {
+		t.Errorf("Expected %d elements in either layer, found %d", 16+32*16+1, n)
+	}
+	if n := count(1 << 5); n != 1 {
+		t.Errorf("Expected only the unlayered element in an unused layer, found %d", n)
+	}
+
+	// subtrees without the layer are pruned:
+	visited := 0
+	trav := traversal[AABB2D]{nodefilter: func(node *bvhNode[AABB2D]) bool {
+		if node.layers&players != 0 {
+			visited++
+			return true
+		}
+		return false
+	}}
+	findDown[AABB2D](&RecordOrder{}, &bvh.root, nil, &trav)
+	if visited == 0 || visited > 12 {
+		t.Errorf("Expected the players layer to prune most nodes, visited %d", visited)
+	}
+
+	// changing the layers of a stored element updates its ancestors:
+	bvh.SetLayers(Point2D{20.0, 20.0}, players)
+	if n := count(players); n != 18 {
+		t.Errorf("Expected 18 elements in the players layer, found %d", n)
+	}
+	bvh.SetLayers(Point2D{20.0, 20.0}, terrain)
+	if n := count(players); n != 17 {
+		t.Errorf("Expected 17 elements in the players layer, found %d", n)
+	}
+	if err := bvh.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	// layers combine with other filters, and are forgotten on erasure:
+	collector := BoxCollector{Box: AABB2D{Point2D{0.0, 0.0}, Point2D{1.0, 1.0}}}
+	bvh.FindAll(&collector, bvh.WithLayerMask(players), WithElementFilter(func(element Boundable[AABB2D]) bool {
+		return element != Point2D{0.0, 0.0}
+	}))
+	if len(collector.Found) != 3 {
+		t.Errorf("Expected 3 filtered elements, found %d", len(collector.Found))
+	}
+	bvh.Erase(Point2D{0.0, 0.0})
+	if bvh.Layers(Point2D{0.0, 0.0}) != ^uint64(0) {
+		t.Errorf("Layers survived erasure")
+	}
+	if err := bvh.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,138 @@
+package gobvh
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// ==============================================
+
+//
+// BVH.BuildLBVH(elements) replaces the contents of the bvh with elements,
+// as a linear BVH: the elements are ordered by the Morton code of their
+// centroids (see CentroidTraits), quantized relative to the bound of all of
+// them, and the hierarchy follows the bits of the codes.
+//
+// The codes are sorted by radix sort, and each node divides its run of
+// elements where the highest bit differing across the run changes, so the
+// build takes O(n) beyond computing the codes; it is the fastest way to
+// load a huge set of points.  The tree is looser than one from Build(),
+// since the divisions follow a fixed grid rather than the data.
+//
+// As ResetArena(), the nodes of the previous contents are recycled, and
+// tags and other per-element annotations are forgotten.  The order of
+// elements is not changed.
+//
+func (bvh *BVH[BoundType]) BuildLBVH(elements []Boundable[BoundType]) {
+	bvh.ResetArena()
+	if len(elements) == 0 {
+		return
+	}
+
+	frame := unionOf(bvh.boundtraits, elements, -1)
+	codes := make([]uint64, len(elements))
+	for index, element := range elements {
+		codes[index] = mortonCode(bvh.boundtraits, element.GetBound(), frame)
+	}
+	sorted, codes := radixSortCodes(elements, codes)
+
+	bvh.buildMortonNode(&bvh.root, sorted, codes)
+	bvh.built(elements)
+}
+
+// ==============================================
+
+// sorts elements by code (stably, least significant byte first), returning
+// new slices; bytes which are the same in every code are skipped
+func radixSortCodes[BoundType any](elements []Boundable[BoundType], codes []uint64) ([]Boundable[BoundType], []uint64) {
+	var and, or uint64 = ^uint64(0), 0
+	for _, code := range codes {
+		and &= code
+		or |= code
+	}
+	varying := and ^ or
+
+	src, dst := append([]Boundable[BoundType](nil), elements...), make([]Boundable[BoundType], len(elements))
+	srccodes, dstcodes := append([]uint64(nil), codes...), make([]uint64, len(codes))
+	for shift := uint(0); shift < 64; shift += 8 {
+		if (varying>>shift)&0xff == 0 {
+			continue
+		}
+		var offsets [256]int
+		for _, code := range srccodes {
+			offsets[(code>>shift)&0xff]++
+		}
+		total := 0
+		for digit, count := range offsets {
+			offsets[digit] = total
+			total += count
+		}
+		for index, code := range srccodes {
+			digit := (code >> shift) & 0xff
+			dst[offsets[digit]] = src[index]
+			dstcodes[offsets[digit]] = code
+			offsets[digit]++
+		}
+		src, dst = dst, src
+		srccodes, dstcodes = dstcodes, srccodes
+	}
+	return src, srccodes
+}
+
+// ..............................................
+
+// fills node with elements, sorted by their Morton codes, as buildNode()
+// does but dividing runs at their highest differing bit
+func (bvh *BVH[BoundType]) buildMortonNode(node *bvhNode[BoundType], elements []Boundable[BoundType], codes []uint64) {
+	// divide the largest run until there are enough for the fanout:
+	fanout := bvh.options.MaxChildren - 1
+	starts := append(make([]int, 0, fanout+1), 0, len(elements))
+	for len(starts)-1 < fanout {
+		largest := 0
+		for run := 1; run < len(starts)-1; run++ {
+			if starts[run+1]-starts[run] > starts[largest+1]-starts[largest] {
+				largest = run
+			}
+		}
+		lo, hi := starts[largest], starts[largest+1]
+		if hi-lo <= fanout {
+			break
+		}
+		split := lo + mortonSplit(codes[lo:hi])
+		starts = append(starts, 0)
+		copy(starts[largest+2:], starts[largest+1:])
+		starts[largest+1] = split
+	}
+
+	runs := len(starts) - 1
+	for run := 0; run < runs; run++ {
+		lo, hi := starts[run], starts[run+1]
+		if hi-lo == 1 || runs == 1 {
+			for _, element := range elements[lo:hi] {
+				node.children = bvh.appendChild(node.children, element)
+			}
+			continue
+		}
+		child := bvh.arena.alloc()
+		child.parent = node
+		child.level = node.level + 1
+		bvh.buildMortonNode(child, elements[lo:hi], codes[lo:hi])
+		node.children = bvh.appendChild(node.children, child)
+	}
+	bvh.recalculateBounds(node)
+}
+
+// ..............................................
+
+// index of the first of the (sorted) codes with the highest bit differing
+// across them set, or the middle if the codes are all the same
+func mortonSplit(codes []uint64) int {
+	first, last := codes[0], codes[len(codes)-1]
+	if first == last {
+		return len(codes) / 2
+	}
+	bit := 63 - uint(bits.LeadingZeros64(first^last))
+	return sort.Search(len(codes), func(index int) bool {
+		return (codes[index]>>bit)&1 == 1
+	})
+}
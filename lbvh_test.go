@@ -0,0 +1,121 @@
+package gobvh
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// ========================================================
+
+// lowerCornerTraits represents each bound by its lower corner, see CentroidTraits
+type lowerCornerTraits struct {
+	Traits2D
+}
+
+func (lowerCornerTraits) Centroid(bound AABB2D) []float64 {
+	return []float64{bound.L[0], bound.L[1]}
+}
+
+// ========================================================
+
+func TestBVHBuildLBVH(t *testing.T) {
+	rng := rand.New(rand.NewSource(787))
+	boxes := randomBoxes(rng, 5000, 2.0)
+	elements := make([]Boundable[AABB2D], len(boxes))
+	inserted := New[AABB2D](Traits2D{})
+	for i, element := range boxes {
+		elements[i] = element
+		inserted.Insert(element)
+	}
+	for i := 0; i < 200; i++ { // duplicates share a Morton code
+		elements = append(elements, Point2D{25.0, 25.0})
+		inserted.Insert(Point2D{25.0, 25.0})
+	}
+
+	bvh := New[AABB2D](Traits2D{})
+	bvh.Insert(Point2D{500.0, 500.0}) // replaced by BuildLBVH()
+	bvh.BuildLBVH(elements)
+	if err := bvh.Validate(); err != nil {
+		t.Errorf(err.Error())
+	}
+	if bvh.Len() != len(elements) || bvh.ContentHash() != inserted.ContentHash() {
+		t.Errorf("Expected %d elements after BuildLBVH(), found %d", len(elements), bvh.Len())
+	}
+	if elements[0] != boxes[0] {
+		t.Errorf("Expected BuildLBVH() to leave the order of elements unchanged")
+	}
+
+	for i := 0; i < 50; i++ {
+		region := randomRegion(rng, 10.0)
+		found := make([]Boundable[AABB2D], 0, 16)
+		bvh.FindAllIntersecting(region, func(element Boundable[AABB2D]) error {
+			found = append(found, element)
+			return nil
+		})
+		if len(found) != len(intersecting(inserted, region)) {
+			t.Errorf("Search of the linear BVH disagrees with the inserted tree in %v", region)
+		}
+	}
+	if bvh.SAHCost() >= inserted.SAHCost() {
+		t.Errorf("Expected a linear BVH to cost less than an inserted tree, found %g and %g", bvh.SAHCost(), inserted.SAHCost())
+	}
+
+	bvh.BuildLBVH(nil)
+	if bvh.Len() != 0 || len(bvh.root.children) != 0 {
+		t.Errorf("Expected BuildLBVH(nil) to empty the tree")
+	}
+}
+
+// ........................................................
+
+func TestRadixSortCodes(t *testing.T) {
+	rng := rand.New(rand.NewSource(7870))
+	elements := make([]Boundable[AABB2D], 1000)
+	codes := make([]uint64, len(elements))
+	for i := range elements {
+		elements[i] = Point2D{float64(i), 0.0}
+		codes[i] = uint64(rng.Intn(50)) << uint(8*rng.Intn(8))
+	}
+
+	sorted, sortedcodes := radixSortCodes(elements, codes)
+	expected := append([]Boundable[AABB2D](nil), elements...)
+	expectedcodes := append([]uint64(nil), codes...)
+	sort.Stable(&mortonSorter[AABB2D]{elements: expected, codes: expectedcodes})
+	for i := range sorted {
+		if sorted[i] != expected[i] || sortedcodes[i] != expectedcodes[i] {
+			t.Fatalf("Radix sort disagrees with a stable sort at %d", i)
+		}
+	}
+	if elements[0] != (Point2D{0.0, 0.0}) {
+		t.Errorf("Expected the radix sort to leave its input unchanged")
+	}
+}
+
+// ........................................................
+
+func TestCentroidTraits(t *testing.T) {
+	bvh := New[AABB2D](lowerCornerTraits{})
+	wide := &Box2D{B: AABB2D{L: Point2D{0.0, 0.0}, H: Point2D{4.0, 4.0}}}
+	bvh.Insert(Point2D{1.0, 1.0})
+	bvh.Insert(wide)
+
+	// by its midpoint, wide would come after (1 1):
+	if elements := bvh.ElementsMortonOrder(); elements[0] != wide {
+		t.Errorf("Expected the centroid from CentroidTraits to order %v first", wide.GetBound())
+	}
+}
+
+// ........................................................
+
+func BenchmarkBuildLBVH(b *testing.B) {
+	rng := rand.New(rand.NewSource(787))
+	elements := make([]Boundable[AABB2D], 100000)
+	for i := range elements {
+		elements[i] = Point2D{rng.Float64() * 100.0, rng.Float64() * 100.0}
+	}
+	bvh := New[AABB2D](Traits2D{})
+	for i := 0; i < b.N; i++ {
+		bvh.BuildLBVH(elements)
+	}
+}
@@ -0,0 +1,52 @@
+package gobvh
+
+// ==============================================
+
+//
+// ExpandTraits is an optional extension of BoundTraits for fattened element
+// bounds, see Options.Margin.
+//
+// Expand(bound, margin) returns bound widened by margin on both sides along
+// every dimension.
+//
+type ExpandTraits[BoundType any] interface {
+	Expand(bound BoundType, margin float64) BoundType
+}
+
+// ..............................................
+
+//
+// BVH.UpdateIfNeeded(element, oldbound) is Update() for elements which move
+// a little at a time: if the element's new bound (element.GetBound()) is
+// still inside the bound of the leaf holding it, the hierarchy is left
+// alone, and only the element's accounting (ContentHash(), Version() and
+// the dirty regions) changes.  Otherwise the element is moved as Update()
+// moves it.
+//
+// With Options.Margin set, each leaf is fattened around its elements, so
+// most small movements cost one search and no restructuring at all.
+// It reports whether the element had to be moved; it returns false, and does
+// nothing, if the element was not found.
+//
+func (bvh *BVH[BoundType]) UpdateIfNeeded(element Boundable[BoundType], oldbound BoundType) bool {
+	container := bvh.findContainer(&bvh.root, element, oldbound)
+	if container == nil {
+		return false
+	}
+	bound := element.GetBound()
+	if boundContainsBound(bvh.boundtraits, container.bound, bound) {
+		bvh.movedInPlace(container, element, oldbound, bound)
+		return false
+	}
+	return bvh.Update(element, oldbound)
+}
+
+// ==============================================
+
+// fattens an element bound by the margin, if the options and traits call for it
+func (bvh *BVH[BoundType]) fatten(bound BoundType) BoundType {
+	if bvh.fattraits == nil {
+		return bound
+	}
+	return bvh.fattraits.Expand(bound, bvh.options.Margin)
+}
@@ -0,0 +1,93 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+// ExpandTraits2D adds ExpandTraits to Traits2D:
+type ExpandTraits2D struct {
+	Traits2D
+}
+
+func (et ExpandTraits2D) Expand(bound AABB2D, margin float64) AABB2D {
+	return AABB2D{
+		L: Point2D{bound.L[0] - margin, bound.L[1] - margin},
+		H: Point2D{bound.H[0] + margin, bound.H[1] + margin},
+	}
+}
+
+// ........................................................
+
+func TestBVHUpdateIfNeeded(t *testing.T) {
+	rng := rand.New(rand.NewSource(43))
+	boxes := randomBoxes(rng, 1000, 2.0)
+	bvh := New[AABB2D](ExpandTraits2D{}, WithMargin(1.0))
+	for _, element := range boxes {
+		bvh.Insert(element)
+	}
+	if err := bvh.Validate(); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+	for _, element := range boxes {
+		container := bvh.findContainer(&bvh.root, element, element.GetBound())
+		if !boundContainsBound(bvh.boundtraits, container.bound, ExpandTraits2D{}.Expand(element.GetBound(), 1.0)) {
+			t.Fatalf("Expected leaf bound %v to contain the fattened element bound", container.bound)
+		}
+	}
+
+	// small steps mostly stay put, large ones move:
+	moves := 0
+	for tick := 0; tick < 10; tick++ {
+		step := 0.2
+		if tick == 9 {
+			step = 50.0
+		}
+		for _, element := range boxes {
+			box := element.(*Box2D)
+			old := box.B
+			dx, dy := (rng.Float64()*2.0-1.0)*step, (rng.Float64()*2.0-1.0)*step
+			box.B = AABB2D{L: Point2D{old.L[0] + dx, old.L[1] + dy}, H: Point2D{old.H[0] + dx, old.H[1] + dy}}
+			if bvh.UpdateIfNeeded(element, old) {
+				moves++
+			}
+		}
+		if err := bvh.Validate(); err != nil {
+			t.Fatalf("Validate() failed: %v", err)
+		}
+	}
+	if moves == 0 || moves >= 5*len(boxes) {
+		t.Errorf("Expected a few elements to move, found %d moves", moves)
+	}
+	for _, element := range boxes {
+		if !bvh.Contains(element) {
+			t.Errorf("Updated element not found at its new bound")
+		}
+	}
+	if bvh.Len() != len(boxes) {
+		t.Errorf("Expected %d elements, found %d", len(boxes), bvh.Len())
+	}
+
+	fresh := New[AABB2D](Traits2D{})
+	for _, element := range boxes {
+		fresh.Insert(element)
+	}
+	if bvh.ContentHash() != fresh.ContentHash() {
+		t.Errorf("ContentHash() does not match that of the same elements inserted")
+	}
+
+	// not stored:
+	stranger := &Box2D{AABB2D{L: Point2D{1, 1}, H: Point2D{2, 2}}}
+	if bvh.UpdateIfNeeded(stranger, stranger.B) {
+		t.Errorf("Expected UpdateIfNeeded() of an element not in the tree to do nothing")
+	}
+
+	// without ExpandTraits the margin is ignored:
+	plain := New[AABB2D](Traits2D{}, WithMargin(1.0))
+	plain.Insert(boxes[0])
+	if !boundsEqual(plain.boundtraits, plain.root.bound, boxes[0].GetBound()) {
+		t.Errorf("Expected the margin to be ignored without ExpandTraits")
+	}
+}
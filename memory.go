@@ -0,0 +1,58 @@
+package gobvh
+
+import (
+	"unsafe"
+)
+
+// ==============================================
+
+//
+// Footprint reports the memory held by the structure of a BVH, see BVH.MemoryFootprint().
+//
+// ChildSlots is the total capacity of the children slices of all nodes, of
+// which UnusedSlots are spare capacity left by InitialCapacity and GrowthFactor
+// (see Options).  ArenaNodes is the number of nodes the arena has allocated
+// room for (see ResetArena()), at least Nodes-1 (the root is not in the arena).
+// Bytes estimates the memory of the arena and the children slices in use;
+// it does not include the elements themselves, or their tags.
+//
+type Footprint struct {
+	Nodes       int
+	Elements    int
+	ChildSlots  int
+	UnusedSlots int
+	ArenaNodes  int
+	Bytes       int
+}
+
+// ..............................................
+
+//
+// BVH.MemoryFootprint() measures the memory held by the structure of the bvh.
+//
+func (bvh *BVH[BoundType]) MemoryFootprint() Footprint {
+	var footprint Footprint
+	measureNode(&bvh.root, &footprint)
+
+	var child Boundable[BoundType]
+	nodesize := int(unsafe.Sizeof(bvh.root))
+	slotsize := int(unsafe.Sizeof(child))
+	footprint.ArenaNodes = bvh.arena.capacity()
+	footprint.Bytes = (1+footprint.ArenaNodes)*nodesize + footprint.ChildSlots*slotsize
+	return footprint
+}
+
+// ..............................................
+
+func measureNode[BoundType any](node *bvhNode[BoundType], footprint *Footprint) {
+	footprint.Nodes++
+	footprint.ChildSlots += cap(node.children)
+	footprint.UnusedSlots += cap(node.children) - len(node.children)
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			measureNode(value, footprint)
+		} else if child != nil {
+			footprint.Elements++
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHMemoryFootprint(t *testing.T) {
+	tight := DefaultOptions()
+	tight.InitialCapacity = 1
+	tight.GrowthFactor = 1.0
+	loose := DefaultOptions()
+	loose.InitialCapacity = 16
+	loose.GrowthFactor = 3.0
+
+	rng := rand.New(rand.NewSource(5))
+	trees := []*BVH[AABB2D]{New[AABB2D](Traits2D{}), NewWithOptions[AABB2D](Traits2D{}, tight), NewWithOptions[AABB2D](Traits2D{}, loose)}
+	for i := 0; i < 1000; i++ {
+		p := Point2D{rng.Float64(), rng.Float64()}
+		for _, bvh := range trees {
+			bvh.Insert(p)
+		}
+	}
+
+	footprints := make([]Footprint, len(trees))
+	for index, bvh := range trees {
+		footprints[index] = bvh.MemoryFootprint()
+		if footprints[index].Elements != 1000 {
+			t.Errorf("Expected 1000 elements in footprint, found %d", footprints[index].Elements)
+		}
+		if footprints[index].Nodes < 2 || footprints[index].Bytes <= 0 {
+			t.Errorf("Implausible footprint %+v", footprints[index])
+		}
+	}
+
+	if footprints[1].UnusedSlots >= footprints[0].UnusedSlots {
+		t.Errorf("Expected less spare capacity with tight growth, found %d vs %d", footprints[1].UnusedSlots, footprints[0].UnusedSlots)
+	}
+	if footprints[1].Bytes >= footprints[0].Bytes || footprints[2].Bytes <= footprints[0].Bytes {
+		t.Errorf("Expected tight < default < loose footprints: %d, %d, %d", footprints[1].Bytes, footprints[0].Bytes, footprints[2].Bytes)
+	}
+}
@@ -0,0 +1,98 @@
+package gobvh
+
+// ==============================================
+
+//
+// BVH.Merge(other) adds the elements of other to this bvh by grafting
+// copies of other's subtrees, rather than inserting its elements one by
+// one; other itself is not modified.
+//
+// The larger of the two trees is kept as the base, and each child of the
+// smaller tree's root is grafted whole into the node whose children are of
+// about its size, chosen as Insert() chooses a leaf.  This is far cheaper
+// than insertion when the trees cover different regions, e.g. combining the
+// per-chunk trees of a parallel build; where they overlap, the grafted
+// subtrees overlap their new siblings, and Optimize() may be worthwhile.
+//
+// Tags and other annotations of other's elements are copied.  Elements
+// should not be stored in both trees, as an element inserted twice would
+// then be stored twice.
+//
+func (bvh *BVH[BoundType]) Merge(other *BVH[BoundType]) {
+	if other == bvh || len(other.root.children) == 0 {
+		return
+	}
+
+	for element, info := range other.info {
+		bvh.setInfo(element, *info)
+	}
+	elements := collectElements(&other.root, make([]Boundable[BoundType], 0, other.count))
+
+	// the smaller tree provides the grafts, as nodes of this bvh:
+	var grafts []Boundable[BoundType]
+	if bvh.root.elements < other.root.elements {
+		for _, child := range bvh.root.children {
+			if value, ok := child.(*bvhNode[BoundType]); ok && bvh.arena.shared {
+				// (grafting changes the levels of the subtree, which a
+				// snapshot may share, see own())
+				clone := bvh.arena.alloc()
+				bvh.cloneNode(clone, value, nil)
+				child = clone
+			}
+			grafts = append(grafts, child)
+		}
+		bvh.root.children = nil // (not to be reused by the clone)
+		bvh.cloneNode(&bvh.root, &other.root, nil)
+	} else {
+		for _, child := range other.root.children {
+			if value, ok := child.(*bvhNode[BoundType]); ok {
+				clone := bvh.arena.alloc()
+				bvh.cloneNode(clone, value, nil)
+				child = clone
+			}
+			grafts = append(grafts, child)
+		}
+	}
+	for _, graft := range grafts {
+		bvh.graft(graft)
+	}
+
+	bvh.countBuilt(elements)
+	bvh.version++
+	bvh.markDirty(&bvh.root, other.root.bound)
+	bvh.evictOverCapacity()
+}
+
+// ==============================================
+
+// adds child (an element or a subtree) to the node whose children are of
+// about its size, along the path Insert() would follow, and splits the
+// node if it is full
+func (bvh *BVH[BoundType]) graft(child Boundable[BoundType]) {
+	bound := child.GetBound()
+	size := 1
+	value, isnode := child.(*bvhNode[BoundType])
+	if isnode {
+		size = value.elements
+	}
+	node := &bvh.root
+	for {
+		next := bvh.chooseNext(node, bound)
+		if next == nil || next.elements < size*bvh.options.MaxChildren/2 {
+			break
+		}
+		node = next
+	}
+
+	node = bvh.own(node)
+	node.children = bvh.appendChild(node.children, child)
+	if isnode {
+		value.parent = node
+		value.level = node.level + 1
+		assignLevels(value)
+	}
+	for ancestor := node; ancestor != nil; ancestor = ancestor.parent {
+		bvh.recalculateBounds(ancestor)
+	}
+	bvh.splitNode(node, &bvh.root)
+}
@@ -0,0 +1,73 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHMerge(t *testing.T) {
+	rng := rand.New(rand.NewSource(799))
+	combined := New[AABB2D](Traits2D{})
+	trees := make([]*BVH[AABB2D], 4)
+	for i := range trees {
+		trees[i] = New[AABB2D](Traits2D{})
+		for _, element := range randomBoxes(rng, 1000*(i+1), 1.0) {
+			box := element.(*Box2D)
+			box.B.L[0] += 100.0 * float64(i) // a chunk of its own
+			box.B.H[0] += 100.0 * float64(i)
+			trees[i].InsertTagged(box, Tag(i))
+			combined.Insert(box)
+		}
+	}
+
+	bvh := trees[1]
+	hash := trees[2].ContentHash()
+	for _, other := range []*BVH[AABB2D]{trees[0], trees[2], trees[3], New[AABB2D](Traits2D{})} {
+		bvh.Merge(other)
+		if err := bvh.Validate(); err != nil {
+			t.Errorf(err.Error())
+		}
+	}
+	if err := trees[2].Validate(); err != nil || trees[2].ContentHash() != hash {
+		t.Errorf("Expected Merge() to leave the other tree unchanged (%v)", err)
+	}
+	if bvh.Len() != combined.Len() || bvh.ContentHash() != combined.ContentHash() {
+		t.Errorf("Expected %d elements after merging, found %d", combined.Len(), bvh.Len())
+	}
+	for i := 0; i < 50; i++ {
+		region := randomRegion(rng, 10.0)
+		region.L[0] *= 4.0
+		region.H[0] = region.L[0] + 10.0
+		if bvh.Count(region) != combined.Count(region) {
+			t.Errorf("Search of the merged tree disagrees with the inserted tree in %v", region)
+		}
+	}
+	for i := range trees {
+		found := bvh.FindAllWithTag(Tag(i), func(AABB2D) bool { return true })
+		if len(found) != 1000*(i+1) {
+			t.Errorf("Expected Merge() to keep the tags of %d elements, found %d", 1000*(i+1), len(found))
+		}
+	}
+
+	// the merged tree remains modifiable, independently of the others:
+	trees[3].ResetArena() // recycles its nodes, which the merged tree must not share
+	for _, element := range randomBoxes(rng, 2000, 1.0) {
+		trees[3].Insert(element)
+	}
+	for _, element := range collectElements(&trees[2].root, nil)[:500] {
+		if !bvh.Erase(element) {
+			t.Errorf("Failed to erase %v from the merged tree", element.GetBound())
+		}
+	}
+	if err := bvh.Validate(); err != nil || bvh.Len() != combined.Len()-500 {
+		t.Errorf("Expected %d elements after erasing, found %d (%v)", combined.Len()-500, bvh.Len(), err)
+	}
+
+	empty := New[AABB2D](Traits2D{})
+	empty.Merge(trees[0])
+	if err := empty.Validate(); err != nil || empty.Len() != trees[0].Len() {
+		t.Errorf("Expected merging into an empty tree to copy it, found %d elements (%v)", empty.Len(), err)
+	}
+}
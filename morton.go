@@ -0,0 +1,154 @@
+package gobvh
+
+import (
+	"sort"
+)
+
+// ==============================================
+
+//
+// CentroidTraits is an optional extension of BoundTraits for the orderings
+// which place elements by a single representative point (ElementsMortonOrder()
+// and BuildLBVH()).
+//
+// Centroid(bound) reports that point, with one entry per dimension; it
+// should lie within the bound, e.g. the center of a sphere or the centroid
+// of a triangle, which may be a better representative than the middle of
+// its box.
+//
+// If your BoundTraits do not implement CentroidTraits, the midpoints of
+// IntervalRange() in each dimension are used.
+//
+type CentroidTraits[BoundType any] interface {
+	Centroid(bound BoundType) []float64
+}
+
+// ..............................................
+
+//
+// BVH.ElementsMortonOrder() returns every stored element, sorted by the
+// Morton code (Z-order) of the element's centroid.
+//
+// Centroids (see CentroidTraits) are quantized relative to the bound of
+// the entire data structure.  Elements that are close in space tend to be
+// close in the result, which is useful for writing cache-coherent files or
+// for feeding builders that expect spatially sorted input.  Ties keep their
+// traversal order.
+//
+func (bvh *BVH[BoundType]) ElementsMortonOrder() []Boundable[BoundType] {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryMortonOrder})
+	}
+	elements := collectElements(&bvh.root, make([]Boundable[BoundType], 0, 8))
+	if len(elements) < 2 {
+		return elements
+	}
+
+	codes := make([]uint64, len(elements))
+	for index, element := range elements {
+		codes[index] = mortonCode(bvh.boundtraits, element.GetBound(), bvh.root.bound)
+	}
+
+	sort.Stable(&mortonSorter[BoundType]{elements: elements, codes: codes})
+	return elements
+}
+
+// ==============================================
+
+type mortonSorter[BoundType any] struct {
+	elements []Boundable[BoundType]
+	codes    []uint64
+}
+
+func (ms *mortonSorter[BoundType]) Len() int {
+	return len(ms.elements)
+}
+
+func (ms *mortonSorter[BoundType]) Less(i, j int) bool {
+	return ms.codes[i] < ms.codes[j]
+}
+
+func (ms *mortonSorter[BoundType]) Swap(i, j int) {
+	ms.elements[i], ms.elements[j] = ms.elements[j], ms.elements[i]
+	ms.codes[i], ms.codes[j] = ms.codes[j], ms.codes[i]
+}
+
+// ..............................................
+
+// the centroid given by CentroidTraits, or else the midpoint of the bound in each dimension
+func boundCentroid[BoundType any](bounder BoundTraits[BoundType], bound BoundType) []float64 {
+	if centroidtraits, ok := bounder.(CentroidTraits[BoundType]); ok {
+		return centroidtraits.Centroid(bound)
+	}
+	dims := bounder.Dimensions(bound)
+	centroid := make([]float64, dims)
+	var i uint
+	for i = 0; i < dims; i++ {
+		lo, hi := bounder.IntervalRange(bound, i)
+		centroid[i] = 0.5 * (lo + hi)
+	}
+	return centroid
+}
+
+// ..............................................
+
+// interleaves the quantized centroid of bound (relative to frame) into a 64-bit Morton code.
+func mortonCode[BoundType any](bounder BoundTraits[BoundType], bound BoundType, frame BoundType) uint64 {
+	quantized, bits := quantizeCentroid(bounder, bound, frame)
+	return interleaveBits(quantized, bits)
+}
+
+// ..............................................
+
+// the centroid of bound, quantized relative to frame, with the number of
+// bits per dimension: the 64 bits of a code are distributed among the
+// dimensions (no more than 32 each, and one each for the first 64 if there
+// are more)
+func quantizeCentroid[BoundType any](bounder BoundTraits[BoundType], bound BoundType, frame BoundType) ([]uint64, uint) {
+	centroid := boundCentroid(bounder, bound)
+	dims := uint(len(centroid))
+	if dims == 0 {
+		return nil, 0
+	}
+
+	// distribute the 64 bits of the code among the dimensions:
+	bits := 64 / dims
+	if bits > 32 {
+		bits = 32
+	}
+	if bits == 0 {
+		bits = 1
+		dims = 64
+	}
+
+	quantized := make([]uint64, dims)
+	scale := float64(uint64(1)<<bits - 1)
+	var i uint
+	for i = 0; i < dims; i++ {
+		lo, hi := bounder.IntervalRange(frame, i)
+		var unit float64
+		if hi > lo {
+			unit = (centroid[i] - lo) / (hi - lo)
+		}
+		if !(unit > 0.0) { // also catches NaN from unbounded intervals
+			unit = 0.0
+		} else if unit > 1.0 {
+			unit = 1.0
+		}
+		quantized[i] = uint64(unit * scale)
+	}
+	return quantized, bits
+}
+
+// ..............................................
+
+// interleaves the low bits of each of the quantized coordinates, most significant first
+func interleaveBits(quantized []uint64, bits uint) uint64 {
+	var code uint64
+	for bit := int(bits) - 1; bit >= 0; bit-- {
+		for _, q := range quantized {
+			code = (code << 1) | ((q >> uint(bit)) & 1)
+		}
+	}
+	return code
+}
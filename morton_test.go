@@ -0,0 +1,36 @@
+package gobvh
+
+import (
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHMortonOrder(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	var x, y float64
+	for x = 0.0; x < 4.0; x += 1.0 {
+		for y = 0.0; y < 4.0; y += 1.0 {
+			bvh.Insert(Point2D{x, y})
+		}
+	}
+
+	elements := bvh.ElementsMortonOrder()
+	if len(elements) != 16 {
+		t.Fatalf("Expected 16 elements, found %d", len(elements))
+	}
+
+	// Z-order over a 4x4 lattice visits each 2x2 quadrant in turn:
+	for quadrant := 0; quadrant < 4; quadrant++ {
+		first := elements[quadrant*4].(Point2D)
+		for index := quadrant * 4; index < quadrant*4+4; index++ {
+			p := elements[index].(Point2D)
+			if int(p[0])/2 != int(first[0])/2 || int(p[1])/2 != int(first[1])/2 {
+				t.Errorf("Element %d (%v) is not in the same quadrant as %v", index, p, first)
+			}
+		}
+	}
+	if elements[0].(Point2D) != (Point2D{0.0, 0.0}) || elements[15].(Point2D) != (Point2D{3.0, 3.0}) {
+		t.Errorf("Expected Morton order from (0 0) to (3 3), found %v to %v", elements[0], elements[15])
+	}
+}
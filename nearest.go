@@ -0,0 +1,54 @@
+package gobvh
+
+// ==============================================
+
+//
+// BVH.FindNearestExcluding(searcher, here, skip) is FindNearest() for
+// searches that must ignore some elements.
+//
+// Elements for which skip(element) reports true are never passed to
+// searcher.Evaluate(), so they cannot shrink the region of interest of
+// the search.
+//
+func (bvh *BVH[BoundType]) FindNearestExcluding(s Searcher[BoundType], here BoundType, skip func(Boundable[BoundType]) bool) error {
+	return bvh.FindNearestWhere(s, here, func(element Boundable[BoundType]) bool {
+		return !skip(element)
+	})
+}
+
+// ..............................................
+
+//
+// BVH.FindNearestWhere(searcher, here, pred, options...) is FindNearest()
+// restricted to the elements for which pred(element) is true, e.g. to find
+// the nearest enemy that is alive without keeping a tree per category.
+//
+// Elements failing pred are never passed to searcher.Evaluate(), so they
+// do not shrink the region of interest of the search; see WithElementFilter(),
+// which does the same for any search.
+//
+func (bvh *BVH[BoundType]) FindNearestWhere(s Searcher[BoundType], here BoundType, pred func(Boundable[BoundType]) bool, opts ...QueryOption[BoundType]) error {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryNearestWhere, Bound: here, Searcher: s, Filter: pred, Options: opts})
+	}
+	trav := bvh.newTraversal(true, opts)
+	WithElementFilter(pred)(trav)
+	return bvh.findNearest(s, here, trav)
+}
+
+// ..............................................
+
+//
+// BVH.FindNearestOther(searcher, element) searches around a stored element
+// for its nearest other element.
+//
+// The search starts at the bound of the element, and the element itself is
+// never passed to searcher.Evaluate(); otherwise a nearest neighbor search
+// would usually find the element at distance 0.  This is the common case
+// of computing the neighbor of every element (e.g. flocking).
+//
+func (bvh *BVH[BoundType]) FindNearestOther(s Searcher[BoundType], element Boundable[BoundType]) error {
+	return bvh.FindNearestExcluding(s, element.GetBound(), func(candidate Boundable[BoundType]) bool {
+		return candidate == element
+	})
+}
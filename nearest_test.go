@@ -0,0 +1,87 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHFindNearestOther(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	var x, y float64
+	for x = 0.0; x < 16.0; x += 2.0 {
+		for y = 0.0; y < 16.0; y += 3.0 {
+			bvh.Insert(Point2D{x, y})
+		}
+	}
+
+	// every element's nearest other element is 2 units away along x:
+	for x = 0.0; x < 16.0; x += 2.0 {
+		for y = 0.0; y < 16.0; y += 3.0 {
+			searcher := NearestNeighbor2D{Target: Point2D{x, y}, FoundDistance: 1e38, t: t}
+			err := bvh.FindNearestOther(&searcher, Point2D{x, y})
+			if err != nil {
+				t.Errorf(err.Error())
+			}
+			if searcher.Found == nil || searcher.FoundDistance != 2.0 {
+				t.Errorf("Expected a neighbor at distance 2 from (%f %f), found %v at %f", x, y, searcher.Found, searcher.FoundDistance)
+			}
+		}
+	}
+
+	// exclude a whole column:
+	searcher := NearestNeighbor2D{Target: Point2D{4.1, 6.0}, FoundDistance: 1e38, t: t}
+	err := bvh.FindNearestExcluding(&searcher, searcher.Target.GetBound(), func(element Boundable[AABB2D]) bool {
+		return element.(Point2D)[0] == 4.0
+	})
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if found, ok := searcher.Found.(Point2D); !ok || found != (Point2D{6.0, 6.0}) {
+		t.Errorf("Expected (6 6) outside the excluded column, found %v", searcher.Found)
+	}
+}
+
+// ........................................................
+
+func TestBVHFindNearestWhere(t *testing.T) {
+	rng := rand.New(rand.NewSource(59))
+	bvh := New[AABB2D](Traits2D{})
+	alive := make(map[Boundable[AABB2D]]bool)
+	points := make([]Point2D, 1000)
+	for i := range points {
+		points[i] = Point2D{rng.Float64() * 100.0, rng.Float64() * 100.0}
+		alive[points[i]] = rng.Intn(4) == 0
+		bvh.Insert(points[i])
+	}
+	isalive := func(element Boundable[AABB2D]) bool {
+		return alive[element]
+	}
+
+	for i := 0; i < 50; i++ {
+		target := Point2D{rng.Float64() * 100.0, rng.Float64() * 100.0}
+		var expected Point2D
+		best := 1e38
+		for _, p := range points {
+			if d := distance2D(p, target); alive[p] && d < best {
+				best, expected = d, p
+			}
+		}
+
+		searcher := NearestNeighbor2D{Target: target, FoundDistance: 1e38, t: t}
+		bvh.FindNearestWhere(&searcher, target.GetBound(), isalive)
+		if found, ok := searcher.Found.(Point2D); !ok || found != expected {
+			t.Errorf("Expected %v nearest alive to %v, found %v", expected, target, searcher.Found)
+		}
+
+		// the same filter as an option, combined with another:
+		searcher = NearestNeighbor2D{Target: target, FoundDistance: 1e38, t: t}
+		bvh.FindNearestBestFirst(&searcher, target.GetBound(), WithElementFilter(isalive), WithElementFilter(func(element Boundable[AABB2D]) bool {
+			return element != Boundable[AABB2D](expected)
+		}))
+		if found, ok := searcher.Found.(Point2D); !ok || found == expected || !alive[found] {
+			t.Errorf("Expected another alive point than %v, found %v", expected, searcher.Found)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package gobvh
+
+// ==============================================
+
+//
+// NodeRef is a read-only view of a node of the hierarchy, for visualization,
+// level-of-detail logic and the like.  See BVH.Root() and NodeCrawler.
+//
+// A NodeRef is only valid until the hierarchy is next modified.
+// The zero NodeRef is not valid, see NodeRef.Valid().
+//
+type NodeRef[BoundType any] struct {
+	node   *bvhNode[BoundType]
+	root   *bvhNode[BoundType]
+	frozen bool // of a snapshot, whose parent pointers are not its own, see Parent()
+}
+
+// ..............................................
+
+//
+// NodeCrawler is an optional extension of BVHCrawler, see BVH.ForEach().
+//
+type NodeCrawler[BoundType any] interface {
+	BeginNode(node NodeRef[BoundType]) error
+}
+
+// ==============================================
+
+//
+// BVH.Root() returns the root node of the hierarchy.
+//
+func (bvh *BVH[BoundType]) Root() NodeRef[BoundType] {
+	return NodeRef[BoundType]{node: &bvh.root, root: &bvh.root}
+}
+
+// ..............................................
+
+//
+// NodeRef.Valid() reports whether the NodeRef refers to a node.
+//
+func (ref NodeRef[BoundType]) Valid() bool {
+	return ref.node != nil
+}
+
+// ..............................................
+
+//
+// NodeRef.Bound() returns the bound of the node.
+//
+func (ref NodeRef[BoundType]) Bound() BoundType {
+	return ref.node.bound
+}
+
+// ..............................................
+
+//
+// NodeRef.Depth() returns the depth of the node, 0 for the root, in O(1).
+//
+// Depths are maintained as the hierarchy changes: when the root splits,
+// every node becomes one level deeper without being visited.
+//
+func (ref NodeRef[BoundType]) Depth() int {
+	return ref.node.level - ref.root.level
+}
+
+// ..............................................
+
+//
+// NodeRef.ElementCount() returns the number of elements in the subtree
+// rooted at the node, in O(1).
+//
+// Counts are maintained as the hierarchy changes, so query planners can
+// weigh a search of the subtree (e.g. against a full scan) without
+// traversing it.
+//
+func (ref NodeRef[BoundType]) ElementCount() int {
+	return ref.node.elements
+}
+
+// ..............................................
+
+//
+// NodeRef.NodeCount() returns the number of nodes in the subtree rooted at
+// the node, including the node itself, in O(1).
+//
+func (ref NodeRef[BoundType]) NodeCount() int {
+	return 1 + ref.node.descendants
+}
+
+// ..............................................
+
+//
+// NodeRef.Parent() returns the parent of the node, not valid for the root.
+//
+// The parent of a node of a snapshot is searched for from the root, as the
+// parent pointers belong to the live tree (see BVH.Snapshot()).
+//
+func (ref NodeRef[BoundType]) Parent() NodeRef[BoundType] {
+	var parent *bvhNode[BoundType]
+	if ref.frozen {
+		parent = findParent(ref.root, ref.node)
+	} else {
+		parent = ref.node.parent
+	}
+	if parent == nil {
+		return NodeRef[BoundType]{}
+	}
+	return NodeRef[BoundType]{node: parent, root: ref.root, frozen: ref.frozen}
+}
+
+// ..............................................
+
+//
+// NodeRef.Children() returns the child nodes of the node.
+//
+func (ref NodeRef[BoundType]) Children() []NodeRef[BoundType] {
+	children := make([]NodeRef[BoundType], 0, len(ref.node.children))
+	for _, child := range ref.node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			children = append(children, NodeRef[BoundType]{node: value, root: ref.root, frozen: ref.frozen})
+		}
+	}
+	return children
+}
+
+// ..............................................
+
+//
+// NodeRef.Elements() returns the elements stored directly in the node.
+//
+func (ref NodeRef[BoundType]) Elements() []Boundable[BoundType] {
+	elements := make([]Boundable[BoundType], 0, len(ref.node.children))
+	for _, child := range ref.node.children {
+		if _, ok := child.(*bvhNode[BoundType]); !ok && child != nil {
+			elements = append(elements, child)
+		}
+	}
+	return elements
+}
+
+// ..............................................
+
+// the node of the subtree rooted at node which holds child, or nil; only
+// the nodes above child's level are searched
+func findParent[BoundType any](node *bvhNode[BoundType], child *bvhNode[BoundType]) *bvhNode[BoundType] {
+	for _, other := range node.children {
+		if other == Boundable[BoundType](child) {
+			return node
+		}
+	}
+	for _, other := range node.children {
+		value, ok := other.(*bvhNode[BoundType])
+		if ok && value.level < child.level {
+			if parent := findParent(value, child); parent != nil {
+				return parent
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+// checks NodeRef.Depth() against the number of parents, returns the number of nodes
+func checkDepths(t *testing.T, ref NodeRef[AABB2D]) int {
+	walked := 0
+	for parent := ref.Parent(); parent.Valid(); parent = parent.Parent() {
+		walked++
+	}
+	if ref.Depth() != walked {
+		t.Errorf("Expected depth %d, found %d", walked, ref.Depth())
+	}
+	count := 1
+	for _, child := range ref.Children() {
+		count += checkDepths(t, child)
+	}
+	return count
+}
+
+// a crawler recording the depths of the nodes holding elements
+type DepthCrawler struct {
+	CheckBound
+	Depths map[int]int
+}
+
+func (dc *DepthCrawler) BeginNode(node NodeRef[AABB2D]) error {
+	dc.Depths[node.Depth()] += len(node.Elements())
+	return nil
+}
+
+// ........................................................
+
+func TestBVHNodeDepth(t *testing.T) {
+	rng := rand.New(rand.NewSource(47))
+	boxes := randomBoxes(rng, 1200, 2.0)
+	bvh := New[AABB2D](Traits2D{})
+	for _, element := range boxes {
+		bvh.Insert(element)
+	}
+	for _, element := range boxes[:300] {
+		bvh.Erase(element)
+	}
+	updates := make([]ElementUpdate[AABB2D], 0)
+	for _, element := range boxes[300:600] {
+		box := element.(*Box2D)
+		old := box.B
+		box.B = AABB2D{L: Point2D{old.L[0] + 30.0, old.L[1]}, H: Point2D{old.H[0] + 30.0, old.H[1]}}
+		updates = append(updates, ElementUpdate[AABB2D]{Element: element, OldBound: old})
+	}
+	bvh.UpdateBatch(updates)
+
+	root := bvh.Root()
+	if root.Depth() != 0 || root.Parent().Valid() {
+		t.Errorf("Expected the root at depth 0 without a parent")
+	}
+	if checkDepths(t, root) < 10 {
+		t.Errorf("Expected a deeper tree")
+	}
+
+	crawler := DepthCrawler{CheckBound: CheckBound{T: t}, Depths: make(map[int]int)}
+	bvh.ForEach(&crawler)
+	total := 0
+	for depth, count := range crawler.Depths {
+		if depth < 1 {
+			t.Errorf("Expected elements below the root, found %d at depth %d", count, depth)
+		}
+		total += count
+	}
+	if total != 900 {
+		t.Errorf("Expected the crawler to see 900 elements, found %d", total)
+	}
+
+	rebuilt := New[AABB2D](Traits2D{})
+	rebuilt.RebuildFrom(bvh, boxes[300:])
+	checkDepths(t, rebuilt.Root())
+}
+
+// ........................................................
+
+// checks the cached counts of the subtree at ref against a traversal,
+// returning its element and node counts
+func checkCounts(t *testing.T, ref NodeRef[AABB2D]) (int, int) {
+	elements, nodes := len(ref.Elements()), 1
+	for _, child := range ref.Children() {
+		childelements, childnodes := checkCounts(t, child)
+		elements += childelements
+		nodes += childnodes
+	}
+	if ref.ElementCount() != elements || ref.NodeCount() != nodes {
+		t.Fatalf("Expected counts of %d elements and %d nodes at depth %d, found %d and %d",
+			elements, nodes, ref.Depth(), ref.ElementCount(), ref.NodeCount())
+	}
+	return elements, nodes
+}
+
+func TestNodeRefCounts(t *testing.T) {
+	rng := rand.New(rand.NewSource(78))
+	boxes := randomBoxes(rng, 2000, 2.0)
+	bvh := New[AABB2D](Traits2D{})
+	checkCounts(t, bvh.Root())
+	for _, element := range boxes {
+		bvh.Insert(element)
+	}
+	if elements, _ := checkCounts(t, bvh.Root()); elements != len(boxes) {
+		t.Errorf("Expected %d elements, found %d", len(boxes), elements)
+	}
+
+	for _, element := range boxes[:700] {
+		bvh.Erase(element)
+	}
+	checkCounts(t, bvh.Root())
+
+	updates := make([]ElementUpdate[AABB2D], 0, 300)
+	for _, element := range boxes[700:1000] {
+		box := element.(*Box2D)
+		updates = append(updates, ElementUpdate[AABB2D]{Element: box, OldBound: box.B})
+		box.B = AABB2D{L: Point2D{box.B.L[0] + 5.0, box.B.L[1]}, H: Point2D{box.B.H[0] + 5.0, box.B.H[1]}}
+	}
+	bvh.UpdateBatch(updates)
+	checkCounts(t, bvh.Root())
+
+	rebuilt := New[AABB2D](Traits2D{})
+	rebuilt.RebuildFrom(bvh, boxes[500:])
+	if elements, _ := checkCounts(t, rebuilt.Root()); elements != len(boxes)-500 {
+		t.Errorf("Expected %d elements after RebuildFrom(), found %d", len(boxes)-500, elements)
+	}
+	if err := rebuilt.Validate(); err != nil {
+		t.Errorf("%v", err)
+	}
+}
@@ -0,0 +1,277 @@
+package gobvh
+
+import (
+	"sort"
+)
+
+// ==============================================
+
+//
+// BVH.SAHCost() estimates the cost of a search by the surface area
+// heuristic: the expected number of nodes visited plus the evaluation costs
+// (see Coster) of the elements examined, by a search which reaches the root,
+// assuming the chance of entering a node is proportional to its surface
+// area (or measure, see MeasureTraits).  Lower is better; it is useful for comparing the quality of trees
+// over the same elements.
+//
+func (bvh *BVH[BoundType]) SAHCost() float64 {
+	if len(bvh.root.children) == 0 {
+		return 0.0
+	}
+	return bvh.sahCost(&bvh.root, nil)
+}
+
+// ..............................................
+
+//
+// BVH.Optimize() rebuilds the whole hierarchy from its current elements, in
+// place, restoring the query performance lost to long runs of insertions and
+// erasures.
+//
+// The tree is rebuilt top-down by the surface area heuristic, as BuildSAH(),
+// and its nodes are recycled for the new hierarchy rather than allocated
+// again (see ResetArena()).  Unlike the builders, it keeps tags and other
+// per-element annotations, and leaves Len(), ContentHash() and DirtyBounds()
+// alone: only the shape of the hierarchy changes, so searches find the same
+// elements.  OptimizeWorst() is cheaper when only parts of the tree decay.
+//
+func (bvh *BVH[BoundType]) Optimize() {
+	if len(bvh.root.children) == 0 {
+		return
+	}
+	elements := collectElements(&bvh.root, make([]Boundable[BoundType], 0, bvh.count))
+	for node := range bvh.dirtyindex {
+		delete(bvh.dirtyindex, node) // recycled nodes must not match stale entries
+	}
+	bvh.own(&bvh.root) // (its children slice may be a snapshot's)
+	bvh.root = bvhNode[BoundType]{children: bvh.root.children, epoch: bvh.arena.epoch}
+	bvh.arena.reset()
+	bvh.buildNodeWith(&bvh.root, elements, bvh.planSAHGroups)
+	bvh.version++
+}
+
+// ..............................................
+
+//
+// BVH.OptimizeWorst(k) rebuilds the k subtrees whose SAHCost() most exceeds
+// that of a fresh build of their elements, and returns the number rebuilt.
+//
+// Subtrees are ranked by the improvement to the cost of the whole tree
+// per element rebuilt, so small badly-degraded regions (e.g. left behind by
+// moving elements) are preferred to large ones: this gives most of the
+// benefit of a full rebuild at a fraction of the time.  Subtrees which would
+// not improve are never rebuilt, and the rebuilt subtrees are disjoint.
+// Only the shape of the hierarchy changes; searches find the same elements.
+//
+func (bvh *BVH[BoundType]) OptimizeWorst(k int) int {
+	if k <= 0 || len(bvh.root.children) == 0 {
+		return 0
+	}
+
+	// measure every node, then estimate a rebuild of every internal node:
+	measured := make([]sahMeasure[BoundType], 0, 1+bvh.root.descendants)
+	bvh.sahCost(&bvh.root, &measured)
+	rootarea := bvh.measure(bvh.root.bound)
+	candidates := measured[:0]
+	for _, m := range measured {
+		if m.node.descendants == 0 {
+			continue // a leaf is already as good as a rebuild
+		}
+		elements := collectElements(m.node, nil)
+		excess := m.cost - bvh.planCost(elements, m.node.bound)
+		if excess <= 0.0 {
+			continue
+		}
+		m.score = excess * areaRatio(bvh.measure(m.node.bound), rootarea) / float64(m.node.elements)
+		candidates = append(candidates, m)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	// choose the best, skipping those inside or around a chosen subtree:
+	blocked := make(map[*bvhNode[BoundType]]bool)
+	chosen := make([]*bvhNode[BoundType], 0, k)
+	for _, m := range candidates {
+		if len(chosen) == k {
+			break
+		}
+		inside := false
+		for node := m.node; node != nil && !inside; node = node.parent {
+			inside = blocked[node]
+		}
+		if inside || blocked[m.node] {
+			continue
+		}
+		for node := m.node; node != nil; node = node.parent {
+			blocked[node] = true
+		}
+		chosen = append(chosen, m.node)
+	}
+
+	// then rebuild them (own() may copy their ancestors, which the choice
+	// compares by pointer, so it comes first):
+	for _, node := range chosen {
+		bvh.rebuildNode(bvh.own(node))
+	}
+	if len(chosen) > 0 {
+		bvh.version++
+	}
+	return len(chosen)
+}
+
+// ==============================================
+
+// the SAH cost of a node, as measured or as estimated for a rebuild
+type sahMeasure[BoundType any] struct {
+	node  *bvhNode[BoundType]
+	cost  float64
+	score float64
+}
+
+// ..............................................
+
+// SAH cost of the subtree at node: one for visiting it, the evaluation cost
+// of its elements, and the costs of its child nodes weighted by relative area.
+// The cost of every node is appended to measured, if not nil.
+func (bvh *BVH[BoundType]) sahCost(node *bvhNode[BoundType], measured *[]sahMeasure[BoundType]) float64 {
+	area := bvh.measure(node.bound)
+	cost := 1.0
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			cost += areaRatio(bvh.measure(value.bound), area) * bvh.sahCost(value, measured)
+		} else {
+			cost += elementCost(child)
+		}
+	}
+	if measured != nil {
+		*measured = append(*measured, sahMeasure[BoundType]{node: node, cost: cost})
+	}
+	return cost
+}
+
+// ..............................................
+
+// SAH cost of the subtree rebuildNode() would build over elements (which are reordered)
+func (bvh *BVH[BoundType]) planCost(elements []Boundable[BoundType], bound BoundType) float64 {
+	area := bvh.measure(bound)
+	cost := 1.0
+	groups := bvh.planGroups(elements)
+	for _, group := range groups {
+		if len(group) == 1 || len(groups) == 1 {
+			for _, element := range group {
+				cost += elementCost(element)
+			}
+			continue
+		}
+		groupbound := unionOf(bvh.boundtraits, group, -1)
+		cost += areaRatio(bvh.measure(groupbound), area) * bvh.planCost(group, groupbound)
+	}
+	return cost
+}
+
+// ..............................................
+
+// divides elements (which are reordered) into the children of a rebuilt
+// node, by repeatedly halving the largest group at its centroid median;
+// a single group means the elements fit in one leaf.
+func (bvh *BVH[BoundType]) planGroups(elements []Boundable[BoundType]) [][]Boundable[BoundType] {
+	fanout := bvh.options.MaxChildren - 1
+	groups := [][]Boundable[BoundType]{elements}
+	for len(groups) < fanout {
+		largest := 0
+		for index, group := range groups {
+			if len(group) > len(groups[largest]) {
+				largest = index
+			}
+		}
+		group := groups[largest]
+		if len(group) <= fanout {
+			break
+		}
+		bvh.sortCentroids(group)
+		half := len(group) / 2
+		groups[largest] = group[:half]
+		groups = append(groups, group[half:])
+	}
+	return groups
+}
+
+// ..............................................
+
+// replaces the subtree at node with a fresh build of its elements, then
+// refits the ancestors (whose bounds do not change, but whose counts may)
+func (bvh *BVH[BoundType]) rebuildNode(node *bvhNode[BoundType]) {
+	elements := collectElements(node, nil)
+	bvh.buildNode(node, elements)
+	for ancestor := node.parent; ancestor != nil; ancestor = ancestor.parent {
+		bvh.recalculateBounds(ancestor)
+	}
+}
+
+// ..............................................
+
+func (bvh *BVH[BoundType]) buildNode(node *bvhNode[BoundType], elements []Boundable[BoundType]) {
+	bvh.buildNodeWith(node, elements, bvh.planGroups)
+}
+
+// ..............................................
+
+// fills node with elements, divided among its children by plan, recursively
+func (bvh *BVH[BoundType]) buildNodeWith(node *bvhNode[BoundType], elements []Boundable[BoundType], plan func([]Boundable[BoundType]) [][]Boundable[BoundType]) {
+	for index := range node.children {
+		node.children[index] = nil
+	}
+	node.children = node.children[:0]
+	groups := plan(elements)
+	for _, group := range groups {
+		if len(group) == 1 || len(groups) == 1 {
+			for _, element := range group {
+				node.children = bvh.appendChild(node.children, element)
+			}
+			continue
+		}
+		child := bvh.arena.alloc()
+		child.parent = node
+		child.level = node.level + 1
+		bvh.buildNodeWith(child, group, plan)
+		node.children = bvh.appendChild(node.children, child)
+	}
+	bvh.recalculateBounds(node)
+}
+
+// ==============================================
+
+// half the surface area of the box given by IntervalRange(): the sum over
+// the axes of the product of the other extents (the perimeter in 2D, and
+// the length in 1D, where a search is a point or interval)
+func sahArea[BoundType any](bounder BoundTraits[BoundType], bound BoundType) float64 {
+	dims := bounder.Dimensions(bound)
+	if dims == 1 {
+		lo, hi := bounder.IntervalRange(bound, 0)
+		return hi - lo
+	}
+	area := 0.0
+	var i, j uint
+	for i = 0; i < dims; i++ {
+		face := 1.0
+		for j = 0; j < dims; j++ {
+			if j != i {
+				lo, hi := bounder.IntervalRange(bound, j)
+				face *= hi - lo
+			}
+		}
+		area += face
+	}
+	return area
+}
+
+// ..............................................
+
+// chance of entering a child of the given area from its parent
+func areaRatio(area float64, parentarea float64) float64 {
+	if parentarea <= 0.0 {
+		return 1.0
+	}
+	return area / parentarea
+}
@@ -0,0 +1,122 @@
+package gobvh
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHOptimizeWorst(t *testing.T) {
+	rng := rand.New(rand.NewSource(29))
+	boxes := randomBoxes(rng, 3000, 2.0)
+
+	// inserting in sorted order makes a poor tree:
+	sort.Slice(boxes, func(i, j int) bool {
+		return boxes[i].GetBound().L[0]+boxes[i].GetBound().L[1] < boxes[j].GetBound().L[0]+boxes[j].GetBound().L[1]
+	})
+	bvh := New[AABB2D](Traits2D{})
+	for _, box := range boxes {
+		bvh.Insert(box)
+	}
+	window := AABB2D{Point2D{20.0, 30.0}, Point2D{45.0, 50.0}}
+	before := BoxCollector{Box: window}
+	bvh.FindAll(&before)
+
+	cost := bvh.SAHCost()
+	if n := bvh.OptimizeWorst(8); n == 0 || n > 8 {
+		t.Fatalf("Expected 1 to 8 subtrees rebuilt, rebuilt %d", n)
+	}
+	if err := bvh.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	improved := bvh.SAHCost()
+	if improved >= cost {
+		t.Errorf("Expected the SAH cost to drop below %g, found %g", cost, improved)
+	}
+	after := BoxCollector{Box: window}
+	bvh.FindAll(&after)
+	if len(after.Found) != len(before.Found) || bvh.Len() != len(boxes) {
+		t.Errorf("Expected %d elements found after optimizing, found %d", len(before.Found), len(after.Found))
+	}
+
+	// repeated optimization keeps improving, until nothing is left to gain:
+	rounds := 0
+	for rounds < 100 && bvh.OptimizeWorst(8) > 0 {
+		rounds++
+	}
+	if err := bvh.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if final := bvh.SAHCost(); final > improved {
+		t.Errorf("Expected the SAH cost to keep dropping from %g, found %g", improved, final)
+	}
+	if n := bvh.OptimizeWorst(8); n != 0 {
+		t.Errorf("Expected nothing left to rebuild, rebuilt %d", n)
+	}
+
+	empty := New[AABB2D](Traits2D{})
+	if empty.OptimizeWorst(4) != 0 || empty.SAHCost() != 0.0 {
+		t.Errorf("Expected nothing to optimize in an empty tree")
+	}
+}
+
+// ........................................................
+
+func TestBVHOptimize(t *testing.T) {
+	rng := rand.New(rand.NewSource(789))
+	bvh := New[AABB2D](Traits2D{})
+	boxes := randomBoxes(rng, 4000, 2.0)
+	for index, box := range boxes {
+		if index%10 == 0 {
+			bvh.InsertTagged(box, 7)
+		} else {
+			bvh.Insert(box)
+		}
+	}
+
+	// churn, leaving half of the elements:
+	for index, box := range boxes {
+		if index%2 == 1 {
+			bvh.Erase(box)
+		}
+	}
+	for _, box := range randomBoxes(rng, 1000, 2.0) {
+		bvh.Insert(box)
+	}
+
+	window := AABB2D{Point2D{20.0, 30.0}, Point2D{45.0, 50.0}}
+	count, hash, cost := bvh.Count(window), bvh.ContentHash(), bvh.SAHCost()
+	bvh.Optimize()
+	if err := bvh.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if bvh.Count(window) != count || bvh.ContentHash() != hash || bvh.Len() != 3000 {
+		t.Errorf("Expected Optimize() to keep the elements, found %d of %d in the window", bvh.Count(window), count)
+	}
+	if bvh.SAHCost() >= cost {
+		t.Errorf("Expected the SAH cost to drop below %g, found %g", cost, bvh.SAHCost())
+	}
+	for index, box := range boxes {
+		if index%10 == 0 && !bvh.HasTag(box, 7) {
+			t.Errorf("Expected Optimize() to keep the tags of %v", box.GetBound())
+			break
+		}
+	}
+
+	// nodes are recycled:
+	nodes := bvh.MemoryFootprint().ArenaNodes
+	for i := 0; i < 5; i++ {
+		bvh.Optimize()
+	}
+	if bvh.MemoryFootprint().ArenaNodes != nodes {
+		t.Errorf("Expected Optimize() to reuse %d nodes, found %d", nodes, bvh.MemoryFootprint().ArenaNodes)
+	}
+	bvh.Insert(Point2D{50.0, 50.0})
+	if err := bvh.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	New[AABB2D](Traits2D{}).Optimize()
+}
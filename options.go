@@ -0,0 +1,420 @@
+package gobvh
+
+import (
+	"math"
+)
+
+// ==============================================
+
+//
+// Options tune the structure of a BVH, see NewWithOptions().
+//
+// MaxChildren is the fan-out: a node holding this many children (elements
+// or nodes) is split (default 16).  Small values give tighter bounds and cheaper
+// queries; large values give cheaper insertions and a shallower tree.
+// Values below 4 are raised to 4.  A node whose split is abandoned keeps
+// growing and is split again when it holds a multiple of MaxChildren.
+//
+// MinSplitChildren is the fewest children each half of a split must receive
+// for the split to be kept (zero selects the default, 2).  Raising it
+// abandons lopsided splits, trading a wider node for a more balanced tree;
+// it is limited to MaxChildren / 2.
+//
+// MinChildren is the fewest children a node other than the root may be left
+// with by Erase() (zero, the default, only removes empty nodes).  A node
+// falling below it is dissolved: its children are merged into a sibling
+// node with room for them, or else the elements of its subtree are
+// reinserted, so erasures do not leave chains of nearly empty nodes behind,
+// at the cost of slower erasures.  It is limited to MaxChildren / 2, and
+// raises MinSplitChildren to match, so splits do not make such nodes.
+//
+// ChooseLimit bounds the metric (the L1 size of the union of bounds) of the
+// child an insertion descends into (zero selects the default, 1e38, which is
+// effectively unlimited).  An element further than the limit from every child
+// node stays in the node itself, which keeps outliers from inflating the
+// bounds of deep subtrees.  It does not apply to an InsertHeuristic.
+//
+// InitialCapacity is the capacity allocated for the children of a new node
+// (zero selects the default, 8).  GrowthFactor is the factor by which a full
+// children slice grows (zero selects the default, 2.0); a factor of 1.0 grows
+// one slot at a time, for the tightest memory use at the cost of copying.
+// Built for tinygo (or with the gobvh_embedded tag) the defaults are 4 and 1.0.
+// See BVH.MemoryFootprint() to measure the effect.
+//
+// SnapGrid, if positive, widens every node bound outwards to a grid with
+// this spacing, so the floating-point noise of repeated unions (which may
+// differ between machines and between insertion histories) never reaches
+// the node bounds.  It requires traits implementing SnapTraits, and is
+// ignored otherwise.  Coarser grids give looser bounds.
+//
+// Margin, if positive, fattens the bound of every element by this margin
+// along each dimension wherever the node bounds are computed, so an element
+// may move that far before it escapes its leaf; see BVH.UpdateIfNeeded().
+// It requires traits implementing ExpandTraits, and is ignored otherwise.
+// Larger margins mean fewer updates but looser bounds.
+//
+// StableErase keeps the remaining children of a node in insertion order when
+// one is erased, for crawlers and serializers which depend on a stable
+// order.  By default the last child is moved into the vacated slot, which
+// is faster but reorders siblings.
+//
+// SplitPolicy chooses how the children of a full node are divided, see SplitPolicy.
+// RefineSplits adds a pass after each volume split which moves children
+// between the two halves while that reduces the overlap of their bounds;
+// it makes insertion slower but improves query pruning, particularly for
+// clustered data.
+//
+// Rotations, after each insertion, swaps the new element with an element of
+// a neighbouring leaf (a cousin: a child of the leaf's sibling) where that
+// lowers the SAH cost most, much as a dynamic AABB tree's rotations do, and
+// then does the same for each ancestor up the insertion path while swaps
+// are made.  It keeps the nodes of fully dynamic workloads tight, even for
+// sorted insertion orders, and the tree balanced, since nothing changes
+// depth; it makes insertion several times slower.
+//
+// RebuildThreshold, if positive, rebuilds a subtree (as OptimizeWorst() does)
+// once its SAHCost() exceeds this multiple of the cost estimated for a fresh
+// build of its elements, e.g. 1.5.  The quality of a subtree is measured
+// each time as many changes as half its elements have been made below it,
+// so decayed regions are repaired as they decay, with pauses proportional
+// to their size rather than the whole tree's.
+// Values between zero and one are raised to one.
+//
+// TuneSteps is the number of restructuring steps (see BVH.Tune()) each
+// Insert() and Erase() performs (zero, the default, performs none).  A step
+// or two keeps the tree from decaying under long runs of updates, at a
+// small, steady cost per update rather than an occasional Optimize().
+//
+// Packing chooses how Build() packs elements into nodes, see Packing.
+// BuildWorkers is the number of goroutines Build() uses to sort and pack
+// them (zero selects the default, runtime.GOMAXPROCS(0)); the tree built is
+// the same for any number.  It also bounds the number of shards
+// BuildShards() divides its elements into.  Built for tinygo (or with the gobvh_embedded
+// tag) Build() always works on the calling goroutine.
+//
+// A SplitStrategy and an InsertHeuristic may also be given to New(), see
+// WithSplitStrategy() and WithInsertHeuristic(); the bvh holds them, and
+// Options() does not report them.
+//
+type Options struct {
+	MaxChildren      int
+	MinSplitChildren int
+	MinChildren      int
+	ChooseLimit      float64
+	InitialCapacity  int
+	GrowthFactor     float64
+	SnapGrid         float64
+	Margin           float64
+	StableErase      bool
+	SplitPolicy      SplitPolicy
+	RefineSplits     bool
+	Packing          Packing
+	BuildWorkers     int
+	Rotations        bool
+	RebuildThreshold float64
+	TuneSteps        int
+
+	splitter any // a SplitStrategy[BoundType], see WithSplitStrategy()
+	inserter any // an InsertHeuristic[BoundType], see WithInsertHeuristic()
+}
+
+// ..............................................
+
+const (
+	defaultMinSplitChildren = 2
+	defaultChooseLimit      = 1e38
+)
+
+// ..............................................
+
+//
+// DefaultOptions() returns the options used by New().
+//
+func DefaultOptions() Options {
+	return Options{
+		MaxChildren:      16,
+		MinSplitChildren: defaultMinSplitChildren,
+		ChooseLimit:      defaultChooseLimit,
+		InitialCapacity:  defaultInitialCapacity,
+		GrowthFactor:     defaultGrowthFactor,
+	}
+}
+
+// ..............................................
+
+//
+// NewWithOptions(traits, options) returns a pointer to a new bounding volume
+// hierarchy data structure, tuned by options.
+//
+// Please supply traits so that the bvh knows how to use the BoundType.
+//
+func NewWithOptions[BoundType any](boundtraits BoundTraits[BoundType], options Options) *BVH[BoundType] {
+	if options.MaxChildren < 4 {
+		options.MaxChildren = 4
+	}
+	if options.MinSplitChildren <= 0 {
+		options.MinSplitChildren = defaultMinSplitChildren
+	}
+	if options.MinSplitChildren > options.MaxChildren/2 {
+		options.MinSplitChildren = options.MaxChildren / 2
+	}
+	if options.MinChildren < 0 {
+		options.MinChildren = 0
+	}
+	if options.MinChildren > options.MaxChildren/2 {
+		options.MinChildren = options.MaxChildren / 2
+	}
+	if options.MinSplitChildren < options.MinChildren {
+		options.MinSplitChildren = options.MinChildren
+	}
+	if options.RebuildThreshold < 0.0 {
+		options.RebuildThreshold = 0.0
+	} else if options.RebuildThreshold > 0.0 && options.RebuildThreshold < 1.0 {
+		options.RebuildThreshold = 1.0
+	}
+	if options.ChooseLimit <= 0.0 {
+		options.ChooseLimit = defaultChooseLimit
+	}
+	if options.InitialCapacity <= 0 {
+		options.InitialCapacity = defaultInitialCapacity
+	}
+	if options.GrowthFactor <= 0.0 {
+		options.GrowthFactor = defaultGrowthFactor
+	} else if options.GrowthFactor < 1.0 {
+		options.GrowthFactor = 1.0
+	}
+	splitter, _ := options.splitter.(SplitStrategy[BoundType])
+	inserter, _ := options.inserter.(InsertHeuristic[BoundType])
+	options.splitter, options.inserter = nil, nil
+	bvh := &BVH[BoundType]{
+		boundtraits: boundtraits,
+		options:     options,
+		splitter:    splitter,
+		inserter:    inserter,
+	}
+	if snaptraits, ok := boundtraits.(SnapTraits[BoundType]); ok && options.SnapGrid > 0.0 {
+		bvh.snaptraits = snaptraits
+	}
+	if fattraits, ok := boundtraits.(ExpandTraits[BoundType]); ok && options.Margin > 0.0 {
+		bvh.fattraits = fattraits
+	}
+	return bvh
+}
+
+// ..............................................
+
+//
+// BVH.Options() reports the options the bvh was created with.
+//
+func (bvh *BVH[BoundType]) Options() Options {
+	return bvh.options
+}
+
+// ==============================================
+
+//
+// Option adjusts one of the Options of a new BVH, see New().
+//
+type Option func(*Options)
+
+// ..............................................
+
+//
+// WithMaxChildren(n) sets Options.MaxChildren, the fan-out.
+//
+func WithMaxChildren(n int) Option {
+	return func(options *Options) {
+		options.MaxChildren = n
+	}
+}
+
+// ..............................................
+
+//
+// WithMinSplitChildren(n) sets Options.MinSplitChildren.
+//
+func WithMinSplitChildren(n int) Option {
+	return func(options *Options) {
+		options.MinSplitChildren = n
+	}
+}
+
+// ..............................................
+
+//
+// WithMinChildren(n) sets Options.MinChildren.
+//
+func WithMinChildren(n int) Option {
+	return func(options *Options) {
+		options.MinChildren = n
+	}
+}
+
+// ..............................................
+
+//
+// WithChooseLimit(limit) sets Options.ChooseLimit.
+//
+func WithChooseLimit(limit float64) Option {
+	return func(options *Options) {
+		options.ChooseLimit = limit
+	}
+}
+
+// ..............................................
+
+//
+// WithGrowth(initial, factor) sets Options.InitialCapacity and
+// Options.GrowthFactor, the allocation of children slices.
+//
+func WithGrowth(initial int, factor float64) Option {
+	return func(options *Options) {
+		options.InitialCapacity = initial
+		options.GrowthFactor = factor
+	}
+}
+
+// ..............................................
+
+//
+// WithSnapGrid(spacing) sets Options.SnapGrid.
+//
+func WithSnapGrid(spacing float64) Option {
+	return func(options *Options) {
+		options.SnapGrid = spacing
+	}
+}
+
+// ..............................................
+
+//
+// WithMargin(margin) sets Options.Margin.
+//
+func WithMargin(margin float64) Option {
+	return func(options *Options) {
+		options.Margin = margin
+	}
+}
+
+// ..............................................
+
+//
+// WithStableErase() sets Options.StableErase.
+//
+func WithStableErase() Option {
+	return func(options *Options) {
+		options.StableErase = true
+	}
+}
+
+// ..............................................
+
+//
+// WithSplitPolicy(policy, refine) sets Options.SplitPolicy and
+// Options.RefineSplits.
+//
+func WithSplitPolicy(policy SplitPolicy, refine bool) Option {
+	return func(options *Options) {
+		options.SplitPolicy = policy
+		options.RefineSplits = refine
+	}
+}
+
+// ..............................................
+
+//
+// WithPacking(packing) sets Options.Packing.
+//
+func WithPacking(packing Packing) Option {
+	return func(options *Options) {
+		options.Packing = packing
+	}
+}
+
+// ..............................................
+
+//
+// WithBuildWorkers(workers) sets Options.BuildWorkers.
+//
+func WithBuildWorkers(workers int) Option {
+	return func(options *Options) {
+		options.BuildWorkers = workers
+	}
+}
+
+// ..............................................
+
+//
+// WithRotations() sets Options.Rotations.
+//
+func WithRotations() Option {
+	return func(options *Options) {
+		options.Rotations = true
+	}
+}
+
+// ..............................................
+
+//
+// WithRebuildThreshold(threshold) sets Options.RebuildThreshold.
+//
+func WithRebuildThreshold(threshold float64) Option {
+	return func(options *Options) {
+		options.RebuildThreshold = threshold
+	}
+}
+
+// ..............................................
+
+//
+// WithTuneSteps(steps) sets Options.TuneSteps.
+//
+func WithTuneSteps(steps int) Option {
+	return func(options *Options) {
+		options.TuneSteps = steps
+	}
+}
+
+// ..............................................
+
+//
+// WithSplitStrategy[BoundType](strategy) divides the nodes of the new bvh
+// with strategy, as BVH.SetSplitStrategy().  The BoundType must be given
+// (it is not inferred from an interface), and must be that of the bvh:
+// a strategy for another BoundType is ignored.
+//
+func WithSplitStrategy[BoundType any](strategy SplitStrategy[BoundType]) Option {
+	return func(options *Options) {
+		options.splitter = strategy
+	}
+}
+
+// ..............................................
+
+//
+// WithInsertHeuristic[BoundType](heuristic) places the insertions of the
+// new bvh with heuristic, as BVH.SetInsertHeuristic().  As with
+// WithSplitStrategy(), a heuristic for another BoundType is ignored.
+//
+func WithInsertHeuristic[BoundType any](heuristic InsertHeuristic[BoundType]) Option {
+	return func(options *Options) {
+		options.inserter = heuristic
+	}
+}
+
+// ==============================================
+
+// appends a child to a children slice, growing it according to the options
+func (bvh *BVH[BoundType]) appendChild(children []Boundable[BoundType], child Boundable[BoundType]) []Boundable[BoundType] {
+	if len(children) == cap(children) {
+		capacity := bvh.options.InitialCapacity
+		if len(children) > 0 {
+			capacity = int(math.Ceil(float64(len(children)) * bvh.options.GrowthFactor))
+		}
+		capacity = maxInt(capacity, len(children)+1)
+		grown := make([]Boundable[BoundType], len(children), capacity)
+		copy(grown, children)
+		children = grown
+	}
+	return append(children, child)
+}
@@ -0,0 +1,343 @@
+package gobvh
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+// the fewest children of any node below the root
+func smallestNode(node *bvhNode[AABB2D], isroot bool) int {
+	smallest := len(node.children)
+	if isroot {
+		smallest = 1 << 30
+	}
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[AABB2D]); ok {
+			if size := smallestNode(value, false); size < smallest {
+				smallest = size
+			}
+		}
+	}
+	return smallest
+}
+
+// the number of elements stored in nodes which also hold nodes
+func mixedElements(node *bvhNode[AABB2D]) int {
+	nodes, elements, mixed := 0, 0, 0
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[AABB2D]); ok {
+			nodes++
+			mixed += mixedElements(value)
+		} else {
+			elements++
+		}
+	}
+	if nodes > 0 {
+		mixed += elements
+	}
+	return mixed
+}
+
+// ........................................................
+
+func TestOptionsDefaults(t *testing.T) {
+	options := NewWithOptions[AABB2D](Traits2D{}, Options{MaxChildren: 6, MinSplitChildren: 5}).Options()
+	if options.MinSplitChildren != 3 {
+		t.Errorf("Expected MinSplitChildren to be limited to MaxChildren / 2, found %d", options.MinSplitChildren)
+	}
+	if options.ChooseLimit != defaultChooseLimit {
+		t.Errorf("Expected the default ChooseLimit, found %v", options.ChooseLimit)
+	}
+	defaults := DefaultOptions()
+	if normalized := NewWithOptions[AABB2D](Traits2D{}, Options{MaxChildren: 16}).Options(); normalized != defaults {
+		t.Errorf("Expected zero options to select the defaults %+v, found %+v", defaults, normalized)
+	}
+}
+
+// ........................................................
+
+func TestOptionsFunctional(t *testing.T) {
+	if options := New[AABB2D](Traits2D{}).Options(); options != DefaultOptions() {
+		t.Errorf("Expected New() without options to use the defaults, found %+v", options)
+	}
+
+	expected := Options{
+		MaxChildren:      8,
+		MinSplitChildren: 3,
+		MinChildren:      2,
+		ChooseLimit:      50.0,
+		InitialCapacity:  4,
+		GrowthFactor:     1.5,
+		SnapGrid:         0.25,
+		StableErase:      true,
+		SplitPolicy:      SplitMedian,
+		RefineSplits:     true,
+		Packing:          PackHilbert,
+	}
+	options := New[AABB2D](Traits2D{},
+		WithMaxChildren(8),
+		WithMinSplitChildren(3),
+		WithMinChildren(2),
+		WithChooseLimit(50.0),
+		WithGrowth(4, 1.5),
+		WithSnapGrid(0.25),
+		WithStableErase(),
+		WithSplitPolicy(SplitMedian, true),
+		WithPacking(PackHilbert),
+	).Options()
+	if options != expected {
+		t.Errorf("Expected options %+v, found %+v", expected, options)
+	}
+
+	// options are normalized as by NewWithOptions():
+	if options := New[AABB2D](Traits2D{}, WithMaxChildren(2)).Options(); options.MaxChildren != 4 {
+		t.Errorf("Expected MaxChildren to be raised to 4, found %d", options.MaxChildren)
+	}
+}
+
+// ........................................................
+
+func TestOptionsStrategies(t *testing.T) {
+	counting := &countingSplit{SplitStrategy: QuadraticSplit[AABB2D]{}}
+	bvh := New[AABB2D](Traits2D{},
+		WithSplitStrategy[AABB2D](counting),
+		WithInsertHeuristic[AABB2D](rootInsert{}),
+	)
+	if bvh.splitter != counting || bvh.inserter != (rootInsert{}) {
+		t.Fatalf("Expected the options to set the split strategy and insert heuristic")
+	}
+	if options := bvh.Options(); options != DefaultOptions() {
+		t.Errorf("Expected the strategies not to be reported by Options(), found %+v", options)
+	}
+	rng := rand.New(rand.NewSource(792))
+	for _, box := range randomBoxes(rng, 500, 2.0) {
+		bvh.Insert(box)
+	}
+	if err := bvh.Validate(); err != nil {
+		t.Error(err)
+	}
+	if counting.calls == 0 {
+		t.Errorf("Expected the split strategy to split nodes")
+	}
+
+	// a strategy for another BoundType is ignored:
+	other := New[AABB2D](Traits2D{},
+		WithSplitStrategy[Interval](QuadraticSplit[Interval]{}),
+		WithInsertHeuristic[Interval](DistanceInsert[Interval]{}),
+	)
+	if other.splitter != nil || other.inserter != nil {
+		t.Errorf("Expected strategies for another BoundType to be ignored")
+	}
+}
+
+// ........................................................
+
+func TestOptionsMinSplitChildren(t *testing.T) {
+	rng := rand.New(rand.NewSource(68))
+	boxes := randomBoxes(rng, 3000, 2.0)
+	for _, minimum := range []int{2, 5, 8} {
+		options := DefaultOptions()
+		options.MinSplitChildren = minimum
+		bvh := NewWithOptions[AABB2D](Traits2D{}, options)
+		for _, element := range boxes {
+			bvh.Insert(element)
+		}
+		checkTree(t, bvh, &bvh.root)
+		if smallest := smallestNode(&bvh.root, true); smallest < minimum {
+			t.Errorf("Expected every node to hold at least %d children, found %d", minimum, smallest)
+		}
+		if count := bvh.Count(bvh.GetBound()); count != len(boxes) {
+			t.Errorf("Expected to find all %d elements, found %d", len(boxes), count)
+		}
+	}
+}
+
+// ........................................................
+
+func TestOptionsChooseLimit(t *testing.T) {
+	rng := rand.New(rand.NewSource(69))
+	boxes := randomBoxes(rng, 2000, 2.0)
+	options := DefaultOptions()
+	options.ChooseLimit = 60.0
+	bvh := NewWithOptions[AABB2D](Traits2D{}, options)
+	for _, element := range boxes {
+		bvh.Insert(element)
+	}
+	checkTree(t, bvh, &bvh.root)
+	if mixedElements(&bvh.root) == 0 {
+		t.Errorf("Expected elements beyond the limit to stay in interior nodes")
+	}
+
+	for i := 0; i < 50; i++ {
+		x, y := rng.Float64()*100.0, rng.Float64()*100.0
+		query := AABB2D{L: Point2D{x, y}, H: Point2D{x + 10.0, y + 10.0}}
+		expected := 0
+		for _, element := range boxes {
+			if boundsOverlap[AABB2D](Traits2D{}, query, element.GetBound()) {
+				expected++
+			}
+		}
+		if count := bvh.Count(query); count != expected {
+			t.Errorf("Expected a count of %d, found %d", expected, count)
+		}
+	}
+	for _, element := range boxes {
+		if !bvh.Erase(element) {
+			t.Fatalf("Expected to erase every element")
+		}
+	}
+}
+
+// ========================================================
+
+// "make bench" (go test -run XXX -bench Options) compares the effect of the tunable options
+// on insertion and on window queries.
+func BenchmarkOptions(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	boxes := randomBoxes(rng, 20000, 1.0)
+	queries := make([]AABB2D, 256)
+	for i := range queries {
+		x, y := rng.Float64()*95.0, rng.Float64()*95.0
+		queries[i] = AABB2D{L: Point2D{x, y}, H: Point2D{x + 5.0, y + 5.0}}
+	}
+
+	variants := []struct {
+		name   string
+		modify func(*Options)
+	}{
+		{"default", func(o *Options) {}},
+		{"MaxChildren=8", func(o *Options) { o.MaxChildren = 8 }},
+		{"MaxChildren=32", func(o *Options) { o.MaxChildren = 32 }},
+		{"MinSplitChildren=4", func(o *Options) { o.MinSplitChildren = 4 }},
+		{"ChooseLimit=20", func(o *Options) { o.ChooseLimit = 20.0 }},
+		{"InitialCapacity=16", func(o *Options) { o.InitialCapacity = 16 }},
+	}
+	for _, variant := range variants {
+		options := DefaultOptions()
+		variant.modify(&options)
+
+		b.Run(fmt.Sprintf("Insert/%s", variant.name), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				bvh := NewWithOptions[AABB2D](Traits2D{}, options)
+				for _, element := range boxes {
+					bvh.Insert(element)
+				}
+			}
+		})
+
+		bvh := NewWithOptions[AABB2D](Traits2D{}, options)
+		for _, element := range boxes {
+			bvh.Insert(element)
+		}
+		b.Run(fmt.Sprintf("Query/%s", variant.name), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				bvh.Count(queries[n%len(queries)])
+			}
+		})
+	}
+}
+
+// ........................................................
+
+func TestOptionsMinChildren(t *testing.T) {
+	options := NewWithOptions[AABB2D](Traits2D{}, Options{MaxChildren: 8, MinChildren: 9}).Options()
+	if options.MinChildren != 4 {
+		t.Errorf("Expected MinChildren to be limited to MaxChildren / 2, found %d", options.MinChildren)
+	}
+
+	rng := rand.New(rand.NewSource(791))
+	boxes := randomBoxes(rng, 3000, 2.0)
+	bvh := NewWithOptions[AABB2D](Traits2D{}, Options{MaxChildren: 8, MinChildren: 3})
+	plain := NewWithOptions[AABB2D](Traits2D{}, Options{MaxChildren: 8})
+	for index, box := range boxes {
+		if index%7 == 0 {
+			bvh.InsertTagged(box, 5)
+		} else {
+			bvh.Insert(box)
+		}
+		plain.Insert(box)
+	}
+
+	// erase most of the elements, in spatial clusters:
+	kept := 0
+	for index, box := range boxes {
+		if index%5 == 0 || box.GetBound().L[0] > 80.0 {
+			kept++
+			continue
+		}
+		if !bvh.Erase(box) || !plain.Erase(box) {
+			t.Fatalf("Failed to erase %v", box.GetBound())
+		}
+		if index%100 == 0 {
+			if err := bvh.Validate(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := bvh.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if bvh.Len() != kept || bvh.ContentHash() != plain.ContentHash() {
+		t.Errorf("Expected %d elements to remain, found %d", kept, bvh.Len())
+	}
+	if smallest := smallestNode(&bvh.root, true); smallest < 3 {
+		t.Errorf("Expected no node below the root with fewer than 3 children, found %d", smallest)
+	}
+	if smallestNode(&plain.root, true) >= 3 {
+		t.Errorf("Expected erasures to leave small nodes without MinChildren")
+	}
+	for index, box := range boxes {
+		if index%35 == 0 && !bvh.HasTag(box, 5) {
+			t.Errorf("Expected reinserted elements to keep their tags")
+			break
+		}
+	}
+	for i := 0; i < 20; i++ {
+		region := randomRegion(rng, 20.0)
+		if bvh.Count(region) != plain.Count(region) {
+			t.Errorf("Expected the same elements in %v with and without MinChildren", region)
+		}
+	}
+}
+
+// ........................................................
+
+func TestOptionsMinChildrenMerges(t *testing.T) {
+	bvh := NewWithOptions[AABB2D](Traits2D{}, Options{MaxChildren: 8, MinChildren: 3})
+	points := []Point2D{{0, 0}, {1, 0}, {2, 0}, {10, 0}, {11, 0}, {12, 0}}
+	for _, point := range points {
+		bvh.Insert(point)
+	}
+
+	// two leaves of three, side by side:
+	leaves := []*bvhNode[AABB2D]{bvh.arena.alloc(), bvh.arena.alloc()}
+	for index, point := range points {
+		leaf := leaves[index/3]
+		leaf.children = append(leaf.children, point)
+	}
+	bvh.root.children = bvh.root.children[:0]
+	for _, leaf := range leaves {
+		leaf.parent = &bvh.root
+		leaf.level = bvh.root.level + 1
+		bvh.recalculateBounds(leaf)
+		bvh.root.children = append(bvh.root.children, leaf)
+	}
+	bvh.recalculateBounds(&bvh.root)
+
+	// the underfull leaf joins its sibling, rather than being reinserted:
+	if !bvh.Erase(points[0]) {
+		t.Fatalf("Failed to erase %v", points[0])
+	}
+	if err := bvh.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if len(bvh.root.children) != 1 || bvh.root.children[0] != leaves[1] || len(leaves[1].children) != 5 {
+		t.Errorf("Expected the underfull leaf to be merged into its sibling")
+	}
+	if bvh.Len() != 5 || bvh.root.bound.L[0] != 1.0 {
+		t.Errorf("Expected 5 elements from x=1, found %d in %v", bvh.Len(), bvh.root.bound)
+	}
+}
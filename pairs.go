@@ -0,0 +1,120 @@
+package gobvh
+
+// ==============================================
+
+//
+// DistanceFunc measures the distance between two bounds.
+//
+// It is used for pruning: the distance between two bounds must never
+// exceed the distance between anything contained in them.  The minimum
+// (gap) distance between two boxes is the usual choice.
+//
+type DistanceFunc[BoundType any] func(a BoundType, b BoundType) float64
+
+// ==============================================
+
+//
+// BVH.NeighborEdges(radius, distance, fn) calls fn(a, b) once for every
+// unordered pair of stored elements whose bounds are within radius of
+// each other, as measured by distance.
+//
+// The pairs are found with a single traversal of the tree against itself,
+// pruning pairs of subtrees which are further apart than radius.
+// An error returned by fn stops the traversal and is returned.
+//
+func (bvh *BVH[BoundType]) NeighborEdges(radius float64, distance DistanceFunc[BoundType], fn func(a Boundable[BoundType], b Boundable[BoundType]) error) error {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryNeighborEdges, Radius: radius, Distance: distance})
+	}
+	prune := func(a BoundType, b BoundType) bool {
+		return distance(a, b) > radius
+	}
+	return selfPairs(&bvh.root, prune, fn)
+}
+
+// ..............................................
+
+//
+// BVH.NeighborGraph(radius, distance) returns the fixed-radius neighbor
+// graph of the stored elements: for each element, the other elements
+// whose bounds are within radius of its bound.  Elements without
+// neighbors are not present in the map.
+//
+// See NeighborEdges() for a version which does not build the map.
+//
+func (bvh *BVH[BoundType]) NeighborGraph(radius float64, distance DistanceFunc[BoundType]) map[Boundable[BoundType]][]Boundable[BoundType] {
+	graph := make(map[Boundable[BoundType]][]Boundable[BoundType])
+	bvh.NeighborEdges(radius, distance, func(a Boundable[BoundType], b Boundable[BoundType]) error {
+		graph[a] = append(graph[a], b)
+		graph[b] = append(graph[b], a)
+		return nil
+	})
+	return graph
+}
+
+// ==============================================
+
+// calls fn for every unordered pair of elements within the subtree rooted at node,
+// skipping pairs of subtrees for which prune(bound, bound) is true.
+func selfPairs[BoundType any](node *bvhNode[BoundType], prune func(BoundType, BoundType) bool, fn func(Boundable[BoundType], Boundable[BoundType]) error) error {
+	for index, child := range node.children {
+		if child == nil {
+			continue
+		}
+		value, ok := child.(*bvhNode[BoundType])
+		if ok {
+			err := selfPairs(value, prune, fn)
+			if err != nil {
+				return err
+			}
+		}
+		for _, other := range node.children[index+1:] {
+			if other != nil {
+				err := crossPairs(child, other, prune, fn)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ..............................................
+
+// calls fn for every pair of elements (x', y') with x' in x and y' in y,
+// skipping pairs of subtrees for which prune(bound, bound) is true.
+// Either of x and y may be an element or a node.
+func crossPairs[BoundType any](x Boundable[BoundType], y Boundable[BoundType], prune func(BoundType, BoundType) bool, fn func(Boundable[BoundType], Boundable[BoundType]) error) error {
+	if prune(x.GetBound(), y.GetBound()) {
+		return nil
+	}
+
+	xnode, xok := x.(*bvhNode[BoundType])
+	ynode, yok := y.(*bvhNode[BoundType])
+	if !xok && !yok {
+		return fn(x, y)
+	}
+
+	// descend into the node with more children, keeping the order of x and y:
+	if xok && (!yok || len(xnode.children) >= len(ynode.children)) {
+		for _, child := range xnode.children {
+			if child != nil {
+				err := crossPairs(child, y, prune, fn)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	} else {
+		for _, child := range ynode.children {
+			if child != nil {
+				err := crossPairs(x, child, prune, fn)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package gobvh
+
+import (
+	"math"
+	"testing"
+)
+
+// ========================================================
+
+// minimum distance between two boxes, a DistanceFunc[AABB2D]
+func distanceBoxBox2D(a AABB2D, b AABB2D) float64 {
+	var dist float64
+	for i := 0; i < 2; i++ {
+		gap := math.Max(0.0, math.Max(a.L[i]-b.H[i], b.L[i]-a.H[i]))
+		dist += gap * gap
+	}
+	return math.Sqrt(dist)
+}
+
+func TestBVHNeighborGraph(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	points := make([]Point2D, 0, 256)
+	var x, y float64
+	for x = 0.0; x < 16.0; x += 1.0 {
+		for y = 0.0; y < 16.0; y += 1.0 {
+			points = append(points, Point2D{x, y})
+			bvh.Insert(Point2D{x, y})
+		}
+	}
+
+	edges := 0
+	seen := make(map[[2]Point2D]bool)
+	err := bvh.NeighborEdges(1.0, distanceBoxBox2D, func(a Boundable[AABB2D], b Boundable[AABB2D]) error {
+		pa, pb := a.(Point2D), b.(Point2D)
+		if distance2D(pa, pb) > 1.0 {
+			t.Errorf("Edge (%v, %v) exceeds the radius", pa, pb)
+		}
+		if seen[[2]Point2D{pa, pb}] || seen[[2]Point2D{pb, pa}] || pa == pb {
+			t.Errorf("Edge (%v, %v) reported twice", pa, pb)
+		}
+		seen[[2]Point2D{pa, pb}] = true
+		edges++
+		return nil
+	})
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if edges != 2*16*15 {
+		t.Errorf("Expected %d edges, found %d", 2*16*15, edges)
+	}
+
+	// compare against brute force with a larger radius:
+	graph := bvh.NeighborGraph(2.5, distanceBoxBox2D)
+	for _, p := range points {
+		expected := 0
+		for _, q := range points {
+			if p != q && distance2D(p, q) <= 2.5 {
+				expected++
+			}
+		}
+		if len(graph[p]) != expected {
+			t.Errorf("Expected %d neighbors for %v, found %d", expected, p, len(graph[p]))
+		}
+	}
+}
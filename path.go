@@ -0,0 +1,111 @@
+package gobvh
+
+// ==============================================
+
+//
+// BVH.FindAlongPath(path, hit, fn) finds every element crossed by a path,
+// the polyline through the points of path (each with one entry per
+// dimension), e.g. a route checked against obstacles.
+//
+// Segment i runs from path[i] to path[i+1], as NewSegment().  For every
+// element crossed, fn(element, segment, t) is called once, for the first
+// segment along the path which hits it, with the parameter 0 <= t <= 1 of
+// the hit along that segment.  Elements are reported in no particular order.
+//
+// hit(element, ray) is the exact test of an element against a segment,
+// called only when the segment enters the element's bound; if it is nil,
+// entering the bound counts as a hit, at the parameter where it is entered.
+// The hierarchy is descended once for the whole path, each node being
+// tested only against the segments which enter its parent.  An error
+// returned by hit or fn stops the search and is returned.
+//
+func (bvh *BVH[BoundType]) FindAlongPath(path [][]float64, hit RayHitFunc[BoundType], fn func(element Boundable[BoundType], segment int, t float64) error) error {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryAlongPath, Path: path, Hit: hit})
+	}
+	if len(path) < 2 || len(bvh.root.children) == 0 {
+		return nil
+	}
+	pw := pathWalker[BoundType]{
+		segments: make([]Ray, len(path)-1),
+		entries:  make([]func(BoundType) (float64, bool), len(path)-1),
+		hit:      hit,
+		fn:       fn,
+		touch:    bvh.hit,
+	}
+	active := make([]int, 0, 4*len(pw.segments))
+	for i := range pw.segments {
+		pw.segments[i] = NewSegment(path[i], path[i+1])
+		pw.entries[i] = bvh.rayEntry(pw.segments[i])
+		active = append(active, i)
+	}
+	return pw.walk(&bvh.root, active)
+}
+
+// ==============================================
+
+// the state of FindAlongPath()
+type pathWalker[BoundType any] struct {
+	segments []Ray
+	entries  []func(BoundType) (float64, bool)
+	hit      RayHitFunc[BoundType]
+	fn       func(Boundable[BoundType], int, float64) error
+	touch    func(Boundable[BoundType]) // reports elements crossed to the evictor
+}
+
+// ..............................................
+
+// visits node with the (ascending) indices of the segments which may reach
+// it; those entering node are appended after them, in the same slice, for
+// its children
+func (pw *pathWalker[BoundType]) walk(node *bvhNode[BoundType], active []int) error {
+	start := len(active)
+	for _, segment := range active {
+		if _, ok := pw.entries[segment](node.bound); ok {
+			active = append(active, segment)
+		}
+	}
+	entering := active[start:]
+	if len(entering) == 0 {
+		return nil
+	}
+
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			if err := pw.walk(value, entering); err != nil {
+				return err
+			}
+		} else if child != nil {
+			if err := pw.element(child, entering); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ..............................................
+
+// reports element for the first of the segments which hits it
+func (pw *pathWalker[BoundType]) element(element Boundable[BoundType], segments []int) error {
+	bound := element.GetBound()
+	for _, segment := range segments {
+		tnear, ok := pw.entries[segment](bound)
+		if !ok {
+			continue
+		}
+		if pw.hit != nil {
+			t, ok, err := pw.hit(element, pw.segments[segment])
+			if err != nil {
+				return err
+			}
+			if !ok || t < 0.0 || t > 1.0 {
+				continue
+			}
+			tnear = t
+		}
+		pw.touch(element)
+		return pw.fn(element, segment, tnear)
+	}
+	return nil
+}
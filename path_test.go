@@ -0,0 +1,85 @@
+package gobvh
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHFindAlongPath(t *testing.T) {
+	rng := rand.New(rand.NewSource(778))
+	boxes := randomBoxes(rng, 2000, 2.0)
+	bvh := New[AABB2D](Traits2D{})
+	for _, box := range boxes {
+		bvh.Insert(box)
+	}
+	path := [][]float64{{5.0, 5.0}, {90.0, 20.0}, {20.0, 60.0}, {95.0, 95.0}, {60.0, 10.0}}
+
+	// the first segment entering each box, by brute force:
+	expected := make(map[Boundable[AABB2D]]int)
+	for _, box := range boxes {
+		for i := 0; i+1 < len(path); i++ {
+			if _, _, ok := rayBoxInterval[AABB2D](Traits2D{}, NewSegment(path[i], path[i+1]), box.GetBound()); ok {
+				expected[box] = i
+				break
+			}
+		}
+	}
+
+	found := make(map[Boundable[AABB2D]]int)
+	err := bvh.FindAlongPath(path, nil, func(element Boundable[AABB2D], segment int, param float64) error {
+		if _, ok := found[element]; ok {
+			t.Errorf("Element %v reported twice", element)
+		}
+		if param < 0.0 || param > 1.0 {
+			t.Errorf("Expected a segment parameter in [0, 1], found %g", param)
+		}
+		found[element] = segment
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != len(expected) || len(found) == 0 {
+		t.Errorf("Expected %d elements along the path, found %d", len(expected), len(found))
+	}
+	for element, segment := range expected {
+		if found[element] != segment {
+			t.Errorf("Expected %v first hit by segment %d, found %d", element, segment, found[element])
+		}
+	}
+
+	// an exact test which rejects the hit passes the element on to later segments:
+	rejected := 0
+	bvh.FindAlongPath(path, func(element Boundable[AABB2D], ray Ray) (float64, bool, error) {
+		tnear, _, ok := rayBoxInterval[AABB2D](Traits2D{}, ray, element.GetBound())
+		return tnear, ok && ray.Origin[0] != path[0][0], nil
+	}, func(element Boundable[AABB2D], segment int, param float64) error {
+		if segment == 0 {
+			t.Errorf("Expected no hits on the first segment")
+		}
+		if expected[element] == 0 {
+			rejected++
+		}
+		return nil
+	})
+	if rejected == 0 {
+		t.Errorf("Expected elements rejected by the first segment to be hit by later ones")
+	}
+
+	// errors stop the search:
+	stop := errors.New("stop")
+	calls := 0
+	err = bvh.FindAlongPath(path, nil, func(Boundable[AABB2D], int, float64) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("Expected the search to stop at the first error, found %v after %d calls", err, calls)
+	}
+	if err := bvh.FindAlongPath(path[:1], nil, nil); err != nil {
+		t.Errorf("Expected a path of one point to find nothing, found %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package gobvh
+
+// ==============================================
+
+//
+// QueryOption adjusts a single search, see FindAll() and FindNearest().
+//
+type QueryOption[BoundType any] func(*traversal[BoundType])
+
+// ..............................................
+
+//
+// WithQueryTransform(fn) presents every node bound to the searcher as fn(bound).
+//
+// This allows a hierarchy built in one space to be searched in another
+// without transforming the elements: for example, a world-space BVH can be
+// queried with an object-space frustum or ray by passing the world-to-object
+// transform.  fn must be conservative, i.e. return a bound in the target
+// space which contains everything inside the original bound.
+//
+// Only node bounds are transformed.  Elements are passed to
+// searcher.Evaluate() unchanged, so the searcher remains responsible for
+// transforming any element it examines.
+//
+func WithQueryTransform[BoundType any](fn func(BoundType) BoundType) QueryOption[BoundType] {
+	return func(trav *traversal[BoundType]) {
+		trav.transform = fn
+	}
+}
+
+// ..............................................
+
+//
+// WithElementFilter(pred) hides the elements for which pred(element) is false.
+//
+// Hidden elements are never passed to searcher.Evaluate(), so they do not
+// affect the search; e.g. they cannot shrink the region of interest of a
+// nearest neighbor search.  Filters given by several options must all pass.
+//
+func WithElementFilter[BoundType any](pred func(Boundable[BoundType]) bool) QueryOption[BoundType] {
+	return func(trav *traversal[BoundType]) {
+		previous := trav.elemfilter
+		if previous == nil {
+			trav.elemfilter = pred
+		} else {
+			trav.elemfilter = func(element Boundable[BoundType]) bool {
+				return previous(element) && pred(element)
+			}
+		}
+	}
+}
+
+// ..............................................
+
+// like WithElementFilter(), for nodes: subtrees for which pred(node) is false are not visited
+func withNodeFilter[BoundType any](pred func(*bvhNode[BoundType]) bool) QueryOption[BoundType] {
+	return func(trav *traversal[BoundType]) {
+		previous := trav.nodefilter
+		if previous == nil {
+			trav.nodefilter = pred
+		} else {
+			trav.nodefilter = func(node *bvhNode[BoundType]) bool {
+				return previous(node) && pred(node)
+			}
+		}
+	}
+}
+
+// ==============================================
+
+//
+// Projection maps a search into a derived space, e.g. world-space bounds
+// into screen space for a camera, see WithProjection().
+//
+// ProjectBound(bound) projects a node bound; it must be conservative, i.e.
+// contain the projection of everything inside bound.  Project(element)
+// projects a single element, as tightly as possible.
+//
+type Projection[BoundType any] interface {
+	ProjectBound(bound BoundType) BoundType
+	Project(element Boundable[BoundType]) BoundType
+}
+
+// ..............................................
+
+//
+// Projected is an element as presented to a searcher by a projected search:
+// GetBound() returns the projected bound, and Element is the stored element.
+//
+type Projected[BoundType any] struct {
+	Element Boundable[BoundType]
+	Bound   BoundType
+}
+
+func (p *Projected[BoundType]) GetBound() BoundType {
+	return p.Bound
+}
+
+// ..............................................
+
+//
+// WithProjection(projection) runs the search in the space of projection,
+// while the hierarchy stays in its own (e.g. world) space.
+//
+// Node bounds are presented to the searcher as projection.ProjectBound(bound),
+// replacing any WithQueryTransform(); elements are passed to
+// searcher.Evaluate() as *Projected, whose bound is projection.Project(element),
+// so a searcher written for the derived space (e.g. a screen-space picking
+// rectangle) works unchanged.  Use Projected.Element to recover the element.
+//
+func WithProjection[BoundType any](projection Projection[BoundType]) QueryOption[BoundType] {
+	return func(trav *traversal[BoundType]) {
+		trav.transform = projection.ProjectBound
+		trav.project = projection.Project
+	}
+}
+
+// ==============================================
+
+//
+// BVH.WithExclusions(excluded) is a QueryOption which ignores the elements
+// in the set excluded, e.g. so that an object can search its surroundings
+// without finding itself or its own parts.
+//
+// As with WithElementFilter(), excluded elements do not affect the search
+// (e.g. they cannot shrink the region of a nearest neighbor search), and
+// subtrees holding only excluded elements are not visited at all.  The set
+// is read when the search starts, in time proportional to its size.
+//
+func (bvh *BVH[BoundType]) WithExclusions(excluded map[Boundable[BoundType]]struct{}) QueryOption[BoundType] {
+	return func(trav *traversal[BoundType]) {
+		if len(excluded) == 0 {
+			return
+		}
+		// count the excluded elements beneath each node:
+		counts := make(map[*bvhNode[BoundType]]int)
+		for element := range excluded {
+			for node := bvh.findContainer(&bvh.root, element, element.GetBound()); node != nil; node = node.parent {
+				counts[node]++
+			}
+		}
+		withNodeFilter(func(node *bvhNode[BoundType]) bool {
+			return counts[node] < node.elements
+		})(trav)
+		WithElementFilter(func(element Boundable[BoundType]) bool {
+			_, ok := excluded[element]
+			return !ok
+		})(trav)
+	}
+}
@@ -0,0 +1,177 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+// Searcher that works in object space, translated by (-10, -10) from world space:
+type ObjectSpaceSearcher struct {
+	Region    func(AABB2D) bool
+	Found     int
+	Evaluated int
+}
+
+func toObjectSpace(b AABB2D) AABB2D {
+	return AABB2D{Point2D{b.L[0] - 10.0, b.L[1] - 10.0}, Point2D{b.H[0] - 10.0, b.H[1] - 10.0}}
+}
+
+func (os *ObjectSpaceSearcher) DoesIntersect(b AABB2D) bool {
+	return os.Region(b)
+}
+
+func (os *ObjectSpaceSearcher) Evaluate(element Boundable[AABB2D]) error {
+	os.Evaluated++
+	if os.Region(toObjectSpace(element.GetBound())) {
+		os.Found++
+	}
+	return nil
+}
+
+// ........................................................
+
+func TestBVHQueryTransform(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	var x, y float64
+	for x = 0.0; x < 16.0; x += 1.0 {
+		for y = 0.0; y < 16.0; y += 1.0 {
+			bvh.Insert(Point2D{x, y})
+		}
+	}
+
+	searcher := ObjectSpaceSearcher{Region: boxPredicate(AABB2D{Point2D{0.0, 0.0}, Point2D{1.0, 1.0}})}
+	err := bvh.FindAll(&searcher, WithQueryTransform(toObjectSpace))
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if searcher.Found != 4 {
+		t.Errorf("Expected 4 elements in the object space region, found %d", searcher.Found)
+	}
+	if searcher.Evaluated >= 64 {
+		t.Errorf("Expected the transformed node bounds to prune the search, %d elements evaluated", searcher.Evaluated)
+	}
+
+	// FindNearest starts from a world space location:
+	searcher = ObjectSpaceSearcher{Region: boxPredicate(AABB2D{Point2D{0.0, 0.0}, Point2D{1.0, 1.0}})}
+	bvh.FindNearest(&searcher, Point2D{10.0, 10.0}.GetBound(), WithQueryTransform(toObjectSpace))
+	if searcher.Found != 4 {
+		t.Errorf("Expected 4 elements from FindNearest in object space, found %d", searcher.Found)
+	}
+}
+
+// ........................................................
+
+// a projection into a "screen space", scaled and offset from world space:
+type ScreenProjection2D struct{}
+
+func (sp ScreenProjection2D) ProjectBound(bound AABB2D) AABB2D {
+	return AABB2D{L: Point2D{bound.L[0]*2.0 + 10.0, bound.L[1] * 0.5}, H: Point2D{bound.H[0]*2.0 + 10.0, bound.H[1] * 0.5}}
+}
+
+func (sp ScreenProjection2D) Project(element Boundable[AABB2D]) AABB2D {
+	return sp.ProjectBound(element.GetBound())
+}
+
+func TestBVHProjection(t *testing.T) {
+	rng := rand.New(rand.NewSource(74))
+	boxes := randomBoxes(rng, 1500, 3.0)
+	bvh := New[AABB2D](Traits2D{})
+	for _, element := range boxes {
+		bvh.Insert(element)
+	}
+	projection := ScreenProjection2D{}
+
+	for i := 0; i < 40; i++ {
+		x, y := rng.Float64()*200.0+10.0, rng.Float64()*50.0
+		screen := AABB2D{L: Point2D{x, y}, H: Point2D{x + 20.0, y + 5.0}}
+		expected := map[Boundable[AABB2D]]bool{}
+		for _, element := range boxes {
+			if boundsOverlap[AABB2D](Traits2D{}, screen, projection.Project(element)) {
+				expected[element] = true
+			}
+		}
+
+		bc := BoxCollector{Box: screen}
+		if err := bvh.FindAll(&bc, WithProjection[AABB2D](projection)); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if len(bc.Found) != len(expected) {
+			t.Errorf("Expected %d elements in the screen box, found %d", len(expected), len(bc.Found))
+		}
+		for _, found := range bc.Found {
+			projected, ok := found.(*Projected[AABB2D])
+			if !ok {
+				t.Fatalf("Expected elements to be presented as *Projected")
+			}
+			if !expected[projected.Element] || projected.Bound != projection.Project(projected.Element) {
+				t.Errorf("Unexpected element %v", projected.Element.GetBound())
+			}
+		}
+	}
+}
+
+// ........................................................
+
+// counts the node bounds a searcher is asked about
+type CountingCollector struct {
+	BoxCollector
+	Tested int
+}
+
+func (cc *CountingCollector) DoesIntersect(bound AABB2D) bool {
+	cc.Tested++
+	return cc.BoxCollector.DoesIntersect(bound)
+}
+
+func TestBVHExclusions(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	self := make(map[Boundable[AABB2D]]struct{})
+	var x, y float64
+	for x = 0.0; x < 32.0; x += 1.0 {
+		for y = 0.0; y < 32.0; y += 1.0 {
+			bvh.Insert(Point2D{x, y})
+			if x < 8.0 && y < 8.0 {
+				self[Point2D{x, y}] = struct{}{} // an object and its parts
+			}
+		}
+	}
+
+	everywhere := AABB2D{Point2D{-1.0, -1.0}, Point2D{33.0, 33.0}}
+	excluded := CountingCollector{BoxCollector: BoxCollector{Box: everywhere}}
+	bvh.FindAll(&excluded, bvh.WithExclusions(self))
+	if len(excluded.Found) != 32*32-64 {
+		t.Errorf("Expected %d elements, found %d", 32*32-64, len(excluded.Found))
+	}
+	for _, element := range excluded.Found {
+		if _, ok := self[element]; ok {
+			t.Errorf("Found excluded element %v", element)
+		}
+	}
+
+	// subtrees of excluded elements are skipped, unlike with a filter:
+	filtered := CountingCollector{BoxCollector: BoxCollector{Box: everywhere}}
+	bvh.FindAll(&filtered, WithElementFilter(func(element Boundable[AABB2D]) bool {
+		_, ok := self[element]
+		return !ok
+	}))
+	if len(filtered.Found) != len(excluded.Found) || excluded.Tested >= filtered.Tested {
+		t.Errorf("Expected fewer nodes tested with exclusions, tested %d and %d", excluded.Tested, filtered.Tested)
+	}
+
+	// the nearest neighbor of an excluded element is outside the set:
+	target := Point2D{0.0, 0.0}
+	knn := NewKNearest(1, target.GetBound(), distanceBoxBox2D)
+	bvh.FindNearest(knn, target.GetBound(), bvh.WithExclusions(self))
+	if results := knn.Results(); len(results) != 1 || (results[0] != Point2D{8.0, 0.0} && results[0] != Point2D{0.0, 8.0}) {
+		t.Errorf("Expected the nearest element outside the object, found %v", results)
+	}
+
+	// an empty set excludes nothing:
+	all := BoxCollector{Box: everywhere}
+	bvh.FindAll(&all, bvh.WithExclusions(nil))
+	if len(all.Found) != 32*32 {
+		t.Errorf("Expected %d elements, found %d", 32*32, len(all.Found))
+	}
+}
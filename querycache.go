@@ -0,0 +1,140 @@
+package gobvh
+
+import (
+	"container/list"
+)
+
+// ==============================================
+
+//
+// BVH.Version() returns a counter which changes whenever elements are
+// inserted or erased, so callers can cheaply tell whether anything
+// has changed since they last looked.
+//
+func (bvh *BVH[BoundType]) Version() uint64 {
+	return bvh.version
+}
+
+// ==============================================
+
+//
+// QueryCache memoizes the results of recent region queries on a BVH, for
+// workloads (e.g. a UI re-issuing the same viewport query every frame)
+// which repeat identical queries between changes.
+//
+// Results are remembered for the size most recently used regions.  When
+// the bvh has changed, only the results whose regions overlap the bvh's
+// DirtyBounds() are discarded; if ClearDirty() has been called in the
+// meantime the changed regions are unknown and every result is discarded.
+//
+// The bound type must be comparable, as regions are looked up by value.
+// A QueryCache is not safe for concurrent use.
+//
+type QueryCache[BoundType comparable] struct {
+	bvh     *BVH[BoundType]
+	size    int
+	order   list.List // of *queryResult, most recently used first
+	entries map[BoundType]*list.Element
+	version uint64 // bvh.Version() when the results were last validated
+	epoch   uint64 // bvh.dirtyepoch when the results were last validated
+}
+
+type queryResult[BoundType any] struct {
+	region   BoundType
+	elements []Boundable[BoundType]
+}
+
+// ..............................................
+
+//
+// NewQueryCache(bvh, size) returns an empty cache of up to size
+// query results on bvh (at least one).
+//
+func NewQueryCache[BoundType comparable](bvh *BVH[BoundType], size int) *QueryCache[BoundType] {
+	return &QueryCache[BoundType]{
+		bvh:     bvh,
+		size:    maxInt(size, 1),
+		entries: make(map[BoundType]*list.Element),
+		version: bvh.version,
+		epoch:   bvh.dirtyepoch,
+	}
+}
+
+// ..............................................
+
+//
+// QueryCache.Query(region) returns the elements whose bounds intersect
+// region, as FindAllIntersecting() would find them, from the cache when
+// it can.
+//
+// The slice returned is shared with the cache and must not be modified.
+//
+func (qc *QueryCache[BoundType]) Query(region BoundType) []Boundable[BoundType] {
+	qc.validate()
+	if entry, ok := qc.entries[region]; ok {
+		qc.order.MoveToFront(entry)
+		return entry.Value.(*queryResult[BoundType]).elements
+	}
+
+	elements := []Boundable[BoundType]{}
+	qc.bvh.FindAllIntersecting(region, func(element Boundable[BoundType]) error {
+		elements = append(elements, element)
+		return nil
+	})
+	qc.entries[region] = qc.order.PushFront(&queryResult[BoundType]{region: region, elements: elements})
+	if qc.order.Len() > qc.size {
+		oldest := qc.order.Back()
+		qc.order.Remove(oldest)
+		delete(qc.entries, oldest.Value.(*queryResult[BoundType]).region)
+	}
+	return elements
+}
+
+// ..............................................
+
+//
+// QueryCache.Len() returns the number of query results held.
+//
+func (qc *QueryCache[BoundType]) Len() int {
+	return qc.order.Len()
+}
+
+// ..............................................
+
+//
+// QueryCache.Reset() discards every query result.
+//
+func (qc *QueryCache[BoundType]) Reset() {
+	qc.order.Init()
+	for region := range qc.entries {
+		delete(qc.entries, region)
+	}
+}
+
+// ==============================================
+
+// discards the results which changes to the bvh may have invalidated
+func (qc *QueryCache[BoundType]) validate() {
+	bvh := qc.bvh
+	if qc.version == bvh.version {
+		return
+	}
+	if qc.epoch != bvh.dirtyepoch {
+		qc.Reset()
+	} else {
+		// every change since the last validation lies within the dirty bounds:
+		for entry := qc.order.Front(); entry != nil; {
+			next := entry.Next()
+			result := entry.Value.(*queryResult[BoundType])
+			for _, dirty := range bvh.dirtybounds {
+				if boundsOverlap(bvh.boundtraits, result.region, dirty) {
+					qc.order.Remove(entry)
+					delete(qc.entries, result.region)
+					break
+				}
+			}
+			entry = next
+		}
+	}
+	qc.version, qc.epoch = bvh.version, bvh.dirtyepoch
+}
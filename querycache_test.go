@@ -0,0 +1,79 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestQueryCache(t *testing.T) {
+	rng := rand.New(rand.NewSource(67))
+	boxes := randomBoxes(rng, 1000, 3.0)
+	bvh := New[AABB2D](Traits2D{})
+	for _, element := range boxes[:900] {
+		bvh.Insert(element)
+	}
+	cache := NewQueryCache(bvh, 2)
+
+	left := AABB2D{L: Point2D{0, 0}, H: Point2D{30, 30}}
+	right := AABB2D{L: Point2D{70, 70}, H: Point2D{100, 100}}
+	middle := AABB2D{L: Point2D{40, 40}, H: Point2D{60, 60}}
+	check := func(region AABB2D) []Boundable[AABB2D] {
+		found := cache.Query(region)
+		if expected := bvh.Count(region); len(found) != expected {
+			t.Errorf("Expected %d elements from the cache, found %d", expected, len(found))
+		}
+		return found
+	}
+
+	first := check(left)
+	check(right)
+	if again := cache.Query(left); len(first) == 0 || &again[0] != &first[0] {
+		t.Errorf("Expected a repeated query to be served from the cache")
+	}
+
+	// the least recently used result is dropped:
+	check(middle)
+	if cache.Len() != 2 {
+		t.Errorf("Expected the cache to hold 2 results, found %d", cache.Len())
+	}
+	if _, ok := cache.entries[right]; ok {
+		t.Errorf("Expected the least recently used result to be dropped")
+	}
+
+	// a change only invalidates the results it overlaps:
+	bvh.ClearDirty()
+	version := bvh.Version()
+	check(left)
+	check(middle)
+	changed := &Box2D{AABB2D{L: Point2D{50, 50}, H: Point2D{51, 51}}}
+	bvh.Insert(changed)
+	if bvh.Version() == version {
+		t.Errorf("Expected Insert() to change the version")
+	}
+	kept := cache.Query(left)
+	if _, ok := cache.entries[middle]; ok {
+		t.Errorf("Expected the result overlapping the change to be dropped")
+	}
+	if again := cache.Query(left); &again[0] != &kept[0] {
+		t.Errorf("Expected the result away from the change to be kept")
+	}
+	found := check(middle)
+	contains := false
+	for _, element := range found {
+		contains = contains || element == changed
+	}
+	if !contains {
+		t.Errorf("Expected the refreshed result to contain the inserted element")
+	}
+
+	// after ClearDirty() the changed regions are unknown:
+	bvh.ClearDirty()
+	bvh.Erase(changed)
+	check(left)
+	if cache.Len() != 1 {
+		t.Errorf("Expected every result to be dropped, found %d", cache.Len())
+	}
+	check(middle)
+}
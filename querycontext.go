@@ -0,0 +1,227 @@
+package gobvh
+
+import (
+	"context"
+)
+
+// ==============================================
+
+//
+// QueryContext carries the state of one search, so that the searcher itself
+// can be stateless, see ContextSearcher and ContextCrawler.
+//
+// Context (if not nil) cancels the search, or sets its deadline; it is
+// checked periodically, and once it is done the search stops and Err()
+// reports why.  Scratch is free for the searcher, e.g. for the best match
+// so far of a nearest neighbor search.  Stats counts the work done, and
+// accumulates if the QueryContext is used for several searches.
+//
+// A QueryContext must not be shared between concurrent searches; give
+// each goroutine its own, and share the searcher.
+//
+type QueryContext struct {
+	Context context.Context
+	Scratch any
+	Stats   QueryStats
+
+	calls int
+	err   error
+}
+
+// ..............................................
+
+//
+// QueryStats counts the work of searches: NodesTested is the number of node
+// bounds given to DoesIntersect(), and ElementsEvaluated the number of
+// elements given to Evaluate().
+//
+type QueryStats struct {
+	NodesTested       int
+	ElementsEvaluated int
+}
+
+// ..............................................
+
+//
+// NewQueryContext(ctx, scratch) returns a QueryContext for searches
+// cancelled by ctx (which may be nil), with the given scratch state.
+//
+func NewQueryContext(ctx context.Context, scratch any) *QueryContext {
+	return &QueryContext{Context: ctx, Scratch: scratch}
+}
+
+// ..............................................
+
+//
+// QueryContext.Err() returns the error of the Context which stopped a
+// search, or nil.
+//
+func (qc *QueryContext) Err() error {
+	return qc.err
+}
+
+// ..............................................
+
+//
+// QueryContext.Reset() clears the statistics and any cancellation, for
+// reuse with a new Context or Scratch.
+//
+func (qc *QueryContext) Reset() {
+	qc.Stats = QueryStats{}
+	qc.calls = 0
+	qc.err = nil
+}
+
+// ==============================================
+
+//
+// ContextSearcher is a Searcher whose state lives in a QueryContext rather
+// than in the searcher, so one searcher may serve many searches at once,
+// and need not be reset between them.  See BVH.FindAllWith().
+//
+type ContextSearcher[BoundType any] interface {
+	DoesIntersect(qc *QueryContext, bound BoundType) bool
+	Evaluate(qc *QueryContext, element Boundable[BoundType]) error
+}
+
+// ..............................................
+
+//
+// ContextCrawler is a BVHCrawler whose state lives in a QueryContext, see
+// ContextSearcher and BVH.ForEachWith().
+//
+type ContextCrawler[BoundType any] interface {
+	BeginBound(qc *QueryContext, b BoundType) error
+	EndBound(qc *QueryContext, b BoundType) error
+	Evaluate(qc *QueryContext, element Boundable[BoundType]) error
+}
+
+// ==============================================
+
+//
+// BVH.FindAllWith(qc, searcher, options...) is FindAll() for a ContextSearcher.
+//
+// If qc.Context is done before the search completes, the search stops and
+// its error is returned.
+//
+func (bvh *BVH[BoundType]) FindAllWith(qc *QueryContext, s ContextSearcher[BoundType], opts ...QueryOption[BoundType]) error {
+	if qc.cancelled(true) {
+		return qc.err
+	}
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QuerySearchWith, QueryContext: qc, ContextSearcher: s, Options: opts})
+	}
+	return qc.result(bvh.findAll(&querySearcher[BoundType]{qc: qc, searcher: s}, opts))
+}
+
+// ..............................................
+
+//
+// BVH.FindNearestWith(qc, searcher, here, options...) is FindNearest() for a
+// ContextSearcher.
+//
+// If qc.Context is done before the search completes, the search stops and
+// its error is returned.
+//
+func (bvh *BVH[BoundType]) FindNearestWith(qc *QueryContext, s ContextSearcher[BoundType], here BoundType, opts ...QueryOption[BoundType]) error {
+	if qc.cancelled(true) {
+		return qc.err
+	}
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryNearestWith, QueryContext: qc, ContextSearcher: s, Bound: here, Options: opts})
+	}
+	return qc.result(bvh.findNearest(&querySearcher[BoundType]{qc: qc, searcher: s}, here, bvh.newTraversal(true, opts)))
+}
+
+// ..............................................
+
+//
+// BVH.ForEachWith(qc, crawler) is ForEach() for a ContextCrawler.
+//
+// If qc.Context is done before the crawl completes, the crawl stops and
+// its error is returned.
+//
+func (bvh *BVH[BoundType]) ForEachWith(qc *QueryContext, crawler ContextCrawler[BoundType]) error {
+	if qc.cancelled(true) {
+		return qc.err
+	}
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryCrawlWith, QueryContext: qc, ContextCrawler: crawler})
+	}
+	return qc.result(forEachNode[BoundType](&queryCrawler[BoundType]{qc: qc, crawler: crawler}, &bvh.root, &bvh.root, false))
+}
+
+// ==============================================
+
+// checks the context every contextCheckInterval calls, or now if asked
+func (qc *QueryContext) cancelled(now bool) bool {
+	if qc.err == nil && qc.Context != nil {
+		qc.calls++
+		if now || qc.calls%contextCheckInterval == 0 {
+			qc.err = qc.Context.Err()
+		}
+	}
+	return qc.err != nil
+}
+
+// ..............................................
+
+// prefers the context's error over the result of the search
+func (qc *QueryContext) result(err error) error {
+	if qc.err != nil {
+		return qc.err
+	}
+	return err
+}
+
+// ..............................................
+
+// querySearcher adapts a ContextSearcher to a Searcher
+type querySearcher[BoundType any] struct {
+	qc       *QueryContext
+	searcher ContextSearcher[BoundType]
+}
+
+func (qs *querySearcher[BoundType]) DoesIntersect(bound BoundType) bool {
+	if qs.qc.cancelled(false) {
+		return false
+	}
+	qs.qc.Stats.NodesTested++
+	return qs.searcher.DoesIntersect(qs.qc, bound)
+}
+
+func (qs *querySearcher[BoundType]) Evaluate(element Boundable[BoundType]) error {
+	if qs.qc.cancelled(false) {
+		return qs.qc.err
+	}
+	qs.qc.Stats.ElementsEvaluated++
+	return qs.searcher.Evaluate(qs.qc, element)
+}
+
+// ..............................................
+
+// queryCrawler adapts a ContextCrawler to a BVHCrawler
+type queryCrawler[BoundType any] struct {
+	qc      *QueryContext
+	crawler ContextCrawler[BoundType]
+}
+
+func (qw *queryCrawler[BoundType]) BeginBound(b BoundType) error {
+	if qw.qc.cancelled(false) {
+		return qw.qc.err
+	}
+	qw.qc.Stats.NodesTested++
+	return qw.crawler.BeginBound(qw.qc, b)
+}
+
+func (qw *queryCrawler[BoundType]) EndBound(b BoundType) error {
+	return qw.crawler.EndBound(qw.qc, b)
+}
+
+func (qw *queryCrawler[BoundType]) Evaluate(element Boundable[BoundType]) error {
+	if qw.qc.cancelled(false) {
+		return qw.qc.err
+	}
+	qw.qc.Stats.ElementsEvaluated++
+	return qw.crawler.Evaluate(qw.qc, element)
+}
@@ -0,0 +1,130 @@
+package gobvh
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+)
+
+// ========================================================
+
+// a stateless nearest neighbor searcher; the best match so far is the scratch state
+type StatelessNearest2D struct{}
+
+type nearestScratch2D struct {
+	target   Point2D
+	best     Boundable[AABB2D]
+	distance float64
+}
+
+func (sn StatelessNearest2D) DoesIntersect(qc *QueryContext, bound AABB2D) bool {
+	scratch := qc.Scratch.(*nearestScratch2D)
+	return distanceBoxBox2D(scratch.target.GetBound(), bound) <= scratch.distance
+}
+
+func (sn StatelessNearest2D) Evaluate(qc *QueryContext, element Boundable[AABB2D]) error {
+	scratch := qc.Scratch.(*nearestScratch2D)
+	if d := distance2D(scratch.target, element.(Point2D)); d < scratch.distance {
+		scratch.best, scratch.distance = element, d
+	}
+	return nil
+}
+
+// counts elements, and cancels a context partway through
+type CancellingCrawler2D struct {
+	CancelAt int
+	Cancel   context.CancelFunc
+}
+
+func (cc CancellingCrawler2D) BeginBound(qc *QueryContext, bound AABB2D) error {
+	return nil
+}
+
+func (cc CancellingCrawler2D) EndBound(qc *QueryContext, bound AABB2D) error {
+	return nil
+}
+
+func (cc CancellingCrawler2D) Evaluate(qc *QueryContext, element Boundable[AABB2D]) error {
+	if qc.Stats.ElementsEvaluated == cc.CancelAt {
+		cc.Cancel()
+	}
+	return nil
+}
+
+// ........................................................
+
+func TestBVHQueryContext(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	for x := 0; x < 50; x++ {
+		for y := 0; y < 50; y++ {
+			bvh.Insert(Point2D{float64(x), float64(y)})
+		}
+	}
+
+	// one searcher serves concurrent searches, each with its own context:
+	var searcher StatelessNearest2D
+	var wg sync.WaitGroup
+	failures := make(chan string, 64)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				target := Point2D{float64(g*6) + 0.4, float64(i*2) + 0.3}
+				qc := NewQueryContext(nil, &nearestScratch2D{target: target, distance: math.Inf(1)})
+				if err := bvh.FindNearestWith(qc, searcher, target.GetBound()); err != nil {
+					failures <- err.Error()
+					return
+				}
+				expected := Point2D{math.Round(target[0]), math.Round(target[1])}
+				if best := qc.Scratch.(*nearestScratch2D).best; best != expected {
+					failures <- "wrong nearest neighbor"
+					return
+				}
+				if qc.Stats.ElementsEvaluated == 0 || qc.Stats.ElementsEvaluated >= 2500 {
+					failures <- "unexpected statistics"
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(failures)
+	for failure := range failures {
+		t.Error(failure)
+	}
+
+	// FindAllWith visits everything of interest:
+	target := Point2D{10.0, 10.0}
+	qc := NewQueryContext(context.Background(), &nearestScratch2D{target: target, distance: 1.5})
+	if err := bvh.FindAllWith(qc, searcher); err != nil {
+		t.Error(err)
+	}
+	if best := qc.Scratch.(*nearestScratch2D).best; best != target {
+		t.Errorf("Expected to find %v, found %v", target, best)
+	}
+
+	// cancellation stops a crawl:
+	ctx, cancel := context.WithCancel(context.Background())
+	qc = NewQueryContext(ctx, nil)
+	err := bvh.ForEachWith(qc, CancellingCrawler2D{CancelAt: 100, Cancel: cancel})
+	if !errors.Is(err, context.Canceled) || !errors.Is(qc.Err(), context.Canceled) {
+		t.Errorf("Expected the crawl to be cancelled, found %v", err)
+	}
+	if n := qc.Stats.ElementsEvaluated; n < 100 || n >= 2500 {
+		t.Errorf("Expected the crawl to stop soon after cancellation, evaluated %d", n)
+	}
+
+	// a done context stops a search before it starts:
+	qc = NewQueryContext(ctx, &nearestScratch2D{target: target, distance: math.Inf(1)})
+	if err := bvh.FindAllWith(qc, searcher); !errors.Is(err, context.Canceled) || qc.Stats.NodesTested != 0 {
+		t.Errorf("Expected a cancelled search, found %v", err)
+	}
+	qc.Reset()
+	qc.Context = nil
+	if err := bvh.FindAllWith(qc, searcher); err != nil || qc.Stats.ElementsEvaluated == 0 {
+		t.Errorf("Expected a reset context to search, found %v", err)
+	}
+}
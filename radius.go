@@ -0,0 +1,22 @@
+package gobvh
+
+// ==============================================
+
+//
+// BVH.FindWithinRadius(center, radius, distance, fn) calls fn(element) for
+// every element whose bound is within radius of center.
+//
+// Subtrees further than radius from center, as measured by distance, are
+// pruned.  center is usually the (degenerate) bound of a point, but any
+// bound works, e.g. to find everything within a distance of a box.
+// An error returned by fn stops the search and is returned.
+//
+func (bvh *BVH[BoundType]) FindWithinRadius(center BoundType, radius float64, distance DistanceFunc[BoundType], fn func(Boundable[BoundType]) error) error {
+	searcher := predicateSearcher[BoundType]{
+		pred: func(bound BoundType) bool {
+			return distance(center, bound) <= radius
+		},
+		fn: fn,
+	}
+	return bvh.FindAll(&searcher)
+}
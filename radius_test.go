@@ -0,0 +1,43 @@
+package gobvh
+
+import (
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHFindWithinRadius(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	var x, y float64
+	for x = 0.0; x < 32.0; x += 1.0 {
+		for y = 0.0; y < 32.0; y += 1.0 {
+			bvh.Insert(Point2D{x, y})
+		}
+	}
+
+	center := Point2D{16.0, 16.0}
+	for _, radius := range []float64{0.5, 1.0, 1.5, 2.0, 5.0} {
+		expected := 0
+		for x = 0.0; x < 32.0; x += 1.0 {
+			for y = 0.0; y < 32.0; y += 1.0 {
+				if distance2D(center, Point2D{x, y}) <= radius {
+					expected++
+				}
+			}
+		}
+		found := 0
+		err := bvh.FindWithinRadius(center.GetBound(), radius, distanceBoxBox2D, func(element Boundable[AABB2D]) error {
+			if distance2D(center, element.(Point2D)) > radius {
+				t.Errorf("Element %v is outside radius %f", element, radius)
+			}
+			found++
+			return nil
+		})
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+		if found != expected {
+			t.Errorf("Radius %f: expected %d elements, found %d", radius, expected, found)
+		}
+	}
+}
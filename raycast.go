@@ -0,0 +1,312 @@
+package gobvh
+
+import (
+	"container/heap"
+	"math"
+)
+
+// ==============================================
+
+//
+// Ray is a parametric line, Origin + t * Direction, for TMin <= t <= TMax.
+//
+// Origin and Direction must have one entry per dimension of the bounds.
+// Use NewRay() for a half-infinite ray, or NewSegment() for a line segment.
+//
+type Ray struct {
+	Origin    []float64
+	Direction []float64
+	TMin      float64
+	TMax      float64
+}
+
+// ..............................................
+
+//
+// NewRay(origin, direction) returns the ray from origin along direction, 0 <= t < +Inf.
+//
+func NewRay(origin []float64, direction []float64) Ray {
+	return Ray{Origin: origin, Direction: direction, TMin: 0.0, TMax: math.Inf(1)}
+}
+
+// ..............................................
+
+//
+// NewSegment(from, to) returns the segment between two points, as a ray with 0 <= t <= 1.
+//
+func NewSegment(from []float64, to []float64) Ray {
+	direction := make([]float64, len(from))
+	for i := range from {
+		direction[i] = to[i] - from[i]
+	}
+	return Ray{Origin: from, Direction: direction, TMin: 0.0, TMax: 1.0}
+}
+
+// ..............................................
+
+//
+// Ray.At(t) returns the point at parameter t along the ray.
+//
+func (ray Ray) At(t float64) []float64 {
+	point := make([]float64, len(ray.Origin))
+	for i := range ray.Origin {
+		point[i] = ray.Origin[i] + float64(t*ray.Direction[i]) // no fused multiply-add
+	}
+	return point
+}
+
+// ==============================================
+
+//
+// RayTraits is an optional extension of BoundTraits for ray queries.
+//
+// RayInterval(ray, bound) reports the range of the ray parameter [tnear, tfar]
+// over which the ray is inside the bound (clipped to [ray.TMin, ray.TMax]),
+// and false if the ray misses the bound.
+//
+// If your BoundTraits do not implement RayTraits, bounds are treated as
+// the axis-aligned boxes given by IntervalRange(); implement RayTraits to
+// give tighter answers for other kinds of bounding volume.
+//
+type RayTraits[BoundType any] interface {
+	RayInterval(ray Ray, bound BoundType) (float64, float64, bool)
+}
+
+// ..............................................
+
+//
+// RayHitFunc is the exact intersection test for an element, used by Raycast().
+//
+// It reports the ray parameter of the element's first intersection with
+// the ray, and whether there is one.  An error stops the raycast.
+//
+type RayHitFunc[BoundType any] func(element Boundable[BoundType], ray Ray) (float64, bool, error)
+
+// ==============================================
+
+//
+// BVH.Raycast(ray, hit) finds the element closest along the ray.
+//
+// Children are visited in near-to-far order of where the ray enters their
+// bounds, and anything the ray enters beyond the closest hit found so far
+// is pruned.  hit(element, ray) is called only for elements whose bounds
+// the ray enters.
+//
+// It returns the closest element hit and the ray parameter of the hit, or
+// nil and +Inf if nothing is hit.
+//
+func (bvh *BVH[BoundType]) Raycast(ray Ray, hit RayHitFunc[BoundType]) (Boundable[BoundType], float64, error) {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryRaycast, Ray: ray, Hit: hit})
+	}
+	var closest Boundable[BoundType]
+	cutoff := ray.TMax
+
+	err := orderedDescent(&bvh.root, bvh.rayEntry(ray), &cutoff, nil, func(element Boundable[BoundType], tnear float64) error {
+		t, ok, err := hit(element, ray)
+		if err != nil {
+			return err
+		}
+		if ok && t >= ray.TMin && t <= cutoff {
+			closest = element
+			cutoff = t
+		}
+		return nil
+	})
+
+	if err != nil || closest == nil {
+		return nil, math.Inf(1), err
+	}
+	bvh.hit(closest)
+	return closest, cutoff, nil
+}
+
+// ..............................................
+
+//
+// BVH.RaycastAny(ray, hit) finds any element hit by the ray, e.g. for shadow
+// rays and line-of-sight checks, which only ask whether something is hit.
+//
+// It descends depth-first, without ordering or a priority queue, and stops
+// at the first element for which hit(element, ray) reports an intersection
+// within [ray.TMin, ray.TMax].  It returns that element and the ray parameter
+// of the hit, or nil and +Inf if nothing is hit.
+//
+func (bvh *BVH[BoundType]) RaycastAny(ray Ray, hit RayHitFunc[BoundType]) (Boundable[BoundType], float64, error) {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryRaycastAny, Ray: ray, Hit: hit})
+	}
+	if len(bvh.root.children) == 0 {
+		return nil, math.Inf(1), nil
+	}
+	element, t, err := anyHit(&bvh.root, ray, bvh.rayEntry(ray), hit)
+	if element != nil {
+		bvh.hit(element)
+	}
+	return element, t, err
+}
+
+// ==============================================
+
+// depth-first search of the subtree rooted at node for any element hit by the ray
+func anyHit[BoundType any](node *bvhNode[BoundType], ray Ray, entry func(BoundType) (float64, bool), hit RayHitFunc[BoundType]) (Boundable[BoundType], float64, error) {
+	if _, ok := entry(node.bound); !ok {
+		return nil, math.Inf(1), nil
+	}
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			if element, t, err := anyHit(value, ray, entry, hit); element != nil || err != nil {
+				return element, t, err
+			}
+		} else if child != nil {
+			if _, ok := entry(child.GetBound()); !ok {
+				continue
+			}
+			t, ok, err := hit(child, ray)
+			if err != nil {
+				return nil, math.Inf(1), err
+			}
+			if ok && t >= ray.TMin && t <= ray.TMax {
+				return child, t, nil
+			}
+		}
+	}
+	return nil, math.Inf(1), nil
+}
+
+// ..............................................
+
+// returns a function reporting where the ray enters a bound, using the traits'
+// RayTraits if available.
+func (bvh *BVH[BoundType]) rayEntry(ray Ray) func(BoundType) (float64, bool) {
+	if raytraits, ok := bvh.boundtraits.(RayTraits[BoundType]); ok {
+		return func(bound BoundType) (float64, bool) {
+			tnear, _, hit := raytraits.RayInterval(ray, bound)
+			return tnear, hit
+		}
+	}
+	return func(bound BoundType) (float64, bool) {
+		tnear, _, hit := rayBoxInterval(bvh.boundtraits, ray, bound)
+		return tnear, hit
+	}
+}
+
+// ..............................................
+
+// slab test of the ray against the axis-aligned box given by IntervalRange()
+func rayBoxInterval[BoundType any](bounder BoundTraits[BoundType], ray Ray, bound BoundType) (float64, float64, bool) {
+	tnear, tfar := ray.TMin, ray.TMax
+	var i uint
+	for i = 0; i < bounder.Dimensions(bound) && int(i) < len(ray.Origin); i++ {
+		lo, hi := bounder.IntervalRange(bound, i)
+		origin, direction := ray.Origin[i], ray.Direction[i]
+		if direction == 0.0 {
+			if origin < lo || origin > hi {
+				return tnear, tfar, false
+			}
+			continue
+		}
+		t0 := (lo - origin) / direction
+		t1 := (hi - origin) / direction
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		tnear = math.Max(tnear, t0)
+		tfar = math.Min(tfar, t1)
+		if tnear > tfar {
+			return tnear, tfar, false
+		}
+	}
+	return tnear, tfar, true
+}
+
+// ..............................................
+
+// calls fn(element, key) for the elements in the subtree rooted at node, in
+// increasing order of key, where key(bound) is computed for the bounds of nodes
+// and elements.  Subtrees and elements whose key is missing or exceeds
+// *cutoff are pruned; fn may lower *cutoff as the traversal progresses.
+// If enter is not nil, nodes are only expanded if enter(node) is true when
+// they reach the front of the queue.
+// key must not decrease from a node to its contents.
+func orderedDescent[BoundType any](node *bvhNode[BoundType], key func(BoundType) (float64, bool), cutoff *float64, enter func(*bvhNode[BoundType]) bool, fn func(Boundable[BoundType], float64) error) error {
+	return orderedContainerDescent(node, key, cutoff, enter, func(element Boundable[BoundType], _ *bvhNode[BoundType], key float64) error {
+		return fn(element, key)
+	})
+}
+
+// as orderedDescent(), calling fn(element, container, key) with the node
+// holding each element
+func orderedContainerDescent[BoundType any](node *bvhNode[BoundType], key func(BoundType) (float64, bool), cutoff *float64, enter func(*bvhNode[BoundType]) bool, fn func(Boundable[BoundType], *bvhNode[BoundType], float64) error) error {
+	if len(node.children) == 0 {
+		return nil
+	}
+	start, ok := key(node.bound)
+	if !ok || start > *cutoff {
+		return nil
+	}
+
+	queue := orderedQueue[BoundType]{{item: node, key: start}}
+	for len(queue) > 0 {
+		entry := heap.Pop(&queue).(orderedEntry[BoundType])
+		if entry.key > *cutoff {
+			break // everything remaining is further still
+		}
+
+		value, isnode := entry.item.(*bvhNode[BoundType])
+		if !isnode {
+			err := fn(entry.item, entry.container, entry.key)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if enter != nil && !enter(value) {
+			continue
+		}
+
+		for _, child := range value.children {
+			if child != nil {
+				childkey, ok := key(child.GetBound())
+				if ok && childkey <= *cutoff {
+					heap.Push(&queue, orderedEntry[BoundType]{item: child, container: value, key: childkey})
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ==============================================
+
+type orderedEntry[BoundType any] struct {
+	item      Boundable[BoundType]
+	container *bvhNode[BoundType] // the node holding item
+	key       float64
+}
+
+// min-heap on key, implements heap.Interface
+type orderedQueue[BoundType any] []orderedEntry[BoundType]
+
+func (q orderedQueue[BoundType]) Len() int {
+	return len(q)
+}
+
+func (q orderedQueue[BoundType]) Less(i, j int) bool {
+	return q[i].key < q[j].key
+}
+
+func (q orderedQueue[BoundType]) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *orderedQueue[BoundType]) Push(x any) {
+	*q = append(*q, x.(orderedEntry[BoundType]))
+}
+
+func (q *orderedQueue[BoundType]) Pop() any {
+	old := *q
+	entry := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return entry
+}
@@ -0,0 +1,143 @@
+package gobvh
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+// Disc2D is an element with an exact ray intersection test:
+type Disc2D struct {
+	C Point2D
+	R float64
+}
+
+func (d *Disc2D) GetBound() AABB2D {
+	return AABB2D{Point2D{d.C[0] - d.R, d.C[1] - d.R}, Point2D{d.C[0] + d.R, d.C[1] + d.R}}
+}
+
+// a RayHitFunc[AABB2D] for discs:
+func hitDisc2D(element Boundable[AABB2D], ray Ray) (float64, bool, error) {
+	d := element.(*Disc2D)
+	ox, oy := ray.Origin[0]-d.C[0], ray.Origin[1]-d.C[1]
+	dx, dy := ray.Direction[0], ray.Direction[1]
+	a := dx*dx + dy*dy
+	b := 2.0 * (ox*dx + oy*dy)
+	c := ox*ox + oy*oy - d.R*d.R
+	disc := b*b - 4.0*a*c
+	if disc < 0.0 {
+		return 0.0, false, nil
+	}
+	sq := math.Sqrt(disc)
+	for _, t := range []float64{(-b - sq) / (2.0 * a), (-b + sq) / (2.0 * a)} {
+		if t >= ray.TMin && t <= ray.TMax {
+			return t, true, nil
+		}
+	}
+	return 0.0, false, nil
+}
+
+func makeDiscs(rng *rand.Rand, n int) []*Disc2D {
+	discs := make([]*Disc2D, n)
+	for i := range discs {
+		discs[i] = &Disc2D{C: Point2D{rng.Float64() * 100.0, rng.Float64() * 100.0}, R: 0.2 + rng.Float64()}
+	}
+	return discs
+}
+
+// ........................................................
+
+func TestBVHRaycast(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	discs := makeDiscs(rng, 500)
+	bvh := New[AABB2D](Traits2D{})
+	for _, d := range discs {
+		bvh.Insert(d)
+	}
+
+	for i := 0; i < 200; i++ {
+		angle := rng.Float64() * 2.0 * math.Pi
+		ray := NewRay([]float64{rng.Float64() * 100.0, rng.Float64() * 100.0}, []float64{math.Cos(angle), math.Sin(angle)})
+		if i%2 == 1 {
+			ray = NewSegment(ray.Origin, ray.At(20.0))
+		}
+
+		// brute force:
+		var expected *Disc2D
+		expectedt := math.Inf(1)
+		for _, d := range discs {
+			if hitt, ok, _ := hitDisc2D(d, ray); ok && hitt < expectedt {
+				expected, expectedt = d, hitt
+			}
+		}
+
+		found, foundt, err := bvh.Raycast(ray, hitDisc2D)
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+		if expected == nil {
+			if found != nil {
+				t.Errorf("Ray %d: expected no hit but found %v at %f", i, found, foundt)
+			}
+		} else if found != Boundable[AABB2D](expected) || foundt != expectedt {
+			t.Errorf("Ray %d: expected %v at %f but found %v at %f", i, expected, expectedt, found, foundt)
+		}
+	}
+
+	empty := New[AABB2D](Traits2D{})
+	if found, _, _ := empty.Raycast(NewRay([]float64{0.0, 0.0}, []float64{1.0, 0.0}), hitDisc2D); found != nil {
+		t.Errorf("Hit something in an empty tree")
+	}
+}
+
+// ........................................................
+
+func TestBVHRaycastAny(t *testing.T) {
+	rng := rand.New(rand.NewSource(76))
+	discs := makeDiscs(rng, 500)
+	bvh := New[AABB2D](Traits2D{})
+	for _, d := range discs {
+		bvh.Insert(d)
+	}
+
+	hits := 0
+	for i := 0; i < 300; i++ {
+		angle := rng.Float64() * 2.0 * math.Pi
+		ray := NewRay([]float64{rng.Float64() * 100.0, rng.Float64() * 100.0}, []float64{math.Cos(angle), math.Sin(angle)})
+		ray.TMax = rng.Float64() * 10.0
+
+		closest, _, _ := bvh.Raycast(ray, hitDisc2D)
+		found, foundt, err := bvh.RaycastAny(ray, hitDisc2D)
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+		if (found == nil) != (closest == nil) {
+			t.Errorf("Ray %d: expected RaycastAny() to agree with Raycast() on whether anything is hit", i)
+		}
+		if found != nil {
+			hits++
+			if hitt, ok, _ := hitDisc2D(found, ray); !ok || hitt != foundt {
+				t.Errorf("Ray %d: expected the element found to be hit at %f", i, foundt)
+			}
+		}
+	}
+	if hits == 0 || hits == 300 {
+		t.Errorf("Expected some rays to hit and some to miss, found %d hits", hits)
+	}
+
+	// the search stops at the first hit:
+	hit, after := false, 0
+	found, _, _ := bvh.RaycastAny(NewRay([]float64{-1.0, 50.0}, []float64{1.0, 0.0}), func(element Boundable[AABB2D], ray Ray) (float64, bool, error) {
+		if hit {
+			after++
+		}
+		t, ok, err := hitDisc2D(element, ray)
+		hit = hit || ok
+		return t, ok, err
+	})
+	if found == nil || after != 0 {
+		t.Errorf("Expected the search to stop at the first hit, found %d tests after it", after)
+	}
+}
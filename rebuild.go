@@ -0,0 +1,153 @@
+package gobvh
+
+// ==============================================
+
+//
+// BVH.RebuildFrom(prev, elements) replaces the contents of this bvh with
+// elements, using the topology of prev (e.g. last frame's tree) as a seed.
+//
+// The nodes of prev are copied (prev itself is not modified), elements which
+// are no longer present are dropped, emptied subtrees are discarded and every
+// remaining node is refit to the current bounds of its elements.  Only
+// elements which prev did not contain are inserted from scratch.
+//
+// For mostly-static scenes this is much cheaper than inserting every
+// element into a new tree each frame.  Elements which moved a long way
+// will leave their nodes with loose bounds, so occasionally rebuilding
+// from scratch is still a good idea.
+//
+func (bvh *BVH[BoundType]) RebuildFrom(prev *BVH[BoundType], elements []Boundable[BoundType]) {
+	keep := make(map[Boundable[BoundType]]bool, len(elements))
+	for _, element := range elements {
+		keep[element] = false
+	}
+
+	// carry annotations (e.g. tags) of surviving elements over from prev:
+	previnfo := prev.info
+	bvh.info = nil
+	for element := range keep {
+		if info, ok := previnfo[element]; ok {
+			bvh.setInfo(element, *info)
+		}
+	}
+
+	if bvh.journaling && prev != bvh {
+		// the contents become prev's, before the prune:
+		for _, element := range collectElements(&bvh.root, nil) {
+			bvh.logChange(ChangeErased, element, element.GetBound())
+		}
+		for _, element := range collectElements(&prev.root, nil) {
+			bvh.logChange(ChangeInserted, element, element.GetBound())
+		}
+	}
+	bvh.own(&bvh.root) // (its children slice may be a snapshot's)
+	bvh.cloneNode(&bvh.root, &prev.root, nil)
+
+	bvh.pruneAndRefit(&bvh.root, keep)
+
+	// account for the elements kept, then insert the rest:
+	bvh.count = 0
+	bvh.contenthash = 0
+	bvh.version++
+	bvh.dirtyepoch++ // the replaced contents are not dirty regions, so caches must start over
+	if bvh.evictor != nil {
+		bvh.evictor.Reset()
+	}
+	for _, element := range collectElements(&bvh.root, nil) {
+		bvh.count++
+		bvh.contenthash += bvh.elementHash(element, element.GetBound())
+		if bvh.evictor != nil {
+			bvh.evictor.Inserted(element)
+		}
+	}
+
+	for _, element := range elements {
+		if seen := keep[element]; !seen {
+			keep[element] = true // guard against duplicates in the input
+			bvh.Insert(element)
+		}
+	}
+}
+
+// ==============================================
+
+// copies the subtree rooted at node into clone, sharing elements but not nodes.
+// New nodes come from the arena; clone's children slice is reused if large enough.
+func (bvh *BVH[BoundType]) cloneNode(clone *bvhNode[BoundType], node *bvhNode[BoundType], parent *bvhNode[BoundType]) {
+	source := node.children // (clone may be node itself)
+	children := clone.children[:0]
+	if cap(children) < len(source) {
+		children = make([]Boundable[BoundType], 0, cap(source))
+	}
+	*clone = bvhNode[BoundType]{
+		bound:       node.bound,
+		parent:      parent,
+		cost:        node.cost,
+		bloom:       node.bloom,
+		layers:      node.layers,
+		level:       node.level,
+		elements:    node.elements,
+		descendants: node.descendants,
+		changes:     node.changes,
+		expiry:      node.expiry,
+		epoch:       bvh.arena.epoch,
+	}
+	for _, child := range source {
+		value, ok := child.(*bvhNode[BoundType])
+		if ok {
+			childclone := bvh.arena.alloc()
+			bvh.cloneNode(childclone, value, clone)
+			children = append(children, childclone)
+		} else {
+			children = append(children, child)
+		}
+	}
+	clone.children = children
+}
+
+// ..............................................
+
+// drops elements absent from keep (marking the ones found), discards emptied
+// nodes and recalculates the bounds of the subtree rooted at node, bottom up.
+// Leaves whose elements changed are marked dirty, and dropped elements are
+// journaled as erased.
+func (bvh *BVH[BoundType]) pruneAndRefit(node *bvhNode[BoundType], keep map[Boundable[BoundType]]bool) {
+	oldbound := node.bound
+	leaf := false
+	changed := false
+	retained := node.children[:0]
+	for _, child := range node.children {
+		value, ok := child.(*bvhNode[BoundType])
+		if ok {
+			bvh.pruneAndRefit(value, keep)
+			if len(value.children) > 0 {
+				retained = append(retained, child)
+			}
+		} else {
+			leaf = true
+			if _, found := keep[child]; found {
+				keep[child] = true
+				retained = append(retained, child)
+			} else {
+				changed = true
+				bvh.logChange(ChangeErased, child, child.GetBound())
+			}
+		}
+	}
+	for index := len(retained); index < len(node.children); index++ {
+		node.children[index] = nil // release dropped children
+	}
+	node.children = retained
+	bvh.recalculateBounds(node)
+
+	if leaf && len(node.children) > 0 && !changed {
+		changed = !boundsEqual(bvh.boundtraits, oldbound, node.bound)
+	}
+	if leaf && changed {
+		dirtybound := oldbound
+		if len(node.children) > 0 {
+			dirtybound = bvh.boundtraits.Union(oldbound, node.bound)
+		}
+		bvh.markDirty(node, dirtybound)
+	}
+}
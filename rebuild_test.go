@@ -0,0 +1,49 @@
+package gobvh
+
+import (
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHRebuildFrom(t *testing.T) {
+	prev := New[AABB2D](Traits2D{})
+	var x, y float64
+	for x = 0.0; x < 16.0; x += 1.0 {
+		for y = 0.0; y < 16.0; y += 1.0 {
+			prev.Insert(Point2D{x, y})
+		}
+	}
+
+	// next frame: the left half is gone and a new row appears at the top
+	elements := make([]Boundable[AABB2D], 0, 256)
+	for x = 8.0; x < 16.0; x += 1.0 {
+		for y = 0.0; y < 17.0; y += 1.0 {
+			elements = append(elements, Point2D{x, y})
+		}
+	}
+
+	bvh := New[AABB2D](Traits2D{})
+	bvh.RebuildFrom(prev, elements)
+
+	if n := len(collectElements(&bvh.root, nil)); n != len(elements) {
+		t.Errorf("Expected %d elements after rebuild, found %d", len(elements), n)
+	}
+	if n := len(collectElements(&prev.root, nil)); n != 256 {
+		t.Errorf("Expected the previous tree to be unchanged, found %d elements", n)
+	}
+	if bvh.root.bound.L[0] != 8.0 || bvh.root.bound.H[1] != 16.0 {
+		t.Errorf("Unexpected bound after rebuild: %v", bvh.root.bound)
+	}
+
+	var cb CheckBound
+	cb.T = t
+	bvh.ForEach(&cb)
+	visualize(t, &bvh.root, "  ")
+
+	for x = 8.0; x < 16.0; x += 1.0 {
+		simpleNNSearch(t, bvh, Point2D{x + 0.1, 16.2}, Point2D{x, 16.0}, true)
+		simpleNNSearch(t, bvh, Point2D{x - 0.1, 0.1}, Point2D{x, 0.0}, false)
+	}
+	simpleNNSearch(t, bvh, Point2D{0.0, 4.0}, Point2D{8.0, 4.0}, true)
+}
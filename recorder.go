@@ -0,0 +1,419 @@
+package gobvh
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// ==============================================
+
+//
+// QueryKind identifies the search a RecordedQuery was made with.
+//
+type QueryKind int
+
+const (
+	QuerySearch             QueryKind = iota // FindAll(Searcher, Options...)
+	QueryNearest                             // FindNearest(Searcher, Bound, Options...)
+	QueryNearestBestFirst                    // FindNearestBestFirst(Searcher, Bound, Options...)
+	QueryIntersecting                        // FindAllIntersecting(Bound, fn)
+	QueryStab                                // Stab(Point, fn)
+	QueryRaycast                             // Raycast(Ray, Hit)
+	QueryRaycastAny                          // RaycastAny(Ray, Hit)
+	QueryShapecast                           // Shapecast(Bound, Point, fn), Point being the displacement
+	QueryMinDistance                         // MinDistance(Bound)
+	QueryCrawl                               // ForEach(Crawler)
+	QueryElements                            // All()
+	QueryMortonOrder                         // ElementsMortonOrder()
+	QueryCount                               // Count(Bound)
+	QueryWithTag                             // FindAllWithTag(Tag, Predicate)
+	QueryOutside                             // FindAllOutside(Bound, fn)
+	QueryIntersectingSorted                  // FindAllIntersectingSorted(Bound, Axis, Descending, fn)
+	QueryIterate                             // Query(Bound)
+	QueryAlongPath                           // FindAlongPath(Path, Hit, fn)
+	QueryNearestWhere                        // FindNearestWhere(Searcher, Bound, Filter, Options...)
+	QueryFarthest                            // FindFarthest(Searcher, Bound, Options...)
+	QueryFarthestElement                     // Farthest(Bound)
+	QueryNearestToRegion                     // NearestToRegion(Bound, K, Distance)
+	QueryAllKNearest                         // AllKNearestFunc(K, fn)
+	QuerySelfCollide                         // SelfCollide(fn)
+	QueryNeighborEdges                       // NeighborEdges(Radius, Distance, fn)
+	QueryRunBatch                            // RunBatch(Regions, fn), Regions being those of the QueryBatch
+	QuerySearchContext                       // FindAllContext(Context, Searcher, Options...)
+	QueryNearestContext                      // FindNearestContext(Context, Searcher, Bound, Options...)
+	QuerySearchWith                          // FindAllWith(QueryContext, ContextSearcher, Options...)
+	QueryNearestWith                         // FindNearestWith(QueryContext, ContextSearcher, Bound, Options...)
+	QueryCrawlWith                           // ForEachWith(QueryContext, ContextCrawler)
+)
+
+// ..............................................
+
+//
+// RecordedQuery is a search captured by a QueryRecorder: its kind and the
+// parameters it was given (fields a kind does not use are left zero).
+// Callbacks which only receive results (the fn of FindAllIntersecting(),
+// Stab() and the like, or the yield of an iterator) are not kept; a
+// replay counts the results instead.
+//
+type RecordedQuery[BoundType any] struct {
+	Kind            QueryKind
+	Bound           BoundType
+	Point           []float64
+	Ray             Ray
+	Path            [][]float64
+	Regions         []BoundType
+	Axis            uint
+	Descending      bool
+	K               int
+	Radius          float64
+	Tag             Tag
+	Searcher        Searcher[BoundType]
+	ContextSearcher ContextSearcher[BoundType]
+	Crawler         BVHCrawler[BoundType]
+	ContextCrawler  ContextCrawler[BoundType]
+	Hit             RayHitFunc[BoundType]
+	Predicate       func(BoundType) bool
+	Filter          func(Boundable[BoundType]) bool
+	Distance        DistanceFunc[BoundType]
+	Context         context.Context
+	QueryContext    *QueryContext
+	Options         []QueryOption[BoundType]
+}
+
+// ..............................................
+
+//
+// QueryRecorder captures the searches made on a BVH, see BVH.SetRecorder(),
+// so that a real workload can be replayed against another tree (e.g. one
+// built with other Options, or after a rebuild) for regression tests and
+// benchmarks.
+//
+// Every kind of search is recorded (see QueryKind), crawls, iterators and
+// query batches included, as the method which performs it: searches made
+// on behalf of other methods are recorded as the search they perform (e.g.
+// FindConstrained() as a FindAll(), FindNearestOther() as a
+// FindNearestWhere(), NeighborGraph() as a NeighborEdges(), and each batch
+// of Serve() as a RunBatch()).
+//
+type QueryRecorder[BoundType any] struct {
+	Queries []RecordedQuery[BoundType]
+
+	lock sync.Mutex // batches may be run on other goroutines, see StartBatch()
+}
+
+// ..............................................
+
+//
+// ReplayResult is the outcome of one replayed query: Found is the number of
+// results reported (elements given to Evaluate() by searches and crawls,
+// or to fn; pairs, for SelfCollide() and NeighborEdges(); neighbors, for
+// AllKNearestFunc(); the count of Count(); one for a ray which hit or an
+// element found by Farthest()), Value is the ray parameter of a hit or the
+// distance found by MinDistance(), Farthest() or (to the last element)
+// NearestToRegion() (otherwise zero), and Elapsed is the time taken.
+//
+type ReplayResult struct {
+	Found   int
+	Value   float64
+	Elapsed time.Duration
+	Err     error
+}
+
+// ==============================================
+
+//
+// NewQueryRecorder() returns an empty recorder.
+//
+func NewQueryRecorder[BoundType any]() *QueryRecorder[BoundType] {
+	return &QueryRecorder[BoundType]{}
+}
+
+// ..............................................
+
+//
+// BVH.SetRecorder(recorder) records subsequent searches in recorder, or
+// stops recording if recorder is nil.
+//
+func (bvh *BVH[BoundType]) SetRecorder(recorder *QueryRecorder[BoundType]) {
+	bvh.recorder = recorder
+}
+
+// ..............................................
+
+//
+// QueryRecorder.Reset() forgets the recorded queries.
+//
+func (recorder *QueryRecorder[BoundType]) Reset() {
+	recorder.Queries = recorder.Queries[:0]
+}
+
+// ..............................................
+
+//
+// QueryRecorder.Replay(bvh) runs the recorded queries, in order, against
+// bvh, and returns one result for each.
+//
+// Searches are given their recorded Searcher (or crawler) again, after
+// calling its Reset() method if it has one (as KNearest does); a searcher
+// with state and no Reset() will see the results of earlier searches.  A
+// recorded QueryContext is Reset() too, and a recorded Context is given
+// again, so a search whose Context has since been cancelled is cut short.
+// Queries made through Replay() are not recorded.
+//
+func (recorder *QueryRecorder[BoundType]) Replay(bvh *BVH[BoundType]) []ReplayResult {
+	saved := bvh.recorder
+	bvh.recorder = nil
+	defer func() {
+		bvh.recorder = saved
+	}()
+
+	results := make([]ReplayResult, len(recorder.Queries))
+	for index, query := range recorder.Queries {
+		result := &results[index]
+		count := func(Boundable[BoundType]) error {
+			result.Found++
+			return nil
+		}
+		pair := func(Boundable[BoundType], Boundable[BoundType]) error {
+			result.Found++
+			return nil
+		}
+		for _, stateful := range []any{query.Searcher, query.ContextSearcher, query.Crawler, query.ContextCrawler} {
+			if resetter, ok := stateful.(interface{ Reset() }); ok {
+				resetter.Reset()
+			}
+		}
+		if query.QueryContext != nil {
+			query.QueryContext.Reset()
+		}
+		searcher := countingSearcher[BoundType]{searcher: query.Searcher, count: &result.Found}
+		contextsearcher := countingContextSearcher[BoundType]{searcher: query.ContextSearcher, count: &result.Found}
+		contextcrawler := countingContextCrawler[BoundType]{crawler: query.ContextCrawler, count: &result.Found}
+
+		start := time.Now()
+		switch query.Kind {
+		case QuerySearch:
+			result.Err = bvh.FindAll(&searcher, query.Options...)
+		case QueryNearest:
+			result.Err = bvh.FindNearest(&searcher, query.Bound, query.Options...)
+		case QueryNearestBestFirst:
+			result.Err = bvh.FindNearestBestFirst(&searcher, query.Bound, query.Options...)
+		case QueryIntersecting:
+			result.Err = bvh.FindAllIntersecting(query.Bound, count)
+		case QueryStab:
+			result.Err = bvh.Stab(query.Point, count)
+		case QueryRaycast, QueryRaycastAny:
+			var element Boundable[BoundType]
+			if query.Kind == QueryRaycast {
+				element, result.Value, result.Err = bvh.Raycast(query.Ray, query.Hit)
+			} else {
+				element, result.Value, result.Err = bvh.RaycastAny(query.Ray, query.Hit)
+			}
+			if element != nil {
+				result.Found = 1
+			} else {
+				result.Value = 0.0
+			}
+		case QueryShapecast:
+			result.Err = bvh.Shapecast(query.Bound, query.Point, func(element Boundable[BoundType], toi float64) error {
+				return count(element)
+			})
+		case QueryMinDistance:
+			result.Value = bvh.MinDistance(query.Bound)
+			if !math.IsInf(result.Value, 1) {
+				result.Found = 1
+			}
+		case QueryCrawl:
+			result.Err = bvh.ForEach(countingCrawlerFor(query.Crawler, &result.Found))
+		case QueryElements:
+			result.Err = forEachElement(&bvh.root, count)
+		case QueryMortonOrder:
+			result.Found = len(bvh.ElementsMortonOrder())
+		case QueryCount:
+			result.Found = bvh.Count(query.Bound)
+		case QueryWithTag:
+			result.Found = len(bvh.FindAllWithTag(query.Tag, query.Predicate))
+		case QueryOutside:
+			result.Err = bvh.FindAllOutside(query.Bound, count)
+		case QueryIntersectingSorted:
+			result.Err = bvh.FindAllIntersectingSorted(query.Bound, query.Axis, query.Descending, count)
+		case QueryIterate:
+			result.Err = bvh.findAllIntersecting(query.Bound, count)
+		case QueryAlongPath:
+			result.Err = bvh.FindAlongPath(query.Path, query.Hit, func(element Boundable[BoundType], segment int, t float64) error {
+				return count(element)
+			})
+		case QueryNearestWhere:
+			result.Err = bvh.FindNearestWhere(&searcher, query.Bound, query.Filter, query.Options...)
+		case QueryFarthest:
+			result.Err = bvh.FindFarthest(&searcher, query.Bound, query.Options...)
+		case QueryFarthestElement:
+			var element Boundable[BoundType]
+			element, result.Value = bvh.Farthest(query.Bound)
+			if element != nil {
+				result.Found = 1
+			} else {
+				result.Value = 0.0
+			}
+		case QueryNearestToRegion:
+			elements, distances := bvh.NearestToRegion(query.Bound, query.K, query.Distance)
+			result.Found = len(elements)
+			if len(distances) > 0 {
+				result.Value = distances[len(distances)-1]
+			}
+		case QueryAllKNearest:
+			result.Err = bvh.AllKNearestFunc(query.K, func(element Boundable[BoundType], neighbors []Boundable[BoundType], distances []float64) error {
+				result.Found += len(neighbors)
+				return nil
+			})
+		case QuerySelfCollide:
+			result.Err = bvh.SelfCollide(pair)
+		case QueryNeighborEdges:
+			result.Err = bvh.NeighborEdges(query.Radius, query.Distance, pair)
+		case QueryRunBatch:
+			batch := QueryBatch[BoundType]{regions: query.Regions}
+			result.Err = bvh.RunBatch(&batch, func(index int, element Boundable[BoundType]) error {
+				return count(element)
+			})
+		case QuerySearchContext:
+			result.Err = bvh.FindAllContext(query.Context, &searcher, query.Options...)
+		case QueryNearestContext:
+			result.Err = bvh.FindNearestContext(query.Context, &searcher, query.Bound, query.Options...)
+		case QuerySearchWith:
+			result.Err = bvh.FindAllWith(query.QueryContext, &contextsearcher, query.Options...)
+		case QueryNearestWith:
+			result.Err = bvh.FindNearestWith(query.QueryContext, &contextsearcher, query.Bound, query.Options...)
+		case QueryCrawlWith:
+			result.Err = bvh.ForEachWith(query.QueryContext, &contextcrawler)
+		}
+		result.Elapsed = time.Since(start)
+	}
+	return results
+}
+
+// ==============================================
+
+// appends a query, copying the slices the caller may reuse
+func (recorder *QueryRecorder[BoundType]) record(query RecordedQuery[BoundType]) {
+	if query.Point != nil {
+		query.Point = append([]float64(nil), query.Point...)
+	}
+	if query.Ray.Origin != nil {
+		query.Ray.Origin = append([]float64(nil), query.Ray.Origin...)
+		query.Ray.Direction = append([]float64(nil), query.Ray.Direction...)
+	}
+	if query.Path != nil {
+		path := make([][]float64, len(query.Path))
+		for index, point := range query.Path {
+			path[index] = append([]float64(nil), point...)
+		}
+		query.Path = path
+	}
+	if query.Regions != nil {
+		query.Regions = append([]BoundType(nil), query.Regions...)
+	}
+	if query.Options != nil {
+		query.Options = append([]QueryOption[BoundType](nil), query.Options...)
+	}
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	recorder.Queries = append(recorder.Queries, query)
+}
+
+// ..............................................
+
+// countingSearcher wraps a Searcher, counting the elements it evaluates
+type countingSearcher[BoundType any] struct {
+	searcher Searcher[BoundType]
+	count    *int
+}
+
+func (cs *countingSearcher[BoundType]) DoesIntersect(bound BoundType) bool {
+	return cs.searcher.DoesIntersect(bound)
+}
+
+func (cs *countingSearcher[BoundType]) Evaluate(element Boundable[BoundType]) error {
+	*cs.count++
+	return cs.searcher.Evaluate(element)
+}
+
+// ..............................................
+
+// countingCrawler wraps a BVHCrawler, counting the elements it evaluates
+type countingCrawler[BoundType any] struct {
+	crawler BVHCrawler[BoundType]
+	count   *int
+}
+
+func (cc *countingCrawler[BoundType]) BeginBound(b BoundType) error {
+	return cc.crawler.BeginBound(b)
+}
+
+func (cc *countingCrawler[BoundType]) EndBound(b BoundType) error {
+	return cc.crawler.EndBound(b)
+}
+
+func (cc *countingCrawler[BoundType]) Evaluate(element Boundable[BoundType]) error {
+	*cc.count++
+	return cc.crawler.Evaluate(element)
+}
+
+// countingNodeCrawler is a countingCrawler for a NodeCrawler
+type countingNodeCrawler[BoundType any] struct {
+	countingCrawler[BoundType]
+	nodecrawler NodeCrawler[BoundType]
+}
+
+func (cc *countingNodeCrawler[BoundType]) BeginNode(node NodeRef[BoundType]) error {
+	return cc.nodecrawler.BeginNode(node)
+}
+
+// returns crawler wrapped to count the elements it evaluates in count,
+// still a NodeCrawler if crawler is one
+func countingCrawlerFor[BoundType any](crawler BVHCrawler[BoundType], count *int) BVHCrawler[BoundType] {
+	counting := countingCrawler[BoundType]{crawler: crawler, count: count}
+	if nodecrawler, ok := crawler.(NodeCrawler[BoundType]); ok {
+		return &countingNodeCrawler[BoundType]{countingCrawler: counting, nodecrawler: nodecrawler}
+	}
+	return &counting
+}
+
+// ..............................................
+
+// countingContextSearcher wraps a ContextSearcher, counting the elements it evaluates
+type countingContextSearcher[BoundType any] struct {
+	searcher ContextSearcher[BoundType]
+	count    *int
+}
+
+func (cs *countingContextSearcher[BoundType]) DoesIntersect(qc *QueryContext, bound BoundType) bool {
+	return cs.searcher.DoesIntersect(qc, bound)
+}
+
+func (cs *countingContextSearcher[BoundType]) Evaluate(qc *QueryContext, element Boundable[BoundType]) error {
+	*cs.count++
+	return cs.searcher.Evaluate(qc, element)
+}
+
+// ..............................................
+
+// countingContextCrawler wraps a ContextCrawler, counting the elements it evaluates
+type countingContextCrawler[BoundType any] struct {
+	crawler ContextCrawler[BoundType]
+	count   *int
+}
+
+func (cc *countingContextCrawler[BoundType]) BeginBound(qc *QueryContext, b BoundType) error {
+	return cc.crawler.BeginBound(qc, b)
+}
+
+func (cc *countingContextCrawler[BoundType]) EndBound(qc *QueryContext, b BoundType) error {
+	return cc.crawler.EndBound(qc, b)
+}
+
+func (cc *countingContextCrawler[BoundType]) Evaluate(qc *QueryContext, element Boundable[BoundType]) error {
+	*cc.count++
+	return cc.crawler.Evaluate(qc, element)
+}
@@ -0,0 +1,285 @@
+package gobvh
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestQueryRecorder(t *testing.T) {
+	rng := rand.New(rand.NewSource(781))
+	boxes := randomBoxes(rng, 1000, 3.0)
+	bvh := New[AABB2D](Traits2D{})
+	for _, box := range boxes {
+		bvh.Insert(box)
+	}
+	recorder := NewQueryRecorder[AABB2D]()
+	bvh.SetRecorder(recorder)
+
+	// a workload, remembering what each query found:
+	expected := make([]int, 0, 16)
+	count := 0
+	counter := func(Boundable[AABB2D]) error {
+		count++
+		return nil
+	}
+	region := AABB2D{Point2D{20.0, 20.0}, Point2D{40.0, 35.0}}
+	bvh.FindAllIntersecting(region, counter)
+	expected = append(expected, count)
+
+	count = 0
+	bvh.Stab([]float64{50.0, 50.0}, counter)
+	expected = append(expected, count)
+
+	collector := BoxCollector{Box: region}
+	bvh.FindAll(&collector, WithElementFilter(func(element Boundable[AABB2D]) bool {
+		return element != boxes[0]
+	}))
+	expected = append(expected, -1) // depends on the shape of the tree
+	inregion := len(collector.Found)
+
+	target := Point2D{60.0, 60.0}.GetBound()
+	knn := NewKNearest(7, target, distanceBoxBox2D)
+	bvh.FindNearestBestFirst(knn, target)
+	nearest := knn.Results()
+	expected = append(expected, -1)
+
+	count = 0
+	bvh.Shapecast(AABB2D{Point2D{0.0, 0.0}, Point2D{1.0, 1.0}}, []float64{90.0, 80.0}, func(Boundable[AABB2D], float64) error {
+		count++
+		return nil
+	})
+	expected = append(expected, count)
+
+	ray := NewRay([]float64{0.0, 50.0}, []float64{1.0, 0.1})
+	hitBox := func(element Boundable[AABB2D], ray Ray) (float64, bool, error) {
+		tnear, _, ok := rayBoxInterval[AABB2D](Traits2D{}, ray, element.GetBound())
+		return tnear, ok, nil
+	}
+	hitelement, hitt, _ := bvh.Raycast(ray, hitBox)
+	if hitelement == nil {
+		t.Fatalf("Expected the ray to hit something")
+	}
+	expected = append(expected, 1)
+	distance := bvh.MinDistance(AABB2D{Point2D{200.0, 200.0}, Point2D{201.0, 201.0}})
+	expected = append(expected, 1)
+
+	bvh.SetRecorder(nil)
+	bvh.Stab([]float64{10.0, 10.0}, counter) // not recorded
+
+	kinds := []QueryKind{QueryIntersecting, QueryStab, QuerySearch, QueryNearestBestFirst, QueryShapecast, QueryRaycast, QueryMinDistance}
+	if len(recorder.Queries) != len(kinds) {
+		t.Fatalf("Expected %d queries recorded, found %d", len(kinds), len(recorder.Queries))
+	}
+	for index, kind := range kinds {
+		if recorder.Queries[index].Kind != kind {
+			t.Errorf("Expected query %d of kind %d, found %d", index, kind, recorder.Queries[index].Kind)
+		}
+	}
+
+	// replay against a differently built tree finds the same:
+	other := NewWithOptions[AABB2D](Traits2D{}, Options{MaxChildren: 4, SplitPolicy: SplitMedian})
+	for _, box := range boxes {
+		other.Insert(box)
+	}
+	other.SetRecorder(recorder)
+	for replay, tree := range []*BVH[AABB2D]{bvh, other} {
+		results := recorder.Replay(tree)
+		if len(results) != len(kinds) || len(recorder.Queries) != len(kinds) {
+			t.Fatalf("Expected %d results without recording the replay, found %d", len(kinds), len(results))
+		}
+		for index, result := range results {
+			if result.Err != nil {
+				t.Errorf("Replayed query %d failed: %v", index, result.Err)
+			}
+			if expected[index] >= 0 && result.Found != expected[index] {
+				t.Errorf("Expected replayed query %d to find %d, found %d", index, expected[index], result.Found)
+			}
+		}
+		// searchers are replayed as they are, after Reset() if they have one:
+		if len(collector.Found) != (replay+2)*inregion {
+			t.Errorf("Expected the replayed searcher to collect %d more elements, found %d", inregion, len(collector.Found))
+		}
+		for index, element := range knn.Results() {
+			if element != nearest[index] {
+				t.Errorf("Expected the replayed nearest neighbor search to find the same")
+			}
+		}
+		if results[5].Value != hitt || results[6].Value != distance {
+			t.Errorf("Expected the replayed ray and distance values %g and %g, found %g and %g", hitt, distance, results[5].Value, results[6].Value)
+		}
+	}
+
+	recorder.Reset()
+	if len(recorder.Queries) != 0 {
+		t.Errorf("Expected no queries after Reset(), found %d", len(recorder.Queries))
+	}
+}
+
+// ........................................................
+
+func TestQueryRecorderKinds(t *testing.T) {
+	rng := rand.New(rand.NewSource(7811))
+	boxes := randomBoxes(rng, 600, 3.0)
+	bvh := New[AABB2D](Traits2D{})
+	for index, box := range boxes {
+		if index%3 == 0 {
+			bvh.InsertTagged(box, Tag(3))
+		} else {
+			bvh.Insert(box)
+		}
+	}
+	recorder := NewQueryRecorder[AABB2D]()
+	bvh.SetRecorder(recorder)
+
+	// every kind of query, remembering what each found:
+	expected := make([]int, 0, 32)
+	count := 0
+	counter := func(Boundable[AABB2D]) error {
+		count++
+		return nil
+	}
+	pairs := func(Boundable[AABB2D], Boundable[AABB2D]) error {
+		count++
+		return nil
+	}
+	found := func(queries ...func()) {
+		for _, query := range queries {
+			count = 0
+			query()
+			expected = append(expected, count)
+		}
+	}
+	region := AABB2D{Point2D{20.0, 20.0}, Point2D{45.0, 40.0}}
+	target := Point2D{60.0, 60.0}.GetBound()
+	crawler := CheckBound{T: t}
+	batch := NewQueryBatch[AABB2D]()
+	batch.Add(region)
+	batch.Add(target)
+	found(
+		func() { count = bvh.Count(region) },
+		func() {
+			bvh.ForEach(&crawler)
+			count = len(boxes)
+		},
+		func() { count = len(bvh.ElementsMortonOrder()) },
+		func() {
+			count = len(bvh.FindAllWithTag(Tag(3), func(bound AABB2D) bool { return boundsOverlap[AABB2D](Traits2D{}, region, bound) }))
+		},
+		func() { bvh.FindAllOutside(region, counter) },
+		func() { bvh.FindAllIntersectingSorted(region, 1, true, counter) },
+		func() {
+			bvh.FindAlongPath([][]float64{{0.0, 0.0}, {50.0, 60.0}, {90.0, 10.0}}, nil, func(element Boundable[AABB2D], segment int, t float64) error {
+				return counter(element)
+			})
+		},
+		func() {
+			elements, _ := bvh.NearestToRegion(region, 9, nil)
+			count = len(elements)
+		},
+		func() {
+			bvh.AllKNearestFunc(2, func(element Boundable[AABB2D], neighbors []Boundable[AABB2D], distances []float64) error {
+				count += len(neighbors)
+				return nil
+			})
+		},
+		func() { bvh.SelfCollide(pairs) },
+		func() { bvh.NeighborEdges(1.0, distanceBoxBox2D, pairs) },
+		func() {
+			bvh.RunBatch(batch, func(query int, element Boundable[AABB2D]) error {
+				return counter(element)
+			})
+		},
+		func() {
+			if element, _ := bvh.Farthest(target); element != nil {
+				count = 1
+			}
+		},
+	)
+
+	// searchers, whose counts depend on the shape of the tree:
+	collector := BoxCollector{Box: region}
+	knn := NewKNearest(5, target, distanceBoxBox2D)
+	qc := NewQueryContext(context.Background(), nil)
+	searches := []func(){
+		func() {
+			bvh.FindNearestWhere(knn, target, func(element Boundable[AABB2D]) bool { return element != boxes[0] })
+		},
+		func() { bvh.FindFarthest(&collector, target) },
+		func() { bvh.FindAllContext(context.Background(), &collector) },
+		func() {
+			knn.Reset()
+			bvh.FindNearestContext(context.Background(), knn, target)
+		},
+		func() { bvh.FindAllWith(qc, &boxContextCollector{box: region}) },
+		func() { bvh.FindNearestWith(qc, &boxContextCollector{box: region}, target) },
+		func() { bvh.ForEachWith(qc, CancellingCrawler2D{CancelAt: -1}) },
+	}
+	for _, search := range searches {
+		search()
+		expected = append(expected, -1)
+	}
+	nearest := knn.Results()
+	bvh.SetRecorder(nil)
+
+	kinds := []QueryKind{
+		QueryCount, QueryCrawl, QueryMortonOrder, QueryWithTag, QueryOutside, QueryIntersectingSorted,
+		QueryAlongPath, QueryNearestToRegion, QueryAllKNearest, QuerySelfCollide, QueryNeighborEdges,
+		QueryRunBatch, QueryFarthestElement, QueryNearestWhere, QueryFarthest, QuerySearchContext,
+		QueryNearestContext, QuerySearchWith, QueryNearestWith, QueryCrawlWith,
+	}
+	if len(recorder.Queries) != len(kinds) {
+		t.Fatalf("Expected %d queries recorded, found %d", len(kinds), len(recorder.Queries))
+	}
+	for index, kind := range kinds {
+		if recorder.Queries[index].Kind != kind {
+			t.Errorf("Expected query %d of kind %d, found %d", index, kind, recorder.Queries[index].Kind)
+		}
+	}
+
+	// replay against a differently built tree finds the same:
+	other := NewWithOptions[AABB2D](Traits2D{}, Options{MaxChildren: 4, SplitPolicy: SplitMedian})
+	for index, box := range boxes {
+		if index%3 == 0 {
+			other.InsertTagged(box, Tag(3))
+		} else {
+			other.Insert(box)
+		}
+	}
+	for _, tree := range []*BVH[AABB2D]{bvh, other} {
+		results := recorder.Replay(tree)
+		for index, result := range results {
+			if result.Err != nil {
+				t.Errorf("Replayed query %d failed: %v", index, result.Err)
+			}
+			if expected[index] >= 0 && result.Found != expected[index] {
+				t.Errorf("Expected replayed query %d (kind %d) to find %d, found %d", index, kinds[index], expected[index], result.Found)
+			}
+			if expected[index] < 0 && result.Found == 0 {
+				t.Errorf("Expected replayed query %d (kind %d) to evaluate elements", index, kinds[index])
+			}
+		}
+		for index, element := range knn.Results() {
+			if element != nearest[index] {
+				t.Errorf("Expected the replayed nearest neighbor search to find the same")
+			}
+		}
+	}
+}
+
+// ........................................................
+
+// a ContextSearcher collecting the elements intersecting box
+type boxContextCollector struct {
+	box AABB2D
+}
+
+func (bc *boxContextCollector) DoesIntersect(qc *QueryContext, bound AABB2D) bool {
+	return boundsOverlap[AABB2D](Traits2D{}, bc.box, bound)
+}
+
+func (bc *boxContextCollector) Evaluate(qc *QueryContext, element Boundable[AABB2D]) error {
+	return nil
+}
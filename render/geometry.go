@@ -0,0 +1,209 @@
+package render
+
+import (
+	"math"
+
+	"github.com/drone115b/gobvh"
+)
+
+// ==============================================
+
+//
+// Vec3 is a point, direction or color.
+//
+type Vec3 [3]float64
+
+func (a Vec3) Add(b Vec3) Vec3 {
+	return Vec3{a[0] + b[0], a[1] + b[1], a[2] + b[2]}
+}
+
+func (a Vec3) Sub(b Vec3) Vec3 {
+	return Vec3{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func (a Vec3) Mul(b Vec3) Vec3 {
+	return Vec3{a[0] * b[0], a[1] * b[1], a[2] * b[2]}
+}
+
+func (a Vec3) Scale(s float64) Vec3 {
+	return Vec3{a[0] * s, a[1] * s, a[2] * s}
+}
+
+func (a Vec3) Dot(b Vec3) float64 {
+	return float64(a[0]*b[0]) + float64(a[1]*b[1]) + float64(a[2]*b[2]) // no fused multiply-add
+}
+
+func (a Vec3) Cross(b Vec3) Vec3 {
+	return Vec3{
+		float64(a[1]*b[2]) - float64(a[2]*b[1]),
+		float64(a[2]*b[0]) - float64(a[0]*b[2]),
+		float64(a[0]*b[1]) - float64(a[1]*b[0]),
+	}
+}
+
+func (a Vec3) Normalize() Vec3 {
+	length := math.Sqrt(a.Dot(a))
+	if length == 0.0 {
+		return a
+	}
+	return a.Scale(1.0 / length)
+}
+
+// ==============================================
+
+//
+// Box3 is the axis-aligned bound of a primitive.
+//
+type Box3 struct {
+	Min Vec3
+	Max Vec3
+}
+
+// ..............................................
+
+//
+// Box3Traits implements gobvh.BoundTraits[Box3].
+//
+type Box3Traits struct{}
+
+func (traits Box3Traits) IntervalRange(bound Box3, dim uint) (float64, float64) {
+	return bound.Min[dim], bound.Max[dim]
+}
+
+func (traits Box3Traits) Union(a Box3, b Box3) Box3 {
+	var union Box3
+	for i := range union.Min {
+		union.Min[i] = math.Min(a.Min[i], b.Min[i])
+		union.Max[i] = math.Max(a.Max[i], b.Max[i])
+	}
+	return union
+}
+
+func (traits Box3Traits) Dimensions(bound Box3) uint {
+	return 3
+}
+
+// ==============================================
+
+//
+// Material describes how a surface scatters and emits light: Albedo is the
+// fraction of light diffusely reflected, per color channel, and Emission
+// the light the surface gives off.
+//
+type Material struct {
+	Albedo   Vec3
+	Emission Vec3
+}
+
+// ..............................................
+
+//
+// Primitive is a renderable shape, stored in the scene's gobvh.BVH.
+//
+// Intersect(ray) reports the ray parameter of the first intersection within
+// [ray.TMin, ray.TMax], and whether there is one.  Normal(point) is the unit
+// surface normal at a point on the surface.
+//
+type Primitive interface {
+	gobvh.Boundable[Box3]
+	Intersect(ray gobvh.Ray) (float64, bool)
+	Normal(point Vec3) Vec3
+	Surface() Material
+}
+
+// ==============================================
+
+//
+// Sphere is a Primitive.
+//
+type Sphere struct {
+	Center   Vec3
+	Radius   float64
+	Material Material
+}
+
+func (s *Sphere) GetBound() Box3 {
+	r := Vec3{s.Radius, s.Radius, s.Radius}
+	return Box3{Min: s.Center.Sub(r), Max: s.Center.Add(r)}
+}
+
+func (s *Sphere) Intersect(ray gobvh.Ray) (float64, bool) {
+	origin, direction := toVec3(ray.Origin), toVec3(ray.Direction)
+	offset := origin.Sub(s.Center)
+	a := direction.Dot(direction)
+	b := offset.Dot(direction)
+	c := offset.Dot(offset) - float64(s.Radius*s.Radius)
+	disc := float64(b*b) - float64(a*c)
+	if disc < 0.0 {
+		return 0.0, false
+	}
+	sq := math.Sqrt(disc)
+	for _, t := range []float64{(-b - sq) / a, (-b + sq) / a} {
+		if t >= ray.TMin && t <= ray.TMax {
+			return t, true
+		}
+	}
+	return 0.0, false
+}
+
+func (s *Sphere) Normal(point Vec3) Vec3 {
+	return point.Sub(s.Center).Normalize()
+}
+
+func (s *Sphere) Surface() Material {
+	return s.Material
+}
+
+// ==============================================
+
+//
+// Triangle is a Primitive; its normal faces the side from which A, B, C
+// appear counter-clockwise, but rays hit both sides.
+//
+type Triangle struct {
+	A, B, C  Vec3
+	Material Material
+}
+
+func (tri *Triangle) GetBound() Box3 {
+	traits := Box3Traits{}
+	return traits.Union(traits.Union(Box3{Min: tri.A, Max: tri.A}, Box3{Min: tri.B, Max: tri.B}), Box3{Min: tri.C, Max: tri.C})
+}
+
+// Moller-Trumbore intersection
+func (tri *Triangle) Intersect(ray gobvh.Ray) (float64, bool) {
+	origin, direction := toVec3(ray.Origin), toVec3(ray.Direction)
+	edge1, edge2 := tri.B.Sub(tri.A), tri.C.Sub(tri.A)
+	p := direction.Cross(edge2)
+	det := edge1.Dot(p)
+	if math.Abs(det) < 1e-12 {
+		return 0.0, false
+	}
+	inverse := 1.0 / det
+	offset := origin.Sub(tri.A)
+	u := offset.Dot(p) * inverse
+	if u < 0.0 || u > 1.0 {
+		return 0.0, false
+	}
+	q := offset.Cross(edge1)
+	v := direction.Dot(q) * inverse
+	if v < 0.0 || u+v > 1.0 {
+		return 0.0, false
+	}
+	t := edge2.Dot(q) * inverse
+	return t, t >= ray.TMin && t <= ray.TMax
+}
+
+func (tri *Triangle) Normal(point Vec3) Vec3 {
+	return tri.B.Sub(tri.A).Cross(tri.C.Sub(tri.A)).Normalize()
+}
+
+func (tri *Triangle) Surface() Material {
+	return tri.Material
+}
+
+// ==============================================
+
+func toVec3(v []float64) Vec3 {
+	return Vec3{v[0], v[1], v[2]}
+}
@@ -0,0 +1,326 @@
+// # render: a tiny CPU path tracer built on GoBVH.
+//
+// Scenes of spheres and triangles are stored in a gobvh.BVH and traced with
+// BVH.Raycast(); shadow rays towards the sun are traced through the
+// pointer-free layout from BVH.ExportFlat(), the same way a compute shader
+// would.  It is an end-to-end exercise of the ray traversal, and an example
+// of using the package for graphics.
+//
+// Example:
+//
+//	scene := render.NewScene(render.DemoScene())
+//	img := render.Render(scene, render.DemoCamera(), 320, 240, 16, 1)
+//	render.WritePNG(file, img)
+//
+// There is no mesh subpackage; load triangles into Triangle primitives.
+//
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"math/rand"
+
+	"github.com/drone115b/gobvh"
+)
+
+// ==============================================
+
+// offset of secondary rays from the surface they leave, against self-intersection
+const rayEpsilon = 1e-6
+
+// number of bounces traced for each camera ray
+const maxBounces = 4
+
+// ..............................................
+
+//
+// Scene is a set of primitives lit by a sky and a sun.
+//
+// Light from the sky arrives along rays which escape the scene; SunDirection
+// (pointing towards the sun) and SunColor give direct light to unoccluded
+// surfaces.
+//
+type Scene struct {
+	SkyColor     Vec3
+	SunDirection Vec3
+	SunColor     Vec3
+
+	bvh  *gobvh.BVH[Box3]
+	flat *gobvh.FlatBuffer[Box3]
+}
+
+// ..............................................
+
+//
+// NewScene(primitives) builds the hierarchy over primitives, with a pale
+// sky and a sun high overhead.
+//
+func NewScene(primitives []Primitive) *Scene {
+	scene := &Scene{
+		SkyColor:     Vec3{0.6, 0.7, 0.9},
+		SunDirection: Vec3{0.4, 1.0, 0.3}.Normalize(),
+		SunColor:     Vec3{1.2, 1.1, 1.0},
+		bvh:          gobvh.New[Box3](Box3Traits{}),
+	}
+	for _, primitive := range primitives {
+		scene.bvh.Insert(primitive)
+	}
+	scene.flat = scene.bvh.ExportFlat(nil)
+	return scene
+}
+
+// ..............................................
+
+//
+// Scene.Intersect(ray) returns the closest primitive hit by the ray and the
+// ray parameter of the hit, or nil if nothing is hit.
+//
+func (scene *Scene) Intersect(ray gobvh.Ray) (Primitive, float64) {
+	closest, t, _ := scene.bvh.Raycast(ray, func(element gobvh.Boundable[Box3], ray gobvh.Ray) (float64, bool, error) {
+		t, ok := element.(Primitive).Intersect(ray)
+		return t, ok, nil
+	})
+	if closest == nil {
+		return nil, t
+	}
+	return closest.(Primitive), t
+}
+
+// ..............................................
+
+//
+// Scene.Occluded(ray) reports whether anything is hit by the ray, traversing
+// the flattened hierarchy.  It stops at the first hit found.
+//
+func (scene *Scene) Occluded(ray gobvh.Ray) bool {
+	fb := scene.flat
+	if fb.NodeCount() == 0 {
+		return false
+	}
+	stack := []int32{0}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !flatNodeHit(fb, node, ray) {
+			continue
+		}
+		start, count := fb.ElementStart[node], fb.ElementCount[node]
+		for _, index := range fb.ElementIndex[start : start+count] {
+			if _, ok := fb.Elements[index].(Primitive).Intersect(ray); ok {
+				return true
+			}
+		}
+		for child := fb.ChildStart[node]; child >= 0 && child < fb.ChildStart[node]+fb.ChildCount[node]; child++ {
+			stack = append(stack, child)
+		}
+	}
+	return false
+}
+
+// ..............................................
+
+//
+// Scene.Radiance(ray, rng) estimates the light arriving along the ray, by
+// following one random path of diffuse bounces.
+//
+func (scene *Scene) Radiance(ray gobvh.Ray, rng *rand.Rand) Vec3 {
+	radiance := Vec3{}
+	throughput := Vec3{1.0, 1.0, 1.0}
+	for bounce := 0; bounce < maxBounces; bounce++ {
+		primitive, t := scene.Intersect(ray)
+		if primitive == nil {
+			return radiance.Add(throughput.Mul(scene.SkyColor))
+		}
+		surface := primitive.Surface()
+		radiance = radiance.Add(throughput.Mul(surface.Emission))
+
+		point := toVec3(ray.At(t))
+		normal := primitive.Normal(point)
+		if normal.Dot(toVec3(ray.Direction)) > 0.0 {
+			normal = normal.Scale(-1.0) // the back of the surface
+		}
+		throughput = throughput.Mul(surface.Albedo)
+
+		// direct light from the sun:
+		if cosine := normal.Dot(scene.SunDirection); cosine > 0.0 {
+			shadow := gobvh.Ray{Origin: point[:], Direction: scene.SunDirection[:], TMin: rayEpsilon, TMax: math.Inf(1)}
+			if !scene.Occluded(shadow) {
+				radiance = radiance.Add(throughput.Mul(scene.SunColor).Scale(cosine))
+			}
+		}
+
+		direction := cosineSample(normal, rng)
+		ray = gobvh.Ray{Origin: point[:], Direction: direction[:], TMin: rayEpsilon, TMax: math.Inf(1)}
+	}
+	return radiance
+}
+
+// ==============================================
+
+//
+// Camera is a pinhole camera at Eye looking towards Target, with the
+// vertical field of view FOV (in degrees).
+//
+type Camera struct {
+	Eye    Vec3
+	Target Vec3
+	Up     Vec3
+	FOV    float64
+}
+
+// ..............................................
+
+//
+// Camera.Ray(u, v, aspect) returns the ray through the image position
+// (u, v), where both range over [0, 1] from the top left corner.
+//
+func (camera Camera) Ray(u float64, v float64, aspect float64) gobvh.Ray {
+	forward := camera.Target.Sub(camera.Eye).Normalize()
+	right := forward.Cross(camera.Up).Normalize()
+	up := right.Cross(forward)
+	height := math.Tan(camera.FOV * math.Pi / 360.0)
+	width := height * aspect
+	direction := forward.Add(right.Scale((2.0*u - 1.0) * width)).Add(up.Scale((1.0 - 2.0*v) * height)).Normalize()
+	eye := camera.Eye
+	return gobvh.NewRay(eye[:], direction[:])
+}
+
+// ==============================================
+
+//
+// Render(scene, camera, width, height, samples, seed) path traces an image,
+// averaging samples paths per pixel.  The same seed gives the same image.
+//
+func Render(scene *Scene, camera Camera, width int, height int, samples int, seed int64) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	rng := rand.New(rand.NewSource(seed))
+	aspect := float64(width) / float64(height)
+	if samples < 1 {
+		samples = 1
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sum := Vec3{}
+			for sample := 0; sample < samples; sample++ {
+				u := (float64(x) + rng.Float64()) / float64(width)
+				v := (float64(y) + rng.Float64()) / float64(height)
+				sum = sum.Add(scene.Radiance(camera.Ray(u, v, aspect), rng))
+			}
+			img.SetRGBA(x, y, toRGBA(sum.Scale(1.0/float64(samples))))
+		}
+	}
+	return img
+}
+
+// ..............................................
+
+//
+// WritePNG(w, img) encodes the image as a PNG.
+//
+func WritePNG(w io.Writer, img image.Image) error {
+	return png.Encode(w, img)
+}
+
+// ==============================================
+
+//
+// DemoScene() returns a small scene: a ground plane of two triangles, a
+// few spheres and a glowing pyramid.
+//
+func DemoScene() []Primitive {
+	ground := Material{Albedo: Vec3{0.7, 0.7, 0.65}}
+	primitives := []Primitive{
+		&Triangle{A: Vec3{-10, 0, -10}, B: Vec3{-10, 0, 10}, C: Vec3{10, 0, 10}, Material: ground},
+		&Triangle{A: Vec3{-10, 0, -10}, B: Vec3{10, 0, 10}, C: Vec3{10, 0, -10}, Material: ground},
+		&Sphere{Center: Vec3{0, 1, 0}, Radius: 1.0, Material: Material{Albedo: Vec3{0.8, 0.3, 0.3}}},
+		&Sphere{Center: Vec3{-2.2, 0.7, 0.8}, Radius: 0.7, Material: Material{Albedo: Vec3{0.3, 0.8, 0.3}}},
+		&Sphere{Center: Vec3{2.0, 0.5, 1.2}, Radius: 0.5, Material: Material{Albedo: Vec3{0.3, 0.3, 0.8}}},
+	}
+	// a small pyramid which gives off light:
+	glow := Material{Albedo: Vec3{0.2, 0.2, 0.2}, Emission: Vec3{4.0, 3.0, 1.0}}
+	apex := Vec3{1.2, 1.2, -1.5}
+	base := []Vec3{{0.6, 0, -2.1}, {1.8, 0, -2.1}, {1.8, 0, -0.9}, {0.6, 0, -0.9}}
+	for i := range base {
+		primitives = append(primitives, &Triangle{A: base[i], B: base[(i+1)%len(base)], C: apex, Material: glow})
+	}
+	// a scattering of small spheres:
+	rng := rand.New(rand.NewSource(5))
+	for i := 0; i < 60; i++ {
+		x, z := rng.Float64()*12.0-6.0, rng.Float64()*8.0-6.0
+		radius := 0.1 + rng.Float64()*0.15
+		albedo := Vec3{rng.Float64(), rng.Float64(), rng.Float64()}
+		primitives = append(primitives, &Sphere{Center: Vec3{x, radius, z}, Radius: radius, Material: Material{Albedo: albedo}})
+	}
+	return primitives
+}
+
+// ..............................................
+
+//
+// DemoCamera() returns a camera framing DemoScene().
+//
+func DemoCamera() Camera {
+	return Camera{Eye: Vec3{0, 2.5, 7}, Target: Vec3{0, 0.7, 0}, Up: Vec3{0, 1, 0}, FOV: 45.0}
+}
+
+// ==============================================
+
+// slab test of the ray against a node of the flat buffer, whose bounds
+// are widened slightly as they were rounded to float32
+func flatNodeHit[BoundType any](fb *gobvh.FlatBuffer[BoundType], node int32, ray gobvh.Ray) bool {
+	tnear, tfar := ray.TMin, ray.TMax
+	for i := uint(0); i < fb.Dimensions; i++ {
+		lo, hi := float64(fb.NodeLo[i][node]), float64(fb.NodeHi[i][node])
+		slack := 1e-6 * math.Max(1.0, math.Max(math.Abs(lo), math.Abs(hi)))
+		lo, hi = lo-slack, hi+slack
+		origin, direction := ray.Origin[i], ray.Direction[i]
+		if direction == 0.0 {
+			if origin < lo || origin > hi {
+				return false
+			}
+			continue
+		}
+		t0, t1 := (lo-origin)/direction, (hi-origin)/direction
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		tnear, tfar = math.Max(tnear, t0), math.Min(tfar, t1)
+		if tnear > tfar {
+			return false
+		}
+	}
+	return true
+}
+
+// ..............................................
+
+// a random direction about normal, with probability proportional to the cosine
+func cosineSample(normal Vec3, rng *rand.Rand) Vec3 {
+	// orthonormal basis about the normal:
+	helper := Vec3{1, 0, 0}
+	if math.Abs(normal[0]) > 0.9 {
+		helper = Vec3{0, 1, 0}
+	}
+	tangent := normal.Cross(helper).Normalize()
+	bitangent := normal.Cross(tangent)
+
+	r, phi := math.Sqrt(rng.Float64()), 2.0*math.Pi*rng.Float64()
+	x, y := r*math.Cos(phi), r*math.Sin(phi)
+	z := math.Sqrt(math.Max(0.0, 1.0-x*x-y*y))
+	return tangent.Scale(x).Add(bitangent.Scale(y)).Add(normal.Scale(z)).Normalize()
+}
+
+// ..............................................
+
+// gamma-corrects a linear color into 8 bits per channel
+func toRGBA(c Vec3) color.RGBA {
+	channel := func(v float64) uint8 {
+		v = math.Pow(math.Min(math.Max(v, 0.0), 1.0), 1.0/2.2)
+		return uint8(v*255.0 + 0.5)
+	}
+	return color.RGBA{R: channel(c[0]), G: channel(c[1]), B: channel(c[2]), A: 255}
+}
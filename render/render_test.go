@@ -0,0 +1,90 @@
+package render
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/drone115b/gobvh"
+)
+
+// ========================================================
+
+func TestPrimitives(t *testing.T) {
+	sphere := &Sphere{Center: Vec3{0, 0, 5}, Radius: 1.0}
+	if hit, ok := sphere.Intersect(gobvh.NewRay([]float64{0, 0, 0}, []float64{0, 0, 1})); !ok || math.Abs(hit-4.0) > 1e-12 {
+		t.Errorf("Expected to hit the sphere at 4, found %v %v", hit, ok)
+	}
+	if _, ok := sphere.Intersect(gobvh.NewRay([]float64{0, 2, 0}, []float64{0, 0, 1})); ok {
+		t.Errorf("Expected to miss the sphere")
+	}
+
+	triangle := &Triangle{A: Vec3{-1, -1, 3}, B: Vec3{1, -1, 3}, C: Vec3{0, 1, 3}}
+	if hit, ok := triangle.Intersect(gobvh.NewRay([]float64{0, 0, 0}, []float64{0, 0, 1})); !ok || math.Abs(hit-3.0) > 1e-12 {
+		t.Errorf("Expected to hit the triangle at 3, found %v %v", hit, ok)
+	}
+	if _, ok := triangle.Intersect(gobvh.NewRay([]float64{2, 0, 0}, []float64{0, 0, 1})); ok {
+		t.Errorf("Expected to miss the triangle")
+	}
+	if normal := triangle.Normal(Vec3{0, 0, 3}); math.Abs(normal[2]-1.0) > 1e-12 {
+		t.Errorf("Expected the triangle to face +z, found %v", normal)
+	}
+}
+
+// ........................................................
+
+func TestSceneOccluded(t *testing.T) {
+	// the flat traversal must agree with BVH.Raycast():
+	scene := NewScene(DemoScene())
+	rng := rand.New(rand.NewSource(3))
+	hits := 0
+	for i := 0; i < 2000; i++ {
+		origin := Vec3{rng.Float64()*12.0 - 6.0, rng.Float64() * 3.0, rng.Float64()*10.0 - 7.0}
+		direction := cosineSample(Vec3{0, 1, 0}, rng).Scale(-1.0)
+		if i%2 == 0 {
+			direction = cosineSample(Vec3{rng.Float64() - 0.5, 0.2, rng.Float64() - 0.5}.Normalize(), rng)
+		}
+		ray := gobvh.NewRay(origin[:], direction[:])
+		primitive, _ := scene.Intersect(ray)
+		if occluded := scene.Occluded(ray); occluded != (primitive != nil) {
+			t.Errorf("Expected Occluded() %v to agree with Intersect() %v", occluded, primitive != nil)
+		}
+		if primitive != nil {
+			hits++
+		}
+	}
+	if hits == 0 || hits == 2000 {
+		t.Errorf("Expected some rays to hit and some to miss, found %d hits", hits)
+	}
+}
+
+// ........................................................
+
+func TestRender(t *testing.T) {
+	scene := NewScene(DemoScene())
+	img := Render(scene, DemoCamera(), 32, 24, 2, 1)
+	again := Render(scene, DemoCamera(), 32, 24, 2, 1)
+	if !bytes.Equal(img.Pix, again.Pix) {
+		t.Errorf("Expected the same seed to render the same image")
+	}
+
+	// the image shows the sky above the horizon and the scene below:
+	top, bottom := img.RGBAAt(16, 0), img.RGBAAt(16, 23)
+	if top.B <= top.R {
+		t.Errorf("Expected blue sky at the top, found %v", top)
+	}
+	if top == bottom {
+		t.Errorf("Expected the ground to differ from the sky")
+	}
+
+	var buffer bytes.Buffer
+	if err := WritePNG(&buffer, img); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	decoded, err := png.Decode(&buffer)
+	if err != nil || decoded.Bounds() != img.Bounds() {
+		t.Errorf("Expected the PNG to decode to the image, found %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package gobvh
+
+// ==============================================
+
+// after an insertion of element (which has bound elembound) into node,
+// walks up the insertion path, see Options.Rotations: at each level, the
+// element, or the ancestor now holding it, is swapped with the cousin (a
+// child of one of its uncles) which lowers the SAH cost most.  A swap
+// changes the bounds the level above sees, so the walk goes on until a
+// level is left as it is.
+func (bvh *BVH[BoundType]) rotateInserted(node *bvhNode[BoundType], element Boundable[BoundType], elembound BoundType) {
+	if node.parent != nil {
+		node = node.parent // the insertion may have split node
+	}
+	container := bvh.findContainer(node, element, elembound)
+	var grown Boundable[BoundType] = element
+	for container != nil && container.parent != nil {
+		holder := bvh.rotateAt(container, grown)
+		if holder == container {
+			return
+		}
+		grown, container = holder, holder.parent
+	}
+}
+
+// ..............................................
+
+// swaps grown (a child of node) with a cousin of the same kind (element or
+// node), a child of a sibling of node, where that lowers the SAH cost (see
+// SAHCost()) the most, and returns the node holding grown afterwards.  Each
+// keeps its depth, so the tree stays balanced.
+//
+// A subtree only moves into a sibling which already encloses it: moving it
+// where it enlarges the sibling also lowers the cost at once, but leaves
+// nodes which later insertions enlarge more than they save.
+func (bvh *BVH[BoundType]) rotateAt(node *bvhNode[BoundType], grown Boundable[BoundType]) *bvhNode[BoundType] {
+	_, grownnode := grown.(*bvhNode[BoundType])
+	grownbound := grown.GetBound()
+	without := unionsWithout(bvh.boundtraits, node.children)
+	index := -1
+	for i, child := range node.children {
+		if child == grown {
+			index = i
+		}
+	}
+	if index < 0 || len(node.children) < 2 || boundContainsBound(bvh.boundtraits, without[index], grownbound) {
+		return node // (if the others enclose grown, no swap shrinks node)
+	}
+	evaluations := directCosts(node.children)
+	before := bvh.measure(node.bound) * (1.0 + evaluations)
+
+	var target *bvhNode[BoundType]
+	swap := -1
+	best := 0.0
+	for _, child := range node.parent.children {
+		sibling, ok := child.(*bvhNode[BoundType])
+		if !ok || sibling == node {
+			continue
+		}
+		if overlaps, _ := furthestDistanceMetric(bvh.boundtraits, sibling.bound, node.bound); !overlaps {
+			continue
+		}
+		siblingwithout := unionsWithout(bvh.boundtraits, sibling.children)
+		siblingevaluations := directCosts(sibling.children)
+		siblingbefore := bvh.measure(sibling.bound) * (1.0 + siblingevaluations)
+		for i, cousin := range sibling.children {
+			if _, cousinnode := cousin.(*bvhNode[BoundType]); cousinnode != grownnode {
+				continue
+			}
+			bound := bvh.boundtraits.Union(without[index], cousin.GetBound())
+			siblingbound := grownbound
+			if len(sibling.children) > 1 {
+				siblingbound = bvh.boundtraits.Union(siblingwithout[i], grownbound)
+			}
+			if grownnode && !boundContainsBound(bvh.boundtraits, sibling.bound, siblingbound) {
+				continue
+			}
+			// a node costs a visit, and the elements directly in it, weighted by its measure:
+			exchange := directCost(cousin) - directCost(grown)
+			after := bvh.measure(bvh.snap(bound))*(1.0+evaluations+exchange) +
+				bvh.measure(bvh.snap(siblingbound))*(1.0+siblingevaluations-exchange)
+			if gain := before + siblingbefore - after; gain > best {
+				best, target, swap = gain, sibling, i
+			}
+		}
+	}
+	if target == nil {
+		return node
+	}
+
+	node, target = bvh.own(node), bvh.own(target)
+	cousin := target.children[swap]
+	node.children[index], target.children[swap] = cousin, grown
+	if value, ok := cousin.(*bvhNode[BoundType]); ok {
+		value.parent = node
+	}
+	if value, ok := grown.(*bvhNode[BoundType]); ok {
+		value.parent = target
+	}
+	bvh.recalculateBounds(node)
+	bvh.recalculateBounds(target)
+	bvh.version++
+	return target
+}
+
+// ..............................................
+
+// the evaluation cost of the elements among children
+func directCosts[BoundType any](children []Boundable[BoundType]) float64 {
+	evaluations := 0.0
+	for _, child := range children {
+		evaluations += directCost(child)
+	}
+	return evaluations
+}
+
+// ..............................................
+
+// the evaluation cost of child in the node holding it: none for a node, whose
+// own children are evaluated below it
+func directCost[BoundType any](child Boundable[BoundType]) float64 {
+	if _, ok := child.(*bvhNode[BoundType]); ok {
+		return 0.0
+	}
+	return elementCost(child)
+}
+
+// ..............................................
+
+// for each child, the union of the bounds of the other children (unset if
+// there is only one child)
+func unionsWithout[BoundType any](bounder BoundTraits[BoundType], children []Boundable[BoundType]) []BoundType {
+	n := len(children)
+	without := make([]BoundType, n)
+	if n < 2 {
+		return without
+	}
+	// suffix unions first, then combined with the prefix unions:
+	without[n-1] = children[n-1].GetBound()
+	for index := n - 2; index > 0; index-- {
+		without[index] = bounder.Union(children[index].GetBound(), without[index+1])
+	}
+	prefix := children[0].GetBound()
+	without[0] = without[1]
+	for index := 1; index < n-1; index++ {
+		without[index] = bounder.Union(prefix, without[index+1])
+		prefix = bounder.Union(prefix, children[index].GetBound())
+	}
+	without[n-1] = prefix
+	return without
+}
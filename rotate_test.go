@@ -0,0 +1,109 @@
+package gobvh
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// ========================================================
+
+func TestOptionsRotations(t *testing.T) {
+	rng := rand.New(rand.NewSource(803))
+	plain := New[AABB2D](Traits2D{})
+	rotated := New[AABB2D](Traits2D{}, WithRotations())
+	live := make([]Boundable[AABB2D], 0, 8000)
+	for round := 0; round < 20000; round++ {
+		if len(live) > 5000 && rng.Intn(2) == 0 {
+			index := rng.Intn(len(live))
+			element := live[index]
+			live[index] = live[len(live)-1]
+			live = live[:len(live)-1]
+			if !plain.Erase(element) || !rotated.Erase(element) {
+				t.Fatalf("Failed to erase %v", element.GetBound())
+			}
+			continue
+		}
+		// a drifting band, as in a long-running world:
+		element := Point2D{float64(round)/400.0 + 3.0*rng.Float64(), 100.0 * rng.Float64()}
+		live = append(live, element)
+		plain.Insert(element)
+		rotated.InsertTagged(element, 5)
+	}
+
+	if err := rotated.Validate(); err != nil {
+		t.Errorf(err.Error())
+	}
+	if rotated.Len() != plain.Len() || rotated.ContentHash() != plain.ContentHash() {
+		t.Errorf("Expected rotations to keep the contents, found %d elements and %d", rotated.Len(), plain.Len())
+	}
+	for i := 0; i < 50; i++ {
+		region := randomRegion(rng, 5.0)
+		region.L[0] *= 0.5
+		region.H[0] = region.L[0] + 5.0
+		if rotated.Count(region) != plain.Count(region) {
+			t.Errorf("Search of the rotated tree disagrees with the plain tree in %v", region)
+		}
+	}
+	if len(rotated.FindAllWithTag(5, func(AABB2D) bool { return true })) != len(live) {
+		t.Errorf("Expected rotations to keep the tags (and bloom filters) of moved elements")
+	}
+	if plainstats, stats := plain.Stats(), rotated.Stats(); stats.Depth > plainstats.Depth {
+		t.Errorf("Expected rotations to keep the tree as shallow, found depths %d and %d", stats.Depth, plainstats.Depth)
+	}
+	if rotated.SAHCost() >= 0.95*plain.SAHCost() {
+		t.Errorf("Expected rotations to lower the cost, found %g and %g", rotated.SAHCost(), plain.SAHCost())
+	}
+}
+
+// ........................................................
+
+func TestOptionsRotationsInsertOrders(t *testing.T) {
+	rng := rand.New(rand.NewSource(8031))
+	scattered := make([]Boundable[AABB2D], 4000)
+	for index := range scattered {
+		scattered[index] = Point2D{100.0 * rng.Float64(), 100.0 * rng.Float64()}
+	}
+	unsorted := append([]Boundable[AABB2D](nil), scattered...)
+	sort.Slice(scattered, func(i, j int) bool {
+		return scattered[i].GetBound().L[0] < scattered[j].GetBound().L[0]
+	})
+	grid := make([]Boundable[AABB2D], 4000)
+	for index := range grid {
+		grid[index] = Point2D{float64(index % 100), float64(index / 100)}
+	}
+	ends := make([]Boundable[AABB2D], 0, len(scattered))
+	for first, last := 0, len(scattered)-1; first < last; first, last = first+1, last-1 {
+		ends = append(ends, scattered[first], scattered[last])
+	}
+
+	// orders which leave a plain tree's nodes long and overlapping, and the
+	// cost rotations bring them to (at most), relative to a plain tree's:
+	orders := []struct {
+		name     string
+		elements []Boundable[AABB2D]
+		ratio    float64
+	}{
+		{"sorted", scattered, 0.95},
+		{"rows", grid, 0.97},
+		{"both ends", ends, 0.75},
+		{"unsorted", unsorted, 0.7}, // (swapping only the elements leaves ~0.75)
+	}
+	for _, order := range orders {
+		plain := New[AABB2D](Traits2D{})
+		rotated := New[AABB2D](Traits2D{}, WithRotations())
+		for _, element := range order.elements {
+			plain.Insert(element)
+			rotated.Insert(element)
+		}
+		if err := rotated.Validate(); err != nil {
+			t.Fatalf("Validate (%s): %v", order.name, err)
+		}
+		if plainstats, stats := plain.Stats(), rotated.Stats(); stats.Depth > plainstats.Depth {
+			t.Errorf("Expected rotations (%s) to keep the tree as shallow, found depths %d and %d", order.name, stats.Depth, plainstats.Depth)
+		}
+		if rotated.SAHCost() >= order.ratio*plain.SAHCost() {
+			t.Errorf("Expected rotations (%s) to lower the cost below %g of %g, found %g", order.name, order.ratio, plain.SAHCost(), rotated.SAHCost())
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// # rtree: an R-tree style adapter for GoBVH.
+//
+// RTree mirrors the call shape of popular Go R-tree packages
+// (e.g. tidwall/rtree's RTreeG: Insert, Delete, Search, Scan, Len, Bounds),
+// so projects can swap their existing R-tree for a gobvh.BVH without
+// rewriting their call sites, e.g. to compare the two on their own data.
+//
+package rtree
+
+import (
+	"math"
+
+	"github.com/drone115b/gobvh"
+)
+
+// ==============================================
+
+//
+// Rect is the axis-aligned bounding box used by RTree.
+//
+type Rect struct {
+	Min [2]float64
+	Max [2]float64
+}
+
+// ..............................................
+
+//
+// RectTraits implements gobvh.BoundTraits[Rect].
+//
+type RectTraits struct{}
+
+func (traits RectTraits) IntervalRange(bound Rect, dim uint) (float64, float64) {
+	return bound.Min[dim], bound.Max[dim]
+}
+
+func (traits RectTraits) Union(a Rect, b Rect) Rect {
+	return Rect{
+		Min: [2]float64{math.Min(a.Min[0], b.Min[0]), math.Min(a.Min[1], b.Min[1])},
+		Max: [2]float64{math.Max(a.Max[0], b.Max[0]), math.Max(a.Max[1], b.Max[1])},
+	}
+}
+
+func (traits RectTraits) Dimensions(bound Rect) uint {
+	return 2
+}
+
+// ==============================================
+
+//
+// RTree stores data items with rectangular bounds in a gobvh.BVH.
+//
+// The zero value is not usable; create one with New().
+//
+type RTree[T comparable] struct {
+	bvh   *gobvh.BVH[Rect]
+	count int
+}
+
+// ..............................................
+
+//
+// New() creates an empty RTree.
+//
+func New[T comparable]() *RTree[T] {
+	return &RTree[T]{bvh: gobvh.New[Rect](RectTraits{})}
+}
+
+// ..............................................
+
+//
+// RTree.Insert(min, max, data) stores data with the bound (min, max).
+//
+func (tr *RTree[T]) Insert(min [2]float64, max [2]float64, data T) {
+	tr.bvh.Insert(&item[T]{rect: Rect{Min: min, Max: max}, data: data})
+	tr.count++
+}
+
+// ..............................................
+
+//
+// RTree.Delete(min, max, data) removes one item stored with exactly this
+// bound and data.  It reports whether an item was removed.
+//
+func (tr *RTree[T]) Delete(min [2]float64, max [2]float64, data T) bool {
+	rect := Rect{Min: min, Max: max}
+	var found *item[T]
+	tr.bvh.FindAll(&rectSearcher[T]{
+		rect: rect,
+		fn: func(candidate *item[T]) bool {
+			if candidate.rect == rect && candidate.data == data {
+				found = candidate
+				return false
+			}
+			return true
+		},
+	})
+	if found != nil && tr.bvh.Erase(found) {
+		tr.count--
+		return true
+	}
+	return false
+}
+
+// ..............................................
+
+//
+// RTree.Search(min, max, iter) calls iter for every item whose bound
+// intersects (min, max).  Returning false from iter stops the search.
+//
+func (tr *RTree[T]) Search(min [2]float64, max [2]float64, iter func(min [2]float64, max [2]float64, data T) bool) {
+	tr.bvh.FindAll(&rectSearcher[T]{
+		rect: Rect{Min: min, Max: max},
+		fn: func(candidate *item[T]) bool {
+			return iter(candidate.rect.Min, candidate.rect.Max, candidate.data)
+		},
+	})
+}
+
+// ..............................................
+
+//
+// RTree.Scan(iter) calls iter for every item.  Returning false from iter stops the scan.
+//
+func (tr *RTree[T]) Scan(iter func(min [2]float64, max [2]float64, data T) bool) {
+	everything := [2]float64{math.Inf(-1), math.Inf(-1)}
+	tr.Search(everything, [2]float64{math.Inf(1), math.Inf(1)}, iter)
+}
+
+// ..............................................
+
+//
+// RTree.Len() reports the number of items stored.
+//
+func (tr *RTree[T]) Len() int {
+	return tr.count
+}
+
+// ..............................................
+
+//
+// RTree.Bounds() reports the bound of all items stored.
+//
+func (tr *RTree[T]) Bounds() ([2]float64, [2]float64) {
+	if tr.count == 0 {
+		return [2]float64{}, [2]float64{}
+	}
+	rect := tr.bvh.GetBound()
+	return rect.Min, rect.Max
+}
+
+// ..............................................
+
+//
+// RTree.BVH() gives access to the underlying hierarchy, e.g. for the
+// queries that have no R-tree equivalent.  Its elements are unexported
+// item types; do not insert into it directly.
+//
+func (tr *RTree[T]) BVH() *gobvh.BVH[Rect] {
+	return tr.bvh
+}
+
+// ==============================================
+
+type item[T comparable] struct {
+	rect Rect
+	data T
+}
+
+func (it *item[T]) GetBound() Rect {
+	return it.rect
+}
+
+// ..............................................
+
+// calls fn for each item intersecting rect, until fn returns false.
+type rectSearcher[T comparable] struct {
+	rect Rect
+	fn   func(*item[T]) bool
+}
+
+var errStop = stopError{}
+
+type stopError struct{}
+
+func (stopError) Error() string {
+	return "rtree: search stopped"
+}
+
+func (rs *rectSearcher[T]) DoesIntersect(bound Rect) bool {
+	return intersects(rs.rect, bound)
+}
+
+func (rs *rectSearcher[T]) Evaluate(element gobvh.Boundable[Rect]) error {
+	candidate, ok := element.(*item[T])
+	if ok && intersects(rs.rect, candidate.rect) {
+		if !rs.fn(candidate) {
+			return errStop // unwinds the traversal
+		}
+	}
+	return nil
+}
+
+func intersects(a Rect, b Rect) bool {
+	return a.Min[0] <= b.Max[0] && b.Min[0] <= a.Max[0] && a.Min[1] <= b.Max[1] && b.Min[1] <= a.Max[1]
+}
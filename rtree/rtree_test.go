@@ -0,0 +1,56 @@
+package rtree
+
+import (
+	"testing"
+)
+
+// ========================================================
+
+func TestRTree(t *testing.T) {
+	tr := New[int]()
+	for i := 0; i < 100; i++ {
+		x, y := float64(i%10), float64(i/10)
+		tr.Insert([2]float64{x, y}, [2]float64{x + 0.5, y + 0.5}, i)
+	}
+	if tr.Len() != 100 {
+		t.Errorf("Expected 100 items, found %d", tr.Len())
+	}
+	min, max := tr.Bounds()
+	if min != [2]float64{0.0, 0.0} || max != [2]float64{9.5, 9.5} {
+		t.Errorf("Unexpected bounds (%v %v)", min, max)
+	}
+
+	found := map[int]bool{}
+	tr.Search([2]float64{2.0, 2.0}, [2]float64{3.0, 3.0}, func(min [2]float64, max [2]float64, data int) bool {
+		found[data] = true
+		return true
+	})
+	if len(found) != 4 || !found[22] || !found[23] || !found[32] || !found[33] {
+		t.Errorf("Unexpected search results %v", found)
+	}
+
+	// stopping early:
+	visits := 0
+	tr.Scan(func(min [2]float64, max [2]float64, data int) bool {
+		visits++
+		return visits < 10
+	})
+	if visits != 10 {
+		t.Errorf("Expected the scan to stop after 10 items, visited %d", visits)
+	}
+
+	if tr.Delete([2]float64{2.0, 2.0}, [2]float64{2.5, 2.5}, 23) {
+		t.Errorf("Deleted an item with mismatched data")
+	}
+	if !tr.Delete([2]float64{2.0, 2.0}, [2]float64{2.5, 2.5}, 22) {
+		t.Errorf("Failed to delete an item")
+	}
+	count := 0
+	tr.Scan(func(min [2]float64, max [2]float64, data int) bool {
+		count++
+		return data != 22
+	})
+	if count != 99 || tr.Len() != 99 {
+		t.Errorf("Expected 99 items after deletion, found %d (Len %d)", count, tr.Len())
+	}
+}
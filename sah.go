@@ -0,0 +1,201 @@
+package gobvh
+
+import (
+	"math"
+)
+
+// ==============================================
+
+//
+// MeasureTraits is an optional extension of BoundTraits for the surface area
+// heuristic (BuildSAH(), SAHCost() and OptimizeWorst()).
+//
+// Measure(bound) reports a size of the bound proportional to the chance that
+// a search enters it: its surface area for ray tracing in 3D, its perimeter
+// in 2D, or e.g. its volume for searches by small regions.  It must grow
+// with the bound, and should be zero only for a degenerate bound.
+//
+// If your BoundTraits do not implement MeasureTraits, bounds are treated as
+// the axis-aligned boxes given by IntervalRange(), measured by half their
+// surface area (the perimeter in 2D, the length in 1D).
+//
+type MeasureTraits[BoundType any] interface {
+	Measure(bound BoundType) float64
+}
+
+// ..............................................
+
+//
+// BVH.BuildSAH(elements) replaces the contents of the bvh with elements,
+// built top-down by the surface area heuristic (SAH).
+//
+// Each node divides its elements by the plane, among a set of candidates
+// along every axis, which minimizes the expected cost of a search: the
+// number of elements on each side weighted by the measure (see
+// MeasureTraits) of their bound.  This gives the lowest SAHCost() of the
+// builders, and so the fastest searches, at a higher cost than Build() or
+// BuildLBVH(); it suits data built once and searched many times, e.g. the
+// scene of an offline ray tracer.
+//
+// As ResetArena(), the nodes of the previous contents are recycled, and
+// tags and other per-element annotations are forgotten.  The order of
+// elements is not changed.
+//
+func (bvh *BVH[BoundType]) BuildSAH(elements []Boundable[BoundType]) {
+	bvh.ResetArena()
+	if len(elements) == 0 {
+		return
+	}
+	items := append(make([]Boundable[BoundType], 0, len(elements)), elements...)
+	bvh.buildNodeWith(&bvh.root, items, bvh.planSAHGroups)
+	bvh.built(elements)
+}
+
+// ==============================================
+
+// number of bins of centroids per axis; the planes between them are the candidates
+const sahBins = 16
+
+// ..............................................
+
+// the measure of a bound, see MeasureTraits
+func (bvh *BVH[BoundType]) measure(bound BoundType) float64 {
+	return measureBound(bvh.boundtraits, bound)
+}
+
+func measureBound[BoundType any](bounder BoundTraits[BoundType], bound BoundType) float64 {
+	if measuretraits, ok := bounder.(MeasureTraits[BoundType]); ok {
+		return measuretraits.Measure(bound)
+	}
+	return sahArea(bounder, bound)
+}
+
+// ..............................................
+
+// divides elements (which are reordered) into the children of a node, as
+// planGroups() does, but halving the largest group at its best SAH plane
+func (bvh *BVH[BoundType]) planSAHGroups(elements []Boundable[BoundType]) [][]Boundable[BoundType] {
+	fanout := bvh.options.MaxChildren - 1
+	groups := [][]Boundable[BoundType]{elements}
+	for len(groups) < fanout {
+		largest := 0
+		for index, group := range groups {
+			if len(group) > len(groups[largest]) {
+				largest = index
+			}
+		}
+		group := groups[largest]
+		if len(group) <= fanout {
+			break
+		}
+		half := bvh.sahPartition(group)
+		groups[largest] = group[:half]
+		groups = append(groups, group[half:])
+	}
+	return groups
+}
+
+// ..............................................
+
+// moves the elements on the low side of the best SAH plane to the front,
+// and returns how many there are (neither side is empty).  The candidates
+// are the boundaries of sahBins equal bins of centroids along each axis; if
+// no candidate divides the elements, they are halved at their median.
+func (bvh *BVH[BoundType]) sahPartition(elements []Boundable[BoundType]) int {
+	bounder := bvh.boundtraits
+	dims := bounder.Dimensions(elements[0].GetBound())
+
+	var bestaxis uint
+	bestplane, bestlo, bestwidth := -1, 0.0, 0.0
+	bestcost := math.Inf(1)
+	var counts [sahBins]int
+	var bounds [sahBins]BoundType
+	var right [sahBins]float64
+	var axis uint
+	for axis = 0; axis < dims; axis++ {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, element := range elements {
+			c := centroidAlong(bounder, element.GetBound(), axis)
+			lo, hi = math.Min(lo, c), math.Max(hi, c)
+		}
+		if !(hi > lo) || math.IsInf(hi-lo, 0) {
+			continue
+		}
+		width := (hi - lo) / sahBins
+
+		counts = [sahBins]int{}
+		for _, element := range elements {
+			bound := element.GetBound()
+			bin := sahBin(centroidAlong(bounder, bound, axis), lo, width)
+			if counts[bin] == 0 {
+				bounds[bin] = bound
+			} else {
+				bounds[bin] = bounder.Union(bounds[bin], bound)
+			}
+			counts[bin]++
+		}
+
+		// sweep from the high end for the cost right of each plane, then from the low end:
+		var union BoundType
+		count := 0
+		for bin := sahBins - 1; bin > 0; bin-- {
+			if counts[bin] > 0 {
+				if count == 0 {
+					union = bounds[bin]
+				} else {
+					union = bounder.Union(union, bounds[bin])
+				}
+				count += counts[bin]
+			}
+			right[bin] = 0.0
+			if count > 0 {
+				right[bin] = float64(count) * bvh.measure(union)
+			}
+		}
+		count = 0
+		for plane := 1; plane < sahBins; plane++ {
+			bin := plane - 1
+			if counts[bin] > 0 {
+				if count == 0 {
+					union = bounds[bin]
+				} else {
+					union = bounder.Union(union, bounds[bin])
+				}
+				count += counts[bin]
+			}
+			if count == 0 || count == len(elements) {
+				continue
+			}
+			cost := float64(count)*bvh.measure(union) + right[plane]
+			if cost < bestcost {
+				bestcost, bestaxis, bestplane, bestlo, bestwidth = cost, axis, plane, lo, width
+			}
+		}
+	}
+
+	if bestplane < 0 {
+		bvh.sortCentroids(elements)
+		return len(elements) / 2
+	}
+	front := 0
+	for index, element := range elements {
+		if sahBin(centroidAlong(bounder, element.GetBound(), bestaxis), bestlo, bestwidth) < bestplane {
+			elements[front], elements[index] = elements[index], elements[front]
+			front++
+		}
+	}
+	return front
+}
+
+// ..............................................
+
+// the bin of a centroid c
+func sahBin(c float64, lo float64, width float64) int {
+	bin := int((c - lo) / width)
+	if bin < 0 {
+		return 0
+	} else if bin >= sahBins {
+		return sahBins - 1
+	}
+	return bin
+}
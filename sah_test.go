@@ -0,0 +1,85 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+// areaTraits measures bounds by their area, see MeasureTraits
+type areaTraits struct {
+	Traits2D
+	calls *int
+}
+
+func (traits areaTraits) Measure(bound AABB2D) float64 {
+	*traits.calls++
+	return (bound.H[0] - bound.L[0]) * (bound.H[1] - bound.L[1])
+}
+
+// ========================================================
+
+func TestBVHBuildSAH(t *testing.T) {
+	rng := rand.New(rand.NewSource(788))
+	elements := make([]Boundable[AABB2D], 0, 4000)
+	for _, element := range randomBoxes(rng, 3000, 2.0) {
+		elements = append(elements, element)
+	}
+	for i := 0; i < 1000; i++ { // a dense cluster, and duplicates
+		elements = append(elements, Point2D{10.0 + rng.Float64(), 10.0})
+	}
+	inserted, packed := New[AABB2D](Traits2D{}), New[AABB2D](Traits2D{})
+	for _, element := range elements {
+		inserted.Insert(element)
+	}
+	packed.Build(elements)
+
+	bvh := New[AABB2D](Traits2D{})
+	bvh.BuildSAH(elements)
+	if err := bvh.Validate(); err != nil {
+		t.Errorf(err.Error())
+	}
+	if bvh.Len() != len(elements) || bvh.ContentHash() != inserted.ContentHash() {
+		t.Errorf("Expected %d elements after BuildSAH(), found %d", len(elements), bvh.Len())
+	}
+	for i := 0; i < 50; i++ {
+		region := randomRegion(rng, 10.0)
+		if bvh.Count(region) != inserted.Count(region) {
+			t.Errorf("Search of the SAH tree disagrees with the inserted tree in %v", region)
+		}
+	}
+	if bvh.SAHCost() >= inserted.SAHCost() || bvh.SAHCost() >= packed.SAHCost() {
+		t.Errorf("Expected the SAH tree to cost least, found %g (inserted %g, packed %g)",
+			bvh.SAHCost(), inserted.SAHCost(), packed.SAHCost())
+	}
+
+	calls := 0
+	measured := New[AABB2D](areaTraits{calls: &calls})
+	measured.BuildSAH(elements)
+	if err := measured.Validate(); err != nil || measured.Len() != len(elements) {
+		t.Errorf("Expected a valid tree built by Measure(), found %d elements (%v)", measured.Len(), err)
+	}
+	if calls == 0 {
+		t.Errorf("Expected BuildSAH() to use MeasureTraits")
+	}
+
+	bvh.BuildSAH(elements[:5])
+	if err := bvh.Validate(); err != nil || bvh.Len() != 5 || bvh.root.descendants != 0 {
+		t.Errorf("Expected a small build to fit in the root, found %d elements (%v)", bvh.Len(), err)
+	}
+}
+
+// ........................................................
+
+func BenchmarkBuildSAH(b *testing.B) {
+	rng := rand.New(rand.NewSource(788))
+	elements := make([]Boundable[AABB2D], 100000)
+	for i, element := range randomBoxes(rng, len(elements), 1.0) {
+		elements[i] = element
+	}
+	bvh := New[AABB2D](Traits2D{})
+	for i := 0; i < b.N; i++ {
+		bvh.BuildSAH(elements)
+	}
+}
@@ -0,0 +1,67 @@
+package gobvh
+
+import (
+	"math"
+)
+
+// ==============================================
+
+//
+// BVH.Shapecast(start, delta, hitFn) sweeps the bound start along delta
+// (one entry per dimension) and calls hitFn(element, toi) for every element
+// whose bound the swept bound touches, in increasing order of the time of
+// impact toi, where 0 <= toi <= 1 is the fraction of delta travelled at
+// first contact.  Elements already overlapping start have toi 0.
+//
+// Bounds are treated as the axis-aligned boxes given by IntervalRange(), so
+// hitFn should perform any exact test; an error returned by hitFn stops the
+// shapecast and is returned, e.g. to stop at the first real hit.
+//
+func (bvh *BVH[BoundType]) Shapecast(start BoundType, delta []float64, hitFn func(element Boundable[BoundType], toi float64) error) error {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryShapecast, Bound: start, Point: delta})
+	}
+	cutoff := 1.0
+	key := func(bound BoundType) (float64, bool) {
+		return sweepBoxInterval(bvh.boundtraits, start, delta, bound)
+	}
+	return orderedDescent(&bvh.root, key, &cutoff, nil, func(element Boundable[BoundType], toi float64) error {
+		bvh.hit(element)
+		return hitFn(element, toi)
+	})
+}
+
+// ==============================================
+
+// reports the earliest time 0 <= t <= 1 at which the box moving moved by
+// t * delta touches the box target, and false if it never does.
+func sweepBoxInterval[BoundType any](bounder BoundTraits[BoundType], moving BoundType, delta []float64, target BoundType) (float64, bool) {
+	tnear, tfar := 0.0, 1.0
+	var i uint
+	for i = 0; i < bounder.Dimensions(target); i++ {
+		mlo, mhi := bounder.IntervalRange(moving, i)
+		tlo, thi := bounder.IntervalRange(target, i)
+		// the moving interval overlaps the target while tlo - mhi <= t * d <= thi - mlo:
+		lo, hi := tlo-mhi, thi-mlo
+		d := 0.0
+		if int(i) < len(delta) {
+			d = delta[i]
+		}
+		if d == 0.0 {
+			if lo > 0.0 || hi < 0.0 {
+				return tnear, false
+			}
+			continue
+		}
+		t0, t1 := lo/d, hi/d
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		tnear = math.Max(tnear, t0)
+		tfar = math.Min(tfar, t1)
+		if tnear > tfar {
+			return tnear, false
+		}
+	}
+	return tnear, true
+}
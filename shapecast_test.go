@@ -0,0 +1,94 @@
+package gobvh
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func translate2D(b AABB2D, delta []float64, t float64) AABB2D {
+	return AABB2D{
+		L: Point2D{b.L[0] + t*delta[0], b.L[1] + t*delta[1]},
+		H: Point2D{b.H[0] + t*delta[0], b.H[1] + t*delta[1]},
+	}
+}
+
+// ........................................................
+
+func TestBVHShapecast(t *testing.T) {
+	rng := rand.New(rand.NewSource(68))
+	boxes := randomBoxes(rng, 1200, 2.0)
+	bvh := New[AABB2D](Traits2D{})
+	for _, element := range boxes {
+		bvh.Insert(element)
+	}
+	traits := Traits2D{}
+
+	for i := 0; i < 50; i++ {
+		x, y := rng.Float64()*100.0, rng.Float64()*100.0
+		start := AABB2D{L: Point2D{x, y}, H: Point2D{x + 1.0, y + 1.5}}
+		delta := []float64{rng.Float64()*60.0 - 30.0, rng.Float64()*60.0 - 30.0}
+
+		expected := 0
+		for _, element := range boxes {
+			if _, ok := sweepBoxInterval[AABB2D](traits, start, delta, element.GetBound()); ok {
+				expected++
+			}
+		}
+
+		found := 0
+		previous := 0.0
+		err := bvh.Shapecast(start, delta, func(element Boundable[AABB2D], toi float64) error {
+			found++
+			if toi < previous {
+				t.Errorf("Expected hits in increasing time of impact, %v came after %v", toi, previous)
+			}
+			previous = toi
+			bound := element.GetBound()
+			if !boundsOverlap[AABB2D](traits, translate2D(start, delta, toi+1e-9), bound) {
+				t.Errorf("Expected the swept bound to touch the element at its time of impact")
+			}
+			if toi > 1e-6 && boundsOverlap[AABB2D](traits, translate2D(start, delta, toi-1e-6), bound) {
+				t.Errorf("Expected the swept bound not to touch the element before its time of impact")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if found != expected {
+			t.Errorf("Expected %d hits, found %d", expected, found)
+		}
+	}
+
+	// a single obstacle, directly in the path:
+	single := New[AABB2D](Traits2D{})
+	wall := &Box2D{AABB2D{L: Point2D{10, -5}, H: Point2D{11, 5}}}
+	single.Insert(wall)
+	single.Insert(&Box2D{AABB2D{L: Point2D{10, 20}, H: Point2D{11, 30}}})
+	hits := 0
+	single.Shapecast(AABB2D{L: Point2D{0, 0}, H: Point2D{2, 2}}, []float64{16, 0}, func(element Boundable[AABB2D], toi float64) error {
+		hits++
+		if element != wall || math.Abs(toi-0.5) > 1e-12 {
+			t.Errorf("Expected to hit the wall at 0.5, found %v", toi)
+		}
+		return nil
+	})
+	if hits != 1 {
+		t.Errorf("Expected exactly one hit, found %d", hits)
+	}
+
+	// an error stops the shapecast:
+	stop := errors.New("stop")
+	calls := 0
+	err := bvh.Shapecast(AABB2D{L: Point2D{0, 0}, H: Point2D{5, 5}}, []float64{100, 100}, func(element Boundable[AABB2D], toi float64) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("Expected the error to stop the shapecast after one call, found %v after %d", err, calls)
+	}
+}
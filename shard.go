@@ -0,0 +1,80 @@
+package gobvh
+
+// ==============================================
+
+// the fewest elements worth building a shard of its own, see BuildShards()
+const minShardSize = 4096
+
+// ..............................................
+
+//
+// BVH.BuildShards(elements) partitions elements spatially into shards, one
+// for each of up to Options.BuildWorkers goroutines, and builds each shard
+// into a tree of its own, concurrently, as Build() does; Merge() then adds
+// the shards to the bvh.
+//
+// The elements are sorted along a Hilbert curve (see PackHilbert) and cut
+// into runs of about equal size, so each shard covers a compact region, and
+// Merge() grafts its subtrees whole rather than inserting its elements one
+// at a time.  Inputs too small to be worth dividing make a single shard.
+//
+// BuildShards() reads only the traits and options of the bvh, never its
+// contents, so it may run while the bvh is searched or modified elsewhere;
+// only the merge needs exclusive access, e.g. for concurrent ingestion:
+//
+//	shards := bvh.BuildShards(batch) // without the lock
+//	lock.Lock()
+//	for _, shard := range shards {
+//		bvh.Merge(shard)
+//	}
+//	lock.Unlock()
+//
+// As for Build(), GetBound() and the traits must be safe to call
+// concurrently.  The order of elements is not changed.
+//
+func (bvh *BVH[BoundType]) BuildShards(elements []Boundable[BoundType]) []*BVH[BoundType] {
+	if len(elements) == 0 {
+		return nil
+	}
+	workers := bvh.buildWorkers()
+	count := len(elements) / minShardSize
+	if count > workers {
+		count = workers
+	}
+	if count < 1 {
+		count = 1
+	}
+
+	items := append(make([]Boundable[BoundType], 0, len(elements)), elements...)
+	if count > 1 {
+		items = bvh.hilbertOrder(items, workers)
+	}
+
+	// each shard is built on a goroutine of its own:
+	options := bvh.options
+	options.BuildWorkers = 1
+	shards := make([]*BVH[BoundType], count)
+	parallelFor(count, count, func(index int) {
+		shard := NewWithOptions(bvh.boundtraits, options)
+		shard.Build(items[index*len(items)/count : (index+1)*len(items)/count])
+		shards[index] = shard
+	})
+	return shards
+}
+
+// ..............................................
+
+//
+// BVH.InsertBulk(elements) inserts elements by building shards of them
+// concurrently and merging the shards into the bvh, see BuildShards().
+//
+// For large batches this is far faster than calling Insert() for each
+// element, and the subtrees grafted are packed as tightly as Build() packs
+// them; where the batch overlaps the existing contents, the grafts overlap
+// their new siblings, as for Merge(), and Optimize() may be worthwhile.
+//
+func (bvh *BVH[BoundType]) InsertBulk(elements []Boundable[BoundType]) {
+	for _, shard := range bvh.BuildShards(elements) {
+		bvh.Merge(shard)
+	}
+}
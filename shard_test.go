@@ -0,0 +1,98 @@
+package gobvh
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHBuildShards(t *testing.T) {
+	rng := rand.New(rand.NewSource(811))
+	boxes := randomBoxes(rng, 5*minShardSize, 1.0)
+	bvh := New[AABB2D](Traits2D{}, WithBuildWorkers(4))
+
+	shards := bvh.BuildShards(boxes)
+	if len(shards) != 4 {
+		t.Fatalf("Expected 4 shards, got %d", len(shards))
+	}
+	seen := make(map[Boundable[AABB2D]]bool)
+	var area float64
+	for _, shard := range shards {
+		if err := shard.Validate(); err != nil {
+			t.Fatalf("Validate shard: %v", err)
+		}
+		for _, element := range collectElements(&shard.root, nil) {
+			if seen[element] {
+				t.Fatalf("Expected each element in a single shard")
+			}
+			seen[element] = true
+		}
+		bound := shard.GetBound()
+		area += (bound.H[0] - bound.L[0]) * (bound.H[1] - bound.L[1])
+	}
+	if len(seen) != len(boxes) || bvh.Len() != 0 {
+		t.Errorf("Expected the shards to hold all %d elements, and the bvh none", len(boxes))
+	}
+	if area > 2.0*101.0*101.0 {
+		t.Errorf("Expected the shards to partition space, but their bounds cover %v", area)
+	}
+	if shards := bvh.BuildShards(boxes[:100]); len(shards) != 1 || shards[0].Len() != 100 {
+		t.Errorf("Expected a small input to make a single shard")
+	}
+}
+
+// ........................................................
+
+func TestBVHInsertBulk(t *testing.T) {
+	rng := rand.New(rand.NewSource(823))
+	boxes := randomBoxes(rng, 3*minShardSize+1000, 1.0)
+	bvh := New[AABB2D](Traits2D{}, WithBuildWorkers(3))
+	inserted := New[AABB2D](Traits2D{})
+	for _, element := range boxes[:1000] {
+		bvh.Insert(element)
+		inserted.Insert(element)
+	}
+
+	// batches are sharded concurrently, and merged under a lock:
+	var lock sync.Mutex
+	var ingesters sync.WaitGroup
+	for batch := 0; batch < 3; batch++ {
+		elements := boxes[1000+batch*minShardSize : 1000+(batch+1)*minShardSize]
+		for _, element := range elements {
+			inserted.Insert(element)
+		}
+		ingesters.Add(1)
+		go func() {
+			defer ingesters.Done()
+			shards := bvh.BuildShards(elements)
+			lock.Lock()
+			defer lock.Unlock()
+			for _, shard := range shards {
+				bvh.Merge(shard)
+			}
+		}()
+	}
+	ingesters.Wait()
+	bvh.InsertBulk(nil)
+
+	if err := bvh.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if bvh.Len() != inserted.Len() || bvh.ContentHash() != inserted.ContentHash() {
+		t.Errorf("Expected %d elements after the bulk insertions, found %d", inserted.Len(), bvh.Len())
+	}
+	for i := 0; i < 50; i++ {
+		region := randomRegion(rng, 10.0)
+		if bvh.Count(region) != inserted.Count(region) {
+			t.Errorf("Search of the bulk-inserted tree disagrees with the inserted tree in %v", region)
+		}
+	}
+
+	bulk := New[AABB2D](Traits2D{})
+	bulk.InsertBulk(boxes)
+	if err := bulk.Validate(); err != nil || bulk.Len() != len(boxes) {
+		t.Errorf("Expected InsertBulk() into an empty tree to hold %d elements, found %d (%v)", len(boxes), bulk.Len(), err)
+	}
+}
@@ -0,0 +1,39 @@
+package gobvh
+
+import (
+	"math"
+)
+
+// ==============================================
+
+//
+// SnapTraits is an optional extension of BoundTraits for grid-snapped node
+// bounds, see Options.SnapGrid.
+//
+// Snap(bound, grid) returns the smallest bound on the grid (with spacing
+// grid along every dimension) which contains bound; SnapInterval() does
+// this for one dimension.
+//
+type SnapTraits[BoundType any] interface {
+	Snap(bound BoundType, grid float64) BoundType
+}
+
+// ..............................................
+
+//
+// SnapInterval(lo, hi, grid) widens the interval [lo, hi] outwards to the
+// nearest multiples of grid.
+//
+func SnapInterval(lo float64, hi float64, grid float64) (float64, float64) {
+	return math.Floor(lo/grid) * grid, math.Ceil(hi/grid) * grid
+}
+
+// ==============================================
+
+// snaps a node bound to the grid, if the options and traits call for it
+func (bvh *BVH[BoundType]) snap(bound BoundType) BoundType {
+	if bvh.snaptraits == nil {
+		return bound
+	}
+	return bvh.snaptraits.Snap(bound, bvh.options.SnapGrid)
+}
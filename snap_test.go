@@ -0,0 +1,134 @@
+package gobvh
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+// SnapTraits2D adds SnapTraits to Traits2D:
+type SnapTraits2D struct {
+	Traits2D
+}
+
+func (st SnapTraits2D) Snap(bound AABB2D, grid float64) AABB2D {
+	var snapped AABB2D
+	for i := range snapped.L {
+		snapped.L[i], snapped.H[i] = SnapInterval(bound.L[i], bound.H[i], grid)
+	}
+	return snapped
+}
+
+// reports whether every node bound lies on the grid and contains its children
+func checkSnapped(t *testing.T, node *bvhNode[AABB2D], grid float64) {
+	for i := range node.bound.L {
+		for _, value := range []float64{node.bound.L[i], node.bound.H[i]} {
+			if math.Abs(value/grid-math.Round(value/grid)) > 1e-9 {
+				t.Fatalf("Expected node bound %v to lie on the grid", node.bound)
+			}
+		}
+	}
+	for _, child := range node.children {
+		bound := child.GetBound()
+		if bound.L[0] < node.bound.L[0] || bound.L[1] < node.bound.L[1] || bound.H[0] > node.bound.H[0] || bound.H[1] > node.bound.H[1] {
+			t.Fatalf("Expected node bound %v to contain child bound %v", node.bound, bound)
+		}
+		if value, ok := child.(*bvhNode[AABB2D]); ok {
+			checkSnapped(t, value, grid)
+		}
+	}
+}
+
+// reports whether two trees have the same shape and exactly the same node bounds
+func sameNodeBounds(a *bvhNode[AABB2D], b *bvhNode[AABB2D]) bool {
+	if a.bound != b.bound || len(a.children) != len(b.children) {
+		return false
+	}
+	for i := range a.children {
+		nodea, oka := a.children[i].(*bvhNode[AABB2D])
+		nodeb, okb := b.children[i].(*bvhNode[AABB2D])
+		if oka != okb || (oka && !sameNodeBounds(nodea, nodeb)) {
+			return false
+		}
+	}
+	return true
+}
+
+// ........................................................
+
+func TestBVHSnapGrid(t *testing.T) {
+	rng := rand.New(rand.NewSource(70))
+	boxes := randomBoxes(rng, 1500, 2.0)
+	options := DefaultOptions()
+	options.SnapGrid = 0.25
+	bvh := NewWithOptions[AABB2D](SnapTraits2D{}, options)
+	for _, element := range boxes {
+		bvh.Insert(element)
+	}
+	checkSnapped(t, &bvh.root, options.SnapGrid)
+	for _, element := range boxes[:500] {
+		bvh.Erase(element)
+	}
+	checkSnapped(t, &bvh.root, options.SnapGrid)
+
+	for i := 0; i < 30; i++ {
+		x, y := rng.Float64()*100.0, rng.Float64()*100.0
+		query := AABB2D{L: Point2D{x, y}, H: Point2D{x + 10.0, y + 10.0}}
+		expected := 0
+		for _, element := range boxes[500:] {
+			if boundsOverlap[AABB2D](Traits2D{}, query, element.GetBound()) {
+				expected++
+			}
+		}
+		if count := bvh.Count(query); count != expected {
+			t.Errorf("Expected a count of %d, found %d", expected, count)
+		}
+	}
+
+	// traits without SnapTraits ignore the grid:
+	plain := NewWithOptions[AABB2D](Traits2D{}, options)
+	plain.Insert(&Box2D{AABB2D{L: Point2D{0.1, 0.1}, H: Point2D{0.2, 0.2}}})
+	if plain.GetBound() != (AABB2D{L: Point2D{0.1, 0.1}, H: Point2D{0.2, 0.2}}) {
+		t.Errorf("Expected bounds not to be snapped without SnapTraits, found %v", plain.GetBound())
+	}
+}
+
+// ........................................................
+
+func TestBVHSnapGridNoise(t *testing.T) {
+	// the same boxes, disturbed by rounding-sized noise, give identical node bounds:
+	rng := rand.New(rand.NewSource(71))
+	boxes := randomBoxes(rng, 300, 2.0)
+	noisy := make([]Boundable[AABB2D], len(boxes))
+	for i, element := range boxes {
+		b := element.GetBound()
+		for d := range b.L {
+			b.L[d] += (rng.Float64() - 0.5) * 1e-12
+			b.H[d] += (rng.Float64() - 0.5) * 1e-12
+		}
+		noisy[i] = &Box2D{b}
+	}
+
+	options := DefaultOptions()
+	options.SnapGrid = 1.0 / 64.0
+	a := NewWithOptions[AABB2D](SnapTraits2D{}, options)
+	b := NewWithOptions[AABB2D](SnapTraits2D{}, options)
+	for i := range boxes {
+		a.Insert(boxes[i])
+		b.Insert(noisy[i])
+	}
+	if !sameNodeBounds(&a.root, &b.root) {
+		t.Errorf("Expected identical node bounds")
+	}
+	options.SnapGrid = 0.0
+	a, b = NewWithOptions[AABB2D](SnapTraits2D{}, options), NewWithOptions[AABB2D](SnapTraits2D{}, options)
+	for i := range boxes {
+		a.Insert(boxes[i])
+		b.Insert(noisy[i])
+	}
+	if sameNodeBounds(&a.root, &b.root) {
+		t.Errorf("Expected the noise to reach unsnapped node bounds")
+	}
+}
@@ -0,0 +1,120 @@
+package gobvh
+
+// ==============================================
+
+//
+// BVH.Snapshot() returns a read-only view of the bvh as it is now, which
+// stays unchanged while the bvh goes on being modified, e.g. for query
+// workers to search a stable tree for the duration of a frame while the
+// writer updates the live one:
+//
+//	view := bvh.Snapshot()
+//	for _, worker := range workers {
+//		go worker.Run(view) // searches view only
+//	}
+//	for _, update := range updates {
+//		bvh.Replace(update.Old, update.New)
+//	}
+//
+// Taking a snapshot is O(1): the view shares the nodes of the bvh, which
+// are then copied on write.  A later modification of the bvh copies only
+// the nodes it changes and their ancestors, leaving the rest shared, so a
+// writer updating a few elements a frame copies a few paths a frame,
+// whatever the size of the tree.  Nodes shared with a snapshot are not
+// recycled by ResetArena() or Optimize(); they are collected with the last
+// view holding them.
+//
+// The view needs no lock against the writer, and is as safe for concurrent
+// searches as any Index which is not being modified.  It carries none of
+// the annotations of the bvh, so the query options made by its methods
+// (WithVisibility(), WithLayerMask() and WithExclusions()), which consult
+// the live bvh, must not be used to search it.  NodeRefs reached by
+// ForEach() are valid for as long as the view is; NodeRef.Parent() takes
+// a search from the root there.
+//
+// Elements themselves are shared, not copied, so an element whose bound
+// changes in place (as Update() expects) changes in the view too, which
+// then no longer bounds it correctly.  To move elements while a snapshot
+// is in use, treat them as immutable and Replace() each with a moved copy.
+//
+func (bvh *BVH[BoundType]) Snapshot() Index[BoundType] {
+	view := &snapshot[BoundType]{
+		tree: BVH[BoundType]{
+			root:        bvh.root,
+			boundtraits: bvh.boundtraits,
+			snaptraits:  bvh.snaptraits,
+			fattraits:   bvh.fattraits,
+			options:     bvh.options,
+			count:       bvh.count,
+			inserter:    bvh.inserter,
+		},
+	}
+
+	// every node allocated so far (and the root's children) is now shared:
+	bvh.arena.epoch++
+	bvh.arena.shared = true
+	return view
+}
+
+// ==============================================
+
+// snapshot is the Index returned by BVH.Snapshot(); tree is never
+// modified, and the parent pointers of its nodes (which belong to the live
+// bvh) are never read.
+type snapshot[BoundType any] struct {
+	tree BVH[BoundType]
+}
+
+var _ Index[struct{}] = (*snapshot[struct{}])(nil)
+
+// ..............................................
+
+func (view *snapshot[BoundType]) GetBound() BoundType {
+	return view.tree.root.bound
+}
+
+func (view *snapshot[BoundType]) FindAll(s Searcher[BoundType], opts ...QueryOption[BoundType]) error {
+	return view.tree.findAll(s, opts)
+}
+
+func (view *snapshot[BoundType]) FindNearest(s Searcher[BoundType], here BoundType, opts ...QueryOption[BoundType]) error {
+	return view.tree.findNearest(s, here, view.tree.newTraversal(true, opts))
+}
+
+func (view *snapshot[BoundType]) ForEach(crawler BVHCrawler[BoundType]) error {
+	return forEachNode(crawler, &view.tree.root, &view.tree.root, true)
+}
+
+// ==============================================
+
+// returns node, ready to be modified: if it is shared with a snapshot (see
+// Snapshot()), a copy of it takes its place in the hierarchy, and is
+// returned instead.  Its ancestors are made ready first, so a modification
+// copies only the path from the root to the nodes it changes; the children
+// of a copy are pointed at it, which the snapshots never see.
+func (bvh *BVH[BoundType]) own(node *bvhNode[BoundType]) *bvhNode[BoundType] {
+	if node.epoch == bvh.arena.epoch {
+		return node
+	}
+	if node == &bvh.root {
+		node.children = append(make([]Boundable[BoundType], 0, cap(node.children)), node.children...)
+		node.epoch = bvh.arena.epoch
+		return node
+	}
+
+	parent := bvh.own(node.parent)
+	copied := bvh.arena.alloc()
+	children := copied.children[:0]
+	*copied = *node
+	copied.children = append(children, node.children...)
+	copied.parent = parent
+	copied.epoch = bvh.arena.epoch
+	fixParentPointers(copied)
+	for index, child := range parent.children {
+		if child == Boundable[BoundType](node) {
+			parent.children[index] = copied
+			break
+		}
+	}
+	return copied
+}
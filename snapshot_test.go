@@ -0,0 +1,198 @@
+package gobvh
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// ========================================================
+
+// the elements of index intersecting region, found by FindAll() or FindNearest()
+func indexIntersecting(index Index[AABB2D], region AABB2D, nearest bool) map[Boundable[AABB2D]]bool {
+	found := make(map[Boundable[AABB2D]]bool)
+	searcher := predicateSearcher[AABB2D]{
+		pred: func(bound AABB2D) bool {
+			return boundsOverlap[AABB2D](Traits2D{}, region, bound)
+		},
+		fn: func(element Boundable[AABB2D]) error {
+			found[element] = true
+			return nil
+		},
+	}
+	if nearest {
+		index.FindNearest(&searcher, region)
+	} else {
+		index.FindAll(&searcher)
+	}
+	return found
+}
+
+// the number of nodes of the subtree rooted at node which are not shared with a snapshot
+func ownedNodes(bvh *BVH[AABB2D], node *bvhNode[AABB2D]) int {
+	owned := 0
+	if node.epoch == bvh.arena.epoch {
+		owned++
+	}
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[AABB2D]); ok {
+			owned += ownedNodes(bvh, value)
+		}
+	}
+	return owned
+}
+
+// ........................................................
+
+// a crawler keeping the nodes it is shown
+type NodeCollector struct {
+	CheckBound
+	Nodes []NodeRef[AABB2D]
+}
+
+func (nc *NodeCollector) BeginNode(node NodeRef[AABB2D]) error {
+	nc.Nodes = append(nc.Nodes, node)
+	return nil
+}
+
+// ........................................................
+
+func TestBVHSnapshot(t *testing.T) {
+	rng := rand.New(rand.NewSource(97))
+	boxes := randomBoxes(rng, 1500, 2.0)
+	bvh := New[AABB2D](Traits2D{}, WithMinChildren(3))
+	bvh.Build(boxes[:1000])
+
+	regions := make([]AABB2D, 20)
+	expected := make([]map[Boundable[AABB2D]]bool, len(regions))
+	for index := range regions {
+		regions[index] = randomRegion(rng, 20.0)
+		expected[index] = intersecting(bvh, regions[index])
+	}
+	view := bvh.Snapshot()
+	other := bvh.Snapshot()
+	bound := view.GetBound()
+
+	// readers search the snapshots while the writer modifies the tree:
+	var readers sync.WaitGroup
+	for _, snapshot := range []Index[AABB2D]{view, other} {
+		readers.Add(1)
+		go func(snapshot Index[AABB2D]) {
+			defer readers.Done()
+			for round := 0; round < 5; round++ {
+				for index, region := range regions {
+					if len(indexIntersecting(snapshot, region, round%2 == 1)) != len(expected[index]) {
+						t.Errorf("Expected the snapshot to find what the tree held")
+						return
+					}
+				}
+			}
+		}(snapshot)
+	}
+	for _, element := range boxes[1000:] {
+		bvh.Insert(element)
+	}
+	for _, element := range boxes[:300] {
+		bvh.Erase(element)
+	}
+	for _, element := range boxes[300:400] {
+		old := element.(*Box2D)
+		bvh.Replace(old, &Box2D{B: AABB2D{L: Point2D{old.B.L[0] + 1.0, old.B.L[1]}, H: Point2D{old.B.H[0] + 1.0, old.B.H[1]}}})
+	}
+	bvh.SetLayers(boxes[400], 2)
+	bvh.EraseAllIntersecting(regions[0])
+	bvh.Tune(50)
+	bvh.OptimizeWorst(3)
+	bvh.Refit()
+	readers.Wait()
+
+	if err := bvh.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if len(intersecting(bvh, regions[0])) != 0 {
+		t.Errorf("Expected the tree to be modified")
+	}
+	for _, snapshot := range []Index[AABB2D]{view, other} {
+		if snapshot.GetBound() != bound {
+			t.Errorf("Expected the snapshot to keep its bound")
+		}
+		for index, region := range regions {
+			found := indexIntersecting(snapshot, region, false)
+			for element := range expected[index] {
+				if !found[element] || len(found) != len(expected[index]) {
+					t.Fatalf("Expected the snapshot to find what the tree held")
+				}
+			}
+		}
+
+		// the nodes are as they were, with their parents found from the root:
+		crawler := NodeCollector{CheckBound: CheckBound{T: t}}
+		if err := snapshot.ForEach(&crawler); err != nil {
+			t.Fatalf("ForEach: %v", err)
+		}
+		elements := 0
+		for _, node := range crawler.Nodes {
+			elements += len(node.Elements())
+		}
+		if elements != 1000 {
+			t.Errorf("Expected the snapshot to hold 1000 elements, found %d", elements)
+		}
+		root := crawler.Nodes[0]
+		for root.Parent().Valid() {
+			root = root.Parent()
+		}
+		if root.Bound() != bound || checkDepths(t, root) != root.NodeCount() {
+			t.Errorf("Expected to walk the nodes of the snapshot from any of them")
+		}
+	}
+}
+
+// ........................................................
+
+func TestBVHSnapshotCopiesPath(t *testing.T) {
+	rng := rand.New(rand.NewSource(313))
+	boxes := randomBoxes(rng, 5001, 2.0)
+	bvh := New[AABB2D](Traits2D{})
+	bvh.Build(boxes[:5000])
+	depth := 0
+	for node := chooseLeaf(bvh, boxes[5000].GetBound()); node != &bvh.root; node = node.parent {
+		depth++
+	}
+
+	// a modification copies the nodes along its path (and any it splits),
+	// not the whole tree:
+	view := bvh.Snapshot()
+	if owned := ownedNodes(bvh, &bvh.root); owned != 0 {
+		t.Errorf("Expected the snapshot to share every node, found %d of the tree's own", owned)
+	}
+	bvh.Insert(boxes[5000])
+	owned := ownedNodes(bvh, &bvh.root)
+	if owned < depth+1 || owned > 2*(depth+1) {
+		t.Errorf("Expected the %d nodes of the path to be copied, found %d", depth+1, owned)
+	}
+	if err := bvh.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	used := bvh.arena.used
+	bvh.Erase(boxes[5000])
+	bvh.Insert(boxes[5000])
+	if copied := bvh.arena.used - used; copied != 0 || ownedNodes(bvh, &bvh.root) != owned {
+		t.Errorf("Expected the copied path to be modified in place, found %d nodes copied", copied)
+	}
+
+	// the arena is not recycled under the snapshot:
+	bvh.Build(boxes[1000:2000])
+	if err := bvh.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if n := countIndex(view); n != 5000 {
+		t.Errorf("Expected the snapshot to keep 5000 elements, found %d", n)
+	}
+	region := randomRegion(rng, 20.0)
+	found := indexIntersecting(view, region, true)
+	for element := range intersecting(bvh, region) {
+		if !found[element] {
+			t.Fatalf("Expected the snapshot to find the elements it held")
+		}
+	}
+}
@@ -0,0 +1,289 @@
+// # soak: a long-running randomized soak test for GoBVH.
+//
+// Run() applies random insertions, erasures, moves and window queries to a
+// gobvh.BVH for a configurable time, checking query results against brute
+// force, and periodically samples BVH.Validate(), BVH.Stats(),
+// BVH.MemoryFootprint() and the Go heap.  The samples show whether the
+// dynamic maintenance degrades the tree or leaks memory over long runs;
+// they are returned and written as CSV:
+//
+//	elapsed_s,operations,elements,nodes,leaves,depth,mean_leaf_depth,mean_leaf_size,max_leaf_size,footprint_bytes,heap_bytes
+//
+// See cmd/gobvh-soak to run it from the command line.
+//
+package soak
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/drone115b/gobvh"
+)
+
+// ==============================================
+
+//
+// Config controls a soak run.
+//
+// Duration is how long to run, and SampleEvery how often to sample the
+// tree.  The number of elements wanders between zero and MaxElements.
+// Every CheckEvery operations a query is checked against brute force.
+// Zero values select the defaults of DefaultConfig().
+//
+type Config struct {
+	Duration    time.Duration
+	SampleEvery time.Duration
+	MaxElements int
+	CheckEvery  int
+	Seed        int64
+}
+
+// ..............................................
+
+//
+// DefaultConfig() returns a one hour run, sampled every minute.
+//
+func DefaultConfig() Config {
+	return Config{
+		Duration:    time.Hour,
+		SampleEvery: time.Minute,
+		MaxElements: 20000,
+		CheckEvery:  1000,
+		Seed:        1,
+	}
+}
+
+// ..............................................
+
+//
+// Sample is the state of the tree at one point of a soak run.
+//
+type Sample struct {
+	Elapsed    time.Duration
+	Operations int
+	Stats      gobvh.Stats
+	Footprint  gobvh.Footprint
+	HeapBytes  uint64
+}
+
+// ==============================================
+
+//
+// Run(config, out) runs a soak test, writing one CSV row per sample to out
+// (if out is not nil), and returns the samples.
+//
+// It stops with an error as soon as Validate() fails or a query disagrees
+// with brute force; the samples taken so far are returned with the error.
+//
+func Run(config Config, out io.Writer) ([]Sample, error) {
+	defaults := DefaultConfig()
+	if config.Duration <= 0 {
+		config.Duration = defaults.Duration
+	}
+	if config.SampleEvery <= 0 {
+		config.SampleEvery = defaults.SampleEvery
+	}
+	if config.MaxElements <= 0 {
+		config.MaxElements = defaults.MaxElements
+	}
+	if config.CheckEvery <= 0 {
+		config.CheckEvery = defaults.CheckEvery
+	}
+
+	var w *csv.Writer
+	if out != nil {
+		w = csv.NewWriter(out)
+		w.Write([]string{"elapsed_s", "operations", "elements", "nodes", "leaves", "depth", "mean_leaf_depth", "mean_leaf_size", "max_leaf_size", "footprint_bytes", "heap_bytes"})
+	}
+
+	state := newState(config)
+	samples := make([]Sample, 0, 16)
+	start := time.Now()
+	nextsample := time.Duration(0)
+	for {
+		elapsed := time.Since(start)
+		if elapsed >= nextsample || elapsed >= config.Duration {
+			if err := state.bvh.Validate(); err != nil {
+				return samples, fmt.Errorf("soak: after %d operations: %w", state.operations, err)
+			}
+			sample := state.sample(elapsed)
+			samples = append(samples, sample)
+			if w != nil {
+				writeSample(w, sample)
+				w.Flush()
+				if err := w.Error(); err != nil {
+					return samples, err
+				}
+			}
+			nextsample += config.SampleEvery
+			if elapsed >= config.Duration {
+				return samples, nil
+			}
+		}
+
+		// a batch of operations between looks at the clock:
+		for i := 0; i < 100; i++ {
+			if err := state.step(); err != nil {
+				return samples, fmt.Errorf("soak: after %d operations: %w", state.operations, err)
+			}
+		}
+	}
+}
+
+// ==============================================
+
+// box is the element type of the soak test
+type box struct {
+	lo [2]float64
+	hi [2]float64
+}
+
+func (b *box) GetBound() box {
+	return *b
+}
+
+func (b box) intersects(other box) bool {
+	return b.lo[0] <= other.hi[0] && other.lo[0] <= b.hi[0] && b.lo[1] <= other.hi[1] && other.lo[1] <= b.hi[1]
+}
+
+type boxTraits struct{}
+
+func (traits boxTraits) IntervalRange(bound box, dim uint) (float64, float64) {
+	return bound.lo[dim], bound.hi[dim]
+}
+
+func (traits boxTraits) Union(a box, b box) box {
+	return box{
+		lo: [2]float64{math.Min(a.lo[0], b.lo[0]), math.Min(a.lo[1], b.lo[1])},
+		hi: [2]float64{math.Max(a.hi[0], b.hi[0]), math.Max(a.hi[1], b.hi[1])},
+	}
+}
+
+func (traits boxTraits) Dimensions(bound box) uint {
+	return 2
+}
+
+// ..............................................
+
+// state is the tree under test, with a plain list of what it should hold
+type state struct {
+	config     Config
+	rng        *rand.Rand
+	bvh        *gobvh.BVH[box]
+	live       []*box
+	index      map[*box]int // position in live
+	operations int
+	unchecked  int // operations since a query was last checked
+}
+
+func newState(config Config) *state {
+	return &state{
+		config: config,
+		rng:    rand.New(rand.NewSource(config.Seed)),
+		bvh:    gobvh.New[box](boxTraits{}),
+		index:  make(map[*box]int),
+	}
+}
+
+func (s *state) randomBox() box {
+	x, y := s.rng.Float64(), s.rng.Float64()
+	size := s.rng.Float64() * 0.01
+	return box{lo: [2]float64{x, y}, hi: [2]float64{x + size, y + size}}
+}
+
+// applies one random operation
+func (s *state) step() error {
+	s.operations++
+	s.unchecked++
+	// insertions are favored while the tree is small, erasures while it is large:
+	fill := float64(len(s.live)) / float64(s.config.MaxElements)
+	choice := s.rng.Float64()
+	switch {
+	case choice < 0.4*(1.0-fill):
+		b := s.randomBox()
+		s.bvh.Insert(&b)
+		s.index[&b] = len(s.live)
+		s.live = append(s.live, &b)
+	case choice < 0.4 && len(s.live) > 0:
+		b := s.live[s.rng.Intn(len(s.live))]
+		if !s.bvh.Erase(b) {
+			return fmt.Errorf("failed to erase a stored element")
+		}
+		last := s.live[len(s.live)-1]
+		s.live[s.index[b]] = last
+		s.index[last] = s.index[b]
+		s.live = s.live[:len(s.live)-1]
+		delete(s.index, b)
+	case choice < 0.7 && len(s.live) > 0:
+		b := s.live[s.rng.Intn(len(s.live))]
+		oldbound := *b
+		dx, dy := (s.rng.Float64()-0.5)*0.02, (s.rng.Float64()-0.5)*0.02
+		*b = box{lo: [2]float64{b.lo[0] + dx, b.lo[1] + dy}, hi: [2]float64{b.hi[0] + dx, b.hi[1] + dy}}
+		if !s.bvh.Update(b, oldbound) {
+			return fmt.Errorf("failed to update a stored element")
+		}
+	default:
+		window := s.randomBox()
+		window.hi[0] += 0.05
+		window.hi[1] += 0.05
+		found := 0
+		s.bvh.FindAllIntersecting(window, func(gobvh.Boundable[box]) error {
+			found++
+			return nil
+		})
+		if s.unchecked >= s.config.CheckEvery {
+			s.unchecked = 0
+			expected := 0
+			for _, b := range s.live {
+				if window.intersects(*b) {
+					expected++
+				}
+			}
+			if found != expected {
+				return fmt.Errorf("query found %d elements, brute force found %d", found, expected)
+			}
+		}
+	}
+	if s.bvh.Len() != len(s.live) {
+		return fmt.Errorf("Len() reports %d elements, %d are stored", s.bvh.Len(), len(s.live))
+	}
+	return nil
+}
+
+// takes a sample of the tree and the heap
+func (s *state) sample(elapsed time.Duration) Sample {
+	var memstats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&memstats)
+	return Sample{
+		Elapsed:    elapsed,
+		Operations: s.operations,
+		Stats:      s.bvh.Stats(),
+		Footprint:  s.bvh.MemoryFootprint(),
+		HeapBytes:  memstats.HeapAlloc,
+	}
+}
+
+// ..............................................
+
+func writeSample(w *csv.Writer, sample Sample) {
+	w.Write([]string{
+		strconv.FormatFloat(sample.Elapsed.Seconds(), 'f', 3, 64),
+		strconv.Itoa(sample.Operations),
+		strconv.Itoa(sample.Stats.Elements),
+		strconv.Itoa(sample.Stats.Nodes),
+		strconv.Itoa(sample.Stats.Leaves),
+		strconv.Itoa(sample.Stats.Depth),
+		strconv.FormatFloat(sample.Stats.MeanLeafDepth, 'f', 3, 64),
+		strconv.FormatFloat(sample.Stats.MeanLeafSize, 'f', 3, 64),
+		strconv.Itoa(sample.Stats.MaxLeafSize),
+		strconv.Itoa(sample.Footprint.Bytes),
+		strconv.FormatUint(sample.HeapBytes, 10),
+	})
+}
@@ -0,0 +1,37 @@
+package soak
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+)
+
+// ========================================================
+
+func TestRun(t *testing.T) {
+	var out bytes.Buffer
+	config := Config{Duration: 300 * time.Millisecond, SampleEvery: 100 * time.Millisecond, MaxElements: 2000, CheckEvery: 50, Seed: 3}
+	samples, err := Run(config, &out)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(samples) < 3 {
+		t.Fatalf("Expected at least 3 samples, found %d", len(samples))
+	}
+	last := samples[len(samples)-1]
+	if last.Operations == 0 || last.Stats.Elements == 0 || last.Footprint.Bytes == 0 || last.HeapBytes == 0 {
+		t.Errorf("Expected a populated final sample, found %+v", last)
+	}
+	if last.Stats.Elements > config.MaxElements {
+		t.Errorf("Expected at most %d elements, found %d", config.MaxElements, last.Stats.Elements)
+	}
+
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rows) != len(samples)+1 || rows[0][0] != "elapsed_s" || len(rows[1]) != len(rows[0]) {
+		t.Errorf("Expected a header and one row per sample, found %d rows", len(rows))
+	}
+}
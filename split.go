@@ -0,0 +1,262 @@
+package gobvh
+
+import (
+	"math"
+	"sort"
+)
+
+// ==============================================
+
+//
+// SplitPolicy chooses how the children of a full node are divided between
+// two nodes, see Options.
+//
+// SplitAuto (the default) uses a median split for nodes holding only
+// zero-extent (point) elements, or one-dimensional bounds (e.g. time
+// intervals, which often nest), and a volume split otherwise.
+//
+// SplitVolume seeds the two halves with the most dissimilar children and
+// assigns the rest to the more similar seed.  It suits elements with extent,
+// but many coincident or collinear points can defeat it, leaving large leaves.
+//
+// SplitMedian sorts the children by the centroids of their bounds along the
+// axis where the centroids are most spread out, and divides them at the
+// median; ties are broken by the remaining axes, so even coincident points
+// are divided evenly.  Declare it for point data to use it at every level
+// of the tree.
+//
+// Other ways of dividing nodes can be plugged in, see SplitStrategy.
+//
+type SplitPolicy int
+
+const (
+	SplitAuto SplitPolicy = iota
+	SplitVolume
+	SplitMedian
+)
+
+// ==============================================
+
+// reports whether the children of node should be divided by partitionMedian()
+func (bvh *BVH[BoundType]) medianSplit(node *bvhNode[BoundType]) bool {
+	switch bvh.options.SplitPolicy {
+	case SplitVolume:
+		return false
+	case SplitMedian:
+		return true
+	}
+
+	// automatic: one dimension, or leaves of points only
+	if bvh.boundtraits.Dimensions(node.bound) == 1 {
+		return true
+	}
+	for _, child := range node.children {
+		if _, ok := child.(*bvhNode[BoundType]); ok {
+			return false
+		}
+		bound := child.GetBound()
+		var i uint
+		for i = 0; i < bvh.boundtraits.Dimensions(bound); i++ {
+			lo, hi := bvh.boundtraits.IntervalRange(bound, i)
+			if lo != hi {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ..............................................
+
+// appends the lower half of children (by centroid, see SplitMedian) to store0
+// and the upper half to store1.  children is reordered.
+func (bvh *BVH[BoundType]) partitionMedian(children []Boundable[BoundType], store0 []Boundable[BoundType], store1 []Boundable[BoundType]) ([]Boundable[BoundType], []Boundable[BoundType]) {
+	bvh.sortCentroids(children)
+
+	half := len(children) / 2
+	for index, child := range children {
+		if index < half {
+			store0 = bvh.appendChild(store0, child)
+		} else {
+			store1 = bvh.appendChild(store1, child)
+		}
+	}
+	return store0, store1
+}
+
+// ..............................................
+
+// sorts children by centroid along the axis where the centroids are most
+// spread out, breaking ties by the remaining axes
+func (bvh *BVH[BoundType]) sortCentroids(children []Boundable[BoundType]) {
+	if len(children) == 0 {
+		return
+	}
+
+	bounder := bvh.boundtraits
+	dims := bounder.Dimensions(children[0].GetBound())
+	var axis, i uint
+	widest := -1.0
+	for i = 0; i < dims; i++ {
+		first := true
+		var lo, hi float64
+		for _, child := range children {
+			c := centroidAlong(bounder, child.GetBound(), i)
+			if first || c < lo {
+				lo = c
+			}
+			if first || c > hi {
+				hi = c
+			}
+			first = false
+		}
+		if hi-lo > widest {
+			widest = hi - lo
+			axis = i
+		}
+	}
+
+	bvh.sorter = medianSorter[BoundType]{bounder: bounder, items: children, axis: axis, dims: dims}
+	sort.Sort(&bvh.sorter)
+	bvh.sorter.items = nil
+}
+
+// ..............................................
+
+func centroidAlong[BoundType any](bounder BoundTraits[BoundType], bound BoundType, dim uint) float64 {
+	lo, hi := bounder.IntervalRange(bound, dim)
+	return 0.5 * (lo + hi)
+}
+
+// ==============================================
+
+// orders items by centroid along axis, then along the following axes.
+// Kept in the BVH so that sorting does not allocate.
+type medianSorter[BoundType any] struct {
+	bounder BoundTraits[BoundType]
+	items   []Boundable[BoundType]
+	axis    uint
+	dims    uint
+}
+
+func (ms *medianSorter[BoundType]) Len() int {
+	return len(ms.items)
+}
+
+func (ms *medianSorter[BoundType]) Less(i, j int) bool {
+	return centroidLess(ms.bounder, ms.items[i].GetBound(), ms.items[j].GetBound(), ms.axis, ms.dims)
+}
+
+func (ms *medianSorter[BoundType]) Swap(i, j int) {
+	ms.items[i], ms.items[j] = ms.items[j], ms.items[i]
+}
+
+// ..............................................
+
+// orders bounds by centroid along axis, then along the following axes in turn
+func centroidLess[BoundType any](bounder BoundTraits[BoundType], bi BoundType, bj BoundType, axis uint, dims uint) bool {
+	var k uint
+	for k = 0; k < dims; k++ {
+		dim := (axis + k) % dims
+		ci, cj := centroidAlong(bounder, bi, dim), centroidAlong(bounder, bj, dim)
+		if ci != cj {
+			return ci < cj
+		}
+	}
+	return false
+}
+
+// ==============================================
+
+// greedily moves children between store0 and store1 while doing so reduces
+// the overlap of the bounds of the two, without increasing their total volume
+// (which would only trade overlap for looser bounds); each keeps at least two children.
+func (bvh *BVH[BoundType]) refineSplit(store0 []Boundable[BoundType], store1 []Boundable[BoundType]) ([]Boundable[BoundType], []Boundable[BoundType]) {
+	bounder := bvh.boundtraits
+	for moves := len(store0) + len(store1); moves > 0 && len(store0) >= 2 && len(store1) >= 2; moves-- {
+		bound0, bound1 := unionOf(bounder, store0, -1), unionOf(bounder, store1, -1)
+		overlap := boxOverlap(bounder, bound0, bound1)
+		if overlap <= 0.0 {
+			break
+		}
+		volume := boxOverlap(bounder, bound0, bound0) + boxOverlap(bounder, bound1, bound1)
+
+		// find the best single move:
+		bestside, bestindex := 0, -1
+		for side, from := range [2][]Boundable[BoundType]{store0, store1} {
+			tobound := bound1
+			if side == 1 {
+				tobound = bound0
+			}
+			for index := 0; index < len(from) && len(from) > 2; index++ {
+				remaining := unionOf(bounder, from, index)
+				grown := bounder.Union(tobound, from[index].GetBound())
+				candidate := boxOverlap(bounder, remaining, grown)
+				candidatevolume := boxOverlap(bounder, remaining, remaining) + boxOverlap(bounder, grown, grown)
+				if candidate < overlap && candidatevolume <= volume {
+					overlap = candidate
+					bestside, bestindex = side, index
+				}
+			}
+		}
+		if bestindex < 0 {
+			break
+		}
+
+		if bestside == 0 {
+			store0, store1 = bvh.moveChild(store0, store1, bestindex)
+		} else {
+			store1, store0 = bvh.moveChild(store1, store0, bestindex)
+		}
+	}
+	return store0, store1
+}
+
+// ..............................................
+
+// moves from[index] to the end of to
+func (bvh *BVH[BoundType]) moveChild(from []Boundable[BoundType], to []Boundable[BoundType], index int) ([]Boundable[BoundType], []Boundable[BoundType]) {
+	moved := from[index]
+	last := len(from) - 1
+	from[index] = from[last]
+	from[last] = nil
+	return from[:last], bvh.appendChild(to, moved)
+}
+
+// ..............................................
+
+// union of the bounds of children, leaving out children[skip] (skip < 0 for none)
+func unionOf[BoundType any](bounder BoundTraits[BoundType], children []Boundable[BoundType], skip int) BoundType {
+	var bound BoundType
+	initialized := false
+	for index, child := range children {
+		if index == skip {
+			continue
+		}
+		if initialized {
+			bound = bounder.Union(bound, child.GetBound())
+		} else {
+			bound = child.GetBound()
+			initialized = true
+		}
+	}
+	return bound
+}
+
+// ..............................................
+
+// volume of the intersection of the boxes given by IntervalRange()
+func boxOverlap[BoundType any](bounder BoundTraits[BoundType], first BoundType, second BoundType) float64 {
+	volume := 1.0
+	var i uint
+	for i = 0; i < bounder.Dimensions(first); i++ {
+		lo0, hi0 := bounder.IntervalRange(first, i)
+		lo1, hi1 := bounder.IntervalRange(second, i)
+		extent := math.Min(hi0, hi1) - math.Max(lo0, lo1)
+		if extent <= 0.0 {
+			return 0.0
+		}
+		volume *= extent
+	}
+	return volume
+}
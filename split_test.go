@@ -0,0 +1,117 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func largestLeaf(node *bvhNode[AABB2D]) int {
+	largest := 0
+	elements := 0
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[AABB2D]); ok {
+			largest = maxInt(largest, largestLeaf(value))
+		} else {
+			elements++
+		}
+	}
+	return maxInt(largest, elements)
+}
+
+// ........................................................
+
+func TestBVHSplitPolicy(t *testing.T) {
+	// points on a coarse grid, with many coincident points:
+	rng := rand.New(rand.NewSource(9))
+	points := make([]Point2D, 2000)
+	for i := range points {
+		points[i] = Point2D{float64(rng.Intn(4)), float64(rng.Intn(4))}
+	}
+
+	leaves := make(map[SplitPolicy]int)
+	for _, policy := range []SplitPolicy{SplitAuto, SplitVolume, SplitMedian} {
+		options := DefaultOptions()
+		options.SplitPolicy = policy
+		bvh := NewWithOptions[AABB2D](Traits2D{}, options)
+		for _, p := range points {
+			bvh.Insert(p)
+		}
+		checkParents(t, &bvh.root)
+		if count := len(collectElements(&bvh.root, nil)); count != len(points) {
+			t.Errorf("Policy %d: expected %d elements, found %d", policy, len(points), count)
+		}
+		for _, p := range points[:50] {
+			searcher := NearestNeighbor2D{Target: p, FoundDistance: 1e38, t: t}
+			bvh.FindNearest(&searcher, p.GetBound())
+			if searcher.FoundDistance != 0.0 {
+				t.Errorf("Policy %d: expected to find %v", policy, p)
+			}
+		}
+		leaves[policy] = largestLeaf(&bvh.root)
+	}
+
+	if leaves[SplitAuto] > 2*DefaultOptions().MaxChildren || leaves[SplitMedian] > 2*DefaultOptions().MaxChildren {
+		t.Errorf("Expected median splits to bound leaf sizes, found %d and %d", leaves[SplitAuto], leaves[SplitMedian])
+	}
+	if leaves[SplitVolume] <= leaves[SplitAuto] {
+		t.Errorf("Expected coincident points to defeat volume splits, found leaves of %d vs %d", leaves[SplitVolume], leaves[SplitAuto])
+	}
+}
+
+// ........................................................
+
+// number of nodes whose bounds intersect a query
+func nodesVisited(node *bvhNode[AABB2D], query AABB2D) int {
+	if !boundsOverlap[AABB2D](Traits2D{}, query, node.bound) {
+		return 0
+	}
+	visited := 1
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[AABB2D]); ok {
+			visited += nodesVisited(value, query)
+		}
+	}
+	return visited
+}
+
+// ........................................................
+
+func TestBVHRefineSplits(t *testing.T) {
+	visited := make([]int, 2)
+	for seed := int64(1); seed < 8; seed++ {
+		// clusters of boxes:
+		rng := rand.New(rand.NewSource(seed))
+		boxes := make([]Boundable[AABB2D], 0, 3000)
+		for cluster := 0; cluster < 30; cluster++ {
+			cx, cy := rng.Float64()*100.0, rng.Float64()*100.0
+			for i := 0; i < 100; i++ {
+				x, y := cx+rng.NormFloat64()*2.0, cy+rng.NormFloat64()*2.0
+				boxes = append(boxes, &Box2D{AABB2D{L: Point2D{x, y}, H: Point2D{x + rng.Float64(), y + rng.Float64()}}})
+			}
+		}
+
+		for index, refine := range []bool{false, true} {
+			options := DefaultOptions()
+			options.RefineSplits = refine
+			bvh := NewWithOptions[AABB2D](Traits2D{}, options)
+			for _, element := range boxes {
+				bvh.Insert(element)
+			}
+			checkTree(t, bvh, &bvh.root)
+			if count := len(collectElements(&bvh.root, nil)); count != len(boxes) {
+				t.Errorf("Expected %d elements, found %d", len(boxes), count)
+			}
+
+			queries := rand.New(rand.NewSource(99))
+			for i := 0; i < 2000; i++ {
+				x, y := queries.Float64()*100.0, queries.Float64()*100.0
+				visited[index] += nodesVisited(&bvh.root, AABB2D{L: Point2D{x, y}, H: Point2D{x + 2.0, y + 2.0}})
+			}
+		}
+	}
+	if visited[1] >= visited[0] {
+		t.Errorf("Expected refined splits to visit fewer nodes, found %d vs %d", visited[1], visited[0])
+	}
+}
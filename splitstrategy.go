@@ -0,0 +1,305 @@
+package gobvh
+
+import (
+	"math"
+	"sort"
+)
+
+// ==============================================
+
+//
+// SplitStrategy divides the children of a full node between two nodes,
+// replacing the built-in SplitPolicy (and RefineSplits), see
+// BVH.SetSplitStrategy().
+//
+// Split(traits, children, minimum) reorders children so that those going to
+// the first node come first, and returns how many there are.  Each node
+// should receive at least minimum children (Options.MinSplitChildren):
+// a split leaving fewer in either is abandoned, and the node keeps growing
+// until it is split again.
+//
+// The package provides VolumeSplit (the L1 furthest-corner heuristic of
+// SplitVolume), AxisMedianSplit (as SplitMedian), LinearSplit and
+// QuadraticSplit (Guttman's R-tree splits) and SAHSplit (a surface area
+// heuristic sweep).
+//
+type SplitStrategy[BoundType any] interface {
+	Split(boundtraits BoundTraits[BoundType], children []Boundable[BoundType], minimum int) int
+}
+
+// ..............................................
+
+//
+// BVH.SetSplitStrategy(strategy) divides the nodes split by later insertions
+// with strategy, or by Options.SplitPolicy again if strategy is nil.
+// Nodes already split are not changed; see Optimize() to rebuild them.
+//
+func (bvh *BVH[BoundType]) SetSplitStrategy(strategy SplitStrategy[BoundType]) {
+	bvh.splitter = strategy
+}
+
+// ==============================================
+
+//
+// VolumeSplit seeds the two nodes with the pair of children furthest apart
+// (by the L1 metric of their furthest corners) and gives every child to the
+// nearer seed.  This is the volume split of the default SplitPolicy.
+//
+type VolumeSplit[BoundType any] struct{}
+
+func (VolumeSplit[BoundType]) Split(boundtraits BoundTraits[BoundType], children []Boundable[BoundType], minimum int) int {
+	bound0, bound1 := getSplitBounds(boundtraits, children)
+	return partitionChildren(children, func(child Boundable[BoundType]) bool {
+		_, metric0 := furthestDistanceMetric(boundtraits, child.GetBound(), bound0)
+		_, metric1 := furthestDistanceMetric(boundtraits, child.GetBound(), bound1)
+		return metric0 < metric1
+	})
+}
+
+// ..............................................
+
+//
+// AxisMedianSplit sorts the children by centroid along the axis where the
+// centroids are most spread out, and divides them at the median, as
+// SplitMedian does.
+//
+type AxisMedianSplit[BoundType any] struct{}
+
+func (AxisMedianSplit[BoundType]) Split(boundtraits BoundTraits[BoundType], children []Boundable[BoundType], minimum int) int {
+	dims := boundtraits.Dimensions(children[0].GetBound())
+	var axis, i uint
+	widest := -1.0
+	for i = 0; i < dims; i++ {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, child := range children {
+			c := centroidAlong(boundtraits, child.GetBound(), i)
+			lo, hi = math.Min(lo, c), math.Max(hi, c)
+		}
+		if hi-lo > widest {
+			widest, axis = hi-lo, i
+		}
+	}
+	sort.Sort(&medianSorter[BoundType]{bounder: boundtraits, items: children, axis: axis, dims: dims})
+	return len(children) / 2
+}
+
+// ..............................................
+
+//
+// LinearSplit is Guttman's linear split: the seeds are the pair of children
+// most widely separated along any axis (relative to the extent of all the
+// children along it), and the rest go, in turn, to the node whose volume
+// they enlarge least.  It is the cheapest split to compute.
+//
+type LinearSplit[BoundType any] struct{}
+
+func (LinearSplit[BoundType]) Split(boundtraits BoundTraits[BoundType], children []Boundable[BoundType], minimum int) int {
+	seed0, seed1 := 0, 1
+	best := math.Inf(-1)
+	dims := boundtraits.Dimensions(children[0].GetBound())
+	var i uint
+	for i = 0; i < dims; i++ {
+		// the child with the highest low side, and the one with the lowest high side:
+		highest, lowest := 0, 0
+		highlo, lowhi := math.Inf(-1), math.Inf(1)
+		minlo, maxhi := math.Inf(1), math.Inf(-1)
+		for index, child := range children {
+			lo, hi := boundtraits.IntervalRange(child.GetBound(), i)
+			if lo > highlo {
+				highest, highlo = index, lo
+			}
+			if hi < lowhi {
+				lowest, lowhi = index, hi
+			}
+			minlo, maxhi = math.Min(minlo, lo), math.Max(maxhi, hi)
+		}
+		if highest == lowest || !(maxhi > minlo) {
+			continue
+		}
+		if separation := (highlo - lowhi) / (maxhi - minlo); separation > best {
+			best, seed0, seed1 = separation, lowest, highest
+		}
+	}
+
+	return guttmanSplit(boundtraits, children, minimum, seed0, seed1, false)
+}
+
+// ..............................................
+
+//
+// QuadraticSplit is Guttman's quadratic split: the seeds are the pair of
+// children which would waste the most volume in one node, and the child
+// with the greatest preference for one node over the other is assigned
+// next, to the node whose volume it enlarges least.  It gives tighter nodes
+// than LinearSplit, in time quadratic in MaxChildren.
+//
+type QuadraticSplit[BoundType any] struct{}
+
+func (QuadraticSplit[BoundType]) Split(boundtraits BoundTraits[BoundType], children []Boundable[BoundType], minimum int) int {
+	seed0, seed1 := 0, 1
+	worst := math.Inf(-1)
+	for i := range children {
+		bi := children[i].GetBound()
+		for j := i + 1; j < len(children); j++ {
+			bj := children[j].GetBound()
+			union := boundtraits.Union(bi, bj)
+			if waste := boxOverlap(boundtraits, union, union) - boxOverlap(boundtraits, bi, bi) - boxOverlap(boundtraits, bj, bj); waste > worst {
+				worst, seed0, seed1 = waste, i, j
+			}
+		}
+	}
+	return guttmanSplit(boundtraits, children, minimum, seed0, seed1, true)
+}
+
+// ..............................................
+
+//
+// SAHSplit sorts the children by centroid along each axis in turn, and
+// divides them at the position which minimizes the surface area heuristic:
+// the number of children in each node weighted by the measure of its bound
+// (see MeasureTraits).  It gives the best trees of these strategies for
+// searches, e.g. ray tracing, at the highest cost.
+//
+type SAHSplit[BoundType any] struct{}
+
+func (SAHSplit[BoundType]) Split(boundtraits BoundTraits[BoundType], children []Boundable[BoundType], minimum int) int {
+	n := len(children)
+	minimum = maxInt(1, minimum)
+	if n < 2*minimum {
+		minimum = 1
+	}
+	dims := boundtraits.Dimensions(children[0].GetBound())
+	sorter := medianSorter[BoundType]{bounder: boundtraits, items: children, dims: dims}
+	suffix := make([]float64, n)
+
+	var bestaxis, axis uint
+	bestcount, bestcost := n/2, math.Inf(1)
+	for axis = 0; axis < dims; axis++ {
+		sorter.axis = axis
+		sort.Sort(&sorter)
+
+		bound := children[n-1].GetBound()
+		for index := n - 1; index > 0; index-- {
+			bound = boundtraits.Union(bound, children[index].GetBound())
+			suffix[index] = float64(n-index) * measureBound(boundtraits, bound)
+		}
+		bound = children[0].GetBound()
+		for count := 1; count < n; count++ {
+			bound = boundtraits.Union(bound, children[count-1].GetBound())
+			if count < minimum || n-count < minimum {
+				continue
+			}
+			if cost := float64(count)*measureBound(boundtraits, bound) + suffix[count]; cost < bestcost {
+				bestaxis, bestcount, bestcost = axis, count, cost
+			}
+		}
+	}
+
+	sorter.axis = bestaxis
+	sort.Sort(&sorter)
+	return bestcount
+}
+
+// ==============================================
+
+// divides children between seed0 and seed1 by least enlargement of volume,
+// giving each at least minimum children; quadratic picks the child with the
+// greatest preference next, otherwise children are taken in order
+func guttmanSplit[BoundType any](boundtraits BoundTraits[BoundType], children []Boundable[BoundType], minimum int, seed0 int, seed1 int, quadratic bool) int {
+	n := len(children)
+	side := make([]int, n) // 0 unassigned, 1 or 2 for the first or second node
+	side[seed0], side[seed1] = 1, 2
+	var bounds [3]BoundType
+	bounds[1], bounds[2] = children[seed0].GetBound(), children[seed1].GetBound()
+	counts := [3]int{0, 1, 1}
+	volume := func(bound BoundType) float64 {
+		return boxOverlap(boundtraits, bound, bound)
+	}
+	enlargement := func(group int, bound BoundType) float64 {
+		return volume(boundtraits.Union(bounds[group], bound)) - volume(bounds[group])
+	}
+
+	for remaining := n - 2; remaining > 0; remaining-- {
+		// a node which needs every remaining child gets them:
+		forced := 0
+		if counts[1]+remaining <= minimum {
+			forced = 1
+		} else if counts[2]+remaining <= minimum {
+			forced = 2
+		}
+
+		next, preference := -1, math.Inf(-1)
+		for index := range children {
+			if side[index] != 0 {
+				continue
+			}
+			if !quadratic || forced != 0 {
+				next = index
+				break
+			}
+			bound := children[index].GetBound()
+			if d := math.Abs(enlargement(1, bound) - enlargement(2, bound)); d > preference {
+				next, preference = index, d
+			}
+		}
+
+		bound := children[next].GetBound()
+		group := forced
+		if group == 0 {
+			// least enlargement, then least volume, then fewest children:
+			d1, d2 := enlargement(1, bound), enlargement(2, bound)
+			v1, v2 := volume(bounds[1]), volume(bounds[2])
+			group = 2
+			if d1 < d2 || (d1 == d2 && (v1 < v2 || (v1 == v2 && counts[1] <= counts[2]))) {
+				group = 1
+			}
+		}
+		side[next] = group
+		bounds[group] = boundtraits.Union(bounds[group], bound)
+		counts[group]++
+	}
+
+	index := 0
+	return partitionChildren(children, func(Boundable[BoundType]) bool {
+		index++
+		return side[index-1] == 1
+	})
+}
+
+// ..............................................
+
+// moves the children for which first(child) is true to the front, keeping
+// the order of each side, and returns how many there are
+func partitionChildren[BoundType any](children []Boundable[BoundType], first func(Boundable[BoundType]) bool) int {
+	rest := make([]Boundable[BoundType], 0, len(children))
+	front := 0
+	for _, child := range children {
+		if first(child) {
+			children[front] = child
+			front++
+		} else {
+			rest = append(rest, child)
+		}
+	}
+	copy(children[front:], rest)
+	return front
+}
+
+// ..............................................
+
+// appends the children divided by the SplitStrategy to store0 and store1
+func (bvh *BVH[BoundType]) partitionStrategy(children []Boundable[BoundType], store0 []Boundable[BoundType], store1 []Boundable[BoundType]) ([]Boundable[BoundType], []Boundable[BoundType]) {
+	count := bvh.splitter.Split(bvh.boundtraits, children, bvh.options.MinSplitChildren)
+	if count < 0 {
+		count = 0
+	} else if count > len(children) {
+		count = len(children)
+	}
+	for _, child := range children[:count] {
+		store0 = bvh.appendChild(store0, child)
+	}
+	for _, child := range children[count:] {
+		store1 = bvh.appendChild(store1, child)
+	}
+	return store0, store1
+}
@@ -0,0 +1,126 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+// countingSplit counts the splits made by another strategy
+type countingSplit struct {
+	SplitStrategy[AABB2D]
+	calls int
+}
+
+func (cs *countingSplit) Split(boundtraits BoundTraits[AABB2D], children []Boundable[AABB2D], minimum int) int {
+	cs.calls++
+	return cs.SplitStrategy.Split(boundtraits, children, minimum)
+}
+
+// ........................................................
+
+var splitStrategies = []struct {
+	name     string
+	strategy SplitStrategy[AABB2D]
+	minimal  bool // whether the strategy always respects the minimum
+}{
+	{"Volume", VolumeSplit[AABB2D]{}, false},
+	{"AxisMedian", AxisMedianSplit[AABB2D]{}, true},
+	{"Linear", LinearSplit[AABB2D]{}, true},
+	{"Quadratic", QuadraticSplit[AABB2D]{}, true},
+	{"SAH", SAHSplit[AABB2D]{}, true},
+}
+
+// ========================================================
+
+func TestSplitStrategies(t *testing.T) {
+	rng := rand.New(rand.NewSource(793))
+	elements := make([]Boundable[AABB2D], 0, 3000)
+	for _, box := range randomBoxes(rng, 2000, 3.0) {
+		elements = append(elements, box)
+	}
+	for i := 0; i < 1000; i++ { // long skinny elements, and coincident points
+		x := rng.Float64() * 100.0
+		elements = append(elements, &Box2D{B: AABB2D{L: Point2D{x, 0.0}, H: Point2D{x + 0.1, 100.0}}})
+		if i%10 == 0 {
+			elements = append(elements, Point2D{50.0, 50.0})
+		}
+	}
+	reference := New[AABB2D](Traits2D{})
+	for _, element := range elements {
+		reference.Insert(element)
+	}
+
+	for _, test := range splitStrategies {
+		counting := &countingSplit{SplitStrategy: test.strategy}
+		bvh := New[AABB2D](Traits2D{}, WithMinSplitChildren(3))
+		bvh.SetSplitStrategy(counting)
+		for _, element := range elements {
+			bvh.Insert(element)
+		}
+		if err := bvh.Validate(); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if counting.calls == 0 {
+			t.Errorf("%s: Expected the strategy to split nodes", test.name)
+		}
+		if test.minimal {
+			if smallest := smallestNode(&bvh.root, true); smallest < 3 {
+				t.Errorf("%s: Expected every node to hold at least 3 children, found %d", test.name, smallest)
+			}
+		}
+		for i := 0; i < 20; i++ {
+			region := randomRegion(rng, 15.0)
+			if bvh.Count(region) != reference.Count(region) {
+				t.Errorf("%s: Expected the same elements in %v as with the default split", test.name, region)
+			}
+		}
+	}
+}
+
+// ........................................................
+
+func TestSplitStrategyContract(t *testing.T) {
+	rng := rand.New(rand.NewSource(7930))
+	for _, test := range splitStrategies {
+		for trial := 0; trial < 50; trial++ {
+			children := make([]Boundable[AABB2D], 16)
+			seen := make(map[Boundable[AABB2D]]bool)
+			for i, box := range randomBoxes(rng, len(children), 10.0) {
+				children[i] = box
+				seen[box] = true
+			}
+			count := test.strategy.Split(Traits2D{}, children, 4)
+			if count < 0 || count > len(children) || (test.minimal && (count < 4 || count > len(children)-4)) {
+				t.Errorf("%s: Expected a split of 16 children leaving at least 4 on each side, found %d", test.name, count)
+			}
+			for _, child := range children {
+				if !seen[child] {
+					t.Fatalf("%s: Expected the children to be reordered, found a duplicate", test.name)
+				}
+				delete(seen, child)
+			}
+		}
+	}
+}
+
+// ........................................................
+
+func TestSetSplitStrategyNil(t *testing.T) {
+	rng := rand.New(rand.NewSource(7931))
+	a, b := New[AABB2D](Traits2D{}), New[AABB2D](Traits2D{})
+	b.SetSplitStrategy(QuadraticSplit[AABB2D]{})
+	b.SetSplitStrategy(nil)
+	for i := 0; i < 1000; i++ {
+		p := Point2D{rng.Float64() * 100.0, rng.Float64() * 100.0}
+		a.Insert(p)
+		b.Insert(p)
+	}
+	var da, db digest
+	da.addNode(&a.root)
+	db.addNode(&b.root)
+	if da.hash != db.hash {
+		t.Errorf("Expected a nil strategy to restore the SplitPolicy")
+	}
+}
@@ -0,0 +1,61 @@
+package gobvh
+
+// ==============================================
+
+//
+// BVH.Stab(point, fn) calls fn(element) for every element whose bound
+// contains point (as the boxes given by IntervalRange(), boundaries
+// included), e.g. for picking or region membership.
+//
+// point has one coordinate per dimension; dimensions beyond the end of
+// point are unconstrained.  It is a plain traversal which builds no query
+// bound and allocates nothing.  An error returned by fn stops the search
+// and is returned.
+//
+func (bvh *BVH[BoundType]) Stab(point []float64, fn func(Boundable[BoundType]) error) error {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryStab, Point: point})
+	}
+	if len(bvh.root.children) == 0 {
+		return nil
+	}
+	return bvh.stabNode(&bvh.root, point, fn)
+}
+
+// ==============================================
+
+func (bvh *BVH[BoundType]) stabNode(node *bvhNode[BoundType], point []float64, fn func(Boundable[BoundType]) error) error {
+	if !boundContains(bvh.boundtraits, node.bound, point) {
+		return nil
+	}
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			if err := bvh.stabNode(value, point, fn); err != nil {
+				return err
+			}
+		} else if child != nil && boundContains(bvh.boundtraits, child.GetBound(), point) {
+			bvh.hit(child)
+			if err := fn(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ..............................................
+
+// reports whether the axis-aligned box given by IntervalRange() contains point
+func boundContains[BoundType any](bounder BoundTraits[BoundType], bound BoundType, point []float64) bool {
+	dims := bounder.Dimensions(bound)
+	for i, coordinate := range point {
+		if uint(i) >= dims {
+			break
+		}
+		lo, hi := bounder.IntervalRange(bound, uint(i))
+		if coordinate < lo || coordinate > hi {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package gobvh
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHStab(t *testing.T) {
+	rng := rand.New(rand.NewSource(73))
+	boxes := randomBoxes(rng, 2000, 6.0)
+	bvh := New[AABB2D](Traits2D{})
+	if err := bvh.Stab([]float64{1, 1}, func(Boundable[AABB2D]) error { return errors.New("called") }); err != nil {
+		t.Errorf("Expected an empty tree to find nothing")
+	}
+	for _, element := range boxes {
+		bvh.Insert(element)
+	}
+
+	for i := 0; i < 100; i++ {
+		point := []float64{rng.Float64() * 100.0, rng.Float64() * 100.0}
+		expected := map[Boundable[AABB2D]]bool{}
+		for _, element := range boxes {
+			b := element.GetBound()
+			if b.L[0] <= point[0] && point[0] <= b.H[0] && b.L[1] <= point[1] && point[1] <= b.H[1] {
+				expected[element] = true
+			}
+		}
+		found := 0
+		bvh.Stab(point, func(element Boundable[AABB2D]) error {
+			if !expected[element] {
+				t.Errorf("Unexpected element %v for point %v", element.GetBound(), point)
+			}
+			found++
+			return nil
+		})
+		if found != len(expected) {
+			t.Errorf("Expected %d elements, found %d", len(expected), found)
+		}
+	}
+
+	// boundaries are included, and a short point is unconstrained in the missing dimension:
+	corner := boxes[0].GetBound().H
+	found := false
+	bvh.Stab(corner[:], func(element Boundable[AABB2D]) error {
+		found = found || element == boxes[0]
+		return nil
+	})
+	if !found {
+		t.Errorf("Expected the corner of a bound to stab it")
+	}
+	column := 0
+	bvh.Stab([]float64{50.0}, func(element Boundable[AABB2D]) error {
+		column++
+		return nil
+	})
+	if expected := bvh.Count(AABB2D{L: Point2D{50, -1}, H: Point2D{50, 200}}); column != expected {
+		t.Errorf("Expected %d elements across the column, found %d", expected, column)
+	}
+
+	point := []float64{50, 50}
+	allocs := testing.AllocsPerRun(10, func() {
+		bvh.Stab(point, func(Boundable[AABB2D]) error { return nil })
+	})
+	if allocs > 0 {
+		t.Errorf("Expected Stab() not to allocate, found %v allocations", allocs)
+	}
+}
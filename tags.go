@@ -0,0 +1,156 @@
+package gobvh
+
+import (
+	"hash/fnv"
+)
+
+// ==============================================
+
+//
+// Tag is a label attached to an element at insertion, see BVH.InsertTagged().
+//
+// Integer tags can be used directly, e.g. Tag(42); use StringTag() for names.
+//
+type Tag uint64
+
+// ..............................................
+
+//
+// StringTag(name) converts a name into a Tag.
+//
+func StringTag(name string) Tag {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(name))
+	return Tag(hasher.Sum64())
+}
+
+// ==============================================
+
+//
+// BVH.InsertTagged(element, tags...) puts a Boundable object into the data
+// structure, labelled with the given tags.
+//
+// Each node of the hierarchy keeps a small bloom filter of the tags stored
+// beneath it, so FindAllWithTag() can skip whole subtrees that do not contain
+// the tag.  The tags are forgotten when the element is erased.  Other
+// annotations the element already has (e.g. layers, see SetLayers()) are
+// kept.
+//
+func (bvh *BVH[BoundType]) InsertTagged(element Boundable[BoundType], tags ...Tag) {
+	info := bvh.infoFor(element)
+	info.tags = append(make([]Tag, 0, len(tags)), tags...)
+	info.bloom = 0
+	for _, tag := range tags {
+		info.bloom |= tagBloom(tag)
+	}
+	bvh.Insert(element)
+}
+
+// ..............................................
+
+//
+// BVH.FindAllWithTag(tag, pred) returns every element labelled with tag
+// whose bound satisfies the predicate.
+//
+// As with CollectCandidates(), the predicate is applied to the bounds of nodes
+// and elements; subtrees failing the predicate, or whose bloom filter
+// rules out the tag, are not visited.
+//
+func (bvh *BVH[BoundType]) FindAllWithTag(tag Tag, pred func(BoundType) bool) []Boundable[BoundType] {
+	if bvh.recorder != nil {
+		bvh.recorder.record(RecordedQuery[BoundType]{Kind: QueryWithTag, Tag: tag, Predicate: pred})
+	}
+	found := make([]Boundable[BoundType], 0, 8)
+	if len(bvh.root.children) == 0 {
+		return found
+	}
+
+	bloom := tagBloom(tag)
+	trav := bvh.newTraversal(false, nil)
+	trav.nodefilter = func(node *bvhNode[BoundType]) bool {
+		return node.bloom&bloom == bloom
+	}
+	trav.elemfilter = func(element Boundable[BoundType]) bool {
+		return bvh.HasTag(element, tag)
+	}
+	collector := predicateSearcher[BoundType]{
+		pred: pred,
+		fn: func(element Boundable[BoundType]) error {
+			found = append(found, element)
+			return nil
+		},
+	}
+	findDown[BoundType](&collector, &bvh.root, nil, trav)
+	return found
+}
+
+// ..............................................
+
+//
+// BVH.HasTag(element, tag) reports whether the element was inserted with the tag.
+//
+func (bvh *BVH[BoundType]) HasTag(element Boundable[BoundType], tag Tag) bool {
+	if info, ok := bvh.info[element]; ok {
+		for _, elemtag := range info.tags {
+			if elemtag == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ==============================================
+
+// annotations attached to individual elements
+type elementInfo struct {
+	tags     []Tag
+	bloom    uint64 // bloom filter of tags
+	hidden   uint64 // views the element is hidden from, see SetVisibility()
+	nolayers uint64 // collision layers the element is not in, see SetLayers()
+	expires  int64  // expiration time (0 if none), see InsertExpiring()
+}
+
+// ..............................................
+
+func (bvh *BVH[BoundType]) setInfo(element Boundable[BoundType], info elementInfo) {
+	if bvh.info == nil {
+		bvh.info = make(map[Boundable[BoundType]]*elementInfo)
+	}
+	bvh.info[element] = &info
+}
+
+// ..............................................
+
+// returns the annotations of element, creating them if necessary
+func (bvh *BVH[BoundType]) infoFor(element Boundable[BoundType]) *elementInfo {
+	if info, ok := bvh.info[element]; ok {
+		return info
+	}
+	bvh.setInfo(element, elementInfo{})
+	return bvh.info[element]
+}
+
+// ..............................................
+
+// bloom filter bits contributed by a child of a node
+func (bvh *BVH[BoundType]) elementBloom(child Boundable[BoundType]) uint64 {
+	if node, ok := child.(*bvhNode[BoundType]); ok {
+		return node.bloom
+	}
+	if info, ok := bvh.info[child]; ok {
+		return info.bloom
+	}
+	return 0
+}
+
+// ..............................................
+
+// two bits of a 64-bit bloom filter, chosen by hashing the tag (splitmix64 finalizer)
+func tagBloom(tag Tag) uint64 {
+	h := uint64(tag)
+	h = (h ^ (h >> 30)) * 0xbf58476d1ce4e5b9
+	h = (h ^ (h >> 27)) * 0x94d049bb133111eb
+	h = h ^ (h >> 31)
+	return (uint64(1) << (h & 63)) | (uint64(1) << ((h >> 6) & 63))
+}
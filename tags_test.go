@@ -0,0 +1,80 @@
+package gobvh
+
+import (
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHTags(t *testing.T) {
+	bvh := New[AABB2D](Traits2D{})
+	oak := StringTag("oak")
+	pine := StringTag("pine")
+
+	var x, y float64
+	for x = 0.0; x < 32.0; x += 1.0 {
+		for y = 0.0; y < 32.0; y += 1.0 {
+			switch {
+			case x < 4.0 && y < 4.0:
+				bvh.InsertTagged(Point2D{x, y}, oak)
+			case int(x+y)%7 == 0:
+				bvh.InsertTagged(Point2D{x, y}, pine, Tag(7))
+			default:
+				bvh.Insert(Point2D{x, y})
+			}
+		}
+	}
+
+	everywhere := func(AABB2D) bool { return true }
+	if n := len(bvh.FindAllWithTag(oak, everywhere)); n != 16 {
+		t.Errorf("Expected 16 oak elements, found %d", n)
+	}
+	if n := len(bvh.FindAllWithTag(Tag(7), everywhere)); n != len(bvh.FindAllWithTag(pine, everywhere)) {
+		t.Errorf("Expected identical counts for elements sharing tags, found %d", n)
+	}
+
+	region := boxPredicate(AABB2D{Point2D{0.0, 0.0}, Point2D{1.0, 31.0}})
+	found := bvh.FindAllWithTag(pine, region)
+	if len(found) != 8 {
+		t.Errorf("Expected 8 pine elements in region, found %d", len(found))
+	}
+	for _, element := range found {
+		if !bvh.HasTag(element, pine) || bvh.HasTag(element, oak) {
+			t.Errorf("Unexpected tags on %v", element)
+		}
+	}
+
+	// subtrees without the tag are pruned:
+	visited := 0
+	trav := traversal[AABB2D]{nodefilter: func(node *bvhNode[AABB2D]) bool {
+		if node.bloom&tagBloom(oak) == tagBloom(oak) {
+			visited++
+			return true
+		}
+		return false
+	}}
+	findDown[AABB2D](&RecordOrder{}, &bvh.root, nil, &trav)
+	if visited == 0 || visited > 8 {
+		t.Errorf("Expected the oak bloom filter to prune most nodes, visited %d", visited)
+	}
+
+	// tags are forgotten on erasure:
+	bvh.Erase(Point2D{0.0, 0.0})
+	if bvh.HasTag(Point2D{0.0, 0.0}, oak) {
+		t.Errorf("Tag survived erasure")
+	}
+	if n := len(bvh.FindAllWithTag(oak, everywhere)); n != 15 {
+		t.Errorf("Expected 15 oak elements, found %d", n)
+	}
+
+	// other annotations are kept:
+	layered := Point2D{50.0, 50.0}
+	bvh.SetLayers(layered, 0x4)
+	bvh.InsertTagged(layered, oak)
+	if bvh.Layers(layered) != 0x4 || !bvh.HasTag(layered, oak) {
+		t.Errorf("Expected the tagged element to keep its layers, found %x", bvh.Layers(layered))
+	}
+	if err := bvh.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
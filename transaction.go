@@ -0,0 +1,100 @@
+package gobvh
+
+// ==============================================
+
+//
+// Transaction groups insertions and erasures of a BVH so that they can be
+// kept together, by Commit(), or undone together, by Rollback(), e.g. when
+// validation fails part way through an update.  See BVH.Begin().
+//
+// The mutations are applied to the bvh at once, so queries made during the
+// transaction see them.  Rollback() restores the contents (the elements,
+// their bounds and annotations, and so ContentHash()), but not necessarily
+// the same hierarchy; Version() keeps counting.  Elements inserted in the
+// transaction must not move before it ends.  Elements evicted to respect
+// the capacity bound (see SetCapacity()) are not restored.
+//
+type Transaction[BoundType any] struct {
+	bvh  *BVH[BoundType]
+	undo []undoEntry[BoundType] // in order of application
+}
+
+// ..............................................
+
+// a mutation made in a transaction, and what is needed to undo it
+type undoEntry[BoundType any] struct {
+	element Boundable[BoundType]
+	erased  bool
+	info    *elementInfo // the annotations of an erased element
+}
+
+// ..............................................
+
+//
+// BVH.Begin() starts a transaction on the bvh.  Only one transaction should
+// be open at a time, and the bvh should not be modified other than through
+// it until it ends.
+//
+func (bvh *BVH[BoundType]) Begin() *Transaction[BoundType] {
+	return &Transaction[BoundType]{bvh: bvh}
+}
+
+// ==============================================
+
+//
+// Transaction.Insert(element) inserts the element into the bvh, as
+// BVH.Insert() does.
+//
+func (tx *Transaction[BoundType]) Insert(element Boundable[BoundType]) {
+	tx.bvh.Insert(element)
+	tx.undo = append(tx.undo, undoEntry[BoundType]{element: element})
+}
+
+// ..............................................
+
+//
+// Transaction.Erase(element) erases the element from the bvh, as
+// BVH.Erase() does, and reports whether it was erased.
+//
+func (tx *Transaction[BoundType]) Erase(element Boundable[BoundType]) bool {
+	var info *elementInfo
+	if stored, ok := tx.bvh.info[element]; ok {
+		copied := *stored
+		info = &copied
+	}
+	if !tx.bvh.Erase(element) {
+		return false
+	}
+	tx.undo = append(tx.undo, undoEntry[BoundType]{element: element, erased: true, info: info})
+	return true
+}
+
+// ..............................................
+
+//
+// Transaction.Commit() ends the transaction, keeping its mutations.
+//
+func (tx *Transaction[BoundType]) Commit() {
+	tx.undo = nil
+}
+
+// ..............................................
+
+//
+// Transaction.Rollback() ends the transaction, undoing its mutations, last
+// first.
+//
+func (tx *Transaction[BoundType]) Rollback() {
+	for index := len(tx.undo) - 1; index >= 0; index-- {
+		entry := tx.undo[index]
+		if !entry.erased {
+			tx.bvh.Erase(entry.element)
+			continue
+		}
+		if entry.info != nil {
+			tx.bvh.setInfo(entry.element, *entry.info)
+		}
+		tx.bvh.Insert(entry.element)
+	}
+	tx.undo = nil
+}
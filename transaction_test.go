@@ -0,0 +1,62 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHTransaction(t *testing.T) {
+	rng := rand.New(rand.NewSource(79))
+	boxes := randomBoxes(rng, 1500, 2.0)
+	bvh := New[AABB2D](Traits2D{})
+	for index, element := range boxes[:1000] {
+		if index%10 == 0 {
+			bvh.InsertTagged(element, Tag(2))
+		} else {
+			bvh.Insert(element)
+		}
+	}
+	hash, count := bvh.ContentHash(), bvh.Len()
+
+	// a failed update is rolled back:
+	tx := bvh.Begin()
+	for index, element := range boxes[1000:] {
+		tx.Insert(element)
+		if !tx.Erase(boxes[index*2]) {
+			t.Fatalf("Failed to erase %v", boxes[index*2].GetBound())
+		}
+	}
+	if tx.Erase(boxes[0]) {
+		t.Errorf("Expected a second erasure in the transaction to fail")
+	}
+	if bvh.Len() != count || bvh.Contains(boxes[0]) || !bvh.Contains(boxes[1000]) {
+		t.Errorf("Expected the transaction to apply its mutations at once")
+	}
+	tx.Rollback()
+	if err := bvh.Validate(); err != nil {
+		t.Errorf("Validate() failed: %v", err)
+	}
+	if bvh.ContentHash() != hash || bvh.Len() != count {
+		t.Errorf("Expected Rollback() to restore the contents")
+	}
+	for index, element := range boxes {
+		if bvh.Contains(element) != (index < 1000) {
+			t.Errorf("Expected element %d to be stored only if it was before", index)
+		}
+		if index < 1000 && bvh.HasTag(element, Tag(2)) != (index%10 == 0) {
+			t.Errorf("Expected Rollback() to restore the tags of element %d", index)
+		}
+	}
+
+	// a successful one is committed:
+	tx = bvh.Begin()
+	tx.Insert(boxes[1000])
+	tx.Erase(boxes[0])
+	tx.Commit()
+	tx.Rollback() // (nothing left to undo)
+	if !bvh.Contains(boxes[1000]) || bvh.Contains(boxes[0]) || bvh.Len() != count {
+		t.Errorf("Expected Commit() to keep the mutations")
+	}
+}
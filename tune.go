@@ -0,0 +1,124 @@
+package gobvh
+
+// ==============================================
+
+//
+// BVH.Tune(steps) restructures the hierarchy a little at a time, and
+// returns the number of elements moved.
+//
+// Each step descends through pseudo-randomly chosen children to a leaf,
+// finds the element whose bound most enlarges the leaf, and reinserts it
+// where Insert() would now put it, tightening the bounds it leaves.
+// Elements placed early, before their neighbours arrived, thus drift to
+// better nodes, and the quality of a tree under constant insertion and
+// erasure does not decay, without the pause of Optimize().
+// Options.TuneSteps runs steps after every Insert() and Erase(); Tune()
+// also suits idle time.
+//
+// Only the shape of the hierarchy changes: Len(), ContentHash(), tags and
+// other annotations, capacity bookkeeping and DirtyBounds() are unaffected,
+// and searches find the same elements.
+//
+func (bvh *BVH[BoundType]) Tune(steps int) int {
+	if bvh.tuning {
+		return 0 // within a step, e.g. an insertion by condense()
+	}
+	bvh.tuning = true
+	defer func() {
+		bvh.tuning = false
+	}()
+
+	moved := 0
+	for step := 0; step < steps && len(bvh.root.children) > 0; step++ {
+		if bvh.tuneStep() {
+			moved++
+		}
+	}
+	if moved > 0 {
+		bvh.version++
+	}
+	return moved
+}
+
+// ==============================================
+
+// reinserts the element which most enlarges a pseudo-randomly chosen node,
+// reporting whether one was moved
+func (bvh *BVH[BoundType]) tuneStep() bool {
+	bvh.tunestate++
+	random := mixHash(bvh.tunestate)
+
+	// descend through random children until one is an element:
+	node := &bvh.root
+	for {
+		child := node.children[random%uint64(len(node.children))]
+		random = mixHash(random)
+		value, ok := child.(*bvhNode[BoundType])
+		if !ok {
+			break
+		}
+		node = value
+	}
+	if node.parent == nil || len(node.children) < 2 || len(node.children) <= bvh.options.MinChildren {
+		return false
+	}
+
+	element := bvh.worstPlaced(node)
+	if element == nil {
+		return false
+	}
+	node = bvh.own(node)
+	bvh.removeChild(node, element)
+	for ; node != nil; node = node.parent {
+		bvh.recalculateBounds(node)
+	}
+
+	chosen := bvh.own(chooseLeaf(bvh, bvh.fatten(element.GetBound()))) // as Insert() chooses
+	chosen.children = bvh.appendChild(chosen.children, element)
+	for node = chosen; node != nil; node = node.parent {
+		bvh.recalculateBounds(node)
+	}
+	bvh.splitNode(chosen, &bvh.root)
+	return true
+}
+
+// ..............................................
+
+// the element child of node whose removal shrinks the measure of its bound
+// the most, or nil if none does
+func (bvh *BVH[BoundType]) worstPlaced(node *bvhNode[BoundType]) Boundable[BoundType] {
+	n := len(node.children)
+	suffix := make([]BoundType, n)
+	suffix[n-1] = node.children[n-1].GetBound()
+	for index := n - 2; index >= 0; index-- {
+		suffix[index] = bvh.boundtraits.Union(node.children[index].GetBound(), suffix[index+1])
+	}
+
+	var worst Boundable[BoundType]
+	var prefix BoundType
+	total := bvh.measure(suffix[0])
+	best := 0.0
+	for index, child := range node.children {
+		if _, ok := child.(*bvhNode[BoundType]); !ok {
+			// the bound of the other children:
+			var others BoundType
+			switch {
+			case index == 0:
+				others = suffix[1]
+			case index == n-1:
+				others = prefix
+			default:
+				others = bvh.boundtraits.Union(prefix, suffix[index+1])
+			}
+			if shrink := total - bvh.measure(others); shrink > best {
+				worst, best = child, shrink
+			}
+		}
+		if index == 0 {
+			prefix = child.GetBound()
+		} else {
+			prefix = bvh.boundtraits.Union(prefix, child.GetBound())
+		}
+	}
+	return worst
+}
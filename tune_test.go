@@ -0,0 +1,83 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHTune(t *testing.T) {
+	rng := rand.New(rand.NewSource(797))
+	plain := New[AABB2D](Traits2D{})
+	tuned := New[AABB2D](Traits2D{}, WithTuneSteps(2))
+	live := make([]Boundable[AABB2D], 0, 4000)
+	for round := 0; round < 8000; round++ {
+		if len(live) > 2000 && rng.Intn(2) == 0 {
+			index := rng.Intn(len(live))
+			element := live[index]
+			live[index] = live[len(live)-1]
+			live = live[:len(live)-1]
+			if !plain.Erase(element) || !tuned.Erase(element) {
+				t.Fatalf("Failed to erase %v", element.GetBound())
+			}
+			continue
+		}
+		// a drifting cluster, so early placements become poor:
+		x := float64(round)/80.0 + rng.Float64()*5.0
+		element := Point2D{x, rng.Float64() * 100.0}
+		live = append(live, element)
+		plain.Insert(element)
+		tuned.InsertTagged(element, 7)
+	}
+
+	if err := tuned.Validate(); err != nil {
+		t.Errorf(err.Error())
+	}
+	if tuned.Len() != plain.Len() || tuned.ContentHash() != plain.ContentHash() {
+		t.Errorf("Expected tuning to keep the contents, found %d elements and %d", tuned.Len(), plain.Len())
+	}
+	for i := 0; i < 50; i++ {
+		region := randomRegion(rng, 10.0)
+		if tuned.Count(region) != plain.Count(region) {
+			t.Errorf("Search of the tuned tree disagrees with the plain tree in %v", region)
+		}
+	}
+	if tags := tuned.FindAllWithTag(7, func(AABB2D) bool { return true }); len(tags) != len(live) {
+		t.Errorf("Expected tuning to keep tags, found %d of %d", len(tags), len(live))
+	}
+	if tuned.SAHCost() >= plain.SAHCost() {
+		t.Errorf("Expected tuning to lower the cost, found %g and %g", tuned.SAHCost(), plain.SAHCost())
+	}
+
+	// explicit steps:
+	version := plain.Version()
+	moved := plain.Tune(500)
+	if moved == 0 || plain.Version() == version {
+		t.Errorf("Expected Tune() to move elements and change the version, moved %d", moved)
+	}
+	if err := plain.Validate(); err != nil {
+		t.Errorf(err.Error())
+	}
+	if New[AABB2D](Traits2D{}).Tune(10) != 0 {
+		t.Errorf("Expected Tune() of an empty tree to move nothing")
+	}
+
+	// with a margin, elements move into leaves chosen by their fattened bounds:
+	fat := New[AABB2D](ExpandTraits2D{}, WithMargin(1.0))
+	for _, element := range live {
+		fat.Insert(element)
+	}
+	if fat.Tune(500) == 0 {
+		t.Errorf("Expected Tune() to move elements of a tree with a margin")
+	}
+	if err := fat.Validate(); err != nil {
+		t.Errorf(err.Error())
+	}
+	for _, element := range live {
+		container := fat.findContainer(&fat.root, element, element.GetBound())
+		if !boundContainsBound(fat.boundtraits, container.bound, ExpandTraits2D{}.Expand(element.GetBound(), 1.0)) {
+			t.Fatalf("Expected leaf bound %v to contain the fattened element bound", container.bound)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package gobvh
+
+import (
+	"sort"
+)
+
+// ==============================================
+
+//
+// ElementUpdate describes an element which has moved, see BVH.UpdateBatch().
+//
+// OldBound is the bound the element had when it was inserted (or last
+// updated); Element.GetBound() must already report the new bound.
+//
+type ElementUpdate[BoundType any] struct {
+	Element  Boundable[BoundType]
+	OldBound BoundType
+}
+
+// ==============================================
+
+//
+// BVH.Update(element, oldbound) moves a stored element, whose bound was oldbound,
+// to the place in the hierarchy matching its new bound (element.GetBound()).
+//
+// If the new bound still fits in the element's node, the element stays there
+// and only the bounds along its path are recalculated; otherwise it is
+// removed and reinserted.  It returns false, and does nothing, if the
+// element was not found.  Tags and other annotations of the element are kept.
+// To move many elements at once, UpdateBatch() is cheaper.
+//
+func (bvh *BVH[BoundType]) Update(element Boundable[BoundType], oldbound BoundType) bool {
+	return bvh.UpdateBatch([]ElementUpdate[BoundType]{{Element: element, OldBound: oldbound}}) == 1
+}
+
+// ..............................................
+
+//
+// BVH.UpdateBatch(updates) moves many stored elements at once, e.g. once per
+// simulation tick, and returns the number of elements found and moved.
+//
+// The updates are grouped by the node containing each element; an element
+// whose new bound still fits in its node stays there, and every other is
+// removed from its node; the bounds along each affected path are
+// recalculated once (rather than once per element), and then the removed
+// elements are reinserted according to their new bounds.
+// Elements which are not found are ignored.  Tags and other annotations of
+// the elements are kept.
+//
+func (bvh *BVH[BoundType]) UpdateBatch(updates []ElementUpdate[BoundType]) int {
+	// group the elements by container, in order of discovery:
+	containers := make([]*bvhNode[BoundType], 0, len(updates))
+	removals := make(map[*bvhNode[BoundType]][]int)
+	for index, update := range updates {
+		container := bvh.findContainer(&bvh.root, update.Element, update.OldBound)
+		if container == nil {
+			continue
+		}
+		container = bvh.own(container)
+		if _, ok := removals[container]; !ok {
+			containers = append(containers, container)
+		}
+		removals[container] = append(removals[container], index)
+	}
+
+	// remove the elements, keeping their annotations, unless they still fit:
+	moved := make([]int, 0, len(updates))
+	stayed := make(map[Boundable[BoundType]]bool)
+	for _, container := range containers {
+		for _, index := range removals[container] {
+			update := updates[index]
+			if stayed[update.Element] {
+				continue // a duplicate in the batch
+			}
+			bound := update.Element.GetBound()
+			if boundContainsBound(bvh.boundtraits, container.bound, bound) {
+				// the refit below tightens the bounds:
+				stayed[update.Element] = true
+				bvh.movedInPlace(container, update.Element, update.OldBound, bound)
+				continue
+			}
+			if !bvh.removeChild(container, update.Element) {
+				continue // a duplicate in the batch
+			}
+			info := bvh.info[update.Element]
+			bvh.erased(container, update.Element, update.OldBound)
+			if info != nil {
+				bvh.info[update.Element] = info
+			}
+			moved = append(moved, index)
+		}
+	}
+
+	bvh.refitAffected(containers)
+
+	for _, index := range moved {
+		bvh.Insert(updates[index].Element)
+	}
+	return len(moved) + len(stayed)
+}
+
+// ..............................................
+
+//
+// BVH.Replace(old, new) puts the element new in place of the stored element
+// old, e.g. to swap in another level of detail, or hot-reloaded content,
+// and reports whether old was found.  Tags and other annotations of old are
+// moved to new.
+//
+// If new's bound fits in the node holding old, new takes old's place there,
+// and only the bounds along its path are recalculated; otherwise old is
+// erased and new inserted.
+//
+func (bvh *BVH[BoundType]) Replace(old Boundable[BoundType], new Boundable[BoundType]) bool {
+	oldbound := old.GetBound()
+	container := bvh.findContainer(&bvh.root, old, oldbound)
+	if container == nil {
+		return false
+	}
+	info := bvh.info[old]
+	newbound := new.GetBound()
+	if !boundContainsBound(bvh.boundtraits, container.bound, newbound) {
+		bvh.Erase(old)
+		if info != nil {
+			bvh.setInfo(new, *info)
+		}
+		bvh.Insert(new)
+		return true
+	}
+
+	container = bvh.own(container)
+	for index, child := range container.children {
+		if child == old {
+			container.children[index] = new
+			break
+		}
+	}
+	if info != nil {
+		delete(bvh.info, old)
+		bvh.info[new] = info
+	}
+	for node := container; node != nil; node = node.parent {
+		bvh.recalculateBounds(node)
+	}
+	bvh.markDirty(container, bvh.boundtraits.Union(oldbound, newbound))
+	bvh.version++
+	bvh.contenthash += bvh.elementHash(new, newbound) - bvh.elementHash(old, oldbound)
+	if bvh.evictor != nil {
+		bvh.evictor.Erased(old)
+		bvh.evictor.Inserted(new)
+	}
+	bvh.logChange(ChangeErased, old, oldbound)
+	bvh.logChange(ChangeInserted, new, newbound)
+	return true
+}
+
+// ..............................................
+
+//
+// BVH.Refit() recalculates the bound of every node, bottom up, from the
+// current bounds of the elements, without changing the hierarchy.
+//
+// When many elements move a little each frame this is far cheaper than
+// updating them one by one: it takes O(n), with no searches or insertions.
+// Elements which moved a long way leave their nodes with loose bounds that
+// slow queries down, so an occasional Optimize() (or rebuild) is still a
+// good idea.  Leaves whose bounds changed are marked dirty, see DirtyBounds().
+//
+func (bvh *BVH[BoundType]) Refit() {
+	bvh.contenthash = 0
+	bvh.refitNode(bvh.own(&bvh.root))
+	bvh.version++
+}
+
+// ==============================================
+
+// returns the node directly containing element, searching where bound overlaps
+// the nodes; nil if it is not found.
+func (bvh *BVH[BoundType]) findContainer(node *bvhNode[BoundType], element Boundable[BoundType], bound BoundType) *bvhNode[BoundType] {
+	if len(node.children) == 0 {
+		return nil
+	}
+	if doesintersect, _ := furthestDistanceMetric(bvh.boundtraits, bound, node.bound); !doesintersect {
+		return nil
+	}
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			if found := bvh.findContainer(value, element, bound); found != nil {
+				return found
+			}
+		} else if child == element {
+			return node
+		}
+	}
+	return nil
+}
+
+// ..............................................
+
+// returns the first element for which eq reports true, and the node directly
+// containing it, searching where bound overlaps the nodes; nil if there is none.
+func (bvh *BVH[BoundType]) findMatch(node *bvhNode[BoundType], bound BoundType, eq func(Boundable[BoundType]) bool) (*bvhNode[BoundType], Boundable[BoundType]) {
+	if len(node.children) == 0 {
+		return nil, nil
+	}
+	if doesintersect, _ := furthestDistanceMetric(bvh.boundtraits, bound, node.bound); !doesintersect {
+		return nil, nil
+	}
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			if container, found := bvh.findMatch(value, bound, eq); container != nil {
+				return container, found
+			}
+		} else if eq(child) {
+			return node, child
+		}
+	}
+	return nil, nil
+}
+
+// ..............................................
+
+// removes child from node.children (without refitting), reports whether it was there
+func (bvh *BVH[BoundType]) removeChild(node *bvhNode[BoundType], child Boundable[BoundType]) bool {
+	for index, other := range node.children {
+		if other == child {
+			node.children = bvh.removeAt(node.children, index)
+			return true
+		}
+	}
+	return false
+}
+
+// ..............................................
+
+// removes children[index], by moving the last child into its place, or (with
+// Options.StableErase) by shifting the later children down
+func (bvh *BVH[BoundType]) removeAt(children []Boundable[BoundType], index int) []Boundable[BoundType] {
+	last := len(children) - 1
+	if bvh.options.StableErase {
+		copy(children[index:], children[index+1:])
+	} else {
+		children[index] = children[last]
+	}
+	children[last] = nil
+	return children[:last]
+}
+
+// ..............................................
+
+// discards the nodes among containers left empty (and their emptied
+// ancestors), then refits every node on the paths from the containers to
+// the root once, deepest first
+func (bvh *BVH[BoundType]) refitAffected(containers []*bvhNode[BoundType]) {
+	affected := make(map[*bvhNode[BoundType]]int)
+	ordered := make([]*bvhNode[BoundType], 0, len(containers))
+	for _, container := range containers {
+		node := container
+		for node.parent != nil && len(node.children) == 0 {
+			bvh.removeChild(node.parent, node)
+			node = node.parent
+		}
+		for ; node != nil; node = node.parent {
+			if _, ok := affected[node]; ok {
+				break // ancestors are already included
+			}
+			affected[node] = node.level
+			ordered = append(ordered, node)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return affected[ordered[i]] > affected[ordered[j]]
+	})
+	for _, node := range ordered {
+		if len(node.children) > 0 || node.parent == nil {
+			bvh.recalculateBounds(node)
+		}
+	}
+}
+
+// ..............................................
+
+// accounts for element (in the container node) moving from oldbound to bound
+// without leaving the node, as an erasure and reinsertion would
+func (bvh *BVH[BoundType]) movedInPlace(container *bvhNode[BoundType], element Boundable[BoundType], oldbound BoundType, bound BoundType) {
+	bvh.markDirty(container, bvh.boundtraits.Union(oldbound, bound))
+	bvh.version++
+	bvh.contenthash += bvh.elementHash(element, bound) - bvh.elementHash(element, oldbound)
+	if bvh.evictor != nil {
+		bvh.evictor.Erased(element)
+		bvh.evictor.Inserted(element)
+	}
+	bvh.logChange(ChangeErased, element, oldbound)
+	bvh.logChange(ChangeInserted, element, bound)
+}
+
+// ..............................................
+
+// recalculates the bounds of the subtree rooted at node, bottom up, and adds
+// the hashes of its elements at their current bounds to the content hash
+func (bvh *BVH[BoundType]) refitNode(node *bvhNode[BoundType]) {
+	oldbound := node.bound
+	leaf := false
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			bvh.refitNode(bvh.own(value))
+		} else {
+			leaf = true
+			bvh.contenthash += bvh.elementHash(child, child.GetBound())
+		}
+	}
+	bvh.recalculateBounds(node)
+	if leaf && !boundsEqual(bvh.boundtraits, oldbound, node.bound) {
+		bvh.markDirty(node, bvh.boundtraits.Union(oldbound, node.bound))
+	}
+}
@@ -0,0 +1,270 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+// checks parent pointers and that every node bound is the union of its children
+func checkTree(t *testing.T, bvh *BVH[AABB2D], node *bvhNode[AABB2D]) {
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[AABB2D]); ok {
+			if value.parent != node {
+				t.Errorf("Broken parent pointer")
+			}
+			if len(value.children) == 0 {
+				t.Errorf("Empty node left in the tree")
+			}
+			checkTree(t, bvh, value)
+		}
+	}
+	if len(node.children) > 0 {
+		bound := node.children[0].GetBound()
+		for _, child := range node.children[1:] {
+			bound = bvh.boundtraits.Union(bound, child.GetBound())
+		}
+		if !boundsEqual(bvh.boundtraits, bound, node.bound) {
+			t.Errorf("Node bound %v does not match its children %v", node.bound, bound)
+		}
+	}
+}
+
+// ........................................................
+
+func TestBVHUpdateBatch(t *testing.T) {
+	rng := rand.New(rand.NewSource(31))
+	boxes := randomBoxes(rng, 1000, 2.0)
+	bvh := New[AABB2D](Traits2D{})
+	for index, element := range boxes {
+		if index%10 == 0 {
+			bvh.InsertTagged(element, Tag(index))
+		} else {
+			bvh.Insert(element)
+		}
+	}
+
+	for tick := 0; tick < 5; tick++ {
+		updates := make([]ElementUpdate[AABB2D], 0, len(boxes))
+		for _, element := range boxes {
+			if rng.Intn(3) == 0 {
+				box := element.(*Box2D)
+				old := box.B
+				dx, dy := rng.Float64()*20.0-10.0, rng.Float64()*20.0-10.0
+				box.B = AABB2D{L: Point2D{old.L[0] + dx, old.L[1] + dy}, H: Point2D{old.H[0] + dx, old.H[1] + dy}}
+				updates = append(updates, ElementUpdate[AABB2D]{Element: element, OldBound: old})
+			}
+		}
+		if moved := bvh.UpdateBatch(updates); moved != len(updates) {
+			t.Errorf("Expected %d elements moved, found %d", len(updates), moved)
+		}
+		checkTree(t, bvh, &bvh.root)
+	}
+
+	if count := len(collectElements(&bvh.root, nil)); count != len(boxes) {
+		t.Errorf("Expected %d elements, found %d", len(boxes), count)
+	}
+	for index, element := range boxes {
+		if index%10 == 0 && !bvh.HasTag(element, Tag(index)) {
+			t.Errorf("Tag lost by UpdateBatch")
+		}
+		found := false
+		bvh.FindConstrained(map[uint]Interval{
+			0: {element.GetBound().L[0], element.GetBound().H[0]},
+			1: {element.GetBound().L[1], element.GetBound().H[1]},
+		}, func(other Boundable[AABB2D]) error {
+			found = found || other == element
+			return nil
+		})
+		if !found {
+			t.Errorf("Moved element not found at its new bound")
+		}
+	}
+
+	// not stored:
+	stranger := &Box2D{AABB2D{L: Point2D{1, 1}, H: Point2D{2, 2}}}
+	if bvh.Update(stranger, stranger.B) {
+		t.Errorf("Expected Update() of an element not in the tree to fail")
+	}
+
+	// everything out of a subtree, so that nodes empty out:
+	updates := make([]ElementUpdate[AABB2D], 0, len(boxes))
+	for _, element := range boxes {
+		box := element.(*Box2D)
+		if box.B.L[0] < 50.0 {
+			old := box.B
+			box.B = AABB2D{L: Point2D{old.L[0] + 500.0, old.L[1]}, H: Point2D{old.H[0] + 500.0, old.H[1]}}
+			updates = append(updates, ElementUpdate[AABB2D]{Element: element, OldBound: old})
+		}
+	}
+	bvh.UpdateBatch(updates)
+	checkTree(t, bvh, &bvh.root)
+	if count := len(collectElements(&bvh.root, nil)); count != len(boxes) {
+		t.Errorf("Expected %d elements, found %d", len(boxes), count)
+	}
+}
+
+// ........................................................
+
+func TestBVHUpdateInPlace(t *testing.T) {
+	rng := rand.New(rand.NewSource(37))
+	boxes := randomBoxes(rng, 500, 2.0)
+	bvh := New[AABB2D](Traits2D{})
+	for _, element := range boxes {
+		bvh.Insert(element)
+	}
+
+	// shrinking each box keeps it within its node:
+	containers := make(map[Boundable[AABB2D]]*bvhNode[AABB2D])
+	updates := make([]ElementUpdate[AABB2D], 0, len(boxes))
+	for _, element := range boxes {
+		box := element.(*Box2D)
+		containers[element] = bvh.findContainer(&bvh.root, element, box.B)
+		old := box.B
+		dx, dy := (old.H[0]-old.L[0])*0.25, (old.H[1]-old.L[1])*0.25
+		box.B = AABB2D{L: Point2D{old.L[0] + dx, old.L[1] + dy}, H: Point2D{old.H[0] - dx, old.H[1] - dy}}
+		updates = append(updates, ElementUpdate[AABB2D]{Element: element, OldBound: old})
+	}
+	version := bvh.Version()
+	if moved := bvh.UpdateBatch(updates); moved != len(updates) {
+		t.Errorf("Expected %d elements moved, found %d", len(updates), moved)
+	}
+	if bvh.Version() == version {
+		t.Errorf("Expected the version to change")
+	}
+	for _, element := range boxes {
+		if container := bvh.findContainer(&bvh.root, element, element.GetBound()); container != containers[element] {
+			t.Errorf("Element moved out of the node it still fits")
+		}
+	}
+	checkTree(t, bvh, &bvh.root)
+	if err := bvh.Validate(); err != nil {
+		t.Errorf("Validate() failed: %v", err)
+	}
+	if bvh.Len() != len(boxes) {
+		t.Errorf("Expected %d elements, found %d", len(boxes), bvh.Len())
+	}
+
+	fresh := New[AABB2D](Traits2D{})
+	for _, element := range boxes {
+		fresh.Insert(element)
+	}
+	if bvh.ContentHash() != fresh.ContentHash() {
+		t.Errorf("ContentHash() does not match that of the same elements inserted")
+	}
+}
+
+// ........................................................
+
+func TestBVHRefit(t *testing.T) {
+	rng := rand.New(rand.NewSource(41))
+	boxes := randomBoxes(rng, 1000, 2.0)
+	bvh := New[AABB2D](Traits2D{})
+	for _, element := range boxes {
+		bvh.Insert(element)
+	}
+	nodes := bvh.Stats().Nodes
+
+	bvh.ClearDirty()
+	version := bvh.Version()
+	for _, element := range boxes {
+		box := element.(*Box2D)
+		dx, dy := rng.Float64()*2.0-1.0, rng.Float64()*2.0-1.0
+		box.B = AABB2D{L: Point2D{box.B.L[0] + dx, box.B.L[1] + dy}, H: Point2D{box.B.H[0] + dx, box.B.H[1] + dy}}
+	}
+	bvh.Refit()
+
+	checkTree(t, bvh, &bvh.root)
+	if err := bvh.Validate(); err != nil {
+		t.Errorf("Validate() failed: %v", err)
+	}
+	if bvh.Stats().Nodes != nodes {
+		t.Errorf("Expected the hierarchy to be unchanged, found %d nodes instead of %d", bvh.Stats().Nodes, nodes)
+	}
+	if bvh.Version() == version {
+		t.Errorf("Expected the version to change")
+	}
+	if len(bvh.DirtyBounds()) == 0 {
+		t.Errorf("Expected the refit leaves to be dirty")
+	}
+	for _, element := range boxes {
+		if !bvh.Contains(element) {
+			t.Errorf("Refit element not found at its new bound")
+		}
+	}
+
+	fresh := New[AABB2D](Traits2D{})
+	for _, element := range boxes {
+		fresh.Insert(element)
+	}
+	if bvh.ContentHash() != fresh.ContentHash() {
+		t.Errorf("ContentHash() does not match that of the same elements inserted")
+	}
+
+	// an empty tree:
+	empty := New[AABB2D](Traits2D{})
+	empty.Refit()
+	if empty.Len() != 0 || len(empty.DirtyBounds()) != 0 {
+		t.Errorf("Expected Refit() of an empty tree to do nothing")
+	}
+}
+
+// ........................................................
+
+func TestBVHReplace(t *testing.T) {
+	rng := rand.New(rand.NewSource(67))
+	boxes := randomBoxes(rng, 500, 2.0)
+	bvh := New[AABB2D](Traits2D{})
+	for index, element := range boxes {
+		if index < 2 {
+			bvh.InsertTagged(element, Tag(3))
+		} else {
+			bvh.Insert(element)
+		}
+	}
+
+	// a smaller stand-in takes the element's place:
+	old := boxes[0].(*Box2D)
+	container := bvh.findContainer(&bvh.root, old, old.B)
+	lod := &Box2D{AABB2D{L: old.B.L, H: Point2D{(old.B.L[0] + old.B.H[0]) / 2, (old.B.L[1] + old.B.H[1]) / 2}}}
+	if !bvh.Replace(old, lod) {
+		t.Fatalf("Replace() failed to find the element")
+	}
+	if bvh.findContainer(&bvh.root, lod, lod.B) != container || bvh.Contains(old) {
+		t.Errorf("Expected the stand-in to take the element's place")
+	}
+	boxes[0] = lod
+
+	// one elsewhere is reinserted:
+	moved := &Box2D{AABB2D{L: Point2D{500, 500}, H: Point2D{501, 501}}}
+	if !bvh.Replace(boxes[1], moved) || bvh.Contains(boxes[1]) || !bvh.Contains(moved) {
+		t.Errorf("Expected the replacement to be reinserted")
+	}
+	boxes[1] = moved
+
+	for _, element := range boxes[:2] {
+		if !bvh.HasTag(element, Tag(3)) {
+			t.Errorf("Expected the replacement to take over the tags")
+		}
+	}
+	checkTree(t, bvh, &bvh.root)
+	if err := bvh.Validate(); err != nil {
+		t.Errorf("Validate() failed: %v", err)
+	}
+	if bvh.Len() != len(boxes) {
+		t.Errorf("Expected %d elements, found %d", len(boxes), bvh.Len())
+	}
+	fresh := New[AABB2D](Traits2D{})
+	for _, element := range boxes {
+		fresh.Insert(element)
+	}
+	if bvh.ContentHash() != fresh.ContentHash() {
+		t.Errorf("ContentHash() does not match that of the same elements inserted")
+	}
+
+	stranger := &Box2D{AABB2D{L: Point2D{1, 1}, H: Point2D{2, 2}}}
+	if bvh.Replace(stranger, lod) {
+		t.Errorf("Expected Replace() of an element not in the tree to fail")
+	}
+}
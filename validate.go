@@ -0,0 +1,154 @@
+package gobvh
+
+import (
+	"fmt"
+)
+
+// ==============================================
+
+//
+// BVH.Validate() checks the structural invariants of the hierarchy, and
+// returns an error describing the first violation found, or nil.
+//
+// Every node bound must contain the bounds of its children (as the boxes
+// given by IntervalRange()), every child node must point back to its parent
+// and lie one level below it, only the root may be empty, node collision
+// layers must cover those of their children, the counts of
+// NodeRef.ElementCount() and NodeCount() must be right, and Len() and
+// ContentHash() must agree with the elements actually stored.  It visits
+// every node and element, so it suits tests, debugging and soak runs
+// rather than production paths.
+//
+func (bvh *BVH[BoundType]) Validate() error {
+	var count int
+	var hash uint64
+	if err := bvh.validateNode(&bvh.root, &count, &hash); err != nil {
+		return err
+	}
+	if count != bvh.count {
+		return fmt.Errorf("gobvh: Len() reports %d elements, but %d are stored", bvh.count, count)
+	}
+	if hash != bvh.contenthash {
+		return fmt.Errorf("gobvh: ContentHash() reports %x, but the elements stored hash to %x", bvh.contenthash, hash)
+	}
+	return nil
+}
+
+// ..............................................
+
+//
+// Stats summarizes the shape of a BVH, see BVH.Stats().
+//
+// Depth is the depth of the deepest leaf (the root alone has depth 0).
+// Leaves are the nodes holding elements; MeanLeafSize and MaxLeafSize
+// count the elements they hold, and MeanLeafDepth is their average depth.
+//
+type Stats struct {
+	Elements      int
+	Nodes         int
+	Leaves        int
+	Depth         int
+	MeanLeafDepth float64
+	MeanLeafSize  float64
+	MaxLeafSize   int
+}
+
+// ..............................................
+
+//
+// BVH.Stats() measures the shape of the hierarchy, visiting every node.
+//
+func (bvh *BVH[BoundType]) Stats() Stats {
+	var stats Stats
+	var depths int
+	measureShape(&bvh.root, 0, &stats, &depths)
+	if stats.Leaves > 0 {
+		stats.MeanLeafDepth = float64(depths) / float64(stats.Leaves)
+		stats.MeanLeafSize = float64(stats.Elements) / float64(stats.Leaves)
+	}
+	return stats
+}
+
+// ==============================================
+
+func (bvh *BVH[BoundType]) validateNode(node *bvhNode[BoundType], count *int, hash *uint64) error {
+	if node != &bvh.root && len(node.children) == 0 {
+		return fmt.Errorf("gobvh: empty node at depth %d", node.level-bvh.root.level)
+	}
+	elements, descendants := 0, 0
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			elements += value.elements
+			descendants += 1 + value.descendants
+		} else if child != nil {
+			elements++
+		}
+	}
+	if elements != node.elements || descendants != node.descendants {
+		return fmt.Errorf("gobvh: node at depth %d counts %d elements and %d descendants, but has %d and %d",
+			node.level-bvh.root.level, node.elements, node.descendants, elements, descendants)
+	}
+	for _, child := range node.children {
+		if child == nil {
+			return fmt.Errorf("gobvh: nil child at depth %d", node.level-bvh.root.level)
+		}
+		if layers := bvh.elementLayers(child); layers&node.layers != layers {
+			return fmt.Errorf("gobvh: node layers %x do not contain child layers %x", node.layers, layers)
+		}
+		if !boundContainsBound(bvh.boundtraits, node.bound, child.GetBound()) {
+			return fmt.Errorf("gobvh: node bound %v does not contain child bound %v", node.bound, child.GetBound())
+		}
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			if value.parent != node {
+				return fmt.Errorf("gobvh: broken parent pointer at depth %d", value.level-bvh.root.level)
+			}
+			if value.level != node.level+1 {
+				return fmt.Errorf("gobvh: node at level %d below a node at level %d", value.level, node.level)
+			}
+			if err := bvh.validateNode(value, count, hash); err != nil {
+				return err
+			}
+		} else {
+			*count++
+			*hash += bvh.elementHash(child, child.GetBound())
+		}
+	}
+	return nil
+}
+
+// ..............................................
+
+func measureShape[BoundType any](node *bvhNode[BoundType], depth int, stats *Stats, depths *int) {
+	stats.Nodes++
+	elements := 0
+	for _, child := range node.children {
+		if value, ok := child.(*bvhNode[BoundType]); ok {
+			measureShape(value, depth+1, stats, depths)
+		} else if child != nil {
+			elements++
+		}
+	}
+	if elements > 0 {
+		stats.Elements += elements
+		stats.Leaves++
+		*depths += depth
+		stats.MaxLeafSize = maxInt(stats.MaxLeafSize, elements)
+		stats.Depth = maxInt(stats.Depth, depth)
+	}
+}
+
+// ..............................................
+
+// reports whether the axis-aligned box given by IntervalRange() for outer
+// contains the one for inner
+func boundContainsBound[BoundType any](bounder BoundTraits[BoundType], outer BoundType, inner BoundType) bool {
+	var i uint
+	for i = 0; i < bounder.Dimensions(inner); i++ {
+		lo0, hi0 := bounder.IntervalRange(outer, i)
+		lo1, hi1 := bounder.IntervalRange(inner, i)
+		if lo1 < lo0 || hi1 > hi0 {
+			return false
+		}
+	}
+	return true
+}
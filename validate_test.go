@@ -0,0 +1,65 @@
+package gobvh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ========================================================
+
+func TestBVHValidate(t *testing.T) {
+	rng := rand.New(rand.NewSource(77))
+	boxes := randomBoxes(rng, 1500, 2.0)
+	bvh := New[AABB2D](Traits2D{})
+	if err := bvh.Validate(); err != nil {
+		t.Errorf("Expected an empty tree to be valid: %v", err)
+	}
+
+	stored := map[Boundable[AABB2D]]bool{}
+	for step := 0; step < 4000; step++ {
+		element := boxes[rng.Intn(len(boxes))]
+		switch {
+		case !stored[element]:
+			bvh.Insert(element)
+			stored[element] = true
+		case rng.Intn(3) == 0:
+			bvh.Erase(element)
+			delete(stored, element)
+		default:
+			box := element.(*Box2D)
+			oldbound := box.B
+			dx, dy := rng.Float64()*4.0-2.0, rng.Float64()*4.0-2.0
+			box.B = AABB2D{L: Point2D{oldbound.L[0] + dx, oldbound.L[1] + dy}, H: Point2D{oldbound.H[0] + dx, oldbound.H[1] + dy}}
+			bvh.Update(box, oldbound)
+		}
+		if step%250 == 0 {
+			if err := bvh.Validate(); err != nil {
+				t.Fatalf("Step %d: %v", step, err)
+			}
+		}
+	}
+	if err := bvh.Validate(); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	stats := bvh.Stats()
+	if stats.Elements != len(stored) || stats.Elements != bvh.Len() {
+		t.Errorf("Expected stats of %d elements, found %d", len(stored), stats.Elements)
+	}
+	if stats.Nodes != bvh.MemoryFootprint().Nodes || stats.Leaves == 0 || stats.Depth < 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if stats.MaxLeafSize < int(stats.MeanLeafSize) || stats.MeanLeafDepth > float64(stats.Depth) {
+		t.Errorf("Inconsistent stats %+v", stats)
+	}
+
+	// an element moved without Update() breaks the invariants:
+	for element := range stored {
+		box := element.(*Box2D)
+		box.B.H[0] += 500.0
+		break
+	}
+	if err := bvh.Validate(); err == nil {
+		t.Errorf("Expected a moved element to be reported")
+	}
+}
@@ -0,0 +1,42 @@
+//go:build tinygo || gobvh_embedded
+
+package gobvh
+
+// ==============================================
+
+// Embedded targets default to tight node capacities, see Options.
+const (
+	defaultInitialCapacity = 4
+	defaultGrowthFactor    = 1.0
+)
+
+// ..............................................
+
+// calls verify for each candidate in turn; parallelism is ignored
+func verifyAll[BoundType any](candidates []Boundable[BoundType], parallelism int, verify func(Boundable[BoundType]) (bool, error)) ([]bool, error) {
+	accepted := make([]bool, len(candidates))
+	for index, element := range candidates {
+		ok, err := verify(element)
+		if err != nil {
+			return nil, err
+		}
+		accepted[index] = ok
+	}
+	return accepted, nil
+}
+
+// ..............................................
+
+// Build() stays on the calling goroutine, see Options
+func defaultBuildWorkers() int {
+	return 1
+}
+
+// ..............................................
+
+// calls fn for every index below n, in turn; workers is ignored
+func parallelFor(n int, workers int, fn func(int)) {
+	for index := 0; index < n; index++ {
+		fn(index)
+	}
+}
@@ -0,0 +1,94 @@
+//go:build !tinygo && !gobvh_embedded
+
+package gobvh
+
+import (
+	"runtime"
+	"sync"
+)
+
+// ==============================================
+
+const (
+	defaultInitialCapacity = 8
+	defaultGrowthFactor    = 2.0
+)
+
+// ..............................................
+
+// calls verify for each candidate on a pool of parallelism goroutines
+func verifyAll[BoundType any](candidates []Boundable[BoundType], parallelism int, verify func(Boundable[BoundType]) (bool, error)) ([]bool, error) {
+	accepted := make([]bool, len(candidates))
+	var firsterr error
+	var errlock sync.Mutex
+	var wg sync.WaitGroup
+
+	work := make(chan int)
+	for worker := 0; worker < parallelism; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range work {
+				ok, err := verify(candidates[index])
+				if err != nil {
+					errlock.Lock()
+					if firsterr == nil {
+						firsterr = err
+					}
+					errlock.Unlock()
+					continue
+				}
+				accepted[index] = ok
+			}
+		}()
+	}
+
+	for index := range candidates {
+		errlock.Lock()
+		failed := firsterr != nil
+		errlock.Unlock()
+		if failed {
+			break
+		}
+		work <- index
+	}
+	close(work)
+	wg.Wait()
+
+	return accepted, firsterr
+}
+
+// ..............................................
+
+// the number of goroutines Build() uses by default, see Options
+func defaultBuildWorkers() int {
+	return runtime.GOMAXPROCS(0)
+}
+
+// ..............................................
+
+// calls fn for every index below n, dividing the indices into contiguous
+// blocks among up to workers goroutines
+func parallelFor(n int, workers int, fn func(int)) {
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for index := 0; index < n; index++ {
+			fn(index)
+		}
+		return
+	}
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		lo, hi := n*worker/workers, n*(worker+1)/workers
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := lo; index < hi; index++ {
+				fn(index)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,63 @@
+package gobvh
+
+// ==============================================
+
+//
+// BVH.SetVisibility(element, mask) sets the views in which a stored element
+// is visible, one bit per view (e.g. per editor layer or per observer).
+//
+// Visibility does not change the structure of the hierarchy: it is applied
+// by searches given the WithVisibility() option, which skip elements not
+// visible in any of the requested views.  Elements are visible in every
+// view (mask ^uint64(0)) until changed; the setting is forgotten when the
+// element is erased.
+//
+func (bvh *BVH[BoundType]) SetVisibility(element Boundable[BoundType], mask uint64) {
+	if mask == ^uint64(0) {
+		if info, ok := bvh.info[element]; ok {
+			info.hidden = 0
+		}
+		return
+	}
+	bvh.infoFor(element).hidden = ^mask
+}
+
+// ..............................................
+
+//
+// BVH.SetVisible(element, visible) shows or hides a stored element in every
+// view, e.g. to soft-delete it; see SetVisibility().
+//
+func (bvh *BVH[BoundType]) SetVisible(element Boundable[BoundType], visible bool) {
+	if visible {
+		bvh.SetVisibility(element, ^uint64(0))
+	} else {
+		bvh.SetVisibility(element, 0)
+	}
+}
+
+// ..............................................
+
+//
+// BVH.Visibility(element) returns the views in which the element is visible,
+// see SetVisibility().
+//
+func (bvh *BVH[BoundType]) Visibility(element Boundable[BoundType]) uint64 {
+	if info, ok := bvh.info[element]; ok {
+		return ^info.hidden
+	}
+	return ^uint64(0)
+}
+
+// ..............................................
+
+//
+// BVH.WithVisibility(views) is a QueryOption which skips the elements not
+// visible in any of the views (a mask, see SetVisibility()).  Use ^uint64(0)
+// to skip only the elements hidden everywhere.
+//
+func (bvh *BVH[BoundType]) WithVisibility(views uint64) QueryOption[BoundType] {
+	return WithElementFilter(func(element Boundable[BoundType]) bool {
+		return bvh.Visibility(element)&views != 0
+	})
+}
@@ -0,0 +1,77 @@
+package gobvh
+
+import (
+	"testing"
+)
+
+// ========================================================
+
+// collects everything a searcher is offered inside a box
+type BoxCollector struct {
+	Box   AABB2D
+	Found []Boundable[AABB2D]
+}
+
+func (bc *BoxCollector) DoesIntersect(bound AABB2D) bool {
+	return boundsOverlap[AABB2D](Traits2D{}, bc.Box, bound)
+}
+
+func (bc *BoxCollector) Evaluate(element Boundable[AABB2D]) error {
+	if bc.DoesIntersect(element.GetBound()) {
+		bc.Found = append(bc.Found, element)
+	}
+	return nil
+}
+
+// ........................................................
+
+func TestBVHVisibility(t *testing.T) {
+	const (
+		editor   uint64 = 1 << 0
+		observer uint64 = 1 << 1
+	)
+	bvh := New[AABB2D](Traits2D{})
+	for i := 0; i < 100; i++ {
+		bvh.Insert(Point2D{float64(i), 0.0})
+	}
+	bvh.InsertTagged(Point2D{100.0, 0.0}, Tag(7))
+
+	for i := 0; i < 10; i++ {
+		bvh.SetVisible(Point2D{float64(i), 0.0}, false) // soft-deleted
+	}
+	for i := 10; i < 30; i++ {
+		bvh.SetVisibility(Point2D{float64(i), 0.0}, editor) // editor only
+	}
+	bvh.SetVisibility(Point2D{100.0, 0.0}, observer)
+
+	box := AABB2D{L: Point2D{-1, -1}, H: Point2D{200, 1}}
+	for _, test := range []struct {
+		views    uint64
+		expected int
+	}{{^uint64(0), 91}, {editor, 90}, {observer, 71}, {0, 0}} {
+		collector := BoxCollector{Box: box}
+		bvh.FindAll(&collector, bvh.WithVisibility(test.views))
+		if len(collector.Found) != test.expected {
+			t.Errorf("Expected %d elements visible in %b, found %d", test.expected, test.views, len(collector.Found))
+		}
+	}
+
+	collector := BoxCollector{Box: box}
+	bvh.FindAll(&collector)
+	if len(collector.Found) != 101 {
+		t.Errorf("Expected searches without WithVisibility() to see everything, found %d", len(collector.Found))
+	}
+	if !bvh.HasTag(Point2D{100.0, 0.0}, Tag(7)) {
+		t.Errorf("Expected visibility to keep tags")
+	}
+
+	bvh.SetVisible(Point2D{0.0, 0.0}, true)
+	if bvh.Visibility(Point2D{0.0, 0.0}) != ^uint64(0) {
+		t.Errorf("Expected the element to be visible again")
+	}
+	bvh.Erase(Point2D{5.0, 0.0})
+	bvh.Insert(Point2D{5.0, 0.0})
+	if bvh.Visibility(Point2D{5.0, 0.0}) != ^uint64(0) {
+		t.Errorf("Expected visibility to be forgotten on erase")
+	}
+}